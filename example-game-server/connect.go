@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// connectClaims are the fields of a connect token this server checks.
+// The matchmaker signs one per (match, player) and hands it to the
+// client with match_found; the client presents it on /join.
+type connectClaims struct {
+	MatchID     string `json:"match_id"`
+	PlayerID    string `json:"player_id"`
+	DisplayName string `json:"display_name"`
+	Audience    string `json:"aud"`
+	ExpiresAt   int64  `json:"exp"`
+}
+
+// jwk is one entry of the matchmaker's /.well-known/jwks.json.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+}
+
+// loadConnectKeys returns the Ed25519 keys connect tokens are verified
+// against, by kid. CONNECT_TOKEN_PUBLIC_KEY (the base64url "x" of the
+// JWK) pins a key with no network access at all; otherwise the key set
+// is fetched once from the platform at startup and every token after
+// that is verified offline.
+func loadConnectKeys(platform string) (map[string]ed25519.PublicKey, error) {
+	if x := os.Getenv("CONNECT_TOKEN_PUBLIC_KEY"); x != "" {
+		key, err := decodeKey(x)
+		if err != nil {
+			return nil, err
+		}
+		// An empty kid matches any token header.
+		return map[string]ed25519.PublicKey{"": key}, nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(platform + "/.well-known/jwks.json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching keys: status %d", resp.StatusCode)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]ed25519.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "OKP" || k.Crv != "Ed25519" {
+			continue
+		}
+		key, err := decodeKey(k.X)
+		if err != nil {
+			return nil, err
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no Ed25519 keys published")
+	}
+	return keys, nil
+}
+
+func decodeKey(x string) (ed25519.PublicKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 public key")
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// matchAudience is the aud claim the matchmaker puts on this match's
+// connect tokens: the hex SHA-256 of the -token auth code. Checking it
+// rejects tokens minted for some other match.
+func matchAudience(authCode string) string {
+	sum := sha256.Sum256([]byte(authCode))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyConnectToken checks a connect token's EdDSA signature, expiry
+// and audience without calling the platform, and returns its claims.
+func (gs *GameServer) verifyConnectToken(token string) (*connectClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "EdDSA" {
+		return nil, fmt.Errorf("unexpected alg %q", header.Alg)
+	}
+	key, ok := gs.connectKeys[header.Kid]
+	if !ok {
+		key, ok = gs.connectKeys[""]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", header.Kid)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !ed25519.Verify(key, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, errors.New("bad signature")
+	}
+
+	var claims connectClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, errors.New("token expired")
+	}
+	if claims.Audience != matchAudience(gs.tokenID) {
+		return nil, errors.New("token is for a different match")
+	}
+	return &claims, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -24,19 +25,21 @@ type RequestBody struct {
 	WinnerID string `json:"winner_id"`
 }
 
-// expectedTokens / joinedTokens hold the per-match connect tokens the
-// matchmaker hands the game server in argv. Each value is the credential
-// a single player presents on /join (HTTP) or the first TCP line. Today
-// it equals the player's ID; phase 2 swaps it for an opaque secret. The
-// game server only needs to verify membership — the matchmaker is
-// authoritative on which tokens are admissible for this match.
+// expectedPlayers / joinedPlayers hold the player IDs the matchmaker
+// hands the game server in argv. Players don't join by ID: each presents
+// the signed connect token from its match_found on /join (HTTP) or the
+// first TCP line, and the server admits the player ID inside it once the
+// signature checks out against connectKeys. A nil connectKeys (the
+// -trust-player-ids flag) accepts bare player IDs, for local testing.
 type GameServer struct {
 	tokenID         string
-	expectedTokens  map[string]bool
-	joinedTokens    map[string]bool
+	expectedPlayers map[string]bool
+	joinedPlayers   map[string]bool
+	connectKeys     map[string]ed25519.PublicKey
 	mutex           sync.RWMutex
 	reportURL       string
 	artifactURL     string
+	heartbeatURL    string
 	shutdownChan    chan struct{}
 }
 
@@ -50,44 +53,59 @@ func platformBase(reportURL string) string {
 	return reportURL
 }
 
-func NewGameServer(tokenID string, connectTokens []string) *GameServer {
+func NewGameServer(tokenID string, playerIDs []string) *GameServer {
 	reportURL := os.Getenv("WEBSITE_URL")
 	if reportURL == "" {
 		reportURL = "https://elo-service.fly.dev/result/report"
 	}
 
-	expectedTokens := make(map[string]bool)
-	for _, t := range connectTokens {
-		expectedTokens[t] = true
+	expectedPlayers := make(map[string]bool)
+	for _, id := range playerIDs {
+		expectedPlayers[id] = true
 	}
 
 	return &GameServer{
-		tokenID:        tokenID,
-		expectedTokens: expectedTokens,
-		joinedTokens:   make(map[string]bool),
-		reportURL:      reportURL,
-		artifactURL:    platformBase(reportURL) + "/match/artifact",
-		shutdownChan:   make(chan struct{}),
+		tokenID:         tokenID,
+		expectedPlayers: expectedPlayers,
+		joinedPlayers:   make(map[string]bool),
+		reportURL:       reportURL,
+		artifactURL:     platformBase(reportURL) + "/match/artifact",
+		heartbeatURL:    platformBase(reportURL) + "/match/heartbeat",
+		shutdownChan:    make(chan struct{}),
 	}
 }
 
-func (gs *GameServer) addPlayer(connectToken string) bool {
+// resolvePlayer maps the credential a client presented to the player it
+// belongs to.
+func (gs *GameServer) resolvePlayer(connectToken string) (string, error) {
+	if gs.connectKeys == nil {
+		return connectToken, nil
+	}
+	claims, err := gs.verifyConnectToken(connectToken)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Verified connect token for %s (%s)", claims.PlayerID, claims.DisplayName)
+	return claims.PlayerID, nil
+}
+
+func (gs *GameServer) addPlayer(playerID string) bool {
 	gs.mutex.Lock()
 	defer gs.mutex.Unlock()
 
-	if !gs.expectedTokens[connectToken] {
-		return false // Token not expected
+	if !gs.expectedPlayers[playerID] {
+		return false // Player not expected
 	}
 
-	if gs.joinedTokens[connectToken] {
-		return false // Already joined with this token
+	if gs.joinedPlayers[playerID] {
+		return false // Already joined
 	}
 
-	gs.joinedTokens[connectToken] = true
-	log.Printf("Player joined (token=%s). Total: %d/%d", connectToken, len(gs.joinedTokens), len(gs.expectedTokens))
+	gs.joinedPlayers[playerID] = true
+	log.Printf("Player %s joined. Total: %d/%d", playerID, len(gs.joinedPlayers), len(gs.expectedPlayers))
 
 	// Check if all expected players have joined
-	if len(gs.joinedTokens) >= len(gs.expectedTokens) {
+	if len(gs.joinedPlayers) >= len(gs.expectedPlayers) {
 		log.Println("All players have joined! Starting game...")
 		go gs.reportResult()
 		return true
@@ -96,21 +114,21 @@ func (gs *GameServer) addPlayer(connectToken string) bool {
 	return true
 }
 
-func (gs *GameServer) getJoinedTokens() []string {
+func (gs *GameServer) getJoinedPlayers() []string {
 	gs.mutex.RLock()
 	defer gs.mutex.RUnlock()
 
-	out := make([]string, 0, len(gs.joinedTokens))
-	for t := range gs.joinedTokens {
-		out = append(out, t)
+	out := make([]string, 0, len(gs.joinedPlayers))
+	for id := range gs.joinedPlayers {
+		out = append(out, id)
 	}
 	return out
 }
 
-func (gs *GameServer) getExpectedTokens() []string {
-	out := make([]string, 0, len(gs.expectedTokens))
-	for t := range gs.expectedTokens {
-		out = append(out, t)
+func (gs *GameServer) getExpectedPlayers() []string {
+	out := make([]string, 0, len(gs.expectedPlayers))
+	for id := range gs.expectedPlayers {
+		out = append(out, id)
 	}
 	return out
 }
@@ -142,17 +160,46 @@ func (gs *GameServer) uploadArtifact(name, contentType string, body []byte) int
 	return resp.StatusCode
 }
 
+// heartbeat tells the platform this server is still running the match
+// until shutdown. Queues with heartbeat_grace_seconds set end matches
+// whose server goes quiet; on other queues the call is a harmless no-op.
+func (gs *GameServer) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-gs.shutdownChan:
+			return
+		case <-ticker.C:
+		}
+		req, err := http.NewRequest(http.MethodPost, gs.heartbeatURL, nil)
+		if err != nil {
+			log.Printf("heartbeat: build request failed: %v", err)
+			continue
+		}
+		req.Header.Set("Authorization", "Bearer "+gs.tokenID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Printf("heartbeat: request failed: %v", err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			log.Printf("heartbeat: status=%d", resp.StatusCode)
+		}
+	}
+}
+
 func (gs *GameServer) reportResult() {
-	// In phase 1, connect_token == player_id, so a joined token is also
-	// a valid winner_id. Phase 2 will need an explicit (player_id,
-	// connect_token) pairing in argv to keep this mapping correct.
-	tokens := gs.getJoinedTokens()
+	// Players are tracked by the ID inside their connect token, which is
+	// what /result/report expects as winner_id.
+	players := gs.getJoinedPlayers()
 
-	log.Printf("Simulating game with %d players: %v", len(tokens), tokens)
+	log.Printf("Simulating game with %d players: %v", len(players), players)
 	time.Sleep(3 * time.Second)
 
 	// Randomly select winner
-	winnerID := tokens[rand.Intn(len(tokens))]
+	winnerID := players[rand.Intn(len(players))]
 	log.Printf("Game finished! Winner: %s", winnerID)
 
 	// Pre-result artifact upload — exercises the "during match" path
@@ -223,20 +270,20 @@ func (gs *GameServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	}
 
 	gs.mutex.RLock()
-	joinedCount := len(gs.joinedTokens)
-	expectedCount := len(gs.expectedTokens)
+	joinedCount := len(gs.joinedPlayers)
+	expectedCount := len(gs.expectedPlayers)
 	gs.mutex.RUnlock()
-	expectedTokens := gs.getExpectedTokens()
-	joinedTokens := gs.getJoinedTokens()
+	expectedPlayers := gs.getExpectedPlayers()
+	joinedPlayers := gs.getJoinedPlayers()
 
 	response := map[string]interface{}{
-		"status":          "healthy",
-		"token_id":        gs.tokenID,
-		"expected_tokens": expectedTokens,
-		"joined_tokens":   joinedTokens,
-		"player_count":    joinedCount,
-		"expected_count":  expectedCount,
-		"ready":           joinedCount >= expectedCount,
+		"status":           "healthy",
+		"token_id":         gs.tokenID,
+		"expected_players": expectedPlayers,
+		"joined_players":   joinedPlayers,
+		"player_count":     joinedCount,
+		"expected_count":   expectedCount,
+		"ready":            joinedCount >= expectedCount,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -262,16 +309,22 @@ func (gs *GameServer) handleHTTPJoin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !gs.expectedTokens[connectToken] {
-		http.Error(w, "Connect token not expected in this game", http.StatusForbidden)
+	playerID, err := gs.resolvePlayer(connectToken)
+	if err != nil {
+		http.Error(w, "Invalid connect token: "+err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	if gs.addPlayer(connectToken) {
+	if !gs.expectedPlayers[playerID] {
+		http.Error(w, "Player not expected in this game", http.StatusForbidden)
+		return
+	}
+
+	if gs.addPlayer(playerID) {
 		w.WriteHeader(http.StatusOK)
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		fmt.Fprintf(w, "Joined successfully. Players: %d/%d",
-			len(gs.getJoinedTokens()), len(gs.expectedTokens))
+		fmt.Fprintf(w, "Player %s joined successfully. Players: %d/%d",
+			playerID, len(gs.getJoinedPlayers()), len(gs.expectedPlayers))
 	} else {
 		http.Error(w, "Already joined", http.StatusConflict)
 	}
@@ -294,14 +347,20 @@ func (gs *GameServer) handleTCPConnection(conn net.Conn) {
 		return
 	}
 
-	if !gs.expectedTokens[connectToken] {
-		conn.Write([]byte("ERROR: Connect token not expected in this game\n"))
+	playerID, err := gs.resolvePlayer(connectToken)
+	if err != nil {
+		conn.Write([]byte("ERROR: Invalid connect token: " + err.Error() + "\n"))
+		return
+	}
+
+	if !gs.expectedPlayers[playerID] {
+		conn.Write([]byte("ERROR: Player not expected in this game\n"))
 		return
 	}
 
-	if gs.addPlayer(connectToken) {
-		response := fmt.Sprintf("OK: Joined successfully. Players: %d/%d\n",
-			len(gs.getJoinedTokens()), len(gs.expectedTokens))
+	if gs.addPlayer(playerID) {
+		response := fmt.Sprintf("OK: Player %s joined successfully. Players: %d/%d\n",
+			playerID, len(gs.getJoinedPlayers()), len(gs.expectedPlayers))
 		conn.Write([]byte(response))
 	} else {
 		conn.Write([]byte("ERROR: Already joined\n"))
@@ -312,31 +371,55 @@ func main() {
 	var tokenID string
 	var httpPort int
 	var tcpPort int
+	var teamsJSON string
+	var trustPlayerIDs bool
 
 	flag.StringVar(&tokenID, "token", "", "Match auth token used for /result/report (required)")
 	flag.IntVar(&httpPort, "http-port", 8080, "HTTP server port")
 	flag.IntVar(&tcpPort, "tcp-port", 8081, "TCP server port")
+	flag.StringVar(&teamsJSON, "teams", "", "Team layout as a JSON array of player-ID arrays (team queues only)")
+	flag.BoolVar(&trustPlayerIDs, "trust-player-ids", false, "Accept bare player IDs on /join instead of signed connect tokens (local testing only)")
 	flag.Parse()
 
-	// Positional args are the per-player connect tokens — the credentials
-	// each client presents on /join. In phase 1 these equal the players'
-	// IDs; phase 2 will swap them for opaque generated secrets.
-	connectTokens := flag.Args()
+	// Positional args are the IDs of the players in this match. Clients
+	// prove which one they are with a signed connect token.
+	playerIDs := flag.Args()
 
 	if tokenID == "" {
 		log.Fatal("Match auth token is required. Use -token flag.")
 	}
 
-	if len(connectTokens) == 0 {
-		log.Fatal("At least one connect token is required.")
+	if len(playerIDs) == 0 {
+		log.Fatal("At least one player ID is required.")
+	}
+
+	// -teams is only passed for queues configured with teams. The
+	// matchmaker already balanced them; the server just uses the layout.
+	var teams [][]string
+	if teamsJSON != "" {
+		if err := json.Unmarshal([]byte(teamsJSON), &teams); err != nil {
+			log.Fatalf("Invalid -teams: %v", err)
+		}
 	}
 
 	// Initialize game server
-	gameServer := NewGameServer(tokenID, connectTokens)
+	gameServer := NewGameServer(tokenID, playerIDs)
+	if trustPlayerIDs {
+		log.Println("WARNING: accepting bare player IDs; anyone who knows one can join as that player")
+	} else {
+		keys, err := loadConnectKeys(platformBase(gameServer.reportURL))
+		if err != nil {
+			log.Fatalf("Failed to load connect token keys: %v", err)
+		}
+		gameServer.connectKeys = keys
+	}
 
 	log.Printf("Starting example game server:")
 	log.Printf("  Token ID: %s", tokenID)
-	log.Printf("  Expected connect tokens: %v", gameServer.getExpectedTokens())
+	log.Printf("  Expected players: %v", gameServer.getExpectedPlayers())
+	if len(teams) > 0 {
+		log.Printf("  Teams: %v", teams)
+	}
 	log.Printf("  HTTP port: %d", httpPort)
 	log.Printf("  TCP port: %d", tcpPort)
 
@@ -345,6 +428,8 @@ func main() {
 		Addr: ":" + strconv.Itoa(httpPort),
 	}
 
+	go gameServer.heartbeat(5 * time.Second)
+
 	// Start HTTP server
 	go func() {
 		http.HandleFunc("/join", corsMiddleware(gameServer.handleHTTPJoin))
@@ -38,6 +38,9 @@ type startContainerRequest struct {
 	HostPorts []int64  `json:"host_ports"`
 	Token     string   `json:"token"`
 	PlayerIDs []string `json:"player_ids"`
+	// Teams is the matchmaker's team layout, one slice of player IDs per
+	// team. Empty for queues without teams.
+	Teams [][]string `json:"teams,omitempty"`
 	// SpectateID names a host-side directory the agent mounts into the
 	// container at /shared/. Game servers that opt into spectating write
 	// to /shared/spectate.stream; the matchmaker pulls bytes from this
@@ -178,6 +181,16 @@ func handleStartContainer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cmd := []string{"-token", req.Token}
+	// -teams is only passed when there is a layout, so game servers that
+	// don't declare the flag keep working on team-less queues.
+	if len(req.Teams) > 0 {
+		teams, err := json.Marshal(req.Teams)
+		if err != nil {
+			http.Error(w, "invalid teams", http.StatusBadRequest)
+			return
+		}
+		cmd = append(cmd, "-teams", string(teams))
+	}
 	cmd = append(cmd, req.PlayerIDs...)
 
 	// Always create the spectator dir and bind it into /shared/, even
@@ -314,7 +327,10 @@ func handleContainerHealth(w http.ResponseWriter, r *http.Request) {
 	if info.State.Running {
 		w.WriteHeader(http.StatusOK)
 	} else {
-		http.Error(w, "container not running", http.StatusServiceUnavailable)
+		// The matchmaker logs this when it ends a match whose server
+		// stopped heartbeating, so say how the container went down.
+		http.Error(w, fmt.Sprintf("container not running (%s, exit code %d, oom killed %t)",
+			info.State.Status, info.State.ExitCode, info.State.OOMKilled), http.StatusServiceUnavailable)
 	}
 }
 
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/golang-jwt/jwt"
+)
+
+// connectKey signs the per-player connect tokens handed out with
+// match_found. It's Ed25519 rather than the HMAC jwtKey so game servers
+// can verify tokens offline with only the public half, which
+// ConnectJWKS publishes. It comes from the server config, which refuses
+// to load without one.
+func connectKey() ed25519.PrivateKey {
+	return server.S.Config.ConnectTokenSigningKey
+}
+
+const (
+	CONNECT_TOKEN_TIMEOUT = time.Minute * 15
+	CONNECT_TOKEN_ISSUER  = "elo-service"
+)
+
+// ConnectClaims identify one player to one match's game server. The
+// audience is MatchAudience(auth code), so a game server can check a
+// token was minted for its own match by hashing the -token it was
+// started with.
+type ConnectClaims struct {
+	MatchID     string `json:"match_id"`
+	PlayerID    string `json:"player_id"`
+	DisplayName string `json:"display_name"`
+	jwt.StandardClaims
+}
+
+// connectKeyID names the signing key in token headers and the JWKS, so
+// game servers can tell when it has been rotated.
+func connectKeyID() string {
+	sum := sha256.Sum256(connectKey().Public().(ed25519.PublicKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// MatchAudience is the aud claim of a match's connect tokens: the hex
+// SHA-256 of its auth code. The code itself stays secret to the game
+// server.
+func MatchAudience(authCode string) string {
+	sum := sha256.Sum256([]byte(authCode))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueConnectToken signs the credential playerID presents to the game
+// server of the match with the given ID and auth code.
+func IssueConnectToken(matchID, authCode, playerID, displayName string) (string, error) {
+	now := time.Now()
+	claims := &ConnectClaims{
+		MatchID:     matchID,
+		PlayerID:    playerID,
+		DisplayName: displayName,
+		StandardClaims: jwt.StandardClaims{
+			Audience:  MatchAudience(authCode),
+			Issuer:    CONNECT_TOKEN_ISSUER,
+			Subject:   playerID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(CONNECT_TOKEN_TIMEOUT).Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = connectKeyID()
+	return token.SignedString(connectKey())
+}
+
+// ValidateConnectToken checks a connect token's signature and expiry.
+// Game servers do the same offline against ConnectJWKS; this is the
+// platform-side equivalent.
+func ValidateConnectToken(tokenString string) (*ConnectClaims, error) {
+	claims := &ConnectClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return connectKey().Public(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	if claims.MatchID == "" {
+		return nil, errors.New("missing match ID")
+	}
+	if claims.PlayerID == "" {
+		return nil, errors.New("missing player ID")
+	}
+
+	return claims, nil
+}
+
+// ConnectJWK is the public connect-token key in JWK form (RFC 8037).
+type ConnectJWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+// ConnectJWKS returns the key set game servers verify connect tokens
+// against.
+func ConnectJWKS() []ConnectJWK {
+	return []ConnectJWK{{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(connectKey().Public().(ed25519.PublicKey)),
+		Kid: connectKeyID(),
+		Alg: "EdDSA",
+		Use: "sig",
+	}}
+}
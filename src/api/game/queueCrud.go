@@ -2,11 +2,15 @@ package game
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/worker/matchmaking"
 	"github.com/labstack/echo"
 )
 
@@ -24,6 +28,68 @@ type CreateGameQueueRequest struct {
 	DefaultRating           int     `json:"default_rating"`
 	KFactor                 int     `json:"k_factor"`
 	MetadataEnabled         *bool   `json:"metadata_enabled"`
+	// Seasons. Setting season_ends_at opens season 1 now; see
+	// models.GameQueue for the rollover semantics.
+	SeasonEndsAt     *time.Time `json:"season_ends_at"`
+	SeasonLengthDays int        `json:"season_length_days"`
+	SeasonSoftReset  *float64   `json:"season_soft_reset"`
+	// Inactivity decay. decay_floor defaults to default_rating.
+	DecayGraceDays int  `json:"decay_grace_days"`
+	DecayPerWeek   int  `json:"decay_per_week"`
+	DecayFloor     *int `json:"decay_floor"`
+	// Placements. 0 placement_matches disables the provisional period.
+	PlacementMatches     int     `json:"placement_matches"`
+	PlacementKMultiplier float64 `json:"placement_k_multiplier"`
+	// Teams. team_count x team_size players per match (e.g. 2x3);
+	// lobby_size may be omitted and is derived from them.
+	TeamCount int `json:"team_count"`
+	TeamSize  int `json:"team_size"`
+	// ReadyCheckSeconds > 0 makes every paired player accept the match
+	// within that many seconds before a server starts.
+	ReadyCheckSeconds int `json:"ready_check_seconds"`
+	// Rating window schedule for rating matchmaking. Omit the steps for
+	// the default (100 → 300 after 30s → unbounded after 60s); a
+	// rating_window_cap > 0 keeps the window from ever exceeding it.
+	RatingWindowSteps []models.RatingWindowStep `json:"rating_window_steps"`
+	RatingWindowCap   int                       `json:"rating_window_cap"`
+	// MaxPingMs > 0 only groups players into regions they reported a
+	// ping within this many milliseconds to on join.
+	MaxPingMs int `json:"max_ping_ms"`
+	// MinPlayers > 0 lets a match start short of lobby_size once that
+	// many queued players have waited fill_timeout_seconds.
+	MinPlayers         int `json:"min_players"`
+	FillTimeoutSeconds int `json:"fill_timeout_seconds"`
+	// Penalties. Points charged for declining a ready check, dodging a
+	// paired match or abandoning a running one (0 = not penalized);
+	// enough points lock a player out of the game's queues per
+	// penalty_lockout_steps. Points decay penalty_decay_per_hour an hour
+	// (default 1).
+	PenaltyDeclinePoints int                         `json:"penalty_decline_points"`
+	PenaltyDodgePoints   int                         `json:"penalty_dodge_points"`
+	PenaltyAbandonPoints int                         `json:"penalty_abandon_points"`
+	PenaltyDecayPerHour  *int                        `json:"penalty_decay_per_hour"`
+	PenaltyLockoutSteps  []models.PenaltyLockoutStep `json:"penalty_lockout_steps"`
+	// Rematch avoidance. Players who met within the last
+	// rematch_lookback_minutes aren't grouped again until both have
+	// waited rematch_max_wait_seconds (0 = never).
+	RematchLookbackMinutes int `json:"rematch_lookback_minutes"`
+	RematchMaxWaitSeconds  int `json:"rematch_max_wait_seconds"`
+	// HeartbeatGraceSeconds > 0 requires game servers to POST
+	// /match/heartbeat at least this often; silent matches are ended.
+	HeartbeatGraceSeconds int `json:"heartbeat_grace_seconds"`
+	// Match duration. Matches are ended as "timeout" after
+	// max_match_duration_minutes (0 = 360); game servers may push that
+	// back by up to max_match_extension_minutes in total.
+	MaxMatchDurationMinutes  int `json:"max_match_duration_minutes"`
+	MaxMatchExtensionMinutes int `json:"max_match_extension_minutes"`
+	// Result reporting. "server" (default) takes results from the game
+	// server; "clients" starts no server and has players report through
+	// /matches/{matchID}/report, recording the result once result_quorum
+	// of them agree (0 = a majority), or from the reports received
+	// result_report_window_seconds (0 = 300) after the first.
+	ResultReporting           string `json:"result_reporting"`
+	ResultQuorum              int    `json:"result_quorum"`
+	ResultReportWindowSeconds int    `json:"result_report_window_seconds"`
 }
 
 // requireGameOwner loads the parent game and verifies the caller owns it.
@@ -73,16 +139,45 @@ func CreateGameQueue(ctx echo.Context) error {
 	}
 
 	queue, err := models.CreateGameQueue(gameID, models.CreateGameQueueParams{
-		Name:                    req.Name,
-		LobbyEnabled:            req.LobbyEnabled,
-		LobbySize:               req.LobbySize,
-		MatchmakingStrategy:     req.MatchmakingStrategy,
-		MatchmakingMachineName:  req.MatchmakingMachineName,
-		MatchmakingMachinePorts: req.MatchmakingMachinePorts,
-		ELOStrategy:             req.ELOStrategy,
-		DefaultRating:           req.DefaultRating,
-		KFactor:                 req.KFactor,
-		MetadataEnabled:         req.MetadataEnabled,
+		Name:                      req.Name,
+		LobbyEnabled:              req.LobbyEnabled,
+		LobbySize:                 req.LobbySize,
+		MatchmakingStrategy:       req.MatchmakingStrategy,
+		MatchmakingMachineName:    req.MatchmakingMachineName,
+		MatchmakingMachinePorts:   req.MatchmakingMachinePorts,
+		ELOStrategy:               req.ELOStrategy,
+		DefaultRating:             req.DefaultRating,
+		KFactor:                   req.KFactor,
+		MetadataEnabled:           req.MetadataEnabled,
+		SeasonEndsAt:              req.SeasonEndsAt,
+		SeasonLengthDays:          req.SeasonLengthDays,
+		SeasonSoftReset:           req.SeasonSoftReset,
+		DecayGraceDays:            req.DecayGraceDays,
+		DecayPerWeek:              req.DecayPerWeek,
+		DecayFloor:                req.DecayFloor,
+		PlacementMatches:          req.PlacementMatches,
+		PlacementKMultiplier:      req.PlacementKMultiplier,
+		TeamCount:                 req.TeamCount,
+		TeamSize:                  req.TeamSize,
+		ReadyCheckSeconds:         req.ReadyCheckSeconds,
+		RatingWindowSteps:         req.RatingWindowSteps,
+		RatingWindowCap:           req.RatingWindowCap,
+		MaxPingMs:                 req.MaxPingMs,
+		MinPlayers:                req.MinPlayers,
+		FillTimeoutSeconds:        req.FillTimeoutSeconds,
+		PenaltyDeclinePoints:      req.PenaltyDeclinePoints,
+		PenaltyDodgePoints:        req.PenaltyDodgePoints,
+		PenaltyAbandonPoints:      req.PenaltyAbandonPoints,
+		PenaltyDecayPerHour:       req.PenaltyDecayPerHour,
+		PenaltyLockoutSteps:       req.PenaltyLockoutSteps,
+		RematchLookbackMinutes:    req.RematchLookbackMinutes,
+		RematchMaxWaitSeconds:     req.RematchMaxWaitSeconds,
+		HeartbeatGraceSeconds:     req.HeartbeatGraceSeconds,
+		MaxMatchDurationMinutes:   req.MaxMatchDurationMinutes,
+		MaxMatchExtensionMinutes:  req.MaxMatchExtensionMinutes,
+		ResultReporting:           req.ResultReporting,
+		ResultQuorum:              req.ResultQuorum,
+		ResultReportWindowSeconds: req.ResultReportWindowSeconds,
 	})
 	if err != nil {
 		if isUniqueConstraintViolation(err) {
@@ -252,3 +347,53 @@ func DeleteGameQueue(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, echo.Map{"message": "Queue deleted successfully"})
 }
+
+// GetGameQueueStats godoc
+// @Summary      Get a queue's matchmaking statistics
+// @Description  Summarizes recent matchmaking in a queue across all its sub-queues: matches formed (and per hour), players matched, average rating spread of formed groups, wait-time median and 90th percentile, and the abandonment rate — the share of queue entries (a party counts once) that left, timed out, or failed a ready check instead of being matched. Game-owner only.
+// @Tags         Games
+// @Produce      json
+// @Security     BearerAuth
+// @Param        gameID       path  string true  "Game UUID"
+// @Param        queueID      path  string true  "GameQueue UUID"
+// @Param        window_hours query int    false "How many recent hours to cover (1-24, default 1)"
+// @Success      200 {object} matchmaking.QueueStats
+// @Failure      400 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /game/{gameID}/queue/{queueID}/stats [get]
+func GetGameQueueStats(ctx echo.Context) error {
+	gameID := ctx.Param("gameID")
+	queueID := ctx.Param("queueID")
+	if gameID == "" || queueID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "gameID and queueID are required")
+	}
+
+	if _, _, err := requireGameOwner(ctx, gameID); err != nil {
+		return err
+	}
+
+	existing, err := models.GetGameQueue(queueID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Queue not found")
+	}
+	if existing.GameID != gameID {
+		return echo.NewHTTPError(http.StatusNotFound, "Queue not found")
+	}
+
+	windowHours := 1
+	if v := ctx.QueryParam("window_hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > matchmaking.MaxQueueStatsWindowHours {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("window_hours must be between 1 and %d", matchmaking.MaxQueueStatsWindowHours))
+		}
+		windowHours = n
+	}
+
+	stats, err := matchmaking.GetQueueStats(ctx.Request().Context(), queueID, windowHours)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "error reading queue stats: "+err.Error())
+	}
+	return ctx.JSON(http.StatusOK, stats)
+}
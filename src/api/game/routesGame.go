@@ -17,6 +17,7 @@ func InitRoutes(e *echo.Echo) error {
 	e.GET("/game/:gameID/queue/:queueID", GetGameQueue)
 	e.PUT("/game/:gameID/queue/:queueID", UpdateGameQueue, auth.RequireUserAuth)
 	e.DELETE("/game/:gameID/queue/:queueID", DeleteGameQueue, auth.RequireUserAuth)
+	e.GET("/game/:gameID/queue/:queueID/stats", GetGameQueueStats, auth.RequireUserAuth)
 	// Admin
 	e.GET("/games", GetGames, auth.RequireAdmin)
 	// e.POST("/game/:id/snapshot", CreateGameSnapshot, auth.RequireAdmin)
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/andy98725/elo-service/src/api/auth"
 	"github.com/andy98725/elo-service/src/api/wsliveness"
 	"github.com/andy98725/elo-service/src/external/redis"
 	"github.com/andy98725/elo-service/src/models"
@@ -332,7 +333,7 @@ func runLobbySession(
 			if !ok {
 				return
 			}
-			handleMatchReady(ctx, conn, playerID, ready.Payload)
+			handleMatchReady(ctx, conn, playerID, playerName, ready.Payload)
 			return
 		case text := <-inbound:
 			if text == "" {
@@ -423,7 +424,9 @@ func runHostCommand(ctx context.Context, rec *redis.LobbyRecord, game *models.Ga
 		// Lobby flow doesn't go through the queue list — it dispatches
 		// directly to StartMatch with the resolved queue. The composite
 		// arg is just queue.ID (no metadata segmentation in lobby flow).
-		if err := matchmaking.StartMatch(ctx, game, queue, queue.ID, ids, &spectateOverride); err != nil {
+		// Lobbies don't collect pings, so the match goes to the default
+		// region.
+		if err := matchmaking.StartMatch(ctx, game, queue, queue.ID, matchmaking.SoloEntries(ids), "", &spectateOverride); err != nil {
 			slog.Error("Failed to start match from lobby", "error", err, "lobbyID", rec.ID)
 			server.S.Redis.PublishLobbyEvent(ctx, rec.ID, mustJSON(lobbyEvent{
 				Event:   "player_say",
@@ -444,7 +447,7 @@ func runHostCommand(ctx context.Context, rec *redis.LobbyRecord, game *models.Ga
 
 // handleMatchReady mirrors the post-match-found path in matchmaking.go so
 // existing clients can share the same handshake after either flow.
-func handleMatchReady(ctx echo.Context, conn *websocket.Conn, playerID, payload string) {
+func handleMatchReady(ctx echo.Context, conn *websocket.Conn, playerID, playerName, payload string) {
 	if strings.HasPrefix(payload, "error:") {
 		conn.WriteJSON(echo.Map{"status": "error", "error": strings.TrimPrefix(payload, "error:")})
 		return
@@ -461,33 +464,51 @@ func handleMatchReady(ctx echo.Context, conn *websocket.Conn, playerID, payload
 		return
 	}
 
-	conn.WriteJSON(echo.Map{"status": "server_starting", "message": "Match found, waiting for server to start..."})
+	// Client-reported (peer-to-peer) matches have no server to wait on.
+	if match.ServerInstanceID != "" {
+		conn.WriteJSON(echo.Map{"status": "server_starting", "message": "Match found, waiting for server to start..."})
 
-	healthURL := fmt.Sprintf("http://%s:%d/containers/%s/health",
-		match.ServerInstance.MachineHost.PublicIP,
-		match.ServerInstance.MachineHost.AgentPort,
-		match.ServerInstance.ContainerID)
-	ready, err := util.WaitUntilServerReady(ctx.Request().Context(), healthURL, server.S.Shutdown)
+		healthURL := fmt.Sprintf("http://%s:%d/containers/%s/health",
+			match.ServerInstance.MachineHost.PublicIP,
+			match.ServerInstance.MachineHost.AgentPort,
+			match.ServerInstance.ContainerID)
+		ready, err := util.WaitUntilServerReady(ctx.Request().Context(), healthURL, server.S.Shutdown)
+		if err != nil {
+			conn.WriteJSON(echo.Map{"status": "error", "error": err.Error()})
+			return
+		}
+		if !ready {
+			conn.WriteJSON(echo.Map{"status": "error", "error": "server not ready"})
+			return
+		}
+	}
+	// Match the matchmaking flow's wire format: server_host + server_ports +
+	// match_id + connect_token (the signed join credential the receiving
+	// player presents to the game server). Hostname preferred over IP when
+	// wildcard TLS is enabled.
+	connectToken, err := auth.IssueConnectToken(match.ID, match.AuthCode, playerID, playerName)
 	if err != nil {
 		conn.WriteJSON(echo.Map{"status": "error", "error": err.Error()})
 		return
 	}
-	if !ready {
-		conn.WriteJSON(echo.Map{"status": "error", "error": "server not ready"})
-		return
-	}
-	// Match the matchmaking flow's wire format: server_host + server_ports +
-	// match_id + connect_token (the join credential the receiving player
-	// presents to the game server). Hostname preferred over IP when wildcard
-	// TLS is enabled. connect_token = playerID for now; phase 2 swaps it for
-	// a per-(match, player) generated secret.
-	conn.WriteJSON(echo.Map{
+	found := echo.Map{
 		"status":        "match_found",
-		"server_host":   match.ServerInstance.MachineHost.PublicAddress(),
-		"server_ports":  []int64(match.ServerInstance.HostPorts),
 		"match_id":      match.ID,
-		"connect_token": playerID,
-	})
+		"connect_token": connectToken,
+	}
+	if match.ServerInstanceID != "" {
+		found["server_host"] = match.ServerInstance.MachineHost.PublicAddress()
+		found["server_ports"] = []int64(match.ServerInstance.HostPorts)
+		found["region"] = match.ServerInstance.MachineHost.Region
+	} else {
+		found["player_ids"] = match.PlayerIDs()
+		found["result_reporting"] = models.RESULT_REPORTING_CLIENTS
+	}
+	if teams := match.TeamLayout(); teams != nil {
+		found["teams"] = teams
+		found["team"] = match.TeamOf(playerID)
+	}
+	conn.WriteJSON(found)
 }
 
 func lobbyTTLRefresh(ctx context.Context, lobbyID, playerID string) chan struct{} {
@@ -0,0 +1,113 @@
+package match
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/andy98725/elo-service/src/worker/matchmaking"
+	"github.com/labstack/echo"
+)
+
+type BackfillRequest struct {
+	// Players is how many replacements the match wants; 0 withdraws an
+	// open request.
+	Players int `json:"players"`
+	// Team optionally names the team replacements join, on team matches.
+	// When omitted they go to the teams with the fewest players left.
+	Team *int `json:"team"`
+}
+
+// matchFromAuthCode resolves the running match whose auth code is carried
+// in Authorization: Bearer.
+func matchFromAuthCode(ctx echo.Context) (*models.Match, error) {
+	token := ctx.Request().Header.Get("Authorization")
+	if strings.HasPrefix(token, "Bearer ") {
+		token = strings.TrimPrefix(token, "Bearer ")
+	}
+	if token == "" {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "missing match auth token")
+	}
+
+	match, err := models.GetMatchByTokenID(token)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "invalid match auth token")
+	}
+	if match.Status != models.MatchStatusStarted {
+		return nil, echo.NewHTTPError(http.StatusForbidden, "match is not underway")
+	}
+	return match, nil
+}
+
+// RequestBackfill godoc
+// @Summary      Request backfill players for the running match
+// @Description  Game server asks the matchmaker for `players` replacement players, e.g. after someone drops, up to the match's open seats (lobby size less the players still in it; report players who left through /match/leave). Auth is the match auth_code carried as Authorization: Bearer <code>. The request is served ahead of new matches in the queue (and metadata sub-queue) the match was paired from, taking the longest-waiting players that fit and can play in the match's region; they receive match_found with this server's host and ports and are added to the match's players. A request may be filled in several steps, and stays open for 10 minutes. A new request replaces the open one; `players: 0` withdraws it. On team matches, `team` picks the team replacements join; by default they go to the teams with the fewest players left. A replacement takes the team slot of a player who left. Poll GET /match/backfill to learn who joined.
+// @Tags         Matches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body BackfillRequest true "Backfill request"
+// @Success      200 {object} map[string]interface{} "match_id, players_requested"
+// @Failure      400 {object} echo.HTTPError
+// @Failure      401 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError "match is not underway"
+// @Failure      500 {object} echo.HTTPError
+// @Router       /match/backfill [post]
+func RequestBackfill(ctx echo.Context) error {
+	match, err := matchFromAuthCode(ctx)
+	if err != nil {
+		return err
+	}
+	req := new(BackfillRequest)
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := matchmaking.RequestBackfill(ctx.Request().Context(), match, req.Players, req.Team); err != nil {
+		if strings.HasPrefix(err.Error(), "invalid ") {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, echo.Map{"match_id": match.ID, "players_requested": req.Players})
+}
+
+// GetBackfill godoc
+// @Summary      Get the running match's players and backfill status
+// @Description  Game server reads the match's current players — including any backfilled since it started — and how many backfill seats are still open. Auth is the match auth_code carried as Authorization: Bearer <code>.
+// @Tags         Matches
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{} "match_id, player_ids, teams, players_requested"
+// @Failure      401 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError "match is not underway"
+// @Failure      500 {object} echo.HTTPError
+// @Router       /match/backfill [get]
+func GetBackfill(ctx echo.Context) error {
+	match, err := matchFromAuthCode(ctx)
+	if err != nil {
+		return err
+	}
+
+	requested := 0
+	rec, err := server.S.Redis.GetBackfill(ctx.Request().Context(), match.ID)
+	if err == nil {
+		requested = rec.Players
+	} else if !errors.Is(err, extRedis.ErrBackfillNotFound) {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	playerIDs := make([]string, 0, len(match.Players)+len(match.GuestIDs))
+	for _, p := range match.Players {
+		playerIDs = append(playerIDs, p.ID)
+	}
+	playerIDs = append(playerIDs, match.GuestIDs...)
+	resp := echo.Map{"match_id": match.ID, "player_ids": playerIDs, "players_requested": requested}
+	if teams := match.TeamLayout(); teams != nil {
+		resp["teams"] = teams
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
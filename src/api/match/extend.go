@@ -0,0 +1,61 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/labstack/echo"
+)
+
+type ExtendRequest struct {
+	// Minutes is how far to push the match's deadline back.
+	Minutes int `json:"minutes"`
+}
+
+// ExtendMatch godoc
+// @Summary      Extend the running match's deadline
+// @Description  Game server pushes back the deadline at which the matchmaker ends its match as "timeout". Auth is the match auth_code carried as Authorization: Bearer <code>. A match's deadline starts at the queue's max_match_duration_minutes after match start; extensions add `minutes` to it, up to max_match_extension_minutes in total. An extension past that limit is cut short; once the limit is reached further calls are refused with 409.
+// @Tags         Matches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body ExtendRequest true "Extension"
+// @Success      200 {object} map[string]interface{} "match_id, deadline, latest_deadline"
+// @Failure      400 {object} echo.HTTPError
+// @Failure      401 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError "match is not underway"
+// @Failure      409 {object} echo.HTTPError "match deadline can't be extended further"
+// @Failure      500 {object} echo.HTTPError
+// @Router       /match/extend [post]
+func ExtendMatch(ctx echo.Context) error {
+	match, err := matchFromAuthCode(ctx)
+	if err != nil {
+		return err
+	}
+	req := new(ExtendRequest)
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if req.Minutes < 1 || req.Minutes > models.MaxMatchLengthMinutes {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid minutes: must be between 1 and %d", models.MaxMatchLengthMinutes))
+	}
+
+	deadline, err := models.ExtendMatchDeadline(match, time.Duration(req.Minutes)*time.Minute)
+	if err != nil {
+		if errors.Is(err, models.ErrMatchDeadlineMaxed) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if errors.Is(err, models.ErrMatchNotUnderway) {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"match_id":        match.ID,
+		"deadline":        deadline,
+		"latest_deadline": match.LatestDeadline().UTC(),
+	})
+}
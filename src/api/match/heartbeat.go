@@ -0,0 +1,34 @@
+package match
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/labstack/echo"
+)
+
+// Heartbeat godoc
+// @Summary      Report that the game server is alive
+// @Description  Game server tells the matchmaker it's still running the match. Auth is the match auth_code carried as Authorization: Bearer <code>. On queues with heartbeat_grace_seconds set, a match whose server goes that long without a heartbeat (counted from match start until the first one) is ended: as "server_crashed" when its container is gone, or as "server_unresponsive" when the container is still running but stays silent for three grace periods. Heartbeats are accepted, and ignored, on queues without it. Send one every grace/3 seconds or so.
+// @Tags         Matches
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{} "match_id, heartbeat_grace_seconds"
+// @Failure      401 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError "match is not underway"
+// @Failure      500 {object} echo.HTTPError
+// @Router       /match/heartbeat [post]
+func Heartbeat(ctx echo.Context) error {
+	match, err := matchFromAuthCode(ctx)
+	if err != nil {
+		return err
+	}
+	if err := models.RecordMatchHeartbeat(match.ID, time.Now().UTC()); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"match_id":                match.ID,
+		"heartbeat_grace_seconds": match.GameQueue.HeartbeatGraceSeconds,
+	})
+}
@@ -0,0 +1,60 @@
+package match
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/worker/matchmaking"
+	"github.com/labstack/echo"
+)
+
+type LeaveRequest struct {
+	// PlayerID is the player who left or was removed.
+	PlayerID string `json:"player_id"`
+	// Reason is free-form, e.g. "left", "disconnected" or "kicked".
+	// Defaults to "left".
+	Reason string `json:"reason"`
+}
+
+// ReportLeave godoc
+// @Summary      Report a player leaving the running match
+// @Description  Game server records that a player left, disconnected for good, or was kicked before the match ended, without ending the match. Auth is the match auth_code carried as Authorization: Bearer <code>. When the match ends, players who left are rated as losers — placed behind everyone who stayed, and split off their team on team matches — whatever the final report says about them, and the result lists them under `abandons`. Leaving costs the queue's penalty_abandon_points. A player can only leave once; repeating the call returns the first leave with `recorded: false`.
+// @Tags         Matches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body LeaveRequest true "Leave"
+// @Success      200 {object} map[string]interface{} "match_id, leave, recorded"
+// @Failure      400 {object} echo.HTTPError
+// @Failure      401 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError "match is not underway"
+// @Failure      500 {object} echo.HTTPError
+// @Router       /match/leave [post]
+func ReportLeave(ctx echo.Context) error {
+	match, err := matchFromAuthCode(ctx)
+	if err != nil {
+		return err
+	}
+	req := new(LeaveRequest)
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if req.PlayerID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "player_id is required")
+	}
+
+	leave, recorded, err := models.RecordMatchLeave(match.ID, req.PlayerID, req.Reason, time.Now())
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid ") || errors.Is(err, models.ErrNotMatchParticipant) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if recorded {
+		matchmaking.PenalizeAbandon(match, req.PlayerID)
+	}
+	return ctx.JSON(http.StatusOK, echo.Map{"match_id": match.ID, "leave": leave, "recorded": recorded})
+}
@@ -2,13 +2,16 @@ package match
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/andy98725/elo-service/src/api/auth"
 	"github.com/andy98725/elo-service/src/api/wsliveness"
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
 	"github.com/andy98725/elo-service/src/models"
 	"github.com/andy98725/elo-service/src/server"
 	"github.com/andy98725/elo-service/src/util"
@@ -30,12 +33,13 @@ const maxMetadataBytes = 4096
 
 // JoinQueueWebsocket godoc
 // @Summary      Join matchmaking queue (WebSocket)
-// @Description  Upgrades to a WebSocket connection and joins the matchmaking queue for a game. Sends status updates until a match is found.
+// @Description  Upgrades to a WebSocket connection and joins the matchmaking queue for a game. Sends status updates until a match is found. On queues with a ready check, a paired player receives match_proposed and must send /accept (or /decline) before the deadline; if anyone else fails to accept, accepting players receive requeued and keep their place at the front of the queue. A party leader queues the whole party as one unit; other party members connect with the same gameID/queueID/metadata to follow the leader's search and receive the same match_found. Clients may report measured pings to the server's regions; the matchmaker then groups players by region (honoring the queue's max_ping_ms) and match_found says which region the server is in. One connection may search several queues of the game at once: repeat queueID and/or metadata, and the player searches every queueID × metadata pairing (at most 8). Being paired in any of them withdraws the player from all the others, and match_found names the queue_id that paired them.
 // @Tags         Matchmaking
 // @Security     BearerAuth
 // @Param        gameID   query string true  "Game UUID to queue for"
-// @Param        queueID  query string false "Specific GameQueue UUID. Defaults to the game's primary queue (oldest by created_at) when omitted."
-// @Param        metadata query string false "Optional sub-queue key (only honored when the resolved queue's metadata_enabled=true; capped at 4 KB)"
+// @Param        queueID  query []string false "Specific GameQueue UUID; repeat to search several. Defaults to the game's primary queue (oldest by created_at) when omitted." collectionFormat(multi)
+// @Param        metadata query []string false "Optional sub-queue key (only honored when the resolved queue's metadata_enabled=true; capped at 4 KB); repeat to search several." collectionFormat(multi)
+// @Param        pings    query string false "Measured round-trip times per region, e.g. nbg1:35,ash:120. Unknown regions are ignored."
 // @Param        token    query string false "JWT token (alternative to Authorization header)"
 // @Router       /match/join [get]
 func JoinQueueWebsocket(ctx echo.Context) error {
@@ -51,40 +55,67 @@ func JoinQueueWebsocket(ctx echo.Context) error {
 		conn.WriteJSON(echo.Map{"status": "error", "error": "gameID is required"})
 		return nil
 	}
-	queueIDParam := ctx.QueryParam("queueID")
-	metadata := ctx.QueryParam("metadata")
-	if len(metadata) > maxMetadataBytes {
-		conn.WriteJSON(echo.Map{"status": "error", "error": "metadata exceeds maximum size"})
+	metadata := ctx.QueryParams()["metadata"]
+	for _, m := range metadata {
+		if len(m) > maxMetadataBytes {
+			conn.WriteJSON(echo.Map{"status": "error", "error": "metadata exceeds maximum size"})
+			return nil
+		}
+	}
+	pings, err := matchmaking.ParsePings(ctx.QueryParam("pings"))
+	if err != nil {
+		conn.WriteJSON(echo.Map{"status": "error", "error": err.Error()})
 		return nil
 	}
 
-	// Resolve the GameQueue up front so we can subscribe match_ready on the
-	// right per-queue channel BEFORE inserting the player into the queue
-	// list. Otherwise a publish from the worker can race the SUBSCRIBE.
-	queue, err := models.ResolveQueue(gameID, queueIDParam)
+	// Resolve the GameQueues up front so we can subscribe match_ready on
+	// the right per-queue channels BEFORE inserting the player into the
+	// queue lists. Otherwise a publish from the worker can race the
+	// SUBSCRIBE.
+	targets, err := matchmaking.ResolveSearch(gameID, ctx.QueryParams()["queueID"], metadata)
 	if err != nil {
-		conn.WriteJSON(echo.Map{"status": "error", "error": "queue not found: " + err.Error()})
+		conn.WriteJSON(echo.Map{"status": "error", "error": err.Error()})
 		return nil
 	}
 
 	// Listen for match ready before joining queue
 	readyChan := make(chan matchmaking.QueueResult, 1)
-	matchmaking.NotifyOnReady(ctx.Request().Context(), id, queue.ID, readyChan)
+	subscribed := make(map[string]bool)
+	for _, target := range targets {
+		if !subscribed[target.Queue.ID] {
+			subscribed[target.Queue.ID] = true
+			matchmaking.NotifyOnReady(ctx.Request().Context(), id, target.Queue.ID, readyChan)
+		}
+	}
 
-	joinResult, err := matchmaking.JoinQueue(ctx.Request().Context(), id, gameID, queue.ID, metadata)
+	joinResults, err := matchmaking.JoinQueue(ctx.Request().Context(), id, targets, pings)
 	if err != nil {
-		slog.Warn("Failed to join queue", "error", err)
-		conn.WriteJSON(echo.Map{"status": "error", "error": err.Error()})
+		msg := echo.Map{"status": "error", "error": err.Error()}
+		var lockout *matchmaking.LockoutError
+		if errors.As(err, &lockout) {
+			msg["locked_until"] = lockout.Until.UTC()
+			msg["lockout_seconds"] = int(time.Until(lockout.Until).Seconds()) + 1
+		} else {
+			slog.Warn("Failed to join queue", "error", err)
+		}
+		conn.WriteJSON(msg)
 		return nil
 	}
+	joinResult := joinResults[0]
 
-	// Start TTL refresh goroutine using the same queue ID the player joined
-	ttlChan := ttlRefresh(ctx.Request().Context(), joinResult.QueueID, id)
-	defer close(*ttlChan)
+	// Start a TTL refresh goroutine for each queue the player joined.
+	// A party member who isn't the leader owns no entry — the leader's
+	// connection keeps the party's entry alive.
+	for _, res := range joinResults {
+		if res.EntryID != "" {
+			ttlChan := ttlRefresh(ctx.Request().Context(), res.QueueID, res.EntryID)
+			defer close(*ttlChan)
+		}
+	}
 
 	// Send searching status every 5 seconds
 	status := "searching"
-	statusChan := statusRefresh(ctx.Request().Context(), conn, &status)
+	statusChan := statusRefresh(ctx.Request().Context(), conn, &status, joinResult.QueueID)
 	defer close(*statusChan)
 
 	// Drive WS keepalive so half-open peers (sleeping laptops, NAT drops)
@@ -114,23 +145,78 @@ func JoinQueueWebsocket(ctx echo.Context) error {
 			select {
 			case inbound <- text:
 			default:
-				// Buffer full — the only command currently honored on
-				// /match/join is /disconnect, so don't block the read pump
-				// (and the Pong dispatch it carries) on a chatty client.
+				// Buffer full — /match/join only honors a few one-shot
+				// commands (/disconnect, /accept, /decline), so don't
+				// block the read pump (and the Pong dispatch it carries)
+				// on a chatty client.
 			}
 		}
 	}()
 
 	// Queue is joined, now we need to wait for the match to start
-	conn.WriteJSON(echo.Map{"status": "queue_joined", "players_in_queue": joinResult.QueueSize})
+	joined := withWaitEstimate(ctx.Request().Context(), echo.Map{"status": "queue_joined", "players_in_queue": joinResult.QueueSize}, joinResult.QueueID)
+	if joinResult.PartyID != "" {
+		joined["party_id"] = joinResult.PartyID
+	}
+	// A multi-queue search also reports each queue it's waiting in; the
+	// top-level fields describe the first.
+	if len(joinResults) > 1 {
+		queues := make([]echo.Map, len(joinResults))
+		for i, res := range joinResults {
+			queues[i] = withWaitEstimate(ctx.Request().Context(), echo.Map{
+				"queue_id":         res.GameQueueID,
+				"metadata":         res.Metadata,
+				"players_in_queue": res.QueueSize,
+			}, res.QueueID)
+		}
+		joined["queues"] = queues
+	}
+	conn.WriteJSON(joined)
+
+	// proposalID is the ready check awaiting this player's answer, if any.
+	// Leaving mid-check counts as declining it.
+	var proposalID string
+	declinePending := func() {
+		if proposalID == "" {
+			return
+		}
+		if err := matchmaking.RespondToProposal(context.Background(), proposalID, id, false); err != nil && !errors.Is(err, extRedis.ErrProposalNotFound) {
+			slog.Warn("Failed to decline match proposal", "error", err, "playerID", id, "proposalID", proposalID)
+		}
+	}
 
 	for {
 		select {
 		case text := <-inbound:
+			if text == "/accept" || text == "/decline" {
+				if proposalID == "" {
+					conn.WriteJSON(echo.Map{"status": "error", "error": "no match to " + strings.TrimPrefix(text, "/")})
+					continue
+				}
+				accept := text == "/accept"
+				if err := matchmaking.RespondToProposal(ctx.Request().Context(), proposalID, id, accept); err != nil {
+					if !errors.Is(err, extRedis.ErrProposalNotFound) {
+						slog.Warn("Failed to answer match proposal", "error", err, "playerID", id, "proposalID", proposalID)
+					}
+					continue
+				}
+				if accept {
+					conn.WriteJSON(echo.Map{"status": "accepted"})
+				}
+				continue
+			}
 			if text == "/disconnect" {
-				if err := server.S.Redis.RemovePlayerFromQueue(ctx.Request().Context(), joinResult.QueueID, id); err != nil {
-					slog.Warn("Failed to remove player from queue on /disconnect",
-						"error", err, "playerID", id, "queueID", joinResult.QueueID)
+				declinePending()
+				// Only the entry's owner withdraws it; a following party
+				// member just stops listening.
+				for _, res := range joinResults {
+					if res.EntryID == "" {
+						continue
+					}
+					if err := matchmaking.CancelQueueEntry(ctx.Request().Context(), res.QueueID, res.EntryID); err != nil {
+						slog.Warn("Failed to remove player from queue on /disconnect",
+							"error", err, "playerID", id, "queueID", res.QueueID)
+					}
 				}
 				conn.WriteJSON(echo.Map{"status": "disconnected"})
 				return nil
@@ -138,6 +224,23 @@ func JoinQueueWebsocket(ctx echo.Context) error {
 			// Unknown commands are silently ignored to leave room for
 			// future additions without breaking older clients.
 		case resp := <-readyChan:
+			if resp.ProposalID != "" {
+				proposalID = resp.ProposalID
+				status = "match_proposed"
+				conn.WriteJSON(echo.Map{
+					"status":      status,
+					"proposal_id": resp.ProposalID,
+					"expires_at":  resp.ExpiresAt.UTC(),
+				})
+				continue
+			}
+			if resp.Requeued != "" {
+				proposalID = ""
+				status = "searching"
+				conn.WriteJSON(echo.Map{"status": "requeued", "message": resp.Requeued})
+				continue
+			}
+			proposalID = ""
 			if resp.Error != nil {
 				conn.WriteJSON(echo.Map{"status": "error", "error": resp.Error.Error()})
 				return nil
@@ -149,40 +252,76 @@ func JoinQueueWebsocket(ctx echo.Context) error {
 				return nil
 			}
 
-			status = "server_starting"
-			conn.WriteJSON(echo.Map{"status": status, "message": "Match found, waiting for server to start..."})
+			// Client-reported (peer-to-peer) matches have no server to
+			// wait on.
+			if match.ServerInstanceID != "" {
+				status = "server_starting"
+				conn.WriteJSON(echo.Map{"status": status, "message": "Match found, waiting for server to start..."})
 
-			healthURL := fmt.Sprintf("http://%s:%d/containers/%s/health",
-				match.ServerInstance.MachineHost.PublicIP,
-				match.ServerInstance.MachineHost.AgentPort,
-				match.ServerInstance.ContainerID)
-			ready, err := util.WaitUntilServerReady(ctx.Request().Context(), healthURL, server.S.Shutdown)
+				healthURL := fmt.Sprintf("http://%s:%d/containers/%s/health",
+					match.ServerInstance.MachineHost.PublicIP,
+					match.ServerInstance.MachineHost.AgentPort,
+					match.ServerInstance.ContainerID)
+				ready, err := util.WaitUntilServerReady(ctx.Request().Context(), healthURL, server.S.Shutdown)
+				if err != nil {
+					slog.Warn("Failed to wait until server is ready", "error", err, "matchID", resp.MatchID)
+					conn.WriteJSON(echo.Map{"status": "error", "error": err.Error()})
+					return nil
+				}
+				if !ready {
+					conn.WriteJSON(echo.Map{"status": "error", "error": "server not ready"})
+					return nil
+				}
+			}
+			// connect_token is the credential the client presents to the
+			// game server when joining: a signed, short-lived JWT naming
+			// this match and player, verifiable offline against the JWKS.
+			connectToken, err := auth.IssueConnectToken(match.ID, match.AuthCode, id, displayName(ctx))
 			if err != nil {
-				slog.Warn("Failed to wait until server is ready", "error", err, "matchID", resp.MatchID)
 				conn.WriteJSON(echo.Map{"status": "error", "error": err.Error()})
 				return nil
 			}
-			if !ready {
-				conn.WriteJSON(echo.Map{"status": "error", "error": "server not ready"})
-				return nil
+			found := echo.Map{
+				"status":        "match_found",
+				"match_id":      match.ID,
+				"queue_id":      match.GameQueueID,
+				"connect_token": connectToken,
 			}
-			conn.WriteJSON(echo.Map{
-				"status": "match_found",
+			if match.ServerInstanceID != "" {
 				// Prefer hostname when wildcard TLS is on so WebGL clients
 				// can wss:// to it; falls back to IP otherwise.
-				"server_host":  match.ServerInstance.MachineHost.PublicAddress(),
-				"server_ports": []int64(match.ServerInstance.HostPorts),
-				"match_id":     match.ID,
-				// connect_token is the credential the client presents to the
-				// game server when joining. Today it equals the player's id —
-				// the wire-compatible no-op shape that lets us land the API
-				// concept ahead of swapping the value to a generated secret.
-				"connect_token": id,
-			})
+				found["server_host"] = match.ServerInstance.MachineHost.PublicAddress()
+				found["server_ports"] = []int64(match.ServerInstance.HostPorts)
+				found["region"] = match.ServerInstance.MachineHost.Region
+			} else {
+				// Peer-to-peer: players find each other by ID and each
+				// report the result through /matches/{matchID}/report.
+				found["player_ids"] = match.PlayerIDs()
+				found["result_reporting"] = models.RESULT_REPORTING_CLIENTS
+			}
+			// Team queues also say who's on which team: teams is the full
+			// layout, team the index of the caller's own.
+			if teams := match.TeamLayout(); teams != nil {
+				found["teams"] = teams
+				found["team"] = match.TeamOf(id)
+			}
+			// Leaving between pairing and here is a dodge: the match
+			// started without this player ever learning where.
+			select {
+			case <-peerGone:
+				matchmaking.PenalizeDodge(match, id)
+				return nil
+			default:
+			}
+			if err := conn.WriteJSON(found); err != nil {
+				matchmaking.PenalizeDodge(match, id)
+			}
 			return nil
 		case <-peerGone:
+			declinePending()
 			return nil
 		case <-ctx.Request().Context().Done():
+			declinePending()
 			return nil
 		case <-server.S.Shutdown:
 			return nil
@@ -214,7 +353,19 @@ func ttlRefresh(ctx context.Context, queueID string, id string) *chan struct{} {
 	return &ttlRefresh
 }
 
-func statusRefresh(ctx context.Context, conn *websocket.Conn, status *string) *chan struct{} {
+// withWaitEstimate adds the composite queue's wait estimate to a status
+// message, when there is one.
+func withWaitEstimate(ctx context.Context, msg echo.Map, composite string) echo.Map {
+	if est, err := matchmaking.WaitEstimateFor(ctx, composite); err == nil && est != nil {
+		msg["estimated_wait_seconds"] = est.MedianSeconds
+		msg["p90_wait_seconds"] = est.P90Seconds
+	}
+	return msg
+}
+
+// statusRefresh sends the current status every 5s. While searching, the
+// heartbeat carries the queue's latest wait estimate.
+func statusRefresh(ctx context.Context, conn *websocket.Conn, status *string, composite string) *chan struct{} {
 	statusRefresh := make(chan struct{})
 	go func() {
 		statusTicker := time.NewTicker(5 * time.Second)
@@ -223,7 +374,11 @@ func statusRefresh(ctx context.Context, conn *websocket.Conn, status *string) *c
 		for {
 			select {
 			case <-statusTicker.C:
-				if err := conn.WriteJSON(echo.Map{"status": *status}); err != nil {
+				msg := echo.Map{"status": *status}
+				if *status == "searching" {
+					msg = withWaitEstimate(ctx, msg, composite)
+				}
+				if err := conn.WriteJSON(msg); err != nil {
 					return
 				}
 			case <-statusRefresh:
@@ -240,14 +395,14 @@ func statusRefresh(ctx context.Context, conn *websocket.Conn, status *string) *c
 
 // QueueSize godoc
 // @Summary      Get matchmaking queue size
-// @Description  Returns the number of players currently in the matchmaking queue for a game
+// @Description  Returns the number of players currently in the matchmaking queue for a game, plus the estimated wait (median and 90th percentile, in seconds, of recent waits in this queue) once the queue has formed matches recently.
 // @Tags         Matchmaking
 // @Produce      json
 // @Security     BearerAuth
 // @Param        gameID   query string true  "Game UUID"
 // @Param        queueID  query string false "Specific GameQueue UUID. Defaults to the game's primary queue when omitted."
 // @Param        metadata query string false "Sub-queue key (only honored when the resolved queue's metadata_enabled=true)"
-// @Success      200 {object} map[string]interface{} "players_in_queue"
+// @Success      200 {object} map[string]interface{} "players_in_queue, estimated_wait_seconds, p90_wait_seconds"
 // @Failure      400 {object} echo.HTTPError
 // @Failure      500 {object} echo.HTTPError
 // @Router       /match/size [get]
@@ -266,5 +421,51 @@ func QueueSize(ctx echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
-	return ctx.JSON(http.StatusOK, echo.Map{"players_in_queue": size})
+	resp := echo.Map{"players_in_queue": size}
+	est, err := matchmaking.QueueWaitEstimate(ctx.Request().Context(), gameID, queueIDParam, metadata)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if est != nil {
+		resp["estimated_wait_seconds"] = est.MedianSeconds
+		resp["p90_wait_seconds"] = est.P90Seconds
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// Lockout godoc
+// @Summary      Get my matchmaking lockout
+// @Description  Returns the caller's penalty standing in a game: current penalty points (after decay) and, while locked out of the game's queues, when the lockout ends. Points are earned by declining ready checks or leaving /match/join after being paired, per each queue's penalty settings; a lockout covers every queue of the game. Clients can use lockout_seconds to show a timer.
+// @Tags         Matchmaking
+// @Produce      json
+// @Security     BearerAuth
+// @Param        gameID   query string true  "Game UUID"
+// @Param        queueID  query string false "GameQueue whose penalty decay applies. Defaults to the game's primary queue."
+// @Success      200 {object} map[string]interface{} "game_id, points, locked, locked_until, lockout_seconds"
+// @Failure      400 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /match/lockout [get]
+func Lockout(ctx echo.Context) error {
+	gameID := ctx.QueryParam("gameID")
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "gameID is required")
+	}
+	id := ctx.Get("id").(string)
+
+	queue, err := models.ResolveQueue(gameID, ctx.QueryParam("queueID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "queue not found: "+err.Error())
+	}
+	penalty, err := models.GetPlayerPenalty(queue, id, time.Now())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := echo.Map{"game_id": gameID, "points": penalty.Points, "locked": false}
+	if now := time.Now(); penalty.IsLockedOut(now) {
+		resp["locked"] = true
+		resp["locked_until"] = penalty.LockedUntil.UTC()
+		resp["lockout_seconds"] = int(penalty.LockedUntil.Sub(now).Seconds()) + 1
+	}
+	return ctx.JSON(http.StatusOK, resp)
 }
@@ -3,6 +3,7 @@ package match
 import (
 	"net/http"
 
+	"github.com/andy98725/elo-service/src/api/auth"
 	"github.com/andy98725/elo-service/src/models"
 	"github.com/labstack/echo"
 )
@@ -14,13 +15,34 @@ type activeMatch struct {
 	MatchID      string  `json:"match_id"`
 	ServerHost   string  `json:"server_host"`
 	ServerPorts  []int64 `json:"server_ports"`
+	Region       string  `json:"region"`
 	StartedAt    string  `json:"started_at"`
-	ConnectToken string  `json:"connect_token"`
+	ConnectToken string  `json:"connect_token,omitempty"`
+	// Teams and Team are omitted for matches without a team layout.
+	Teams [][]string `json:"teams,omitempty"`
+	Team  *int       `json:"team,omitempty"`
+	// Left is set when the game server reported the caller gone from
+	// the match. They get no connect token and will be rated as a loser.
+	Left *models.MatchLeave `json:"left,omitempty"`
+}
+
+// displayName is the caller's name as carried in their connect tokens.
+func displayName(c echo.Context) string {
+	if u, ok := c.Get("user").(*models.User); ok && u != nil {
+		return u.Username
+	}
+	if g, ok := c.Get("guest").(models.Guest); ok && g.DisplayName != "" {
+		return g.DisplayName
+	}
+	if id, ok := c.Get("id").(string); ok {
+		return id
+	}
+	return ""
 }
 
 // GetMyActiveMatches godoc
 // @Summary      List the caller's active matches in a game
-// @Description  Returns every started match in this game that the caller is a participant in. Empty list when none. Used by clients to rediscover the game server after a page reload — the response shape mirrors the matchmaking WebSocket's match_found payload. Guests must preserve their JWT across reloads to use this; a fresh guest token is a new identity and won't match prior participation.
+// @Description  Returns every started match in this game that the caller is a participant in. Empty list when none. Used by clients to rediscover the game server after a page reload — the response shape mirrors the matchmaking WebSocket's match_found payload. Each call signs a fresh connect_token — except for matches the game server reported the caller as having left, which carry `left` (reason and time) instead: the caller was dropped and will be rated as a loser. Guests must preserve their JWT across reloads to use this; a fresh guest token is a new identity and won't match prior participation.
 // @Tags         Matches
 // @Produce      json
 // @Security     BearerAuth
@@ -43,13 +65,29 @@ func GetMyActiveMatches(ctx echo.Context) error {
 
 	out := make([]activeMatch, 0, len(matches))
 	for _, m := range matches {
-		out = append(out, activeMatch{
-			MatchID:      m.ID,
-			ServerHost:   m.ServerInstance.MachineHost.PublicAddress(),
-			ServerPorts:  []int64(m.ServerInstance.HostPorts),
-			StartedAt:    m.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
-			ConnectToken: playerID,
-		})
+		am := activeMatch{
+			MatchID:     m.ID,
+			ServerHost:  m.ServerInstance.MachineHost.PublicAddress(),
+			ServerPorts: []int64(m.ServerInstance.HostPorts),
+			Region:      m.ServerInstance.MachineHost.Region,
+			StartedAt:   m.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			Left:        m.LeaveOf(playerID),
+		}
+		if am.Left == nil {
+			// Connect tokens are short-lived, so a reconnecting client
+			// gets a freshly signed one rather than the token from
+			// match_found.
+			connectToken, err := auth.IssueConnectToken(m.ID, m.AuthCode, playerID, displayName(ctx))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			am.ConnectToken = connectToken
+		}
+		if teams := m.TeamLayout(); teams != nil {
+			team := m.TeamOf(playerID)
+			am.Teams, am.Team = teams, &team
+		}
+		out = append(out, am)
 	}
 
 	return ctx.JSON(http.StatusOK, echo.Map{"matches": out})
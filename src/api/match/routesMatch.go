@@ -9,6 +9,7 @@ func InitRoutes(e *echo.Echo) error {
 	// Matchmaking
 	e.GET("/match/join", JoinQueueWebsocket, auth.RequireUserOrGuestAuth)
 	e.GET("/match/size", QueueSize, auth.RequireUserOrGuestAuth)
+	e.GET("/match/lockout", Lockout, auth.RequireUserOrGuestAuth)
 
 	// CRUD
 	e.GET("/match/:matchID", GetMatch, auth.RequireUserAuth)
@@ -28,6 +29,22 @@ func InitRoutes(e *echo.Echo) error {
 	// code in Authorization: Bearer; no JWT middleware needed.
 	e.POST("/match/artifact", UploadMatchArtifact)
 
+	// Game-server backfill: ask the matchmaker for replacement players,
+	// auth'd by the match auth code like /match/artifact.
+	e.POST("/match/backfill", RequestBackfill)
+	e.GET("/match/backfill", GetBackfill)
+
+	// Game-server heartbeat: lets the worker end matches whose server
+	// has died, auth'd by the match auth code like /match/artifact.
+	e.POST("/match/heartbeat", Heartbeat)
+
+	// Game-server deadline extension, auth'd by the match auth code.
+	e.POST("/match/extend", ExtendMatch)
+
+	// Game-server leave reports: players who left a running match are
+	// rated as losers when it ends. Auth'd by the match auth code.
+	e.POST("/match/leave", ReportLeave)
+
 	// Per-match artifact retrieval. Auth gated like /results/<id> —
 	// participant/owner/admin always; PublicResults=true unlocks any auth.
 	e.GET("/matches/:matchID/artifacts", ListMatchArtifacts, auth.RequireUserOrGuestAuth)
@@ -0,0 +1,166 @@
+package matchResults
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/util"
+	"github.com/labstack/echo"
+	"gorm.io/gorm"
+)
+
+type ReportOutcomeRequest struct {
+	WinnerIDs []string `json:"winner_ids"`
+	// Placements, Scores, Teams and TeamPlacements mean the same as on
+	// /result/report.
+	Placements     map[string]int     `json:"placements"`
+	Scores         map[string]float64 `json:"scores"`
+	Teams          [][]string         `json:"teams"`
+	TeamPlacements []int              `json:"team_placements"`
+	Reason         string             `json:"reason"`
+}
+
+// ReportOutcome godoc
+// @Summary      Report a match outcome as a player
+// @Description  For queues with `result_reporting: clients` (peer-to-peer games with no game server). Each participant reports how the match ended with their own token, in the same shape as /result/report. Reports agree when they finish every pair of players in the same order and split them into the same teams. Once `result_quorum` reports agree (a majority of the players when 0), the result is recorded from the earliest of them and ratings are updated. Once the reports conflict so that no outcome can reach the quorum, the match ends as `disputed` with no rating change. A match still pending `result_report_window_seconds` after the first report is settled from the reports received by then: the outcome a majority of them agree on is recorded and rated, otherwise the match ends `disputed`. Disputed matches get no rating change, and the game owner can find it under /game/{gameID}/results/disputed. Reports are final; repeating the call returns the first report with `recorded: false`. `status` is `pending`, `agreed` or `disputed`.
+// @Tags         Results
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        matchID path string               true "Match UUID"
+// @Param        body    body ReportOutcomeRequest true "Outcome"
+// @Success      200 {object} map[string]interface{} "match_id, report, recorded, status, reports, quorum"
+// @Failure      400 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError "not a participant, or the match's result comes from its game server"
+// @Failure      404 {object} echo.HTTPError
+// @Failure      409 {object} echo.HTTPError "match already ended"
+// @Failure      500 {object} echo.HTTPError
+// @Router       /matches/{matchID}/report [post]
+func ReportOutcome(ctx echo.Context) error {
+	id := ctx.Get("id").(string)
+	req := new(ReportOutcomeRequest)
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	match, err := models.GetMatch(ctx.Param("matchID"))
+	if err == gorm.ErrRecordNotFound {
+		return echo.NewHTTPError(http.StatusNotFound, "Match not found")
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if match.Status != models.MatchStatusStarted {
+		return echo.NewHTTPError(http.StatusConflict, "match already ended")
+	}
+
+	outcome := models.MatchOutcome{
+		WinnerIDs:      req.WinnerIDs,
+		Placements:     req.Placements,
+		Scores:         req.Scores,
+		Teams:          req.Teams,
+		TeamPlacements: req.TeamPlacements,
+	}
+	if err := outcome.Normalize(match); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	now := time.Now()
+	report, recorded, verdict, err := models.RecordMatchReport(match.ID, id, outcome, req.Reason, now)
+	switch {
+	case errors.Is(err, models.ErrNotClientReported), errors.Is(err, models.ErrNotMatchParticipant):
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case err != nil && strings.HasPrefix(err.Error(), "invalid "):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case err != nil:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Reload so the tally below sees every report, this one included.
+	if match, err = models.GetMatch(match.ID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if verdict != models.ReportsPending {
+		_, err = SettleReports(ctx.Request().Context(), match, now)
+	}
+	if err != nil {
+		// A report racing this one may have ended the match first.
+		if underway, uErr := models.IsMatchUnderway(match.ID); uErr != nil || underway {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to end match")
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"match_id": match.ID,
+		"report":   report,
+		"recorded": recorded,
+		"status":   verdict,
+		"reports":  len(match.ReportLog()),
+		"quorum":   match.ResultQuorum(),
+	})
+}
+
+// SettleReports ends a client-reported match whose reports have reached
+// a verdict at now: rated on the agreed outcome, or unrated as
+// "disputed". A match still pending is left running. Returns the
+// verdict.
+func SettleReports(ctx context.Context, match *models.Match, now time.Time) (models.ReportVerdict, error) {
+	verdict, agreed := match.TallyReports(now)
+	var err error
+	switch verdict {
+	case models.ReportsAgreed:
+		_, err = EndMatch(ctx, match, agreed.Outcome(), agreed.Reason, true)
+	case models.ReportsDisputed:
+		slog.Warn("Match reports conflict", "matchID", match.ID, "reports", len(match.ReportLog()))
+		_, err = EndMatch(ctx, match, models.MatchOutcome{WinnerIDs: []string{}}, models.MatchResultDisputed, false)
+	}
+	return verdict, err
+}
+
+// GetDisputedMatchResultsOfGame godoc
+// @Summary      List a game's disputed match results
+// @Description  Returns the game's client-reported matches whose player reports conflicted, newest first, with each player's report under `reports`. They were recorded as `disputed` with no rating change. Game owner or admin only.
+// @Tags         Results
+// @Produce      json
+// @Security     BearerAuth
+// @Param        gameID   path  string true  "Game UUID"
+// @Param        page     query int    false "Page number (default 0)"
+// @Param        pageSize query int    false "Page size (default 10)"
+// @Success      200 {object} map[string]interface{} "matchResults, nextPage"
+// @Failure      400 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /game/{gameID}/results/disputed [get]
+func GetDisputedMatchResultsOfGame(ctx echo.Context) error {
+	gameID := ctx.Param("gameID")
+	user := ctx.Get("user").(*models.User)
+	page, pageSize, err := util.ParsePagination(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	game, err := models.GetGame(gameID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Game not found")
+	}
+	if game.OwnerID != user.ID && !user.IsAdmin {
+		return echo.NewHTTPError(http.StatusForbidden, models.ErrNotGameOwner.Error())
+	}
+
+	matchResults, nextPage, err := models.GetDisputedMatchResultsOfGame(gameID, page, pageSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	matchResultsResp := make([]models.MatchResultResp, len(matchResults))
+	for i, matchResult := range matchResults {
+		matchResultsResp[i] = *matchResult.ToResp()
+	}
+
+	return ctx.JSON(http.StatusOK, echo.Map{"matchResults": matchResultsResp, "nextPage": nextPage})
+}
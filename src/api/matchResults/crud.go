@@ -11,7 +11,7 @@ import (
 
 // GetMatchResult godoc
 // @Summary      Get a match result
-// @Description  Returns the result of a completed match
+// @Description  Returns the result of a completed match, including the per-player rating changes it caused (`rating_changes`, omitted for unrated matches)
 // @Tags         Results
 // @Produce      json
 // @Security     BearerAuth
@@ -14,22 +14,39 @@ import (
 )
 
 type ReportResultsRequest struct {
-	TokenID       string   `json:"token_id"`
-	WinnerID      string   `json:"winner_id"`
-	WinnerIDs     []string `json:"winner_ids"`
-	Reason        string   `json:"reason"`
-	AdjustRatings *bool    `json:"adjust_ratings"`
+	TokenID   string   `json:"token_id"`
+	WinnerID  string   `json:"winner_id"`
+	WinnerIDs []string `json:"winner_ids"`
+	// Placements optionally maps player ID → finishing position (1 =
+	// first; equal values tie) for a full ordering. Players left out
+	// rank behind everyone listed. When omitted, winner_ids place 1st
+	// and everyone else ties for 2nd.
+	Placements map[string]int `json:"placements"`
+	// Scores optionally maps player ID → numeric score. Stored on the
+	// result; when Placements is omitted, players are ranked by score
+	// (highest first).
+	Scores map[string]float64 `json:"scores"`
+	// Teams optionally lists the team layout as arrays of player IDs.
+	// TeamPlacements gives each team's finishing position (1 = first;
+	// equal values tie) and must match Teams in length. When omitted,
+	// the team holding a winner places first and the rest tie for
+	// second. Used by team-aware rating strategies (trueskill).
+	Teams          [][]string `json:"teams"`
+	TeamPlacements []int      `json:"team_placements"`
+	Reason         string     `json:"reason"`
+	AdjustRatings  *bool      `json:"adjust_ratings"`
 }
 
 // ReportResults godoc
 // @Summary      Report match results
-// @Description  Called by the game server to report the outcome of a match
+// @Description  Called by the game server to report the outcome of a match. Optional `placements` (player → finishing position) and `scores` (player → score) report a full free-for-all ordering; optional `teams` + `team_placements` carry the team layout and finishing order for team-aware rating strategies. `winner_id`/`winner_ids` alone still work. Matches on queues with `result_reporting: clients` only take their players' reports (POST /matches/{matchID}/report) and are refused with 403.
 // @Tags         Results
 // @Accept       json
 // @Produce      json
 // @Param        body body ReportResultsRequest true "Match result payload"
 // @Success      200 {object} map[string]string "message"
 // @Failure      400 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError "the match's result is reported by its players"
 // @Failure      404 {object} echo.HTTPError
 // @Failure      409 {object} echo.HTTPError "match already ended"
 // @Failure      500 {object} echo.HTTPError
@@ -54,12 +71,28 @@ func ReportResults(c echo.Context) error {
 	if match.Status != models.MatchStatusStarted {
 		return echo.NewHTTPError(http.StatusConflict, "match already ended")
 	}
+	// Client-reported queues only take results through the players'
+	// quorum, which a server report would otherwise bypass.
+	if match.GameQueue.ClientReported() {
+		return echo.NewHTTPError(http.StatusForbidden, models.ErrClientReported.Error())
+	}
+
+	outcome := models.MatchOutcome{
+		WinnerIDs:      req.WinnerIDs,
+		Placements:     req.Placements,
+		Scores:         req.Scores,
+		Teams:          req.Teams,
+		TeamPlacements: req.TeamPlacements,
+	}
+	if err := outcome.Normalize(match); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
 	adjustRatings := true
 	if req.AdjustRatings != nil {
 		adjustRatings = *req.AdjustRatings
 	}
-	status, err := EndMatch(c.Request().Context(), match, req.WinnerIDs, req.Reason, adjustRatings)
+	status, err := EndMatch(c.Request().Context(), match, outcome, req.Reason, adjustRatings)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to end match")
 	}
@@ -84,7 +117,7 @@ func ReportResults(c echo.Context) error {
 // cooldown entirely: there is no container to keep alive and no
 // auth_code work to defer, so we run a degenerate phase-A-then-B
 // inline.
-func EndMatch(ctx context.Context, match *models.Match, winnerIDs []string, reason string, adjustRatings bool) (string, error) {
+func EndMatch(ctx context.Context, match *models.Match, outcome models.MatchOutcome, reason string, adjustRatings bool) (string, error) {
 	if isUnderway, err := models.IsMatchUnderway(match.ID); err != nil {
 		return "", err
 	} else if !isUnderway {
@@ -110,7 +143,7 @@ func EndMatch(ctx context.Context, match *models.Match, winnerIDs []string, reas
 	if match.ServerInstanceID == "" {
 		// No container to keep alive — write the result and immediately
 		// finalize. Skips the cooldown lifecycle entirely.
-		if _, err := models.MatchEnded(match.ID, winnerIDs, reason, "", nil, adjustRatings); err != nil {
+		if _, err := models.MatchEnded(match.ID, outcome, reason, "", nil, adjustRatings); err != nil {
 			slog.Error("Failed to record match result", "error", err, "matchID", match.ID)
 			return "", err
 		}
@@ -124,7 +157,7 @@ func EndMatch(ctx context.Context, match *models.Match, winnerIDs []string, reas
 	// Phase A: write the result with empty logs/artifacts placeholders.
 	// Phase B re-reads the agent and S3 index at sweep time, so anything
 	// uploaded during the cooldown window still lands in MatchResult.
-	if _, err := models.MatchEnded(match.ID, winnerIDs, reason, "", nil, adjustRatings); err != nil {
+	if _, err := models.MatchEnded(match.ID, outcome, reason, "", nil, adjustRatings); err != nil {
 		slog.Error("Failed to record match result", "error", err, "matchID", match.ID)
 		return "", err
 	}
@@ -10,6 +10,10 @@ func InitRoutes(e *echo.Echo) error {
 	e.POST("/result/report", ReportResults)
 	e.GET("/results/:matchID/logs", GetMatchLogs, auth.RequireUserAuth)
 
+	// Player reporting, for client-reported (peer-to-peer) queues
+	e.POST("/matches/:matchID/report", ReportOutcome, auth.RequireUserOrGuestAuth)
+	e.GET("/game/:gameID/results/disputed", GetDisputedMatchResultsOfGame, auth.RequireUserAuth)
+
 	// CRUD
 	e.GET("/results/:matchID", GetMatchResult, auth.RequireUserOrGuestAuth)
 	e.GET("/game/:gameID/results", GetMatchResultsOfGame, auth.RequireUserOrGuestAuth)
@@ -0,0 +1,209 @@
+package party
+
+import (
+	"errors"
+	"net/http"
+
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/worker/matchmaking"
+	"github.com/labstack/echo"
+)
+
+type PartyResp struct {
+	ID       string                 `json:"id"`
+	LeaderID string                 `json:"leader_id"`
+	Members  []extRedis.PartyMember `json:"members"`
+	// Invites lists player IDs with an outstanding invite.
+	Invites []string `json:"invites"`
+	// GameQueueID is the queue the party is searching in, or empty when
+	// it isn't searching.
+	GameQueueID string `json:"game_queue_id,omitempty"`
+	Searching   bool   `json:"searching"`
+}
+
+func toResp(info *matchmaking.PartyInfo) *PartyResp {
+	invites := info.Invites
+	if invites == nil {
+		invites = []string{}
+	}
+	return &PartyResp{
+		ID:          info.Party.ID,
+		LeaderID:    info.Party.LeaderID,
+		Members:     info.Members,
+		Invites:     invites,
+		GameQueueID: info.Party.GameQueueID,
+		Searching:   info.Party.QueueID != "",
+	}
+}
+
+func displayName(c echo.Context) string {
+	if u, ok := c.Get("user").(*models.User); ok && u != nil {
+		return u.Username
+	}
+	if g, ok := c.Get("guest").(models.Guest); ok && g.DisplayName != "" {
+		return g.DisplayName
+	}
+	if id, ok := c.Get("id").(string); ok {
+		return id
+	}
+	return ""
+}
+
+// partyError maps party errors onto HTTP statuses.
+func partyError(err error) error {
+	switch {
+	case errors.Is(err, extRedis.ErrPartyNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, matchmaking.ErrNotPartyLeader), errors.Is(err, extRedis.ErrNotInvited):
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case errors.Is(err, extRedis.ErrAlreadyInParty), errors.Is(err, extRedis.ErrPartyFull), errors.Is(err, matchmaking.ErrPartyInQueue):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
+
+// CreateParty godoc
+// @Summary      Create a party
+// @Description  Creates a party led by the caller. A player can be in one party at a time. The leader invites others by player ID; once they accept, the leader's /match/join queues the whole party as one unit so everyone lands in the same match.
+// @Tags         Party
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} PartyResp
+// @Failure      409 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /party [post]
+func CreateParty(ctx echo.Context) error {
+	id := ctx.Get("id").(string)
+	info, err := matchmaking.CreateParty(ctx.Request().Context(), id, displayName(ctx))
+	if err != nil {
+		return partyError(err)
+	}
+	return ctx.JSON(http.StatusOK, toResp(info))
+}
+
+// GetMyParty godoc
+// @Summary      Get the caller's party
+// @Description  Returns the party the caller belongs to, including members (leader first), outstanding invites, and whether it's currently searching for a match.
+// @Tags         Party
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} PartyResp
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /party [get]
+func GetMyParty(ctx echo.Context) error {
+	id := ctx.Get("id").(string)
+	info, err := matchmaking.GetPlayerParty(ctx.Request().Context(), id)
+	if err != nil {
+		return partyError(err)
+	}
+	return ctx.JSON(http.StatusOK, toResp(info))
+}
+
+// GetMyPartyInvites godoc
+// @Summary      List the caller's party invites
+// @Description  Returns the parties that have invited the caller and haven't been joined yet.
+// @Tags         Party
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{} "invites"
+// @Failure      500 {object} echo.HTTPError
+// @Router       /party/invites [get]
+func GetMyPartyInvites(ctx echo.Context) error {
+	id := ctx.Get("id").(string)
+	infos, err := matchmaking.PlayerPartyInvites(ctx.Request().Context(), id)
+	if err != nil {
+		return partyError(err)
+	}
+	resp := make([]*PartyResp, len(infos))
+	for i, info := range infos {
+		resp[i] = toResp(info)
+	}
+	return ctx.JSON(http.StatusOK, echo.Map{"invites": resp})
+}
+
+type InviteToPartyRequest struct {
+	PlayerID string `json:"player_id"`
+}
+
+// InviteToParty godoc
+// @Summary      Invite a player to a party
+// @Description  Leader only. Invites a user or guest by player ID; they accept with POST /party/{partyID}/join.
+// @Tags         Party
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        partyID path string               true "Party ID"
+// @Param        body    body InviteToPartyRequest true "Player to invite"
+// @Success      200 {object} PartyResp
+// @Failure      400 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      409 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /party/{partyID}/invite [post]
+func InviteToParty(ctx echo.Context) error {
+	id := ctx.Get("id").(string)
+	var req InviteToPartyRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.PlayerID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "player_id is required")
+	}
+	if req.PlayerID == id {
+		return echo.NewHTTPError(http.StatusBadRequest, "cannot invite yourself")
+	}
+	info, err := matchmaking.InviteToParty(ctx.Request().Context(), id, ctx.Param("partyID"), req.PlayerID)
+	if err != nil {
+		return partyError(err)
+	}
+	return ctx.JSON(http.StatusOK, toResp(info))
+}
+
+// JoinParty godoc
+// @Summary      Accept a party invite
+// @Description  Joins a party the caller was invited to. Refused with 409 if the caller is already in a party, the party is full, or it's currently searching for a match.
+// @Tags         Party
+// @Produce      json
+// @Security     BearerAuth
+// @Param        partyID path string true "Party ID"
+// @Success      200 {object} PartyResp
+// @Failure      403 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      409 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /party/{partyID}/join [post]
+func JoinParty(ctx echo.Context) error {
+	id := ctx.Get("id").(string)
+	info, err := matchmaking.JoinParty(ctx.Request().Context(), id, displayName(ctx), ctx.Param("partyID"))
+	if err != nil {
+		return partyError(err)
+	}
+	return ctx.JSON(http.StatusOK, toResp(info))
+}
+
+// LeaveParty godoc
+// @Summary      Leave a party
+// @Description  Leaves the party. When the leader leaves, the party is disbanded. If the party was searching, it's pulled out of the queue and every member's /match/join session ends with an error.
+// @Tags         Party
+// @Produce      json
+// @Security     BearerAuth
+// @Param        partyID path string true "Party ID"
+// @Success      200 {object} map[string]interface{} "status: left or disbanded"
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /party/{partyID}/leave [post]
+func LeaveParty(ctx echo.Context) error {
+	id := ctx.Get("id").(string)
+	disbanded, err := matchmaking.LeaveParty(ctx.Request().Context(), id, ctx.Param("partyID"))
+	if err != nil {
+		return partyError(err)
+	}
+	status := "left"
+	if disbanded {
+		status = "disbanded"
+	}
+	return ctx.JSON(http.StatusOK, echo.Map{"status": status})
+}
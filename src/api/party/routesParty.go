@@ -0,0 +1,17 @@
+package party
+
+import (
+	"github.com/andy98725/elo-service/src/api/auth"
+	"github.com/labstack/echo"
+)
+
+func InitRoutes(e *echo.Echo) error {
+	e.POST("/party", CreateParty, auth.RequireUserOrGuestAuth)
+	e.GET("/party", GetMyParty, auth.RequireUserOrGuestAuth)
+	e.GET("/party/invites", GetMyPartyInvites, auth.RequireUserOrGuestAuth)
+	e.POST("/party/:partyID/invite", InviteToParty, auth.RequireUserOrGuestAuth)
+	e.POST("/party/:partyID/join", JoinParty, auth.RequireUserOrGuestAuth)
+	e.POST("/party/:partyID/leave", LeaveParty, auth.RequireUserOrGuestAuth)
+
+	return nil
+}
@@ -2,6 +2,7 @@ package rating
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/andy98725/elo-service/src/models"
 	"github.com/andy98725/elo-service/src/util"
@@ -10,13 +11,13 @@ import (
 
 // GetRating godoc
 // @Summary      Get user rating for a game queue
-// @Description  Returns the authenticated user's rating for the given game's queue. A row is lazy-created at the queue's DefaultRating on first access. Defaults to the game's primary queue when queueID is omitted.
+// @Description  Returns the authenticated user's rating for the given game's queue. A row is lazy-created at the queue's DefaultRating on first access. `deviation` is the Glicko-2 rating deviation (grows with inactivity); it stays at its default for non-glicko2 queues. `mu`/`sigma` are the trueskill skill estimate and uncertainty (zero until the player is first rated by a trueskill queue). `provisional` is true while the player has played fewer than the queue's `placement_matches` rated matches. Defaults to the game's primary queue when queueID is omitted.
 // @Tags         Ratings
 // @Produce      json
 // @Security     BearerAuth
 // @Param        gameId  path  string true  "Game UUID"
 // @Param        queueID query string false "Specific GameQueue UUID (defaults to primary queue)"
-// @Success      200 {object} map[string]interface{} "player_id, game_queue_id, rating"
+// @Success      200 {object} map[string]interface{} "player_id, game_queue_id, rating, deviation, mu, sigma, games_played, provisional"
 // @Failure      400 {object} echo.HTTPError
 // @Failure      404 {object} echo.HTTPError
 // @Failure      500 {object} echo.HTTPError
@@ -45,12 +46,69 @@ func GetRating(ctx echo.Context) error {
 		"player_id":     rating.PlayerID,
 		"game_queue_id": rating.GameQueueID,
 		"rating":        rating.Rating,
+		"deviation":     rating.CurrentDeviation(time.Now()),
+		"mu":            rating.Mu,
+		"sigma":         rating.Sigma,
+		"games_played":  rating.GamesPlayed,
+		"provisional":   rating.IsProvisional(queue),
+	})
+}
+
+// GetRatingHistory godoc
+// @Summary      Get user rating history for a game queue
+// @Description  Returns the authenticated user's rating changes in the given game's queue, newest first, paginated. Each entry records the rating before and after, the delta, what caused it, and the match result ID for match-driven changes. Defaults to the game's primary queue when queueID is omitted.
+// @Tags         Ratings
+// @Produce      json
+// @Security     BearerAuth
+// @Param        gameId   path  string true  "Game UUID"
+// @Param        queueID  query string false "Specific GameQueue UUID (defaults to primary queue)"
+// @Param        page     query int    false "Page number (default 0)"
+// @Param        pageSize query int    false "Page size (default 10, max 100)"
+// @Success      200 {object} map[string]interface{} "history, nextPage, game_queue_id"
+// @Failure      400 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /user/rating/{gameId}/history [get]
+func GetRatingHistory(ctx echo.Context) error {
+	gameID := ctx.Param("gameId")
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "gameId is required")
+	}
+	userID, err := models.UserIDFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "error getting user: "+err.Error())
+	}
+
+	queue, err := models.ResolveQueue(gameID, ctx.QueryParam("queueID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "queue not found: "+err.Error())
+	}
+
+	page, pageSize, err := util.ParsePagination(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	changes, nextPage, err := models.GetRatingHistory(userID, queue.ID, page, pageSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "error getting rating history: "+err.Error())
+	}
+
+	out := make([]models.RatingChangeResp, len(changes))
+	for i, c := range changes {
+		out[i] = *c.ToResp()
+	}
+
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"history":       out,
+		"nextPage":      nextPage,
+		"game_queue_id": queue.ID,
 	})
 }
 
 // GetLeaderboard godoc
 // @Summary      Game queue leaderboard
-// @Description  Returns the top-rated players for a game queue, paginated. Ordered by rating descending. Public — no auth required. Defaults to the game's primary queue when queueID is omitted.
+// @Description  Returns the top-rated players for a game queue, paginated. Ordered by rating descending. Provisional players (still playing their placement matches) are omitted. Public — no auth required. Defaults to the game's primary queue when queueID is omitted.
 // @Tags         Ratings
 // @Produce      json
 // @Param        gameId   path  string true  "Game UUID"
@@ -77,22 +135,25 @@ func GetLeaderboard(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	ratings, nextPage, err := models.GetLeaderboard(queue.ID, page, pageSize)
+	ratings, nextPage, err := models.GetLeaderboard(queue, page, pageSize)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "error getting leaderboard: "+err.Error())
 	}
 
 	type entry struct {
-		PlayerID string `json:"player_id"`
-		Username string `json:"username"`
-		Rating   int    `json:"rating"`
+		PlayerID  string  `json:"player_id"`
+		Username  string  `json:"username"`
+		Rating    int     `json:"rating"`
+		Deviation float64 `json:"deviation"`
 	}
+	now := time.Now()
 	out := make([]entry, len(ratings))
 	for i, r := range ratings {
 		out[i] = entry{
-			PlayerID: r.PlayerID,
-			Username: r.Player.Username,
-			Rating:   r.Rating,
+			PlayerID:  r.PlayerID,
+			Username:  r.Player.Username,
+			Rating:    r.Rating,
+			Deviation: r.CurrentDeviation(now),
 		}
 	}
 
@@ -0,0 +1,94 @@
+package rating
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/labstack/echo"
+)
+
+// RecalculateRatings godoc
+// @Summary      Recalculate a queue's ratings (admin)
+// @Description  Queues a background job that replays every rated match result of the queue, oldest first, through the queue's current rating strategy and settings, and rewrites its ratings. Use after changing `k_factor`, `elo_strategy`, or placement settings. With `dryRun=true` nothing is written — the job only reports the before/after diff. Poll the returned job via GET /ratings/recalculations/{id}. Admin only. Defaults to the game's primary queue when queueID is omitted.
+// @Tags         Ratings
+// @Produce      json
+// @Security     BearerAuth
+// @Param        gameId  path  string true  "Game UUID"
+// @Param        queueID query string false "Specific GameQueue UUID (defaults to primary queue)"
+// @Param        dryRun  query bool   false "Only compute the diff (default false)"
+// @Success      202 {object} models.RatingRecalculationResp
+// @Failure      400 {object} echo.HTTPError
+// @Failure      403 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /game/{gameId}/ratings/recalculate [post]
+func RecalculateRatings(ctx echo.Context) error {
+	gameID := ctx.Param("gameId")
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "gameId is required")
+	}
+	dryRun := false
+	if raw := ctx.QueryParam("dryRun"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "dryRun must be a boolean")
+		}
+		dryRun = v
+	}
+
+	queue, err := models.ResolveQueue(gameID, ctx.QueryParam("queueID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "queue not found: "+err.Error())
+	}
+
+	user := ctx.Get("user").(*models.User)
+	job, err := models.CreateRatingRecalculation(queue.ID, user.ID, dryRun)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "error creating recalculation: "+err.Error())
+	}
+	if err := server.S.Redis.PublishRecalculationTrigger(ctx.Request().Context()); err != nil {
+		// The worker also drains pending jobs on startup, so the job
+		// isn't lost — it just waits for the next wake-up.
+		slog.Warn("Failed to publish recalculation trigger", "jobID", job.ID, "error", err)
+	}
+
+	return ctx.JSON(http.StatusAccepted, job.ToResp())
+}
+
+// GetRatingRecalculation godoc
+// @Summary      Get a rating recalculation job (admin)
+// @Description  Returns a recalculation job's status (`pending`, `running`, `completed`, `failed`). While running, `processed`/`total` report live progress through the queue's match results. Once finished, `diff` lists every player whose rating changed (before, after, delta) — for dry runs this is what would have changed. Admin only.
+// @Tags         Ratings
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Recalculation job UUID"
+// @Success      200 {object} models.RatingRecalculationResp
+// @Failure      403 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /ratings/recalculations/{id} [get]
+func GetRatingRecalculation(ctx echo.Context) error {
+	job, err := models.GetRatingRecalculation(ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, models.ErrRatingRecalculationNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "error getting recalculation: "+err.Error())
+	}
+
+	resp := job.ToResp()
+	if job.Status == models.RatingRecalculationStatusRunning {
+		done, total, ok, err := server.S.Redis.GetRecalculationProgress(ctx.Request().Context(), job.ID)
+		if err != nil {
+			slog.Warn("Failed to read recalculation progress", "jobID", job.ID, "error", err)
+		} else if ok {
+			resp.Processed = done
+			resp.Total = total
+		}
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
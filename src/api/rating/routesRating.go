@@ -7,7 +7,12 @@ import (
 
 func InitRoutes(e *echo.Echo) error {
 	e.GET("/user/rating/:gameId", GetRating, auth.RequireUserAuth)
+	e.GET("/user/rating/:gameId/history", GetRatingHistory, auth.RequireUserAuth)
 	e.GET("/game/:gameId/leaderboard", GetLeaderboard)
+	e.GET("/game/:gameId/seasons", ListSeasons)
+	e.GET("/game/:gameId/seasons/:season/leaderboard", GetSeasonLeaderboard)
+	e.POST("/game/:gameId/ratings/recalculate", RecalculateRatings, auth.RequireAdmin)
+	e.GET("/ratings/recalculations/:id", GetRatingRecalculation, auth.RequireAdmin)
 
 	return nil
 }
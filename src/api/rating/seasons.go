@@ -0,0 +1,116 @@
+package rating
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/util"
+	"github.com/labstack/echo"
+)
+
+// ListSeasons godoc
+// @Summary      List a queue's seasons
+// @Description  Returns the current season of a game queue (number, start, scheduled end — `ends_at` is null when no rollover is scheduled, `number` is 0 when seasons were never enabled) and its archived seasons, most recent first. Public — no auth required. Defaults to the game's primary queue when queueID is omitted.
+// @Tags         Ratings
+// @Produce      json
+// @Param        gameId  path  string true  "Game UUID"
+// @Param        queueID query string false "Specific GameQueue UUID (defaults to primary queue)"
+// @Success      200 {object} map[string]interface{} "current, seasons, game_queue_id"
+// @Failure      400 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /game/{gameId}/seasons [get]
+func ListSeasons(ctx echo.Context) error {
+	gameID := ctx.Param("gameId")
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "gameId is required")
+	}
+
+	queue, err := models.ResolveQueue(gameID, ctx.QueryParam("queueID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "queue not found: "+err.Error())
+	}
+
+	seasons, err := models.GetSeasons(queue.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "error getting seasons: "+err.Error())
+	}
+
+	out := make([]models.SeasonResp, len(seasons))
+	for i, s := range seasons {
+		out[i] = *s.ToResp()
+	}
+
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"current": echo.Map{
+			"number":     queue.SeasonNumber,
+			"started_at": queue.SeasonStartedAt,
+			"ends_at":    queue.SeasonEndsAt,
+		},
+		"seasons":       out,
+		"game_queue_id": queue.ID,
+	})
+}
+
+// GetSeasonLeaderboard godoc
+// @Summary      Archived season leaderboard
+// @Description  Returns the final standings of an archived season, paginated, ordered by rank (equal ratings share a rank). The current season's standings are the live /game/{gameId}/leaderboard. Public — no auth required. Defaults to the game's primary queue when queueID is omitted.
+// @Tags         Ratings
+// @Produce      json
+// @Param        gameId   path  string true  "Game UUID"
+// @Param        season   path  int    true  "Season number"
+// @Param        queueID  query string false "Specific GameQueue UUID (defaults to primary queue)"
+// @Param        page     query int    false "Page number (default 0)"
+// @Param        pageSize query int    false "Page size (default 10, max 100)"
+// @Success      200 {object} map[string]interface{} "season, leaderboard, nextPage, game_queue_id"
+// @Failure      400 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /game/{gameId}/seasons/{season}/leaderboard [get]
+func GetSeasonLeaderboard(ctx echo.Context) error {
+	gameID := ctx.Param("gameId")
+	if gameID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "gameId is required")
+	}
+	number, err := strconv.Atoi(ctx.Param("season"))
+	if err != nil || number < 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "season must be a positive integer")
+	}
+
+	queue, err := models.ResolveQueue(gameID, ctx.QueryParam("queueID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "queue not found: "+err.Error())
+	}
+
+	page, pageSize, err := util.ParsePagination(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	season, err := models.GetSeason(queue.ID, number)
+	if err != nil {
+		if errors.Is(err, models.ErrSeasonNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "error getting season: "+err.Error())
+	}
+
+	standings, nextPage, err := models.GetSeasonStandings(season.ID, page, pageSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "error getting season standings: "+err.Error())
+	}
+
+	out := make([]models.SeasonStandingResp, len(standings))
+	for i, s := range standings {
+		out[i] = *s.ToResp()
+	}
+
+	return ctx.JSON(http.StatusOK, echo.Map{
+		"season":        season.ToResp(),
+		"leaderboard":   out,
+		"nextPage":      nextPage,
+		"game_queue_id": queue.ID,
+	})
+}
@@ -3,10 +3,12 @@ package api
 import (
 	"net/http"
 
+	"github.com/andy98725/elo-service/src/api/auth"
 	"github.com/andy98725/elo-service/src/api/game"
 	"github.com/andy98725/elo-service/src/api/lobby"
 	"github.com/andy98725/elo-service/src/api/match"
 	"github.com/andy98725/elo-service/src/api/matchResults"
+	"github.com/andy98725/elo-service/src/api/party"
 	"github.com/andy98725/elo-service/src/api/playerData"
 	"github.com/andy98725/elo-service/src/api/rating"
 	"github.com/andy98725/elo-service/src/api/user"
@@ -27,8 +29,20 @@ func HealthCheck(c echo.Context) error {
 	}{Status: "healthy!"})
 }
 
+// ConnectKeys godoc
+// @Summary      Connect-token verification keys
+// @Description  Returns the JSON Web Key Set game servers verify players' connect tokens against. Tokens are EdDSA (Ed25519) JWTs; fetch this once at startup and verify offline. The kid in a token's header names the key that signed it.
+// @Tags         Matches
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "keys"
+// @Router       /.well-known/jwks.json [get]
+func ConnectKeys(c echo.Context) error {
+	return c.JSON(http.StatusOK, echo.Map{"keys": auth.ConnectJWKS()})
+}
+
 func InitRoutes(e *echo.Echo) error {
 	e.GET("/health", HealthCheck)
+	e.GET("/.well-known/jwks.json", ConnectKeys)
 
 	if err := user.InitRoutes(e); err != nil {
 		return err
@@ -42,6 +56,9 @@ func InitRoutes(e *echo.Echo) error {
 	if err := lobby.InitRoutes(e); err != nil {
 		return err
 	}
+	if err := party.InitRoutes(e); err != nil {
+		return err
+	}
 	if err := matchResults.InitRoutes(e); err != nil {
 		return err
 	}
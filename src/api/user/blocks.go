@@ -0,0 +1,77 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/labstack/echo"
+	"gorm.io/gorm"
+)
+
+// GetBlocks godoc
+// @Summary      List blocked players
+// @Description  Returns the players the caller has blocked, newest first. The matchmaker never puts two players in the same match when either has blocked the other.
+// @Tags         Users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{} "blocks"
+// @Failure      500 {object} echo.HTTPError
+// @Router       /user/blocks [get]
+func GetBlocks(ctx echo.Context) error {
+	id := ctx.Get("id").(string)
+	blocks, err := models.GetPlayerBlocks(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "error getting blocks: "+err.Error())
+	}
+	return ctx.JSON(http.StatusOK, echo.Map{"blocks": blocks})
+}
+
+// BlockPlayer godoc
+// @Summary      Block a player
+// @Description  Adds a player (user or guest ID) to the caller's block list, so matchmaking never groups the two of them again, in any game. Idempotent.
+// @Tags         Users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        playerID path string true "Player to block"
+// @Success      200 {object} models.PlayerBlock
+// @Failure      400 {object} echo.HTTPError
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /user/blocks/{playerID} [put]
+func BlockPlayer(ctx echo.Context) error {
+	id := ctx.Get("id").(string)
+	block, err := models.BlockPlayer(id, ctx.Param("playerID"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid ") {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "player not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "error blocking player: "+err.Error())
+	}
+	return ctx.JSON(http.StatusOK, block)
+}
+
+// UnblockPlayer godoc
+// @Summary      Unblock a player
+// @Description  Removes a player from the caller's block list.
+// @Tags         Users
+// @Security     BearerAuth
+// @Param        playerID path string true "Player to unblock"
+// @Success      200 {object} map[string]string "status"
+// @Failure      404 {object} echo.HTTPError
+// @Failure      500 {object} echo.HTTPError
+// @Router       /user/blocks/{playerID} [delete]
+func UnblockPlayer(ctx echo.Context) error {
+	id := ctx.Get("id").(string)
+	if err := models.UnblockPlayer(id, ctx.Param("playerID")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "player not blocked")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "error unblocking player: "+err.Error())
+	}
+	return ctx.JSON(http.StatusOK, echo.Map{"status": "ok"})
+}
@@ -15,6 +15,9 @@ func InitRoutes(e *echo.Echo) error {
 	e.PUT("/user", UpdateUser, auth.RequireUserAuth)
 	e.PUT("/user/password", ChangePassword, auth.RequireUserAuth)
 	e.DELETE("/user", DeleteUser, auth.RequireUserAuth)
+	e.GET("/user/blocks", GetBlocks, auth.RequireUserOrGuestAuth)
+	e.PUT("/user/blocks/:playerID", BlockPlayer, auth.RequireUserOrGuestAuth)
+	e.DELETE("/user/blocks/:playerID", UnblockPlayer, auth.RequireUserOrGuestAuth)
 
 	return nil
 }
@@ -7,7 +7,7 @@
 // silent network failures) go undetected for minutes — the server keeps
 // refreshing the player's queue TTL, the player gets paired into a real
 // match they never connect to, and the host slot is wasted until match GC
-// fires after MATCH_MAX_DURATION.
+// fires at the match's deadline.
 //
 // Mechanism: the server emits Ping frames on PingInterval. RFC 6455 clients
 // (browsers, gorilla/websocket, most native libraries) reply with Pong
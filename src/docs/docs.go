@@ -15,6 +15,27 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Returns the JSON Web Key Set game servers verify players' connect tokens against. Tokens are EdDSA (Ed25519) JWTs; fetch this once at startup and verify offline. The kid in a token's header names the key that signed it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "Connect-token verification keys",
+                "responses": {
+                    "200": {
+                        "description": "keys",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
         "/game": {
             "post": {
                 "security": [
@@ -389,21 +410,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/game/{gameID}/results": {
+        "/game/{gameID}/queue/{queueID}/stats": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns a paginated list of match results for a specific game",
+                "description": "Summarizes recent matchmaking in a queue across all its sub-queues: matches formed (and per hour), players matched, average rating spread of formed groups, wait-time median and 90th percentile, and the abandonment rate — the share of queue entries (a party counts once) that left, timed out, or failed a ready check instead of being matched. Game-owner only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Results"
+                    "Games"
                 ],
-                "summary": "Get match results for a game",
+                "summary": "Get a queue's matchmaking statistics",
                 "parameters": [
                     {
                         "type": "string",
@@ -413,24 +434,24 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Page number (default 0)",
-                        "name": "page",
-                        "in": "query"
+                        "type": "string",
+                        "description": "GameQueue UUID",
+                        "name": "queueID",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Page size (default 10)",
-                        "name": "pageSize",
+                        "description": "How many recent hours to cover (1-24, default 1)",
+                        "name": "window_hours",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "matchResults, nextPage",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_worker_matchmaking.QueueStats"
                         }
                     },
                     "400": {
@@ -439,6 +460,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -448,30 +481,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/game/{gameId}/leaderboard": {
+        "/game/{gameID}/results": {
             "get": {
-                "description": "Returns the top-rated players for a game queue, paginated. Ordered by rating descending. Public — no auth required. Defaults to the game's primary queue when queueID is omitted.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a paginated list of match results for a specific game",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Ratings"
+                    "Results"
                 ],
-                "summary": "Game queue leaderboard",
+                "summary": "Get match results for a game",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Game UUID",
-                        "name": "gameId",
+                        "name": "gameID",
                         "in": "path",
                         "required": true
                     },
-                    {
-                        "type": "string",
-                        "description": "Specific GameQueue UUID (defaults to primary queue)",
-                        "name": "queueID",
-                        "in": "query"
-                    },
                     {
                         "type": "integer",
                         "description": "Page number (default 0)",
@@ -480,14 +512,14 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Page size (default 10, max 100)",
+                        "description": "Page size (default 10)",
                         "name": "pageSize",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "leaderboard, nextPage",
+                        "description": "matchResults, nextPage",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -508,30 +540,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/game/{id}": {
+        "/game/{gameID}/results/disputed": {
             "get": {
-                "description": "Returns a single game by its UUID. Public — no auth required.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the game's client-reported matches whose player reports conflicted, newest first, with each player's report under ` + "`" + `reports` + "`" + `. They were recorded as ` + "`" + `disputed` + "`" + ` with no rating change. Game owner or admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Games"
+                    "Results"
                 ],
-                "summary": "Get a game by ID",
+                "summary": "List a game's disputed match results",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Game UUID",
-                        "name": "id",
+                        "name": "gameID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 10)",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "matchResults, nextPage",
                         "schema": {
-                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.GameResp"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -540,54 +590,70 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
                     }
                 }
-            },
-            "put": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Updates game settings. Only the game owner can update.",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/game/{gameId}/leaderboard": {
+            "get": {
+                "description": "Returns the top-rated players for a game queue, paginated. Ordered by rating descending. Provisional players (still playing their placement matches) are omitted. Public — no auth required. Defaults to the game's primary queue when queueID is omitted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Games"
+                    "Ratings"
                 ],
-                "summary": "Update a game",
+                "summary": "Game queue leaderboard",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Game UUID",
-                        "name": "id",
+                        "name": "gameId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Fields to update",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.UpdateGameParams"
-                        }
+                        "type": "string",
+                        "description": "Specific GameQueue UUID (defaults to primary queue)",
+                        "name": "queueID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 10, max 100)",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "leaderboard, nextPage",
                         "schema": {
-                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.GameResp"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -596,12 +662,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "403": {
-                        "description": "caller is not the game owner",
-                        "schema": {
-                            "$ref": "#/definitions/echo.HTTPError"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -609,38 +669,49 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/game/{gameId}/ratings/recalculate": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Deletes a game. Only the game owner can delete.",
+                "description": "Queues a background job that replays every rated match result of the queue, oldest first, through the queue's current rating strategy and settings, and rewrites its ratings. Use after changing ` + "`" + `k_factor` + "`" + `, ` + "`" + `elo_strategy` + "`" + `, or placement settings. With ` + "`" + `dryRun=true` + "`" + ` nothing is written — the job only reports the before/after diff. Poll the returned job via GET /ratings/recalculations/{id}. Admin only. Defaults to the game's primary queue when queueID is omitted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Games"
+                    "Ratings"
                 ],
-                "summary": "Delete a game",
+                "summary": "Recalculate a queue's ratings (admin)",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Game UUID",
-                        "name": "id",
+                        "name": "gameId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Specific GameQueue UUID (defaults to primary queue)",
+                        "name": "queueID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only compute the diff (default false)",
+                        "name": "dryRun",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "message",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.RatingRecalculationResp"
                         }
                     },
                     "400": {
@@ -650,7 +721,13 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "caller is not the game owner",
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -664,38 +741,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/games": {
+        "/game/{gameId}/seasons": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Returns a paginated list of all games. Admin only.",
+                "description": "Returns the current season of a game queue (number, start, scheduled end — ` + "`" + `ends_at` + "`" + ` is null when no rollover is scheduled, ` + "`" + `number` + "`" + ` is 0 when seasons were never enabled) and its archived seasons, most recent first. Public — no auth required. Defaults to the game's primary queue when queueID is omitted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Games"
+                    "Ratings"
                 ],
-                "summary": "List all games (admin)",
+                "summary": "List a queue's seasons",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Page number (default 0)",
-                        "name": "page",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Game UUID",
+                        "name": "gameId",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Page size (default 10)",
-                        "name": "pageSize",
+                        "type": "string",
+                        "description": "Specific GameQueue UUID (defaults to primary queue)",
+                        "name": "queueID",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "games, nextPage",
+                        "description": "current, seasons, game_queue_id",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -707,8 +780,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -722,40 +795,66 @@ const docTemplate = `{
                 }
             }
         },
-        "/games/{gameID}/data/me/player": {
+        "/game/{gameId}/seasons/{season}/leaderboard": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Returns every player-authored entry the caller has written for the given game. See server endpoint to read entries written by the game server.",
+                "description": "Returns the final standings of an archived season, paginated, ordered by rank (equal ratings share a rank). The current season's standings are the live /game/{gameId}/leaderboard. Public — no auth required. Defaults to the game's primary queue when queueID is omitted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "PlayerData"
+                    "Ratings"
                 ],
-                "summary": "List own player-authored entries",
+                "summary": "Archived season leaderboard",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Game UUID",
-                        "name": "gameID",
+                        "name": "gameId",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "entries",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    {
+                        "type": "integer",
+                        "description": "Season number",
+                        "name": "season",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Specific GameQueue UUID (defaults to primary queue)",
+                        "name": "queueID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 10, max 100)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "season, leaderboard, nextPage, game_queue_id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -769,61 +868,53 @@ const docTemplate = `{
                 }
             }
         },
-        "/games/{gameID}/data/me/server": {
+        "/game/{id}": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Returns every server-authored entry the game server has written for the calling player in the given game. Read-only from the player side.",
+                "description": "Returns a single game by its UUID. Public — no auth required.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "PlayerData"
+                    "Games"
                 ],
-                "summary": "List own server-authored entries",
+                "summary": "Get a game by ID",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Game UUID",
-                        "name": "gameID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "entries",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.GameResp"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     }
                 }
-            }
-        },
-        "/games/{gameID}/data/me/{key}": {
+            },
             "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Writes a player-authored JSON value for the calling player at the given key. Replaces any existing player-authored entry at that key. The same key may also exist as a server-authored entry — the two are independent.",
+                "description": "Updates game settings. Only the game owner can update.",
                 "consumes": [
                     "application/json"
                 ],
@@ -831,42 +922,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "PlayerData"
+                    "Games"
                 ],
-                "summary": "Upsert a player-authored entry",
+                "summary": "Update a game",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Game UUID",
-                        "name": "gameID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Entry key (a-zA-Z0-9._-, max 128 chars)",
-                        "name": "key",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Arbitrary JSON value (max 64KB)",
+                        "description": "Fields to update",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.UpdateGameParams"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.GameResp"
                         }
                     },
                     "400": {
@@ -875,14 +956,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/echo.HTTPError"
-                        }
-                    },
-                    "413": {
-                        "description": "Request Entity Too Large",
+                    "403": {
+                        "description": "caller is not the game owner",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -901,33 +976,26 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Removes the calling player's player-authored entry at the given key. Does not affect any server-authored entry at the same key.",
+                "description": "Deletes a game. Only the game owner can delete.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "PlayerData"
+                    "Games"
                 ],
-                "summary": "Delete a player-authored entry",
+                "summary": "Delete a game",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Game UUID",
-                        "name": "gameID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Entry key",
-                        "name": "key",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status",
+                        "description": "message",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -941,14 +1009,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/echo.HTTPError"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "caller is not the game owner",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -962,35 +1024,38 @@ const docTemplate = `{
                 }
             }
         },
-        "/games/{gameID}/data/{playerID}/player": {
+        "/games": {
             "get": {
-                "description": "Read the player-authored entries for one of the players in the active match. Auth is the match auth code, passed as Authorization: Bearer \u003ccode\u003e.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a paginated list of all games. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "PlayerData"
+                    "Games"
                 ],
-                "summary": "List a player's player-authored entries (game server)",
+                "summary": "List all games (admin)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Game UUID",
-                        "name": "gameID",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Page number (default 0)",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Player UUID (registered users only — guests rejected with 400)",
-                        "name": "playerID",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Page size (default 10)",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "entries",
+                        "description": "games, nextPage",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1002,12 +1067,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/echo.HTTPError"
-                        }
-                    },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
@@ -1023,16 +1082,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/games/{gameID}/data/{playerID}/server": {
+        "/games/{gameID}/data/me/player": {
             "get": {
-                "description": "Read the server-authored entries for one of the players in the active match. Auth is the match auth code, passed as Authorization: Bearer \u003ccode\u003e.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every player-authored entry the caller has written for the given game. See server endpoint to read entries written by the game server.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "PlayerData"
                 ],
-                "summary": "List a player's server-authored entries (game server)",
+                "summary": "List own player-authored entries",
                 "parameters": [
                     {
                         "type": "string",
@@ -1040,13 +1104,6 @@ const docTemplate = `{
                         "name": "gameID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Player UUID (registered users only — guests rejected with 400)",
-                        "name": "playerID",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -1057,20 +1114,55 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
+                    }
+                }
+            }
+        },
+        "/games/{gameID}/data/me/server": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every server-authored entry the game server has written for the calling player in the given game. Read-only from the player side.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PlayerData"
+                ],
+                "summary": "List own server-authored entries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game UUID",
+                        "name": "gameID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "entries",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -1084,9 +1176,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/games/{gameID}/data/{playerID}/{key}": {
+        "/games/{gameID}/data/me/{key}": {
             "put": {
-                "description": "Writes a server-authored JSON value for a player in the active match. Replaces any existing server-authored entry at that key. The same key may also exist as a player-authored entry — the two are independent.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Writes a player-authored JSON value for the calling player at the given key. Replaces any existing player-authored entry at that key. The same key may also exist as a server-authored entry — the two are independent.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1096,7 +1193,7 @@ const docTemplate = `{
                 "tags": [
                     "PlayerData"
                 ],
-                "summary": "Upsert a server-authored entry (game server)",
+                "summary": "Upsert a player-authored entry",
                 "parameters": [
                     {
                         "type": "string",
@@ -1107,15 +1204,8 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Player UUID (registered users only)",
-                        "name": "playerID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Entry key (a-zA-Z0-9._-, max 128 chars)",
-                        "name": "key",
+                        "description": "Entry key (a-zA-Z0-9._-, max 128 chars)",
+                        "name": "key",
                         "in": "path",
                         "required": true
                     },
@@ -1151,12 +1241,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/echo.HTTPError"
-                        }
-                    },
                     "413": {
                         "description": "Request Entity Too Large",
                         "schema": {
@@ -1172,14 +1256,19 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Removes a server-authored entry for a player in the active match. Does not affect any player-authored entry at the same key.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the calling player's player-authored entry at the given key. Does not affect any server-authored entry at the same key.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "PlayerData"
                 ],
-                "summary": "Delete a server-authored entry (game server)",
+                "summary": "Delete a player-authored entry",
                 "parameters": [
                     {
                         "type": "string",
@@ -1188,13 +1277,6 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
-                    {
-                        "type": "string",
-                        "description": "Player UUID (registered users only)",
-                        "name": "playerID",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "string",
                         "description": "Entry key",
@@ -1225,12 +1307,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/echo.HTTPError"
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1246,21 +1322,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/games/{gameID}/match/me": {
+        "/games/{gameID}/data/{playerID}/player": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Returns every started match in this game that the caller is a participant in. Empty list when none. Used by clients to rediscover the game server after a page reload — the response shape mirrors the matchmaking WebSocket's match_found payload. Guests must preserve their JWT across reloads to use this; a fresh guest token is a new identity and won't match prior participation.",
+                "description": "Read the player-authored entries for one of the players in the active match. Auth is the match auth code, passed as Authorization: Bearer \u003ccode\u003e.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Matches"
+                    "PlayerData"
                 ],
-                "summary": "List the caller's active matches in a game",
+                "summary": "List a player's player-authored entries (game server)",
                 "parameters": [
                     {
                         "type": "string",
@@ -1268,22 +1339,41 @@ const docTemplate = `{
                         "name": "gameID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player UUID (registered users only — guests rejected with 400)",
+                        "name": "playerID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "matches",
+                        "description": "entries",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1293,21 +1383,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/games/{gameID}/matches/live": {
+        "/games/{gameID}/data/{playerID}/server": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Returns every started match in this game that has spectating enabled (game-level flag AND per-match override). Empty list when none. 404 when the game itself does not have spectate_enabled, even if matches exist — the gate is the game's, not the caller's.",
+                "description": "Read the server-authored entries for one of the players in the active match. Auth is the match auth code, passed as Authorization: Bearer \u003ccode\u003e.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Matches"
+                    "PlayerData"
                 ],
-                "summary": "List spectatable live matches for a game",
+                "summary": "List a player's server-authored entries (game server)",
                 "parameters": [
                     {
                         "type": "string",
@@ -1315,24 +1400,37 @@ const docTemplate = `{
                         "name": "gameID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player UUID (registered users only — guests rejected with 400)",
+                        "name": "playerID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "matches",
+                        "description": "entries",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -1346,9 +1444,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/guest/login": {
-            "post": {
-                "description": "Returns a JWT token for a guest user with the given display name",
+        "/games/{gameID}/data/{playerID}/{key}": {
+            "put": {
+                "description": "Writes a server-authored JSON value for a player in the active match. Replaces any existing server-authored entry at that key. The same key may also exist as a player-authored entry — the two are independent.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1356,23 +1454,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "PlayerData"
                 ],
-                "summary": "Create a guest session",
+                "summary": "Upsert a server-authored entry (game server)",
                 "parameters": [
                     {
-                        "description": "Guest login payload",
+                        "type": "string",
+                        "description": "Game UUID",
+                        "name": "gameID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player UUID (registered users only)",
+                        "name": "playerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Entry key (a-zA-Z0-9._-, max 128 chars)",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Arbitrary JSON value (max 64KB)",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/src_api_user.GuestRequest"
+                            "type": "object"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "token, displayName, id",
+                        "description": "status",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1391,20 +1510,59 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
                     }
                 }
-            }
-        },
-        "/health": {
-            "get": {
-                "description": "Returns a simple health status",
+            },
+            "delete": {
+                "description": "Removes a server-authored entry for a player in the active match. Does not affect any player-authored entry at the same key.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "PlayerData"
+                ],
+                "summary": "Delete a server-authored entry (game server)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game UUID",
+                        "name": "gameID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player UUID (registered users only)",
+                        "name": "playerID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Entry key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
                         "description": "status",
@@ -1414,50 +1572,74 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
                     }
                 }
             }
         },
-        "/lobby/find": {
+        "/games/{gameID}/match/me": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns lobbies for the given game, optionally filtered to those whose Tags contain ALL the comma-separated tags in the query. Lobbies hosted with private=true are excluded from this listing and must be joined directly via their lobby ID.",
+                "description": "Returns every started match in this game that the caller is a participant in. Empty list when none. Used by clients to rediscover the game server after a page reload — the response shape mirrors the matchmaking WebSocket's match_found payload. Each call signs a fresh connect_token — except for matches the game server reported the caller as having left, which carry ` + "`" + `left` + "`" + ` (reason and time) instead: the caller was dropped and will be rated as a loser. Guests must preserve their JWT across reloads to use this; a fresh guest token is a new identity and won't match prior participation.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Lobby"
+                    "Matches"
                 ],
-                "summary": "List open lobbies for a game",
+                "summary": "List the caller's active matches in a game",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Game UUID",
                         "name": "gameID",
-                        "in": "query",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Comma-separated tags; lobby must include every tag to be returned",
-                        "name": "tags",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "lobbies",
+                        "description": "matches",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -1471,29 +1653,207 @@ const docTemplate = `{
                 }
             }
         },
-        "/lobby/host": {
+        "/games/{gameID}/matches/live": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Upgrades to a WebSocket. Creates a new lobby for a game and keeps the host's connection open. The host owns chat, /disconnect \u003cname\u003e, and /start commands. Lobby is torn down when the host disconnects.",
+                "description": "Returns every started match in this game that has spectating enabled (game-level flag AND per-match override). Empty list when none. 404 when the game itself does not have spectate_enabled, even if matches exist — the gate is the game's, not the caller's.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Lobby"
+                    "Matches"
                 ],
-                "summary": "Host a lobby (WebSocket)",
+                "summary": "List spectatable live matches for a game",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Game UUID",
                         "name": "gameID",
-                        "in": "query",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Specific GameQueue UUID. Defaults to the game's primary queue when omitted.",
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "matches",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/guest/login": {
+            "post": {
+                "description": "Returns a JWT token for a guest user with the given display name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Create a guest session",
+                "parameters": [
+                    {
+                        "description": "Guest login payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/src_api_user.GuestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "token, displayName, id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Returns a simple health status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/lobby/find": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns lobbies for the given game, optionally filtered to those whose Tags contain ALL the comma-separated tags in the query. Lobbies hosted with private=true are excluded from this listing and must be joined directly via their lobby ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Lobby"
+                ],
+                "summary": "List open lobbies for a game",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game UUID",
+                        "name": "gameID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated tags; lobby must include every tag to be returned",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "lobbies",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/lobby/host": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upgrades to a WebSocket. Creates a new lobby for a game and keeps the host's connection open. The host owns chat, /disconnect \u003cname\u003e, and /start commands. Lobby is torn down when the host disconnects.",
+                "tags": [
+                    "Lobby"
+                ],
+                "summary": "Host a lobby (WebSocket)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game UUID",
+                        "name": "gameID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Specific GameQueue UUID. Defaults to the game's primary queue when omitted.",
                         "name": "queueID",
                         "in": "query"
                     },
@@ -1559,75 +1919,885 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Required when the lobby is password-protected (see /lobby/find)",
-                        "name": "password",
-                        "in": "query"
+                        "description": "Required when the lobby is password-protected (see /lobby/find)",
+                        "name": "password",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT token (alternative to Authorization header)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/match/artifact": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Game server uploads opaque bytes (replay file, preview image, highlight reel, etc.) for the active match. Auth is the match auth_code carried as Authorization: Bearer \u003ccode\u003e. Name must match [a-zA-Z0-9._-]{1,64}; uploading the same name again overwrites. Up to 10 distinct names per match. Body is capped at 1 MiB; Content-Type is preserved and returned on download. The platform doesn't interpret the bytes — ` + "`" + `preview` + "`" + ` and ` + "`" + `replay` + "`" + ` are conventional names that generic UIs may render but no validation is performed on shape.",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "Upload a named artifact for the active match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Artifact name (a-zA-Z0-9._-, max 64 chars)",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "name, size_bytes, content_type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "invalid name or too many artifacts",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "match is not underway",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "413": {
+                        "description": "artifact exceeds 1 MiB",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/match/backfill": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Game server reads the match's current players — including any backfilled since it started — and how many backfill seats are still open. Auth is the match auth_code carried as Authorization: Bearer \u003ccode\u003e.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "Get the running match's players and backfill status",
+                "responses": {
+                    "200": {
+                        "description": "match_id, player_ids, teams, players_requested",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "match is not underway",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Game server asks the matchmaker for ` + "`" + `players` + "`" + ` replacement players, e.g. after someone drops, up to the match's open seats (lobby size less the players still in it; report players who left through /match/leave). Auth is the match auth_code carried as Authorization: Bearer \u003ccode\u003e. The request is served ahead of new matches in the queue (and metadata sub-queue) the match was paired from, taking the longest-waiting players that fit and can play in the match's region; they receive match_found with this server's host and ports and are added to the match's players. A request may be filled in several steps, and stays open for 10 minutes. A new request replaces the open one; ` + "`" + `players: 0` + "`" + ` withdraws it. On team matches, ` + "`" + `team` + "`" + ` picks the team replacements join; by default they go to the teams with the fewest players left. A replacement takes the team slot of a player who left. Poll GET /match/backfill to learn who joined.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "Request backfill players for the running match",
+                "parameters": [
+                    {
+                        "description": "Backfill request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/src_api_match.BackfillRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "match_id, players_requested",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "match is not underway",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/match/extend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Game server pushes back the deadline at which the matchmaker ends its match as \"timeout\". Auth is the match auth_code carried as Authorization: Bearer \u003ccode\u003e. A match's deadline starts at the queue's max_match_duration_minutes after match start; extensions add ` + "`" + `minutes` + "`" + ` to it, up to max_match_extension_minutes in total. An extension past that limit is cut short; once the limit is reached further calls are refused with 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "Extend the running match's deadline",
+                "parameters": [
+                    {
+                        "description": "Extension",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/src_api_match.ExtendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "match_id, deadline, latest_deadline",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "match is not underway",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "match deadline can't be extended further",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/match/game/{gameID}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a paginated list of matches for a specific game",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "Get matches for a game",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game UUID",
+                        "name": "gameID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 10)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "matches, nextPage",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/match/heartbeat": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Game server tells the matchmaker it's still running the match. Auth is the match auth_code carried as Authorization: Bearer \u003ccode\u003e. On queues with heartbeat_grace_seconds set, a match whose server goes that long without a heartbeat (counted from match start until the first one) is ended: as \"server_crashed\" when its container is gone, or as \"server_unresponsive\" when the container is still running but stays silent for three grace periods. Heartbeats are accepted, and ignored, on queues without it. Send one every grace/3 seconds or so.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "Report that the game server is alive",
+                "responses": {
+                    "200": {
+                        "description": "match_id, heartbeat_grace_seconds",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "match is not underway",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/match/join": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upgrades to a WebSocket connection and joins the matchmaking queue for a game. Sends status updates until a match is found. On queues with a ready check, a paired player receives match_proposed and must send /accept (or /decline) before the deadline; if anyone else fails to accept, accepting players receive requeued and keep their place at the front of the queue. A party leader queues the whole party as one unit; other party members connect with the same gameID/queueID/metadata to follow the leader's search and receive the same match_found. Clients may report measured pings to the server's regions; the matchmaker then groups players by region (honoring the queue's max_ping_ms) and match_found says which region the server is in. One connection may search several queues of the game at once: repeat queueID and/or metadata, and the player searches every queueID × metadata pairing (at most 8). Being paired in any of them withdraws the player from all the others, and match_found names the queue_id that paired them.",
+                "tags": [
+                    "Matchmaking"
+                ],
+                "summary": "Join matchmaking queue (WebSocket)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game UUID to queue for",
+                        "name": "gameID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Specific GameQueue UUID; repeat to search several. Defaults to the game's primary queue (oldest by created_at) when omitted.",
+                        "name": "queueID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Optional sub-queue key (only honored when the resolved queue's metadata_enabled=true; capped at 4 KB); repeat to search several.",
+                        "name": "metadata",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Measured round-trip times per region, e.g. nbg1:35,ash:120. Unknown regions are ignored.",
+                        "name": "pings",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT token (alternative to Authorization header)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/match/leave": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Game server records that a player left, disconnected for good, or was kicked before the match ended, without ending the match. Auth is the match auth_code carried as Authorization: Bearer \u003ccode\u003e. When the match ends, players who left are rated as losers — placed behind everyone who stayed, and split off their team on team matches — whatever the final report says about them, and the result lists them under ` + "`" + `abandons` + "`" + `. Leaving costs the queue's penalty_abandon_points. A player can only leave once; repeating the call returns the first leave with ` + "`" + `recorded: false` + "`" + `.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "Report a player leaving the running match",
+                "parameters": [
+                    {
+                        "description": "Leave",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/src_api_match.LeaveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "match_id, leave, recorded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "match is not underway",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/match/lockout": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the caller's penalty standing in a game: current penalty points (after decay) and, while locked out of the game's queues, when the lockout ends. Points are earned by declining ready checks or leaving /match/join after being paired, per each queue's penalty settings; a lockout covers every queue of the game. Clients can use lockout_seconds to show a timer.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matchmaking"
+                ],
+                "summary": "Get my matchmaking lockout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game UUID",
+                        "name": "gameID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "GameQueue whose penalty decay applies. Defaults to the game's primary queue.",
+                        "name": "queueID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "game_id, points, locked, locked_until, lockout_seconds",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/match/size": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the number of players currently in the matchmaking queue for a game, plus the estimated wait (median and 90th percentile, in seconds, of recent waits in this queue) once the queue has formed matches recently.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matchmaking"
+                ],
+                "summary": "Get matchmaking queue size",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game UUID",
+                        "name": "gameID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Specific GameQueue UUID. Defaults to the game's primary queue when omitted.",
+                        "name": "queueID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sub-queue key (only honored when the resolved queue's metadata_enabled=true)",
+                        "name": "metadata",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "players_in_queue, estimated_wait_seconds, p90_wait_seconds",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/match/{matchID}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns match details. User must be a participant or game owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "Get a match by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Match UUID",
+                        "name": "matchID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.MatchResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a paginated list of all matches. Admin only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "List all matches (admin)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number (default 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 10)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "matches, nextPage",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{matchID}/artifacts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every artifact uploaded by the game server during this match, with content_type, size_bytes, uploaded_at, and a download url. Auth gate matches the result-visibility rule for the game (PublicResults true → any auth; otherwise participant/owner/admin only).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "List artifacts attached to a match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Match UUID",
+                        "name": "matchID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "artifacts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{matchID}/artifacts/{name}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Streams the raw bytes of one named artifact, with the Content-Type the game server uploaded it with. Same auth gate as ListMatchArtifacts.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Matches"
+                ],
+                "summary": "Download one artifact's bytes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Match UUID",
+                        "name": "matchID",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "JWT token (alternative to Authorization header)",
-                        "name": "token",
-                        "in": "query"
+                        "description": "Artifact name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
                     }
                 ],
-                "responses": {}
+                "responses": {
+                    "200": {
+                        "description": "raw artifact bytes",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
             }
         },
-        "/match/artifact": {
+        "/matches/{matchID}/report": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Game server uploads opaque bytes (replay file, preview image, highlight reel, etc.) for the active match. Auth is the match auth_code carried as Authorization: Bearer \u003ccode\u003e. Name must match [a-zA-Z0-9._-]{1,64}; uploading the same name again overwrites. Up to 10 distinct names per match. Body is capped at 1 MiB; Content-Type is preserved and returned on download. The platform doesn't interpret the bytes — ` + "`" + `preview` + "`" + ` and ` + "`" + `replay` + "`" + ` are conventional names that generic UIs may render but no validation is performed on shape.",
+                "description": "For queues with ` + "`" + `result_reporting: clients` + "`" + ` (peer-to-peer games with no game server). Each participant reports how the match ended with their own token, in the same shape as /result/report. Reports agree when they finish every pair of players in the same order and split them into the same teams. Once ` + "`" + `result_quorum` + "`" + ` reports agree (a majority of the players when 0), the result is recorded from the earliest of them and ratings are updated. Once the reports conflict so that no outcome can reach the quorum, the match ends as ` + "`" + `disputed` + "`" + ` with no rating change. A match still pending ` + "`" + `result_report_window_seconds` + "`" + ` after the first report is settled from the reports received by then: the outcome a majority of them agree on is recorded and rated, otherwise the match ends ` + "`" + `disputed` + "`" + `. Disputed matches get no rating change, and the game owner can find it under /game/{gameID}/results/disputed. Reports are final; repeating the call returns the first report with ` + "`" + `recorded: false` + "`" + `. ` + "`" + `status` + "`" + ` is ` + "`" + `pending` + "`" + `, ` + "`" + `agreed` + "`" + ` or ` + "`" + `disputed` + "`" + `.",
                 "consumes": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Matches"
+                    "Results"
                 ],
-                "summary": "Upload a named artifact for the active match",
+                "summary": "Report a match outcome as a player",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Artifact name (a-zA-Z0-9._-, max 64 chars)",
-                        "name": "name",
-                        "in": "query",
+                        "description": "Match UUID",
+                        "name": "matchID",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Outcome",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/src_api_matchResults.ReportOutcomeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "name, size_bytes, content_type",
+                        "description": "match_id, report, recorded, status, reports, quorum",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "invalid name or too many artifacts",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "not a participant, or the match's result comes from its game server",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "403": {
-                        "description": "match is not underway",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "413": {
-                        "description": "artifact exceeds 1 MiB",
+                    "409": {
+                        "description": "match already ended",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -1641,52 +2811,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/match/game/{gameID}": {
+        "/matches/{matchID}/stream": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns a paginated list of matches for a specific game",
+                "description": "Long-polling proxy over the S3-backed spectator chunks for a match. Pass cursor=0 on first call; the response carries the next cursor in the X-Spectate-Cursor header. Body is the concatenated bytes of chunks [cursor, latest_seq]. When caught up, the request blocks for up to ~30s before returning empty. X-Spectate-EOF=true means the match has ended and no more bytes will arrive — stop polling. Bytes are game-defined; the server treats them as opaque.",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
                     "Matches"
                 ],
-                "summary": "Get matches for a game",
+                "summary": "Tail a live spectator stream",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Game UUID",
-                        "name": "gameID",
+                        "description": "Match UUID",
+                        "name": "matchID",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Page number (default 0)",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Page size (default 10)",
-                        "name": "pageSize",
+                        "description": "Next chunk seq to fetch (default 0)",
+                        "name": "cursor",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "matches, nextPage",
+                        "description": "raw chunk bytes",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -1700,94 +2869,65 @@ const docTemplate = `{
                 }
             }
         },
-        "/match/join": {
+        "/party": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Upgrades to a WebSocket connection and joins the matchmaking queue for a game. Sends status updates until a match is found.",
+                "description": "Returns the party the caller belongs to, including members (leader first), outstanding invites, and whether it's currently searching for a match.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Matchmaking"
+                    "Party"
                 ],
-                "summary": "Join matchmaking queue (WebSocket)",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Game UUID to queue for",
-                        "name": "gameID",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Specific GameQueue UUID. Defaults to the game's primary queue (oldest by created_at) when omitted.",
-                        "name": "queueID",
-                        "in": "query"
+                "summary": "Get the caller's party",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/src_api_party.PartyResp"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Optional sub-queue key (only honored when the resolved queue's metadata_enabled=true; capped at 4 KB)",
-                        "name": "metadata",
-                        "in": "query"
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "JWT token (alternative to Authorization header)",
-                        "name": "token",
-                        "in": "query"
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
                     }
-                ],
-                "responses": {}
-            }
-        },
-        "/match/size": {
-            "get": {
+                }
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns the number of players currently in the matchmaking queue for a game",
+                "description": "Creates a party led by the caller. A player can be in one party at a time. The leader invites others by player ID; once they accept, the leader's /match/join queues the whole party as one unit so everyone lands in the same match.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Matchmaking"
-                ],
-                "summary": "Get matchmaking queue size",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Game UUID",
-                        "name": "gameID",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Specific GameQueue UUID. Defaults to the game's primary queue when omitted.",
-                        "name": "queueID",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Sub-queue key (only honored when the resolved queue's metadata_enabled=true)",
-                        "name": "metadata",
-                        "in": "query"
-                    }
+                    "Party"
                 ],
+                "summary": "Create a party",
                 "responses": {
                     "200": {
-                        "description": "players_in_queue",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/src_api_party.PartyResp"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -1801,41 +2941,27 @@ const docTemplate = `{
                 }
             }
         },
-        "/match/{matchID}": {
+        "/party/invites": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns match details. User must be a participant or game owner.",
+                "description": "Returns the parties that have invited the caller and haven't been joined yet.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Matches"
-                ],
-                "summary": "Get a match by ID",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Match UUID",
-                        "name": "matchID",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Party"
                 ],
+                "summary": "List the caller's party invites",
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.MatchResp"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
+                        "description": "invites",
                         "schema": {
-                            "$ref": "#/definitions/echo.HTTPError"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
@@ -1847,41 +2973,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/matches": {
-            "get": {
+        "/party/{partyID}/invite": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns a paginated list of all matches. Admin only.",
+                "description": "Leader only. Invites a user or guest by player ID; they accept with POST /party/{partyID}/join.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Matches"
+                    "Party"
                 ],
-                "summary": "List all matches (admin)",
+                "summary": "Invite a player to a party",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Page number (default 0)",
-                        "name": "page",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Party ID",
+                        "name": "partyID",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Page size (default 10)",
-                        "name": "pageSize",
-                        "in": "query"
+                        "description": "Player to invite",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/src_api_party.InviteToPartyRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "matches, nextPage",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/src_api_party.PartyResp"
                         }
                     },
                     "400": {
@@ -1890,8 +3022,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -1905,40 +3049,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/matches/{matchID}/artifacts": {
-            "get": {
+        "/party/{partyID}/join": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns every artifact uploaded by the game server during this match, with content_type, size_bytes, uploaded_at, and a download url. Auth gate matches the result-visibility rule for the game (PublicResults true → any auth; otherwise participant/owner/admin only).",
+                "description": "Joins a party the caller was invited to. Refused with 409 if the caller is already in a party, the party is full, or it's currently searching for a match.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Matches"
+                    "Party"
                 ],
-                "summary": "List artifacts attached to a match",
+                "summary": "Accept a party invite",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Match UUID",
-                        "name": "matchID",
+                        "description": "Party ID",
+                        "name": "partyID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "artifacts",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/src_api_party.PartyResp"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -1949,6 +3092,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1958,48 +3107,36 @@ const docTemplate = `{
                 }
             }
         },
-        "/matches/{matchID}/artifacts/{name}": {
-            "get": {
+        "/party/{partyID}/leave": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Streams the raw bytes of one named artifact, with the Content-Type the game server uploaded it with. Same auth gate as ListMatchArtifacts.",
+                "description": "Leaves the party. When the leader leaves, the party is disbanded. If the party was searching, it's pulled out of the queue and every member's /match/join session ends with an error.",
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "Matches"
+                    "Party"
                 ],
-                "summary": "Download one artifact's bytes",
+                "summary": "Leave a party",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Match UUID",
-                        "name": "matchID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Artifact name",
-                        "name": "name",
+                        "description": "Party ID",
+                        "name": "partyID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "raw artifact bytes",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                        "description": "status: left or disbanded",
                         "schema": {
-                            "$ref": "#/definitions/echo.HTTPError"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "404": {
@@ -2017,45 +3154,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/matches/{matchID}/stream": {
+        "/ratings/recalculations/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Long-polling proxy over the S3-backed spectator chunks for a match. Pass cursor=0 on first call; the response carries the next cursor in the X-Spectate-Cursor header. Body is the concatenated bytes of chunks [cursor, latest_seq]. When caught up, the request blocks for up to ~30s before returning empty. X-Spectate-EOF=true means the match has ended and no more bytes will arrive — stop polling. Bytes are game-defined; the server treats them as opaque.",
+                "description": "Returns a recalculation job's status (` + "`" + `pending` + "`" + `, ` + "`" + `running` + "`" + `, ` + "`" + `completed` + "`" + `, ` + "`" + `failed` + "`" + `). While running, ` + "`" + `processed` + "`" + `/` + "`" + `total` + "`" + ` report live progress through the queue's match results. Once finished, ` + "`" + `diff` + "`" + ` lists every player whose rating changed (before, after, delta) — for dry runs this is what would have changed. Admin only.",
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "Matches"
+                    "Ratings"
                 ],
-                "summary": "Tail a live spectator stream",
+                "summary": "Get a rating recalculation job (admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Match UUID",
-                        "name": "matchID",
+                        "description": "Recalculation job UUID",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Next chunk seq to fetch (default 0)",
-                        "name": "cursor",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "raw chunk bytes",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.RatingRecalculationResp"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -2077,7 +3208,7 @@ const docTemplate = `{
         },
         "/result/report": {
             "post": {
-                "description": "Called by the game server to report the outcome of a match",
+                "description": "Called by the game server to report the outcome of a match. Optional ` + "`" + `placements` + "`" + ` (player → finishing position) and ` + "`" + `scores` + "`" + ` (player → score) report a full free-for-all ordering; optional ` + "`" + `teams` + "`" + ` + ` + "`" + `team_placements` + "`" + ` carry the team layout and finishing order for team-aware rating strategies. ` + "`" + `winner_id` + "`" + `/` + "`" + `winner_ids` + "`" + ` alone still work. Matches on queues with ` + "`" + `result_reporting: clients` + "`" + ` only take their players' reports (POST /matches/{matchID}/report) and are refused with 403.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2115,6 +3246,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
+                    "403": {
+                        "description": "the match's result is reported by its players",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -2201,7 +3338,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns the result of a completed match",
+                "description": "Returns the result of a completed match, including the per-player rating changes it caused (` + "`" + `rating_changes` + "`" + `, omitted for unrated matches)",
                 "produces": [
                     "application/json"
                 ],
@@ -2541,6 +3678,134 @@ const docTemplate = `{
                 }
             }
         },
+        "/user/blocks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the players the caller has blocked, newest first. The matchmaker never puts two players in the same match when either has blocked the other.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "List blocked players",
+                "responses": {
+                    "200": {
+                        "description": "blocks",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/blocks/{playerID}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds a player (user or guest ID) to the caller's block list, so matchmaking never groups the two of them again, in any game. Idempotent.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Block a player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player to block",
+                        "name": "playerID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.PlayerBlock"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes a player from the caller's block list.",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Unblock a player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player to unblock",
+                        "name": "playerID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
         "/user/game": {
             "get": {
                 "security": [
@@ -2689,8 +3954,67 @@ const docTemplate = `{
                             "$ref": "#/definitions/echo.HTTPError"
                         }
                     },
-                    "401": {
-                        "description": "current password did not match",
+                    "401": {
+                        "description": "current password did not match",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/rating/{gameId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the authenticated user's rating for the given game's queue. A row is lazy-created at the queue's DefaultRating on first access. ` + "`" + `deviation` + "`" + ` is the Glicko-2 rating deviation (grows with inactivity); it stays at its default for non-glicko2 queues. ` + "`" + `mu` + "`" + `/` + "`" + `sigma` + "`" + ` are the trueskill skill estimate and uncertainty (zero until the player is first rated by a trueskill queue). ` + "`" + `provisional` + "`" + ` is true while the player has played fewer than the queue's ` + "`" + `placement_matches` + "`" + ` rated matches. Defaults to the game's primary queue when queueID is omitted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ratings"
+                ],
+                "summary": "Get user rating for a game queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game UUID",
+                        "name": "gameId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Specific GameQueue UUID (defaults to primary queue)",
+                        "name": "queueID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "player_id, game_queue_id, rating, deviation, mu, sigma, games_played, provisional",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/echo.HTTPError"
                         }
@@ -2698,21 +4022,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/rating/{gameId}": {
+        "/user/rating/{gameId}/history": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns the authenticated user's rating for the given game's queue. A row is lazy-created at the queue's DefaultRating on first access. Defaults to the game's primary queue when queueID is omitted.",
+                "description": "Returns the authenticated user's rating changes in the given game's queue, newest first, paginated. Each entry records the rating before and after, the delta, what caused it, and the match result ID for match-driven changes. Defaults to the game's primary queue when queueID is omitted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Ratings"
                 ],
-                "summary": "Get user rating for a game queue",
+                "summary": "Get user rating history for a game queue",
                 "parameters": [
                     {
                         "type": "string",
@@ -2726,11 +4050,23 @@ const docTemplate = `{
                         "description": "Specific GameQueue UUID (defaults to primary queue)",
                         "name": "queueID",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 10, max 100)",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "player_id, game_queue_id, rating",
+                        "description": "history, nextPage, game_queue_id",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2946,18 +4282,44 @@ const docTemplate = `{
                 "message": {}
             }
         },
+        "github_com_andy98725_elo-service_src_external_redis.PartyMember": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
         "github_com_andy98725_elo-service_src_models.GameQueueResp": {
             "type": "object",
             "properties": {
+                "decay_floor": {
+                    "type": "integer"
+                },
+                "decay_grace_days": {
+                    "type": "integer"
+                },
+                "decay_per_week": {
+                    "type": "integer"
+                },
                 "default_rating": {
                     "type": "integer"
                 },
                 "elo_strategy": {
                     "type": "string"
                 },
+                "fill_timeout_seconds": {
+                    "type": "integer"
+                },
                 "game_id": {
                     "type": "string"
                 },
+                "heartbeat_grace_seconds": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "string"
                 },
@@ -2982,11 +4344,95 @@ const docTemplate = `{
                 "matchmaking_strategy": {
                     "type": "string"
                 },
+                "max_match_duration_minutes": {
+                    "type": "integer"
+                },
+                "max_match_extension_minutes": {
+                    "type": "integer"
+                },
+                "max_ping_ms": {
+                    "type": "integer"
+                },
                 "metadata_enabled": {
                     "type": "boolean"
                 },
+                "min_players": {
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
+                },
+                "penalty_abandon_points": {
+                    "type": "integer"
+                },
+                "penalty_decay_per_hour": {
+                    "type": "integer"
+                },
+                "penalty_decline_points": {
+                    "type": "integer"
+                },
+                "penalty_dodge_points": {
+                    "type": "integer"
+                },
+                "penalty_lockout_steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.PenaltyLockoutStep"
+                    }
+                },
+                "placement_k_multiplier": {
+                    "type": "number"
+                },
+                "placement_matches": {
+                    "type": "integer"
+                },
+                "rating_window_cap": {
+                    "type": "integer"
+                },
+                "rating_window_steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.RatingWindowStep"
+                    }
+                },
+                "ready_check_seconds": {
+                    "type": "integer"
+                },
+                "rematch_lookback_minutes": {
+                    "type": "integer"
+                },
+                "rematch_max_wait_seconds": {
+                    "type": "integer"
+                },
+                "result_quorum": {
+                    "type": "integer"
+                },
+                "result_report_window_seconds": {
+                    "type": "integer"
+                },
+                "result_reporting": {
+                    "type": "string"
+                },
+                "season_ends_at": {
+                    "type": "string"
+                },
+                "season_length_days": {
+                    "type": "integer"
+                },
+                "season_number": {
+                    "type": "integer"
+                },
+                "season_soft_reset": {
+                    "type": "number"
+                },
+                "season_started_at": {
+                    "type": "string"
+                },
+                "team_count": {
+                    "type": "integer"
+                },
+                "team_size": {
+                    "type": "integer"
                 }
             }
         },
@@ -3025,6 +4471,68 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_andy98725_elo-service_src_models.MatchLeave": {
+            "type": "object",
+            "properties": {
+                "left_at": {
+                    "type": "string"
+                },
+                "player_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andy98725_elo-service_src_models.MatchReport": {
+            "type": "object",
+            "properties": {
+                "placements": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "player_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reported_at": {
+                    "type": "string"
+                },
+                "scores": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "team_placements": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "teams": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "winner_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "github_com_andy98725_elo-service_src_models.MatchResp": {
             "type": "object",
             "properties": {
@@ -3051,15 +4559,35 @@ const docTemplate = `{
                 },
                 "status": {
                     "type": "string"
+                },
+                "teams": {
+                    "description": "Teams is omitted for matches without a team layout.",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
                 }
             }
         },
         "github_com_andy98725_elo-service_src_models.MatchResultResp": {
             "type": "object",
             "properties": {
+                "abandons": {
+                    "description": "Abandons lists players who left before the match ended; omitted\nwhen everyone stayed.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.MatchLeave"
+                    }
+                },
                 "game_id": {
                     "type": "string"
                 },
+                "game_queue_id": {
+                    "type": "string"
+                },
                 "guest_ids": {
                     "type": "array",
                     "items": {
@@ -3069,20 +4597,187 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "placements": {
+                    "description": "Placements and Scores are omitted unless the report included a\nfull ordering or per-player scores.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
                 "players": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.UserResp"
                     }
                 },
-                "result": {
+                "rating_changes": {
+                    "description": "RatingChanges is only populated on the single-result endpoint.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.RatingChangeResp"
+                    }
+                },
+                "reports": {
+                    "description": "Reports lists what each player reported on a client-reported\nqueue; omitted for results reported by a game server.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.MatchReport"
+                    }
+                },
+                "result": {
+                    "type": "string"
+                },
+                "scores": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "team_placements": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "teams": {
+                    "description": "Teams and TeamPlacements are omitted for matches reported with\nwinner_ids only.",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "winner_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_andy98725_elo-service_src_models.PenaltyLockoutStep": {
+            "type": "object",
+            "properties": {
+                "lockout_seconds": {
+                    "type": "integer"
+                },
+                "points": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_andy98725_elo-service_src_models.PlayerBlock": {
+            "type": "object",
+            "properties": {
+                "blocked_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "player_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andy98725_elo-service_src_models.RatingChangeResp": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "integer"
+                },
+                "before": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delta": {
+                    "type": "integer"
+                },
+                "game_queue_id": {
+                    "type": "string"
+                },
+                "match_result_id": {
+                    "type": "string"
+                },
+                "player_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andy98725_elo-service_src_models.RatingDiffEntry": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "integer"
+                },
+                "before": {
+                    "type": "integer"
+                },
+                "delta": {
+                    "type": "integer"
+                },
+                "player_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andy98725_elo-service_src_models.RatingRecalculationResp": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "diff": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.RatingDiffEntry"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "game_queue_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "started_at": {
                     "type": "string"
                 },
-                "winner_ids": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_andy98725_elo-service_src_models.RatingWindowStep": {
+            "type": "object",
+            "properties": {
+                "after_seconds": {
+                    "type": "integer"
+                },
+                "max_spread": {
+                    "type": "integer"
                 }
             }
         },
@@ -3140,12 +4835,29 @@ const docTemplate = `{
         "github_com_andy98725_elo-service_src_models.UpdateGameQueueParams": {
             "type": "object",
             "properties": {
+                "decay_floor": {
+                    "type": "integer"
+                },
+                "decay_grace_days": {
+                    "description": "Decay settings are pointers so they can be set back to 0 (e.g.\ndecay_per_week 0 turns decay off).",
+                    "type": "integer"
+                },
+                "decay_per_week": {
+                    "type": "integer"
+                },
                 "default_rating": {
                     "type": "integer"
                 },
                 "elo_strategy": {
                     "type": "string"
                 },
+                "fill_timeout_seconds": {
+                    "type": "integer"
+                },
+                "heartbeat_grace_seconds": {
+                    "description": "HeartbeatGraceSeconds is a pointer so heartbeats can be turned off\n(0).",
+                    "type": "integer"
+                },
                 "k_factor": {
                     "type": "integer"
                 },
@@ -3167,11 +4879,100 @@ const docTemplate = `{
                 "matchmaking_strategy": {
                     "type": "string"
                 },
+                "max_match_duration_minutes": {
+                    "description": "MaxMatchExtensionMinutes is a pointer so extensions can be turned\noff (0).",
+                    "type": "integer"
+                },
+                "max_match_extension_minutes": {
+                    "type": "integer"
+                },
+                "max_ping_ms": {
+                    "description": "MaxPingMs is a pointer so region filtering can be turned off (0).",
+                    "type": "integer"
+                },
                 "metadata_enabled": {
                     "type": "boolean"
                 },
+                "min_players": {
+                    "description": "Fill timeout settings are pointers so they can be turned off (0).",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
+                },
+                "penalty_abandon_points": {
+                    "type": "integer"
+                },
+                "penalty_decay_per_hour": {
+                    "type": "integer"
+                },
+                "penalty_decline_points": {
+                    "description": "Penalty points and decay are pointers so they can be turned off\n(0). PenaltyLockoutSteps replaces the whole schedule when sent.",
+                    "type": "integer"
+                },
+                "penalty_dodge_points": {
+                    "type": "integer"
+                },
+                "penalty_lockout_steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.PenaltyLockoutStep"
+                    }
+                },
+                "placement_k_multiplier": {
+                    "type": "number"
+                },
+                "placement_matches": {
+                    "description": "PlacementMatches is a pointer so placements can be turned off (0).",
+                    "type": "integer"
+                },
+                "rating_window_cap": {
+                    "type": "integer"
+                },
+                "rating_window_steps": {
+                    "description": "RatingWindowSteps replaces the whole schedule when sent.\nRatingWindowCap is a pointer so the cap can be removed (0).",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.RatingWindowStep"
+                    }
+                },
+                "ready_check_seconds": {
+                    "description": "ReadyCheckSeconds is a pointer so the ready check can be turned\noff (0).",
+                    "type": "integer"
+                },
+                "rematch_lookback_minutes": {
+                    "description": "Rematch settings are pointers so avoidance can be turned off (0).",
+                    "type": "integer"
+                },
+                "rematch_max_wait_seconds": {
+                    "type": "integer"
+                },
+                "result_quorum": {
+                    "type": "integer"
+                },
+                "result_report_window_seconds": {
+                    "type": "integer"
+                },
+                "result_reporting": {
+                    "description": "ResultQuorum is a pointer so it can be set back to 0 (a\nmajority).",
+                    "type": "string"
+                },
+                "season_ends_at": {
+                    "description": "SeasonEndsAt reschedules the end of the current season (opening\nseason 1 if seasons were never enabled). Must be in the future.",
+                    "type": "string"
+                },
+                "season_length_days": {
+                    "type": "integer"
+                },
+                "season_soft_reset": {
+                    "type": "number"
+                },
+                "team_count": {
+                    "description": "Team settings are pointers so teams can be switched off (both 0).\nSetting them re-derives LobbySize.",
+                    "type": "integer"
+                },
+                "team_size": {
+                    "type": "integer"
                 }
             }
         },
@@ -3195,15 +4996,67 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_andy98725_elo-service_src_worker_matchmaking.QueueStats": {
+            "type": "object",
+            "properties": {
+                "abandonment_rate": {
+                    "type": "number"
+                },
+                "average_rating_spread": {
+                    "type": "number"
+                },
+                "entries_abandoned": {
+                    "type": "integer"
+                },
+                "game_queue_id": {
+                    "type": "string"
+                },
+                "matches_formed": {
+                    "type": "integer"
+                },
+                "matches_per_hour": {
+                    "type": "number"
+                },
+                "median_wait_seconds": {
+                    "type": "integer"
+                },
+                "p90_wait_seconds": {
+                    "type": "integer"
+                },
+                "players_matched": {
+                    "type": "integer"
+                },
+                "window_hours": {
+                    "type": "integer"
+                }
+            }
+        },
         "src_api_game.CreateGameQueueRequest": {
             "type": "object",
             "properties": {
+                "decay_floor": {
+                    "type": "integer"
+                },
+                "decay_grace_days": {
+                    "description": "Inactivity decay. decay_floor defaults to default_rating.",
+                    "type": "integer"
+                },
+                "decay_per_week": {
+                    "type": "integer"
+                },
                 "default_rating": {
                     "type": "integer"
                 },
                 "elo_strategy": {
                     "type": "string"
                 },
+                "fill_timeout_seconds": {
+                    "type": "integer"
+                },
+                "heartbeat_grace_seconds": {
+                    "description": "HeartbeatGraceSeconds \u003e 0 requires game servers to POST\n/match/heartbeat at least this often; silent matches are ended.",
+                    "type": "integer"
+                },
                 "k_factor": {
                     "type": "integer"
                 },
@@ -3225,11 +5078,100 @@ const docTemplate = `{
                 "matchmaking_strategy": {
                     "type": "string"
                 },
+                "max_match_duration_minutes": {
+                    "description": "Match duration. Matches are ended as \"timeout\" after\nmax_match_duration_minutes (0 = 360); game servers may push that\nback by up to max_match_extension_minutes in total.",
+                    "type": "integer"
+                },
+                "max_match_extension_minutes": {
+                    "type": "integer"
+                },
+                "max_ping_ms": {
+                    "description": "MaxPingMs \u003e 0 only groups players into regions they reported a\nping within this many milliseconds to on join.",
+                    "type": "integer"
+                },
                 "metadata_enabled": {
                     "type": "boolean"
                 },
+                "min_players": {
+                    "description": "MinPlayers \u003e 0 lets a match start short of lobby_size once that\nmany queued players have waited fill_timeout_seconds.",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
+                },
+                "penalty_abandon_points": {
+                    "type": "integer"
+                },
+                "penalty_decay_per_hour": {
+                    "type": "integer"
+                },
+                "penalty_decline_points": {
+                    "description": "Penalties. Points charged for declining a ready check, dodging a\npaired match or abandoning a running one (0 = not penalized);\nenough points lock a player out of the game's queues per\npenalty_lockout_steps. Points decay penalty_decay_per_hour an hour\n(default 1).",
+                    "type": "integer"
+                },
+                "penalty_dodge_points": {
+                    "type": "integer"
+                },
+                "penalty_lockout_steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.PenaltyLockoutStep"
+                    }
+                },
+                "placement_k_multiplier": {
+                    "type": "number"
+                },
+                "placement_matches": {
+                    "description": "Placements. 0 placement_matches disables the provisional period.",
+                    "type": "integer"
+                },
+                "rating_window_cap": {
+                    "type": "integer"
+                },
+                "rating_window_steps": {
+                    "description": "Rating window schedule for rating matchmaking. Omit the steps for\nthe default (100 → 300 after 30s → unbounded after 60s); a\nrating_window_cap \u003e 0 keeps the window from ever exceeding it.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_models.RatingWindowStep"
+                    }
+                },
+                "ready_check_seconds": {
+                    "description": "ReadyCheckSeconds \u003e 0 makes every paired player accept the match\nwithin that many seconds before a server starts.",
+                    "type": "integer"
+                },
+                "rematch_lookback_minutes": {
+                    "description": "Rematch avoidance. Players who met within the last\nrematch_lookback_minutes aren't grouped again until both have\nwaited rematch_max_wait_seconds (0 = never).",
+                    "type": "integer"
+                },
+                "rematch_max_wait_seconds": {
+                    "type": "integer"
+                },
+                "result_quorum": {
+                    "type": "integer"
+                },
+                "result_report_window_seconds": {
+                    "type": "integer"
+                },
+                "result_reporting": {
+                    "description": "Result reporting. \"server\" (default) takes results from the game\nserver; \"clients\" starts no server and has players report through\n/matches/{matchID}/report, recording the result once result_quorum\nof them agree (0 = a majority), or from the reports received\nresult_report_window_seconds (0 = 300) after the first.",
+                    "type": "string"
+                },
+                "season_ends_at": {
+                    "description": "Seasons. Setting season_ends_at opens season 1 now; see\nmodels.GameQueue for the rollover semantics.",
+                    "type": "string"
+                },
+                "season_length_days": {
+                    "type": "integer"
+                },
+                "season_soft_reset": {
+                    "type": "number"
+                },
+                "team_count": {
+                    "description": "Teams. team_count x team_size players per match (e.g. 2x3);\nlobby_size may be omitted and is derived from them.",
+                    "type": "integer"
+                },
+                "team_size": {
+                    "type": "integer"
                 }
             }
         },
@@ -3287,15 +5229,124 @@ const docTemplate = `{
                 }
             }
         },
+        "src_api_match.BackfillRequest": {
+            "type": "object",
+            "properties": {
+                "players": {
+                    "description": "Players is how many replacements the match wants; 0 withdraws an\nopen request.",
+                    "type": "integer"
+                },
+                "team": {
+                    "description": "Team optionally names the team replacements join, on team matches.\nWhen omitted they go to the teams with the fewest players left.",
+                    "type": "integer"
+                }
+            }
+        },
+        "src_api_match.ExtendRequest": {
+            "type": "object",
+            "properties": {
+                "minutes": {
+                    "description": "Minutes is how far to push the match's deadline back.",
+                    "type": "integer"
+                }
+            }
+        },
+        "src_api_match.LeaveRequest": {
+            "type": "object",
+            "properties": {
+                "player_id": {
+                    "description": "PlayerID is the player who left or was removed.",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason is free-form, e.g. \"left\", \"disconnected\" or \"kicked\".\nDefaults to \"left\".",
+                    "type": "string"
+                }
+            }
+        },
+        "src_api_matchResults.ReportOutcomeRequest": {
+            "type": "object",
+            "properties": {
+                "placements": {
+                    "description": "Placements, Scores, Teams and TeamPlacements mean the same as on\n/result/report.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "scores": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "team_placements": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "teams": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "winner_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "src_api_matchResults.ReportResultsRequest": {
             "type": "object",
             "properties": {
                 "adjust_ratings": {
                     "type": "boolean"
                 },
+                "placements": {
+                    "description": "Placements optionally maps player ID → finishing position (1 =\nfirst; equal values tie) for a full ordering. Players left out\nrank behind everyone listed. When omitted, winner_ids place 1st\nand everyone else ties for 2nd.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
                 "reason": {
                     "type": "string"
                 },
+                "scores": {
+                    "description": "Scores optionally maps player ID → numeric score. Stored on the\nresult; when Placements is omitted, players are ranked by score\n(highest first).",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "team_placements": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "teams": {
+                    "description": "Teams optionally lists the team layout as arrays of player IDs.\nTeamPlacements gives each team's finishing position (1 = first;\nequal values tie) and must match Teams in length. When omitted,\nthe team holding a winner places first and the rest tie for\nsecond. Used by team-aware rating strategies (trueskill).",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
                 "token_id": {
                     "type": "string"
                 },
@@ -3310,6 +5361,45 @@ const docTemplate = `{
                 }
             }
         },
+        "src_api_party.InviteToPartyRequest": {
+            "type": "object",
+            "properties": {
+                "player_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "src_api_party.PartyResp": {
+            "type": "object",
+            "properties": {
+                "game_queue_id": {
+                    "description": "GameQueueID is the queue the party is searching in, or empty when\nit isn't searching.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invites": {
+                    "description": "Invites lists player IDs with an outstanding invite.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "leader_id": {
+                    "type": "string"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andy98725_elo-service_src_external_redis.PartyMember"
+                    }
+                },
+                "searching": {
+                    "type": "boolean"
+                }
+            }
+        },
         "src_api_user.ChangePasswordRequest": {
             "type": "object",
             "properties": {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
 // ContainerConfig is the payload sent to the host agent to start a game server container.
@@ -16,6 +17,10 @@ type ContainerConfig struct {
 	HostPorts []int64  `json:"host_ports"`
 	Token     string   `json:"token"`
 	PlayerIDs []string `json:"player_ids"`
+	// Teams is the team layout for queues with TeamCount set: each inner
+	// slice is one team's player IDs. Omitted otherwise. The agent passes
+	// it to the container as `-teams <json>`.
+	Teams [][]string `json:"teams,omitempty"`
 	// SpectateID names the host-side directory the agent mounts at
 	// /shared/ in the container. The matchmaker generates a fresh UUID
 	// per container; the agent uses it as the URL component on
@@ -134,3 +139,27 @@ func GetContainerLogs(ctx context.Context, hostIP string, agentPort int64, agent
 	}
 	return io.ReadAll(resp.Body)
 }
+
+// ContainerHealth asks the host agent whether a container is still
+// running. running=false with a nil error means the agent answered that
+// the container has stopped or no longer exists, and state is the agent's
+// description of it; an error means the agent couldn't be asked, which
+// says nothing about the container.
+func ContainerHealth(ctx context.Context, hostIP string, agentPort int64, agentToken string, containerID string) (running bool, state string, err error) {
+	url := agentURL(hostIP, agentPort, "/containers/"+containerID+"/health")
+	resp, err := agentDo(ctx, http.MethodGet, url, agentToken, nil)
+	if err != nil {
+		return false, "", fmt.Errorf("container health: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, "running", nil
+	case http.StatusNotFound, http.StatusServiceUnavailable:
+		body, _ := io.ReadAll(resp.Body)
+		return false, strings.TrimSpace(string(body)), nil
+	default:
+		return false, "", agentError(resp)
+	}
+}
@@ -46,14 +46,15 @@ type HostConnectionInfo struct {
 	AgentToken string
 }
 
-// CreateHost provisions a new Hetzner VM that runs the game-server-host-agent.
-// Blocks until the agent is reachable (VM fully booted and agent running).
+// CreateHost provisions a new Hetzner VM in the given location that
+// runs the game-server-host-agent. Blocks until the agent is reachable
+// (VM fully booted and agent running).
 //
 // When `tls` is non-nil, Caddy is co-installed and the agent is told to
 // shift its docker host-port bindings by internalPortShift; clients then
 // connect over TLS to the public port range while the actual game container
 // is bound on port+shift internally.
-func (h *HetznerConnection) CreateHost(ctx context.Context, serverType string, agentPort int64, tls *HostTLSOpts) (*HostConnectionInfo, error) {
+func (h *HetznerConnection) CreateHost(ctx context.Context, region, serverType string, agentPort int64, tls *HostTLSOpts) (*HostConnectionInfo, error) {
 	agentToken, err := GenerateToken()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate agent token: %w", err)
@@ -62,12 +63,12 @@ func (h *HetznerConnection) CreateHost(ctx context.Context, serverType string, a
 	userData := hostCloudConfig(agentPort, agentToken, tls)
 	serverName := fmt.Sprintf("game-host-%d", time.Now().Unix())
 
-	slog.Info("Creating host VM", "serverName", serverName, "serverType", serverType)
+	slog.Info("Creating host VM", "serverName", serverName, "serverType", serverType, "region", region)
 	createOpts := hcloud.ServerCreateOpts{
 		Name:      serverName,
 		ServerType: &hcloud.ServerType{Name: serverType, Architecture: hcloud.ArchitectureX86, CPUType: hcloud.CPUTypeShared},
 		Image:     &hcloud.Image{Name: "ubuntu-24.04"},
-		Location:  &hcloud.Location{Name: "nbg1"},
+		Location:  &hcloud.Location{Name: region},
 		UserData:  userData,
 		PublicNet: &hcloud.ServerCreatePublicNet{EnableIPv4: true, EnableIPv6: false},
 		Labels:    map[string]string{"role": "game-host"},
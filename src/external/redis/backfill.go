@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrBackfillNotFound = errors.New("backfill request not found")
+
+// A backfill request is a running match asking the matchmaker for
+// replacement players. backfill_<matchID> holds the record, and
+// backfills_<composite> indexes the open requests drawing from a queue,
+// scored by when they were made so the oldest is filled first.
+func backfillKey(matchID string) string    { return "backfill_" + matchID }
+func backfillsKey(composite string) string { return "backfills_" + composite }
+
+// BackfillRecord is one open backfill request. QueueID is the composite
+// queue key replacements are drawn from and Region where the match runs.
+// Team is the team index replacements join, or -1 to spread them over
+// the smallest teams (ignored for matches without teams). Players is how
+// many are still wanted.
+type BackfillRecord struct {
+	MatchID     string
+	GameQueueID string
+	QueueID     string
+	Region      string
+	Team        int
+	Players     int
+	RequestedAt time.Time
+}
+
+// SetBackfill opens or replaces a match's backfill request. ttl bounds
+// how long an unfilled request lingers.
+func (r *Redis) SetBackfill(ctx context.Context, rec *BackfillRecord, ttl time.Duration) error {
+	pipe := r.Client.TxPipeline()
+	pipe.HSet(ctx, backfillKey(rec.MatchID), map[string]interface{}{
+		"match_id":      rec.MatchID,
+		"game_queue_id": rec.GameQueueID,
+		"queue_id":      rec.QueueID,
+		"region":        rec.Region,
+		"team":          rec.Team,
+		"players":       rec.Players,
+		"requested_at":  rec.RequestedAt.Unix(),
+	})
+	pipe.Expire(ctx, backfillKey(rec.MatchID), ttl)
+	pipe.ZAdd(ctx, backfillsKey(rec.QueueID), redis.Z{Score: float64(rec.RequestedAt.Unix()), Member: rec.MatchID})
+	pipe.Expire(ctx, backfillsKey(rec.QueueID), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetBackfill returns a match's open backfill request, or
+// ErrBackfillNotFound when there is none.
+func (r *Redis) GetBackfill(ctx context.Context, matchID string) (*BackfillRecord, error) {
+	raw, err := r.Client.HGetAll(ctx, backfillKey(matchID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, ErrBackfillNotFound
+	}
+	team, _ := strconv.Atoi(raw["team"])
+	players, _ := strconv.Atoi(raw["players"])
+	requested, _ := strconv.ParseInt(raw["requested_at"], 10, 64)
+	return &BackfillRecord{
+		MatchID:     raw["match_id"],
+		GameQueueID: raw["game_queue_id"],
+		QueueID:     raw["queue_id"],
+		Region:      raw["region"],
+		Team:        team,
+		Players:     players,
+		RequestedAt: time.Unix(requested, 0),
+	}, nil
+}
+
+// Backfills returns the open backfill requests drawing from a queue,
+// oldest first. Index entries whose record has expired are dropped.
+func (r *Redis) Backfills(ctx context.Context, composite string) ([]*BackfillRecord, error) {
+	ids, err := r.Client.ZRange(ctx, backfillsKey(composite), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	var out []*BackfillRecord
+	for _, id := range ids {
+		rec, err := r.GetBackfill(ctx, id)
+		if errors.Is(err, ErrBackfillNotFound) {
+			r.Client.ZRem(ctx, backfillsKey(composite), id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, nil
+}
+
+// DeleteBackfill closes a match's backfill request.
+func (r *Redis) DeleteBackfill(ctx context.Context, matchID string, composite string) error {
+	pipe := r.Client.TxPipeline()
+	pipe.Del(ctx, backfillKey(matchID))
+	pipe.ZRem(ctx, backfillsKey(composite), matchID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ReduceBackfill records that filled players joined a match through its
+// request, closing the request once nobody more is wanted.
+func (r *Redis) ReduceBackfill(ctx context.Context, rec *BackfillRecord, filled int) error {
+	left, err := r.Client.HIncrBy(ctx, backfillKey(rec.MatchID), "players", int64(-filled)).Result()
+	if err != nil {
+		return err
+	}
+	if left <= 0 {
+		return r.DeleteBackfill(ctx, rec.MatchID, rec.QueueID)
+	}
+	return nil
+}
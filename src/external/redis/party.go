@@ -0,0 +1,324 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrPartyNotFound  = errors.New("party not found")
+	ErrPartyFull      = errors.New("party is full")
+	ErrAlreadyInParty = errors.New("player is already in a party")
+	ErrNotInvited     = errors.New("player has not been invited to this party")
+)
+
+// PartyEntryPrefix marks a matchmaking queue entry that stands for a whole
+// party rather than a single player. Queue lists otherwise hold player IDs
+// (UUIDs or guest IDs), neither of which can start with this prefix.
+const PartyEntryPrefix = "party_"
+
+// PartyQueueEntry is the queue-list entry a party is enqueued as.
+func PartyQueueEntry(partyID string) string { return PartyEntryPrefix + partyID }
+
+// ParsePartyQueueEntry returns the party ID behind a queue entry, or
+// ok=false when the entry is a single player.
+func ParsePartyQueueEntry(entry string) (partyID string, ok bool) {
+	if !strings.HasPrefix(entry, PartyEntryPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(entry, PartyEntryPrefix), true
+}
+
+func partyKey(partyID string) string        { return "party_" + partyID }
+func partyMembersKey(partyID string) string { return "party_members_" + partyID }
+func partyOrderKey(partyID string) string   { return "party_order_" + partyID }
+func partyInvitesKey(partyID string) string { return "party_invites_" + partyID }
+func playerPartyKey(playerID string) string { return "player_party_" + playerID }
+func playerPartyInvitesKey(playerID string) string {
+	return "player_party_invites_" + playerID
+}
+
+// createPartyScript atomically claims the leader's player→party pointer
+// and writes the party record, so one player can never lead or belong to
+// two parties at once.
+//
+// KEYS[1] = player_party_<leaderID>, KEYS[2] = party_<partyID>,
+// KEYS[3] = party_members_<partyID>, KEYS[4] = party_order_<partyID>
+// ARGV[1] = partyID, ARGV[2] = leaderID, ARGV[3] = leader display name,
+// ARGV[4] = created_at, ARGV[5] = ttl in seconds.
+var createPartyScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'EX', ARGV[5]) == false then
+  return 0
+end
+redis.call('HSET', KEYS[2], 'id', ARGV[1], 'leader_id', ARGV[2], 'created_at', ARGV[4])
+redis.call('HSET', KEYS[3], ARGV[2], ARGV[3])
+redis.call('RPUSH', KEYS[4], ARGV[2])
+redis.call('EXPIRE', KEYS[2], ARGV[5])
+redis.call('EXPIRE', KEYS[3], ARGV[5])
+redis.call('EXPIRE', KEYS[4], ARGV[5])
+return 1
+`)
+
+// joinPartyScript atomically checks the invite and the size cap, claims
+// the player's party pointer, and adds them as a member.
+//
+// KEYS[1] = party_<partyID>, KEYS[2] = party_members_<partyID>,
+// KEYS[3] = party_invites_<partyID>, KEYS[4] = player_party_<playerID>,
+// KEYS[5] = player_party_invites_<playerID>, KEYS[6] = party_order_<partyID>
+// ARGV[1] = partyID, ARGV[2] = playerID, ARGV[3] = display name,
+// ARGV[4] = max members, ARGV[5] = ttl in seconds.
+//
+// Returns 1 on success, -1 party missing, -2 not invited, -3 full,
+// -4 already in a party.
+var joinPartyScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return -1
+end
+if redis.call('SISMEMBER', KEYS[3], ARGV[2]) == 0 then
+  return -2
+end
+if redis.call('HLEN', KEYS[2]) >= tonumber(ARGV[4]) then
+  return -3
+end
+if redis.call('SET', KEYS[4], ARGV[1], 'NX', 'EX', ARGV[5]) == false then
+  return -4
+end
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+redis.call('RPUSH', KEYS[6], ARGV[2])
+redis.call('SREM', KEYS[3], ARGV[2])
+redis.call('SREM', KEYS[5], ARGV[1])
+return 1
+`)
+
+// PartyRecord is a premade group that queues as one unit. The leader
+// created it and is the only member who can invite or queue it.
+//
+// While the party is searching, QueueID is the composite queue key its
+// entry sits in and GameQueueID the queue it was resolved to; both are
+// empty otherwise. They can go stale when the entry times out, so check
+// the entry's TTL key (IsPlayerConnectionAlive) before trusting them.
+type PartyRecord struct {
+	ID          string    `json:"id"`
+	LeaderID    string    `json:"leader_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	QueueID     string    `json:"queue_id"`
+	GameQueueID string    `json:"game_queue_id"`
+}
+
+// PartyMember is one player in a party.
+type PartyMember struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// CreateParty writes a new party led by rec.LeaderID. Returns
+// ErrAlreadyInParty when the leader already belongs to a party.
+func (r *Redis) CreateParty(ctx context.Context, rec *PartyRecord, leaderName string, ttl time.Duration) error {
+	keys := []string{playerPartyKey(rec.LeaderID), partyKey(rec.ID), partyMembersKey(rec.ID), partyOrderKey(rec.ID)}
+	args := []interface{}{rec.ID, rec.LeaderID, leaderName, rec.CreatedAt.Format(time.RFC3339Nano), int64(ttl.Seconds())}
+	res, err := createPartyScript.Run(ctx, r.Client, keys, args...).Int64()
+	if err != nil {
+		return err
+	}
+	if res == 0 {
+		return ErrAlreadyInParty
+	}
+	return nil
+}
+
+func (r *Redis) GetParty(ctx context.Context, partyID string) (*PartyRecord, error) {
+	fields, err := r.Client.HGetAll(ctx, partyKey(partyID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrPartyNotFound
+	}
+	createdAt, _ := time.Parse(time.RFC3339Nano, fields["created_at"])
+	return &PartyRecord{
+		ID:          fields["id"],
+		LeaderID:    fields["leader_id"],
+		CreatedAt:   createdAt,
+		QueueID:     fields["queue_id"],
+		GameQueueID: fields["game_queue_id"],
+	}, nil
+}
+
+// PlayerParty returns the ID of the party the player belongs to, or ""
+// when they're not in one. A pointer left behind by an expired party is
+// cleared on read.
+func (r *Redis) PlayerParty(ctx context.Context, playerID string) (string, error) {
+	partyID, err := r.Client.Get(ctx, playerPartyKey(playerID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	exists, err := r.Client.Exists(ctx, partyKey(partyID)).Result()
+	if err != nil {
+		return "", err
+	}
+	if exists == 0 {
+		r.Client.Del(ctx, playerPartyKey(playerID))
+		return "", nil
+	}
+	return partyID, nil
+}
+
+// PartyMembers returns the party's members in join order, leader first.
+func (r *Redis) PartyMembers(ctx context.Context, partyID string) ([]PartyMember, error) {
+	pipe := r.Client.Pipeline()
+	orderCmd := pipe.LRange(ctx, partyOrderKey(partyID), 0, -1)
+	namesCmd := pipe.HGetAll(ctx, partyMembersKey(partyID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	names := namesCmd.Val()
+	members := make([]PartyMember, 0, len(names))
+	for _, id := range orderCmd.Val() {
+		if name, ok := names[id]; ok {
+			members = append(members, PartyMember{ID: id, Name: name})
+		}
+	}
+	return members, nil
+}
+
+// PartyMemberIDs is PartyMembers reduced to player IDs.
+func (r *Redis) PartyMemberIDs(ctx context.Context, partyID string) ([]string, error) {
+	members, err := r.PartyMembers(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(members))
+	for i, m := range members {
+		ids[i] = m.ID
+	}
+	return ids, nil
+}
+
+// PartyInvites returns the player IDs with an outstanding invite.
+func (r *Redis) PartyInvites(ctx context.Context, partyID string) ([]string, error) {
+	return r.Client.SMembers(ctx, partyInvitesKey(partyID)).Result()
+}
+
+// PlayerPartyInvites returns the IDs of parties that have invited the
+// player. Entries for parties that no longer exist are pruned on read.
+func (r *Redis) PlayerPartyInvites(ctx context.Context, playerID string) ([]string, error) {
+	ids, err := r.Client.SMembers(ctx, playerPartyInvitesKey(playerID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		invited, err := r.Client.SIsMember(ctx, partyInvitesKey(id), playerID).Result()
+		if err != nil {
+			return nil, err
+		}
+		if !invited {
+			r.Client.SRem(ctx, playerPartyInvitesKey(playerID), id)
+			continue
+		}
+		out = append(out, id)
+	}
+	return out, nil
+}
+
+// InviteToParty records an invite for playerID. Invites live as long as
+// the party does.
+func (r *Redis) InviteToParty(ctx context.Context, partyID, playerID string, ttl time.Duration) error {
+	pipe := r.Client.Pipeline()
+	pipe.SAdd(ctx, partyInvitesKey(partyID), playerID)
+	pipe.Expire(ctx, partyInvitesKey(partyID), ttl)
+	pipe.SAdd(ctx, playerPartyInvitesKey(playerID), partyID)
+	pipe.Expire(ctx, playerPartyInvitesKey(playerID), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// JoinParty adds an invited player to the party. Returns ErrPartyNotFound,
+// ErrNotInvited, ErrPartyFull (at maxMembers), or ErrAlreadyInParty.
+func (r *Redis) JoinParty(ctx context.Context, partyID, playerID, name string, maxMembers int, ttl time.Duration) error {
+	keys := []string{
+		partyKey(partyID), partyMembersKey(partyID), partyInvitesKey(partyID),
+		playerPartyKey(playerID), playerPartyInvitesKey(playerID), partyOrderKey(partyID),
+	}
+	args := []interface{}{partyID, playerID, name, maxMembers, int64(ttl.Seconds())}
+	res, err := joinPartyScript.Run(ctx, r.Client, keys, args...).Int64()
+	if err != nil {
+		return err
+	}
+	switch res {
+	case -1:
+		return ErrPartyNotFound
+	case -2:
+		return ErrNotInvited
+	case -3:
+		return ErrPartyFull
+	case -4:
+		return ErrAlreadyInParty
+	}
+	return nil
+}
+
+// RemovePartyMember drops a non-leader member and their party pointer.
+func (r *Redis) RemovePartyMember(ctx context.Context, partyID, playerID string) error {
+	pipe := r.Client.Pipeline()
+	pipe.HDel(ctx, partyMembersKey(partyID), playerID)
+	pipe.LRem(ctx, partyOrderKey(partyID), 0, playerID)
+	pipe.Del(ctx, playerPartyKey(playerID))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// DeleteParty disbands the party, clearing every member's pointer and
+// outstanding invite.
+func (r *Redis) DeleteParty(ctx context.Context, partyID string) error {
+	members, err := r.Client.HKeys(ctx, partyMembersKey(partyID)).Result()
+	if err != nil {
+		return err
+	}
+	invites, err := r.Client.SMembers(ctx, partyInvitesKey(partyID)).Result()
+	if err != nil {
+		return err
+	}
+	pipe := r.Client.Pipeline()
+	for _, id := range members {
+		pipe.Del(ctx, playerPartyKey(id))
+	}
+	for _, id := range invites {
+		pipe.SRem(ctx, playerPartyInvitesKey(id), partyID)
+	}
+	pipe.Del(ctx, partyKey(partyID), partyMembersKey(partyID), partyOrderKey(partyID), partyInvitesKey(partyID))
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// RefreshParty pushes the party's expiry out to ttl from now. Called on
+// every mutation and queue join so an active party never lapses.
+func (r *Redis) RefreshParty(ctx context.Context, partyID string, ttl time.Duration) error {
+	members, err := r.Client.HKeys(ctx, partyMembersKey(partyID)).Result()
+	if err != nil {
+		return err
+	}
+	pipe := r.Client.Pipeline()
+	pipe.Expire(ctx, partyKey(partyID), ttl)
+	pipe.Expire(ctx, partyMembersKey(partyID), ttl)
+	pipe.Expire(ctx, partyInvitesKey(partyID), ttl)
+	pipe.Expire(ctx, partyOrderKey(partyID), ttl)
+	for _, id := range members {
+		pipe.Expire(ctx, playerPartyKey(id), ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// SetPartyQueue records which queue the party is searching in. Pass
+// empty strings to clear it.
+func (r *Redis) SetPartyQueue(ctx context.Context, partyID, gameQueueID, queueID string) error {
+	return r.Client.HSet(ctx, partyKey(partyID), "game_queue_id", gameQueueID, "queue_id", queueID).Err()
+}
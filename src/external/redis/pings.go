@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// pings_<playerID> maps region → the round-trip time in milliseconds the
+// player measured to it when they last joined a queue.
+func playerPingsKey(playerID string) string { return "pings_" + playerID }
+
+// SetPlayerPings replaces a player's reported pings. An empty map clears
+// them, so a player who stops reporting is treated as region-agnostic.
+func (r *Redis) SetPlayerPings(ctx context.Context, playerID string, pings map[string]int, ttl time.Duration) error {
+	pipe := r.Client.TxPipeline()
+	pipe.Del(ctx, playerPingsKey(playerID))
+	if len(pings) > 0 {
+		fields := make(map[string]interface{}, len(pings))
+		for region, ms := range pings {
+			fields[region] = ms
+		}
+		pipe.HSet(ctx, playerPingsKey(playerID), fields)
+		pipe.Expire(ctx, playerPingsKey(playerID), ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// PlayerPings returns the reported pings for each of playerIDs. Players
+// who reported none are absent.
+func (r *Redis) PlayerPings(ctx context.Context, playerIDs []string) (map[string]map[string]int, error) {
+	out := make(map[string]map[string]int)
+	if len(playerIDs) == 0 {
+		return out, nil
+	}
+	pipe := r.Client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(playerIDs))
+	for i, id := range playerIDs {
+		cmds[i] = pipe.HGetAll(ctx, playerPingsKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	for i, cmd := range cmds {
+		raw := cmd.Val()
+		if len(raw) == 0 {
+			continue
+		}
+		pings := make(map[string]int, len(raw))
+		for region, v := range raw {
+			if ms, err := strconv.Atoi(v); err == nil {
+				pings[region] = ms
+			}
+		}
+		out[playerIDs[i]] = pings
+	}
+	return out, nil
+}
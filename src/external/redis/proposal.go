@@ -0,0 +1,145 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrProposalNotFound = errors.New("match proposal not found")
+
+// A proposal is a paired group waiting on a ready check. It lives outside
+// the queue lists: proposal_<id> holds the record, proposal_responses_<id>
+// maps player ID → "accept" / "decline", and the proposals set indexes
+// every open proposal so the worker can sweep deadlines.
+const proposalsKey = "proposals"
+
+// ReadyCheckTriggerChannel wakes the worker to resolve proposals. It's
+// separate from MatchmakingTriggerChannel because pairing is rate
+// limited and drops triggers that arrive too soon after the last run; a
+// dropped ready-check wake-up would leave players waiting.
+const ReadyCheckTriggerChannel = "trigger_ready_check"
+
+func proposalKey(id string) string          { return "proposal_" + id }
+func proposalResponsesKey(id string) string { return "proposal_responses_" + id }
+
+// ProposalRecord is one open ready check. QueueID is the composite queue
+// key the group was paired from and Region where its match will run.
+// Entries is the paired group and Stats its queue statistics, both
+// encoded by the matchmaker.
+type ProposalRecord struct {
+	ID          string
+	GameQueueID string
+	QueueID     string
+	Region      string
+	ExpiresAt   time.Time
+	Entries     string
+	Stats       string
+}
+
+// CreateProposal stores a proposal. ttl should outlast ExpiresAt so the
+// worker can still resolve it after the deadline; it only bounds how
+// long an abandoned record lingers.
+func (r *Redis) CreateProposal(ctx context.Context, rec *ProposalRecord, ttl time.Duration) error {
+	pipe := r.Client.TxPipeline()
+	pipe.HSet(ctx, proposalKey(rec.ID), map[string]interface{}{
+		"id":            rec.ID,
+		"game_queue_id": rec.GameQueueID,
+		"queue_id":      rec.QueueID,
+		"region":        rec.Region,
+		"expires_at":    rec.ExpiresAt.Unix(),
+		"entries":       rec.Entries,
+		"stats":         rec.Stats,
+	})
+	pipe.Expire(ctx, proposalKey(rec.ID), ttl)
+	pipe.SAdd(ctx, proposalsKey, rec.ID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *Redis) GetProposal(ctx context.Context, id string) (*ProposalRecord, error) {
+	raw, err := r.Client.HGetAll(ctx, proposalKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, ErrProposalNotFound
+	}
+	expires, _ := strconv.ParseInt(raw["expires_at"], 10, 64)
+	return &ProposalRecord{
+		ID:          raw["id"],
+		GameQueueID: raw["game_queue_id"],
+		QueueID:     raw["queue_id"],
+		Region:      raw["region"],
+		ExpiresAt:   time.Unix(expires, 0),
+		Entries:     raw["entries"],
+		Stats:       raw["stats"],
+	}, nil
+}
+
+// ProposalIDs lists every open proposal.
+func (r *Redis) ProposalIDs(ctx context.Context) ([]string, error) {
+	return r.Client.SMembers(ctx, proposalsKey).Result()
+}
+
+// RespondToProposal records a player's answer. The first answer sticks:
+// an accept can't be withdrawn, nor a decline taken back. Returns
+// ErrProposalNotFound once the proposal has been resolved. ttl matches
+// the one the proposal was created with.
+func (r *Redis) RespondToProposal(ctx context.Context, id, playerID string, accept bool, ttl time.Duration) error {
+	open, err := r.Client.SIsMember(ctx, proposalsKey, id).Result()
+	if err != nil {
+		return err
+	}
+	if !open {
+		return ErrProposalNotFound
+	}
+	answer := "decline"
+	if accept {
+		answer = "accept"
+	}
+	pipe := r.Client.TxPipeline()
+	pipe.HSetNX(ctx, proposalResponsesKey(id), playerID, answer)
+	pipe.Expire(ctx, proposalResponsesKey(id), ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// ProposalResponses returns each responding player's answer (true =
+// accepted).
+func (r *Redis) ProposalResponses(ctx context.Context, id string) (map[string]bool, error) {
+	raw, err := r.Client.HGetAll(ctx, proposalResponsesKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, len(raw))
+	for pid, answer := range raw {
+		out[pid] = answer == "accept"
+	}
+	return out, nil
+}
+
+// ClaimProposal closes a proposal and deletes it. Returns false when
+// another resolver already claimed it, so exactly one caller acts on the
+// outcome.
+func (r *Redis) ClaimProposal(ctx context.Context, id string) (bool, error) {
+	removed, err := r.Client.SRem(ctx, proposalsKey, id).Result()
+	if err != nil {
+		return false, err
+	}
+	if removed == 0 {
+		return false, nil
+	}
+	return true, r.Client.Del(ctx, proposalKey(id), proposalResponsesKey(id)).Err()
+}
+
+func (r *Redis) SubscribeReadyCheckTrigger(ctx context.Context) *redis.PubSub {
+	return r.Client.Subscribe(ctx, ReadyCheckTriggerChannel)
+}
+
+func (r *Redis) PublishReadyCheckTrigger(ctx context.Context) error {
+	return r.Client.Publish(ctx, ReadyCheckTriggerChannel, "1").Err()
+}
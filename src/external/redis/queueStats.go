@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Queue statistics. Wait samples and estimates are kept per composite
+// queue key, since sub-queues fill at different rates; the formed-match
+// and abandonment logs are per GameQueue and feed the owner's stats.
+//
+//	qwaits_<composite>     list of recent wait times (seconds), newest first
+//	qwait_est_<composite>  hash: median, p90, samples
+//	qformed_<gameQueueID>  zset of formed-match records scored by unix time
+//	qabandon_<gameQueueID> zset of abandoned entries scored by unix time
+func queueWaitsKey(composite string) string     { return "qwaits_" + composite }
+func queueWaitEstKey(composite string) string   { return "qwait_est_" + composite }
+func queueFormedKey(gameQueueID string) string  { return "qformed_" + gameQueueID }
+func queueAbandonKey(gameQueueID string) string { return "qabandon_" + gameQueueID }
+
+// PushQueueWaits adds wait samples to a queue, keeps the newest `keep`,
+// and returns the samples now stored.
+func (r *Redis) PushQueueWaits(ctx context.Context, composite string, waits []int64, keep int64, ttl time.Duration) ([]int64, error) {
+	if len(waits) == 0 {
+		return nil, nil
+	}
+	values := make([]interface{}, len(waits))
+	for i, w := range waits {
+		values[i] = w
+	}
+	pipe := r.Client.TxPipeline()
+	pipe.LPush(ctx, queueWaitsKey(composite), values...)
+	pipe.LTrim(ctx, queueWaitsKey(composite), 0, keep-1)
+	pipe.Expire(ctx, queueWaitsKey(composite), ttl)
+	samples := pipe.LRange(ctx, queueWaitsKey(composite), 0, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	out := make([]int64, 0, len(samples.Val()))
+	for _, v := range samples.Val() {
+		if w, err := strconv.ParseInt(v, 10, 64); err == nil {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+// SetQueueWaitEstimate stores a queue's current wait estimate.
+func (r *Redis) SetQueueWaitEstimate(ctx context.Context, composite string, median, p90 int64, samples int, ttl time.Duration) error {
+	pipe := r.Client.TxPipeline()
+	pipe.HSet(ctx, queueWaitEstKey(composite), map[string]interface{}{
+		"median":  median,
+		"p90":     p90,
+		"samples": samples,
+	})
+	pipe.Expire(ctx, queueWaitEstKey(composite), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// QueueWaitEstimate returns a queue's stored wait estimate. samples is 0
+// when there is none.
+func (r *Redis) QueueWaitEstimate(ctx context.Context, composite string) (median, p90 int64, samples int, err error) {
+	raw, err := r.Client.HGetAll(ctx, queueWaitEstKey(composite)).Result()
+	if err != nil || len(raw) == 0 {
+		return 0, 0, 0, err
+	}
+	median, _ = strconv.ParseInt(raw["median"], 10, 64)
+	p90, _ = strconv.ParseInt(raw["p90"], 10, 64)
+	samples, _ = strconv.Atoi(raw["samples"])
+	return median, p90, samples, nil
+}
+
+// RecordMatchFormed logs one formed match. record is the matchmaker's
+// encoding of it. Entries older than retention are dropped.
+func (r *Redis) RecordMatchFormed(ctx context.Context, gameQueueID string, at time.Time, record string, retention time.Duration) error {
+	return r.recordQueueEvent(ctx, queueFormedKey(gameQueueID), at, []string{uuid.New().String() + "|" + record}, retention)
+}
+
+// RecordQueueAbandons logs count queue entries that left without a match.
+func (r *Redis) RecordQueueAbandons(ctx context.Context, gameQueueID string, at time.Time, count int, retention time.Duration) error {
+	members := make([]string, count)
+	for i := range members {
+		members[i] = uuid.New().String()
+	}
+	return r.recordQueueEvent(ctx, queueAbandonKey(gameQueueID), at, members, retention)
+}
+
+func (r *Redis) recordQueueEvent(ctx context.Context, key string, at time.Time, members []string, retention time.Duration) error {
+	if len(members) == 0 {
+		return nil
+	}
+	zs := make([]redis.Z, len(members))
+	for i, m := range members {
+		zs[i] = redis.Z{Score: float64(at.Unix()), Member: m}
+	}
+	pipe := r.Client.TxPipeline()
+	pipe.ZAdd(ctx, key, zs...)
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(at.Add(-retention).Unix(), 10))
+	pipe.Expire(ctx, key, retention)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// MatchesFormedSince returns the records RecordMatchFormed logged at or
+// after since, oldest first.
+func (r *Redis) MatchesFormedSince(ctx context.Context, gameQueueID string, since time.Time) ([]string, error) {
+	members, err := r.Client.ZRangeByScore(ctx, queueFormedKey(gameQueueID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since.Unix(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]string, len(members))
+	for i, m := range members {
+		// Strip the uniqueness prefix.
+		_, records[i], _ = strings.Cut(m, "|")
+	}
+	return records, nil
+}
+
+// QueueAbandonsSince counts the entries RecordQueueAbandons logged at or
+// after since.
+func (r *Redis) QueueAbandonsSince(ctx context.Context, gameQueueID string, since time.Time) (int64, error) {
+	return r.Client.ZCount(ctx, queueAbandonKey(gameQueueID), strconv.FormatInt(since.Unix(), 10), "+inf").Result()
+}
@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Rating recalculations run in a single long database transaction, so
+// their live progress is published here instead of on the job row.
+// Progress keys expire on their own; the job row holds the final counts.
+const recalculationProgressTTL = 24 * time.Hour
+
+func recalculationProgressKey(jobID string) string {
+	return "recalculation_progress_" + jobID
+}
+
+func (r *Redis) SetRecalculationProgress(ctx context.Context, jobID string, done, total int) error {
+	return r.Client.Set(ctx, recalculationProgressKey(jobID), fmt.Sprintf("%d/%d", done, total), recalculationProgressTTL).Err()
+}
+
+// GetRecalculationProgress returns the last published progress for a
+// running job. ok is false when none has been published yet.
+func (r *Redis) GetRecalculationProgress(ctx context.Context, jobID string) (done, total int, ok bool, err error) {
+	val, err := r.Client.Get(ctx, recalculationProgressKey(jobID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, 0, false, nil
+	}
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if _, err := fmt.Sscanf(val, "%d/%d", &done, &total); err != nil {
+		return 0, 0, false, err
+	}
+	return done, total, true, nil
+}
+
+const RecalculationTriggerChannel = "trigger_rating_recalculation"
+
+func (r *Redis) SubscribeRecalculationTrigger(ctx context.Context) *redis.PubSub {
+	return r.Client.Subscribe(ctx, RecalculationTriggerChannel)
+}
+
+func (r *Redis) PublishRecalculationTrigger(ctx context.Context) error {
+	return r.Client.Publish(ctx, RecalculationTriggerChannel, "1").Err()
+}
@@ -68,6 +68,11 @@ func NewRedis(redisURL string) (*Redis, error) {
 // queueID below is the composite key produced by QueueKey: either a bare
 // game_queue UUID, or "<game_queue UUID>::<sha256(metadata)>" when the
 // queue has metadata segmentation enabled.
+//
+// Each queue list entry is either a player ID or a party entry (see
+// PartyQueueEntry). The per-entry TTL key and join timestamp are keyed by
+// the entry, so a party shares one of each; "playerID" parameters below
+// accept either kind of entry.
 
 func (r *Redis) AddPlayerToQueue(ctx context.Context, queueID string, playerID string) error {
 	_, err := r.Client.LPos(ctx, "queue_"+queueID, playerID, redis.LPosArgs{}).Result()
@@ -102,6 +107,8 @@ func (r *Redis) AddPlayerToQueueWithTTL(ctx context.Context, queueID string, pla
 		// games) so that toggling MatchmakingStrategy on a queue doesn't
 		// leave stale entries with no timestamp.
 		pipe.HSet(ctx, "qjoined_"+queueID, playerID, time.Now().Unix())
+		pipe.SAdd(ctx, searchKey(playerID), queueID)
+		pipe.Expire(ctx, searchKey(playerID), ttl)
 		if _, err := pipe.Exec(ctx); err != nil {
 			return err
 		}
@@ -130,13 +137,19 @@ func (r *Redis) RemovePlayerFromQueue(ctx context.Context, queueID string, playe
 	// Also remove the individual player TTL key
 	pipe.Del(ctx, "player_queue_"+queueID+"_"+playerID)
 	pipe.HDel(ctx, "qjoined_"+queueID, playerID)
+	pipe.SRem(ctx, searchKey(playerID), queueID)
 	_, err = pipe.Exec(ctx)
 	return err
 }
 
-// RefreshPlayerQueueTTL extends the TTL for a specific player in the queue
+// RefreshPlayerQueueTTL extends the TTL for a specific player in the
+// queue, and for the record of the queues they're searching.
 func (r *Redis) RefreshPlayerQueueTTL(ctx context.Context, queueID string, playerID string, ttl time.Duration) error {
-	return r.Client.Expire(ctx, "player_queue_"+queueID+"_"+playerID, ttl).Err()
+	pipe := r.Client.Pipeline()
+	pipe.Expire(ctx, "player_queue_"+queueID+"_"+playerID, ttl)
+	pipe.Expire(ctx, searchKey(playerID), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
 // IsPlayerConnectionAlive checks if a player is still in queue by checking their TTL key
@@ -165,26 +178,6 @@ func (r *Redis) PopPlayersFromQueue(ctx context.Context, queueID string, count i
 	return players, nil
 }
 
-// RemovePlayersFromQueue removes a specific set of players from a queue and
-// drops their TTL + join-time records. Used by rating-based matchmaking,
-// which selects pairs by rating window rather than popping the front of
-// the list.
-func (r *Redis) RemovePlayersFromQueue(ctx context.Context, queueID string, playerIDs []string) error {
-	if len(playerIDs) == 0 {
-		return nil
-	}
-	pipe := r.Client.Pipeline()
-	for _, p := range playerIDs {
-		pipe.LRem(ctx, "queue_"+queueID, 1, p)
-		pipe.Del(ctx, "player_queue_"+queueID+"_"+p)
-	}
-	fields := make([]string, len(playerIDs))
-	copy(fields, playerIDs)
-	pipe.HDel(ctx, "qjoined_"+queueID, fields...)
-	_, err := pipe.Exec(ctx)
-	return err
-}
-
 // QueueJoinTimes returns the unix-second join timestamps for every player
 // currently tracked in the queue's qjoined hash. Players missing from the
 // hash (e.g. left over from a queue that pre-dates the hash) are simply
@@ -204,7 +197,14 @@ func (r *Redis) QueueJoinTimes(ctx context.Context, queueID string) (map[string]
 	return out, nil
 }
 
-func (r *Redis) PushPlayersToQueue(ctx context.Context, queueID string, playerIDs []string) error {
+// PushPlayersToQueue returns entries to the back of the queue after a
+// failed dispatch, restoring the TTL keys ClaimQueueEntries dropped
+// so CleanupExpiredPlayers doesn't sweep them before the owning
+// connection's next refresh.
+func (r *Redis) PushPlayersToQueue(ctx context.Context, queueID string, playerIDs []string, ttl time.Duration) error {
+	if len(playerIDs) == 0 {
+		return nil
+	}
 	interfacePlayers := make([]interface{}, len(playerIDs))
 	for i, p := range playerIDs {
 		interfacePlayers[i] = p
@@ -214,15 +214,79 @@ func (r *Redis) PushPlayersToQueue(ctx context.Context, queueID string, playerID
 	now := time.Now().Unix()
 	for _, p := range playerIDs {
 		pipe.HSetNX(ctx, "qjoined_"+queueID, p, now)
+		pipe.Set(ctx, "player_queue_"+queueID+"_"+p, "1", ttl)
+		pipe.SAdd(ctx, searchKey(p), queueID)
+		pipe.Expire(ctx, searchKey(p), ttl)
 	}
 	_, err := pipe.Exec(ctx)
 	return err
 }
 
+// PushPlayersToQueueFront returns entries to the front of the queue, in
+// order, ahead of everyone still waiting. joinedAt restores each entry's
+// original join time so a rating window keeps the width it had grown to;
+// entries missing from it are stamped now.
+func (r *Redis) PushPlayersToQueueFront(ctx context.Context, queueID string, playerIDs []string, joinedAt map[string]int64, ttl time.Duration) error {
+	if len(playerIDs) == 0 {
+		return nil
+	}
+	// LPUSH prepends one at a time, so push in reverse to keep order.
+	interfacePlayers := make([]interface{}, len(playerIDs))
+	for i, p := range playerIDs {
+		interfacePlayers[len(playerIDs)-1-i] = p
+	}
+	pipe := r.Client.Pipeline()
+	pipe.LPush(ctx, "queue_"+queueID, interfacePlayers...)
+	now := time.Now().Unix()
+	for _, p := range playerIDs {
+		ts, ok := joinedAt[p]
+		if !ok {
+			ts = now
+		}
+		pipe.HSet(ctx, "qjoined_"+queueID, p, ts)
+		pipe.Set(ctx, "player_queue_"+queueID+"_"+p, "1", ttl)
+		pipe.SAdd(ctx, searchKey(p), queueID)
+		pipe.Expire(ctx, searchKey(p), ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GameQueueSize is the number of entries in the queue; a party counts
+// once. See QueuePlayerCount for the number of players.
 func (r *Redis) GameQueueSize(ctx context.Context, queueID string) (int64, error) {
 	return r.Client.LLen(ctx, "queue_"+queueID).Result()
 }
 
+// QueuePlayerCount is the number of players waiting in the queue, counting
+// every member of a queued party.
+func (r *Redis) QueuePlayerCount(ctx context.Context, queueID string) (int64, error) {
+	entries, err := r.Client.LRange(ctx, "queue_"+queueID, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	pipe := r.Client.Pipeline()
+	var sizes []*redis.IntCmd
+	var count int64
+	for _, entry := range entries {
+		if partyID, ok := ParsePartyQueueEntry(entry); ok {
+			sizes = append(sizes, pipe.HLen(ctx, partyMembersKey(partyID)))
+			continue
+		}
+		count++
+	}
+	if len(sizes) == 0 {
+		return count, nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	for _, size := range sizes {
+		count += size.Val()
+	}
+	return count, nil
+}
+
 func (r *Redis) AllQueues(ctx context.Context) ([]string, error) {
 	return r.Client.Keys(ctx, "queue_*").Result()
 }
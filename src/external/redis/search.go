@@ -0,0 +1,129 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrQueueEntryTaken = errors.New("queue entry is no longer in the queue")
+
+// A queue entry may sit in several queues at once — a player searching
+// "casual OR ranked", say. search_<entry> holds every composite queue key
+// the entry is in, so pairing it in one can take it out of all the others
+// in the same step.
+func searchKey(entry string) string { return "search_" + entry }
+
+// claimEntriesScript atomically takes paired entries out of the queue
+// that paired them and out of every other queue in their search sets. If
+// any entry has already left the pairing queue — another queue claimed it
+// first — nothing is removed.
+//
+// The other queues' keys are read from the search sets, so they can't be
+// declared up front; the script assumes a single Redis node.
+//
+// ARGV[1] = composite queue key, ARGV[2..] = entry IDs.
+// Returns 0 when an entry was already taken. Otherwise returns, for each
+// entry in order, the other queues it was still in, flattened as
+// queue, join time pairs ("" when the join time is unknown).
+var claimEntriesScript = redis.NewScript(`
+local composite = ARGV[1]
+for i = 2, #ARGV do
+  if not redis.call('LPOS', 'queue_' .. composite, ARGV[i]) then
+    return 0
+  end
+end
+local claimed = {}
+for i = 2, #ARGV do
+  local entry = ARGV[i]
+  local queues = redis.call('SMEMBERS', 'search_' .. entry)
+  local others = {}
+  for _, q in ipairs(queues) do
+    if q ~= composite and redis.call('LPOS', 'queue_' .. q, entry) then
+      table.insert(others, q)
+      table.insert(others, redis.call('HGET', 'qjoined_' .. q, entry) or '')
+    end
+  end
+  table.insert(queues, composite)
+  for _, q in ipairs(queues) do
+    redis.call('LREM', 'queue_' .. q, 1, entry)
+    redis.call('DEL', 'player_queue_' .. q .. '_' .. entry)
+    redis.call('HDEL', 'qjoined_' .. q, entry)
+  end
+  redis.call('DEL', 'search_' .. entry)
+  table.insert(claimed, others)
+end
+return claimed
+`)
+
+// ClaimQueueEntries removes paired entries from the queue that paired
+// them, along with their TTL and join-time records, and withdraws the
+// same entries from every other queue they're searching. It returns,
+// by entry ID, the other queues each entry was withdrawn from and its
+// unix-second join time in each (0 when unknown), so a dispatch that
+// fails can hand them back to ReturnToSearches. Returns
+// ErrQueueEntryTaken, removing nothing, when any entry is no longer in
+// the queue.
+func (r *Redis) ClaimQueueEntries(ctx context.Context, queueID string, entries []string) (map[string]map[string]int64, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, 0, len(entries)+1)
+	args = append(args, queueID)
+	for _, e := range entries {
+		args = append(args, e)
+	}
+	res, err := claimEntriesScript.Run(ctx, r.Client, nil, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+	claimed, ok := res.([]interface{})
+	if !ok {
+		return nil, ErrQueueEntryTaken
+	}
+	searches := make(map[string]map[string]int64)
+	for i, raw := range claimed {
+		others, _ := raw.([]interface{})
+		for j := 0; j+1 < len(others); j += 2 {
+			queue, _ := others[j].(string)
+			joined, _ := others[j+1].(string)
+			ts, _ := strconv.ParseInt(joined, 10, 64)
+			if searches[entries[i]] == nil {
+				searches[entries[i]] = make(map[string]int64)
+			}
+			searches[entries[i]][queue] = ts
+		}
+	}
+	return searches, nil
+}
+
+// ReturnToSearches puts an entry back at the end of the other queues
+// ClaimQueueEntries withdrew it from, restoring its join time in each
+// (stamping now where it's unknown) and its TTL and search-set records.
+func (r *Redis) ReturnToSearches(ctx context.Context, entry string, searches map[string]int64, ttl time.Duration) error {
+	if len(searches) == 0 {
+		return nil
+	}
+	pipe := r.Client.Pipeline()
+	now := time.Now().Unix()
+	for queue, ts := range searches {
+		if ts == 0 {
+			ts = now
+		}
+		pipe.RPush(ctx, "queue_"+queue, entry)
+		pipe.HSet(ctx, "qjoined_"+queue, entry, ts)
+		pipe.Set(ctx, "player_queue_"+queue+"_"+entry, "1", ttl)
+		pipe.SAdd(ctx, searchKey(entry), queue)
+	}
+	pipe.Expire(ctx, searchKey(entry), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// SearchQueues returns the composite queue keys an entry is searching.
+func (r *Redis) SearchQueues(ctx context.Context, entry string) ([]string, error) {
+	return r.Client.SMembers(ctx, searchKey(entry)).Result()
+}
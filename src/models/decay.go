@@ -0,0 +1,106 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/andy98725/elo-service/src/server"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// RatingChangeReasonDecay marks inactivity decay applied by the worker
+	// to a rating that hasn't been played for longer than the queue's
+	// grace period.
+	RatingChangeReasonDecay = "decay"
+
+	// RATING_DECAY_PERIOD is the unit DecayPerWeek is charged in.
+	RATING_DECAY_PERIOD = 7 * 24 * time.Hour
+)
+
+// GetQueuesWithDecay returns the IDs of queues with inactivity decay
+// turned on.
+func GetQueuesWithDecay() ([]string, error) {
+	var ids []string
+	err := server.S.DB.Model(&GameQueue{}).
+		Where("decay_per_week > 0").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ApplyRatingDecay charges inactivity decay on every idle rating in the
+// queue and returns how many rows moved. A row starts decaying
+// DecayGraceDays after its last rated match (UpdatedAt) and loses
+// DecayPerWeek for each full week since then, clamped at DecayFloor.
+// Only whole weeks are charged; the remainder carries over via DecayedAt,
+// so the result doesn't depend on how often the worker ticks. Trueskill
+// μ moves by the same amount as Rating so the two stay in step.
+//
+// Runs in one transaction per queue, locking candidate rows FOR UPDATE in
+// player-ID order — the same order ApplyClassicElo uses — so it can't
+// deadlock against a match finishing concurrently. Writes use
+// UpdateColumns so UpdatedAt (the idle clock) is left untouched.
+func ApplyRatingDecay(gameQueueID string, now time.Time) (int, error) {
+	decayed := 0
+	err := server.S.DB.Transaction(func(tx *gorm.DB) error {
+		var queue GameQueue
+		if err := tx.First(&queue, "id = ?", gameQueueID).Error; err != nil {
+			return err
+		}
+		if queue.DecayPerWeek <= 0 {
+			return nil
+		}
+		grace := time.Duration(queue.DecayGraceDays) * 24 * time.Hour
+
+		// Coarse prefilter: anything played within grace + one period
+		// can't owe a full week yet.
+		var ratings []Rating
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("game_queue_id = ? AND rating > ? AND updated_at <= ?",
+				queue.ID, queue.DecayFloor, now.Add(-grace-RATING_DECAY_PERIOD)).
+			Order("player_id ASC").
+			Find(&ratings).Error; err != nil {
+			return err
+		}
+
+		changes := make([]RatingChange, 0, len(ratings))
+		for i := range ratings {
+			r := &ratings[i]
+			start := r.UpdatedAt.Add(grace)
+			if r.DecayedAt != nil && r.DecayedAt.After(start) {
+				start = *r.DecayedAt
+			}
+			weeks := int(now.Sub(start) / RATING_DECAY_PERIOD)
+			if weeks < 1 {
+				continue
+			}
+			decayedAt := start.Add(time.Duration(weeks) * RATING_DECAY_PERIOD).UTC()
+
+			before := r.Rating
+			r.Rating = max(queue.DecayFloor, before-weeks*queue.DecayPerWeek)
+			updates := map[string]interface{}{
+				"rating":     r.Rating,
+				"decayed_at": decayedAt,
+			}
+			if r.Sigma > 0 {
+				r.Mu = math.Max(float64(queue.DecayFloor), r.Mu-float64(before-r.Rating))
+				updates["mu"] = r.Mu
+			}
+			if err := tx.Model(r).UpdateColumns(updates).Error; err != nil {
+				return err
+			}
+			if r.Rating != before {
+				changes = append(changes, newRatingChange(r, before, RatingChangeReasonDecay))
+			}
+		}
+		if len(changes) > 0 {
+			if err := tx.CreateInBatches(changes, bulkInsertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		decayed = len(changes)
+		return nil
+	})
+	return decayed, err
+}
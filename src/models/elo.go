@@ -14,36 +14,42 @@ import (
 // (the caller's MatchEnded tx) — it locks each rating row FOR UPDATE in
 // player-ID order so concurrent matches that share players cannot deadlock.
 //
-// Scoring: for each ordered pair (i, j) of non-guest players,
+// Scoring: for each ordered pair (i, j) of non-guest players, S_ij is
+// 1 if i placed ahead of j, 0 if behind, 0.5 for a tie (see
+// MatchOutcome.pairScore). With a winner_ids-only report that reads as:
 //   - both in winners → 0.5/0.5  (tied for first)
 //   - i in winners, j out → 1/0
 //   - i out, j in winners → 0/1
 //   - both out → 0.5/0.5  (tied for last; also covers the empty-winners draw)
 //
+// With full placements, 2nd place in an 8-player free-for-all scores a
+// win against six opponents and a loss against one, so it gains rating
+// where 8th loses it.
+//
 // Per-player delta is K_eff * Σ_{j≠i} (S_ij − E_ij) where K_eff = K * 2 / N
 // and N is the number of non-guest players in the match. For N=2 this
-// reduces to standard Elo: K * (S − E) over the single pair.
-func ApplyClassicElo(tx *gorm.DB, queue *GameQueue, playerIDs, winnerIDs []string) error {
-	nonGuests := make([]string, 0, len(playerIDs))
-	for _, pid := range playerIDs {
-		if !util.IsGuestID(pid) {
-			nonGuests = append(nonGuests, pid)
-		}
-	}
+// reduces to standard Elo: K * (S − E) over the single pair. A player
+// still in the queue's placement period uses K * PlacementKMultiplier
+// instead, so deltas are no longer zero-sum while someone is provisional.
+//
+// Returns one unsaved RatingChange per updated row; MatchEnded stamps
+// them with the match result and persists them in the same tx.
+func ApplyClassicElo(tx *gorm.DB, queue *GameQueue, playerIDs []string, outcome MatchOutcome) ([]RatingChange, error) {
+	nonGuests := ratedPlayerIDs(playerIDs)
 	if len(nonGuests) < 2 {
-		return nil
-	}
-	sort.Strings(nonGuests)
-
-	winnerSet := make(map[string]bool, len(winnerIDs))
-	for _, w := range winnerIDs {
-		winnerSet[w] = true
+		return nil, nil
 	}
 
 	for _, pid := range nonGuests {
-		row := &Rating{PlayerID: pid, GameQueueID: queue.ID, Rating: queue.DefaultRating}
+		row := &Rating{
+			PlayerID:    pid,
+			GameQueueID: queue.ID,
+			Rating:      queue.DefaultRating,
+			Deviation:   GLICKO2_DEFAULT_DEVIATION,
+			Volatility:  GLICKO2_DEFAULT_VOLATILITY,
+		}
 		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -52,40 +58,63 @@ func ApplyClassicElo(tx *gorm.DB, queue *GameQueue, playerIDs, winnerIDs []strin
 		var r Rating
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 			First(&r, "player_id = ? AND game_queue_id = ?", pid, queue.ID).Error; err != nil {
-			return err
+			return nil, err
 		}
 		ratings = append(ratings, &r)
 	}
 
+	before := make([]int, len(ratings))
+	for i, r := range ratings {
+		before[i] = r.Rating
+	}
+	rateClassicElo(queue, ratings, outcome)
+
+	changes := make([]RatingChange, 0, len(ratings))
+	for i, r := range ratings {
+		if err := tx.Save(r).Error; err != nil {
+			return nil, err
+		}
+		changes = append(changes, newRatingChange(r, before[i], RatingChangeReasonMatch))
+	}
+	return changes, nil
+}
+
+// rateClassicElo applies one match to ratings (in player-ID order) in
+// memory. ApplyClassicElo persists the result; a recalculation replays
+// through it directly.
+func rateClassicElo(queue *GameQueue, ratings []*Rating, outcome MatchOutcome) {
 	n := len(ratings)
-	kEff := float64(queue.KFactor) * 2.0 / float64(n)
 	deltas := make([]float64, n)
 	for i := 0; i < n; i++ {
-		iWon := winnerSet[ratings[i].PlayerID]
+		k := float64(queue.KFactor)
+		if ratings[i].IsProvisional(queue) {
+			k *= queue.PlacementKMultiplier
+		}
+		kEff := k * 2.0 / float64(n)
 		for j := 0; j < n; j++ {
 			if i == j {
 				continue
 			}
-			jWon := winnerSet[ratings[j].PlayerID]
-			var s float64
-			switch {
-			case iWon && !jWon:
-				s = 1.0
-			case !iWon && jWon:
-				s = 0.0
-			default:
-				s = 0.5
-			}
+			s := outcome.pairScore(ratings[i].PlayerID, ratings[j].PlayerID)
 			e := 1.0 / (1.0 + math.Pow(10, float64(ratings[j].Rating-ratings[i].Rating)/400.0))
 			deltas[i] += kEff * (s - e)
 		}
 	}
-
 	for i, r := range ratings {
 		r.Rating += int(math.Round(deltas[i]))
-		if err := tx.Save(r).Error; err != nil {
-			return err
+		r.GamesPlayed++
+	}
+}
+
+// ratedPlayerIDs returns the non-guest players among playerIDs in
+// player-ID order, the order their rating rows are locked in.
+func ratedPlayerIDs(playerIDs []string) []string {
+	nonGuests := make([]string, 0, len(playerIDs))
+	for _, pid := range playerIDs {
+		if !util.IsGuestID(pid) {
+			nonGuests = append(nonGuests, pid)
 		}
 	}
-	return nil
+	sort.Strings(nonGuests)
+	return nonGuests
 }
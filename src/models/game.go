@@ -13,14 +13,21 @@ const (
 	MATCHMAKING_STRATEGY_RATING = "rating"
 	ELO_STRATEGY_UNRANKED       = "unranked"
 	ELO_STRATEGY_CLASSIC        = "classic"
+	ELO_STRATEGY_GLICKO2        = "glicko2"
+	ELO_STRATEGY_TRUESKILL      = "trueskill"
+	RESULT_REPORTING_SERVER     = "server"
+	RESULT_REPORTING_CLIENTS    = "clients"
 )
 
+// MATCHMAKING_STRATEGIES lists the strategies a queue may select: the
+// built-ins, plus any extra strategy matchmaking.RegisterStrategy adds.
 var MATCHMAKING_STRATEGIES = []string{MATCHMAKING_STRATEGY_RANDOM, MATCHMAKING_STRATEGY_RATING}
 
 // ErrNotGameOwner is returned by mutation operations when the caller is not
 // the owner of the target game. Handlers should map this to HTTP 403.
 var ErrNotGameOwner = errors.New("not the owner of this game")
-var ELO_STRATEGIES = []string{ELO_STRATEGY_UNRANKED, ELO_STRATEGY_CLASSIC}
+var ELO_STRATEGIES = []string{ELO_STRATEGY_UNRANKED, ELO_STRATEGY_CLASSIC, ELO_STRATEGY_GLICKO2, ELO_STRATEGY_TRUESKILL}
+var RESULT_REPORTING_MODES = []string{RESULT_REPORTING_SERVER, RESULT_REPORTING_CLIENTS}
 
 // Game holds identity and game-wide policy. Per-pool matchmaking knobs
 // (image, ports, lobby size, ELO strategy, etc.) live on GameQueue —
@@ -1,7 +1,9 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"slices"
 	"strings"
 	"time"
@@ -23,6 +25,13 @@ var ErrNotQueueOwner = errors.New("not the owner of this game queue")
 const DefaultQueueName = "primary"
 const DefaultMatchmakingMachineName = "docker.io/andy98725/example-server:latest"
 
+// DefaultSeasonSoftReset pulls ratings halfway back to DefaultRating at
+// each season rollover.
+const DefaultSeasonSoftReset = 0.5
+
+// DefaultPlacementKMultiplier doubles a provisional player's K factor.
+const DefaultPlacementKMultiplier = 2.0
+
 // GameQueue is a matchmaking pool within a Game. One Game can have many
 // queues (e.g. "ranked-1v1", "casual-2v2", "stress-test-image"). Default
 // queue = the oldest one (ORDER BY created_at, id), referenced when the
@@ -52,51 +61,321 @@ type GameQueue struct {
 	DefaultRating           int           `json:"default_rating" gorm:"default:1000"`
 	KFactor                 int           `json:"k_factor" gorm:"default:32"`
 	MetadataEnabled         bool          `json:"metadata_enabled"`
+
+	// Seasons. SeasonNumber is the season currently being played (0 =
+	// seasons never enabled). When SeasonEndsAt passes, the worker
+	// archives the leaderboard as a Season, pulls every rating
+	// SeasonSoftReset of the way back toward DefaultRating (0 = keep,
+	// 1 = full reset), and starts the next season — ending
+	// SeasonLengthDays later, or left open-ended (nil) when
+	// SeasonLengthDays is 0. See RolloverSeason.
+	SeasonNumber     int        `json:"season_number" gorm:"not null;default:0"`
+	SeasonStartedAt  *time.Time `json:"season_started_at"`
+	SeasonEndsAt     *time.Time `json:"season_ends_at" gorm:"index"`
+	SeasonLengthDays int        `json:"season_length_days" gorm:"not null;default:0"`
+	SeasonSoftReset  float64    `json:"season_soft_reset" gorm:"not null;default:0.5"`
+
+	// Inactivity decay. A rating untouched by a match for DecayGraceDays
+	// loses DecayPerWeek points for every further full week idle, never
+	// dropping below DecayFloor (ratings already at or below the floor
+	// are left alone). DecayPerWeek 0 disables decay. See
+	// ApplyRatingDecay.
+	DecayGraceDays int `json:"decay_grace_days" gorm:"not null;default:0"`
+	DecayPerWeek   int `json:"decay_per_week" gorm:"not null;default:0"`
+	DecayFloor     int `json:"decay_floor" gorm:"not null;default:1000"`
+
+	// Placements. A player with fewer than PlacementMatches rated matches
+	// in this queue is provisional: hidden from the leaderboard, and on
+	// classic queues their K factor is multiplied by PlacementKMultiplier
+	// so they converge on their real rating quickly. 0 disables.
+	PlacementMatches     int     `json:"placement_matches" gorm:"not null;default:0"`
+	PlacementKMultiplier float64 `json:"placement_k_multiplier" gorm:"not null;default:2"`
+
+	// Teams. When TeamCount is set, each match is TeamCount teams of
+	// TeamSize players (LobbySize is their product) and the matchmaker
+	// splits every paired group into rating-balanced teams, keeping
+	// parties together. 0 = no teams; the game server gets a flat
+	// player list as before.
+	TeamCount int `json:"team_count" gorm:"not null;default:0"`
+	TeamSize  int `json:"team_size" gorm:"not null;default:0"`
+
+	// ReadyCheckSeconds, when set, holds every paired group in a ready
+	// check before a server is started: each player has this long to
+	// accept. Decliners and no-shows are dropped; everyone else goes
+	// back to the front of the queue. 0 disables the ready check.
+	ReadyCheckSeconds int `json:"ready_check_seconds" gorm:"not null;default:0"`
+
+	// Rating window. On rating-matchmaking queues a group is accepted
+	// only when its rating spread fits the window for how long its
+	// longest-waiting entry has waited, per the RatingWindowStep list in
+	// RatingWindowSteps (null = DefaultRatingWindowSteps; see
+	// RatingWindowSchedule). RatingWindowCap, when set, bounds every
+	// step so the window never goes unbounded. 0 = no cap.
+	RatingWindowSteps json.RawMessage `json:"rating_window_steps" gorm:"type:jsonb"`
+	RatingWindowCap   int             `json:"rating_window_cap" gorm:"not null;default:0"`
+
+	// MaxPingMs makes matchmaking latency-aware: a player who reported
+	// pings on join is only grouped into regions they reach within this
+	// many milliseconds (or their best region, if none qualifies). 0
+	// groups players regardless of region; each match still goes to the
+	// region with the lowest worst-case ping for its players.
+	MaxPingMs int `json:"max_ping_ms" gorm:"not null;default:0"`
+
+	// Fill timeout. Once MinPlayers of the players queued for a region
+	// have each waited FillTimeoutSeconds, the matchmaker stops holding
+	// out for a full lobby and starts a match with as many of them as it
+	// may group together, up to LobbySize. 0 = always wait for LobbySize
+	// players. Not available on team queues.
+	MinPlayers         int `json:"min_players" gorm:"not null;default:0"`
+	FillTimeoutSeconds int `json:"fill_timeout_seconds" gorm:"not null;default:0"`
+
+	// Penalties. Declining a ready check (or letting it lapse) costs
+	// PenaltyDeclinePoints and leaving /match/join between pairing and
+	// match_found costs PenaltyDodgePoints, and a game server reporting
+	// the player left a running match (/match/leave) costs
+	// PenaltyAbandonPoints, each charged to the player's standing in the
+	// whole game (see PlayerPenalty). Reaching a step of PenaltyLockoutSteps
+	// (null = DefaultPenaltyLockoutSteps; see PenaltyLockoutSchedule)
+	// locks them out of the game's queues for that step's lockout. Points
+	// shed PenaltyDecayPerHour an hour. 0 points = the offense isn't
+	// penalized.
+	PenaltyDeclinePoints int             `json:"penalty_decline_points" gorm:"not null;default:0"`
+	PenaltyDodgePoints   int             `json:"penalty_dodge_points" gorm:"not null;default:0"`
+	PenaltyAbandonPoints int             `json:"penalty_abandon_points" gorm:"not null;default:0"`
+	PenaltyDecayPerHour  int             `json:"penalty_decay_per_hour" gorm:"not null;default:1"`
+	PenaltyLockoutSteps  json.RawMessage `json:"penalty_lockout_steps" gorm:"type:jsonb"`
+
+	// Rematch avoidance. Players who met in this queue within the last
+	// RematchLookbackMinutes aren't grouped again until both have waited
+	// RematchMaxWaitSeconds, when a rematch beats waiting on. 0 lookback
+	// = off; 0 max wait = never rematch inside the lookback. Blocked
+	// players (see PlayerBlock) are never grouped, whatever this says.
+	RematchLookbackMinutes int `json:"rematch_lookback_minutes" gorm:"not null;default:0"`
+	RematchMaxWaitSeconds  int `json:"rematch_max_wait_seconds" gorm:"not null;default:0"`
+
+	// Heartbeats. When set, game servers in this queue must POST
+	// /match/heartbeat at least every HeartbeatGraceSeconds (counted from
+	// match start until the first one). A match that misses it is ended
+	// as "server_crashed" when the agent reports its container gone, or
+	// as "server_unresponsive" once a still-running container has been
+	// silent for HEARTBEAT_UNRESPONSIVE_FACTOR grace periods. 0 = game
+	// servers don't heartbeat; matches only end at their deadline.
+	HeartbeatGraceSeconds int `json:"heartbeat_grace_seconds" gorm:"not null;default:0"`
+
+	// Match duration. A match still underway MaxMatchDurationMinutes
+	// after it started is ended as "timeout". Its game server can push
+	// that deadline back through /match/extend, by up to
+	// MaxMatchExtensionMinutes in total (see Match.Deadline). 0
+	// extension = the deadline is fixed.
+	MaxMatchDurationMinutes  int `json:"max_match_duration_minutes" gorm:"not null;default:360"`
+	MaxMatchExtensionMinutes int `json:"max_match_extension_minutes" gorm:"not null;default:0"`
+
+	// Result reporting. "server" queues hear the result from their game
+	// server's /result/report. "clients" queues are for peer-to-peer
+	// games: no game server is started, and each participant reports the
+	// outcome with their own token (see RecordMatchReport). The result
+	// is recorded once ResultQuorum reports agree (0 = a majority);
+	// reports that conflict so no outcome can reach the quorum end the
+	// match "disputed", unrated, for the game owner to sort out. So a
+	// player can't stall the result by staying quiet, the match is
+	// settled ResultReportWindowSeconds after the first report from the
+	// reports received by then.
+	ResultReporting           string `json:"result_reporting" gorm:"not null;default:'server'"`
+	ResultQuorum              int    `json:"result_quorum" gorm:"not null;default:0"`
+	ResultReportWindowSeconds int    `json:"result_report_window_seconds" gorm:"not null;default:300"`
+}
+
+// MaxReadyCheckSeconds caps how long a ready check can hold players.
+const MaxReadyCheckSeconds = 120
+
+// MaxPingLimitMs caps MaxPingMs; anything looser is no limit at all.
+const MaxPingLimitMs = 10000
+
+// MaxFillTimeoutSeconds caps FillTimeoutSeconds.
+const MaxFillTimeoutSeconds = 3600
+
+// MaxRematchLookbackMinutes caps RematchLookbackMinutes at a day.
+const MaxRematchLookbackMinutes = 24 * 60
+
+// MaxRematchWaitSeconds caps RematchMaxWaitSeconds.
+const MaxRematchWaitSeconds = 3600
+
+// MinHeartbeatGraceSeconds and MaxHeartbeatGraceSeconds bound a non-zero
+// HeartbeatGraceSeconds. The floor leaves room for a GC tick or two.
+const (
+	MinHeartbeatGraceSeconds = 10
+	MaxHeartbeatGraceSeconds = 3600
+)
+
+// DefaultMaxMatchDurationMinutes is the match deadline of queues that
+// don't set one. MaxMatchLengthMinutes caps a match's deadline,
+// extensions included, at a week.
+const (
+	DefaultMaxMatchDurationMinutes = 6 * 60
+	MaxMatchLengthMinutes          = 7 * 24 * 60
+)
+
+// DefaultResultReportWindowSeconds is the report window of queues that
+// don't set one; MaxResultReportWindowSeconds caps it.
+const (
+	DefaultResultReportWindowSeconds = 300
+	MaxResultReportWindowSeconds     = 3600
+)
+
+// ClientReported reports whether this queue's results come from its
+// players rather than a game server.
+func (q *GameQueue) ClientReported() bool {
+	return q.ResultReporting == RESULT_REPORTING_CLIENTS
+}
+
+// MinLobbySize is the fewest players a match in this queue can start
+// with: MinPlayers when set, else LobbySize.
+func (q *GameQueue) MinLobbySize() int {
+	if q.MinPlayers > 0 {
+		return q.MinPlayers
+	}
+	return q.LobbySize
+}
+
+// HasTeams reports whether matches in this queue are split into teams.
+func (q *GameQueue) HasTeams() bool {
+	return q.TeamCount > 0
 }
 
 type GameQueueResp struct {
-	ID                      string  `json:"id"`
-	GameID                  string  `json:"game_id"`
-	Name                    string  `json:"name"`
-	LobbyEnabled            bool    `json:"lobby_enabled"`
-	LobbySize               int     `json:"lobby_size"`
-	MatchmakingStrategy     string  `json:"matchmaking_strategy"`
-	MatchmakingMachineName  string  `json:"matchmaking_machine_name"`
-	MatchmakingMachinePorts []int64 `json:"matchmaking_machine_ports"`
-	ELOStrategy             string  `json:"elo_strategy"`
-	DefaultRating           int     `json:"default_rating"`
-	KFactor                 int     `json:"k_factor"`
-	MetadataEnabled         bool    `json:"metadata_enabled"`
+	ID                        string               `json:"id"`
+	GameID                    string               `json:"game_id"`
+	Name                      string               `json:"name"`
+	LobbyEnabled              bool                 `json:"lobby_enabled"`
+	LobbySize                 int                  `json:"lobby_size"`
+	MatchmakingStrategy       string               `json:"matchmaking_strategy"`
+	MatchmakingMachineName    string               `json:"matchmaking_machine_name"`
+	MatchmakingMachinePorts   []int64              `json:"matchmaking_machine_ports"`
+	ELOStrategy               string               `json:"elo_strategy"`
+	DefaultRating             int                  `json:"default_rating"`
+	KFactor                   int                  `json:"k_factor"`
+	MetadataEnabled           bool                 `json:"metadata_enabled"`
+	SeasonNumber              int                  `json:"season_number"`
+	SeasonStartedAt           *time.Time           `json:"season_started_at"`
+	SeasonEndsAt              *time.Time           `json:"season_ends_at"`
+	SeasonLengthDays          int                  `json:"season_length_days"`
+	SeasonSoftReset           float64              `json:"season_soft_reset"`
+	DecayGraceDays            int                  `json:"decay_grace_days"`
+	DecayPerWeek              int                  `json:"decay_per_week"`
+	DecayFloor                int                  `json:"decay_floor"`
+	PlacementMatches          int                  `json:"placement_matches"`
+	PlacementKMultiplier      float64              `json:"placement_k_multiplier"`
+	TeamCount                 int                  `json:"team_count"`
+	TeamSize                  int                  `json:"team_size"`
+	ReadyCheckSeconds         int                  `json:"ready_check_seconds"`
+	RatingWindowSteps         []RatingWindowStep   `json:"rating_window_steps"`
+	RatingWindowCap           int                  `json:"rating_window_cap"`
+	MaxPingMs                 int                  `json:"max_ping_ms"`
+	MinPlayers                int                  `json:"min_players"`
+	FillTimeoutSeconds        int                  `json:"fill_timeout_seconds"`
+	PenaltyDeclinePoints      int                  `json:"penalty_decline_points"`
+	PenaltyDodgePoints        int                  `json:"penalty_dodge_points"`
+	PenaltyAbandonPoints      int                  `json:"penalty_abandon_points"`
+	PenaltyDecayPerHour       int                  `json:"penalty_decay_per_hour"`
+	PenaltyLockoutSteps       []PenaltyLockoutStep `json:"penalty_lockout_steps"`
+	RematchLookbackMinutes    int                  `json:"rematch_lookback_minutes"`
+	RematchMaxWaitSeconds     int                  `json:"rematch_max_wait_seconds"`
+	HeartbeatGraceSeconds     int                  `json:"heartbeat_grace_seconds"`
+	MaxMatchDurationMinutes   int                  `json:"max_match_duration_minutes"`
+	MaxMatchExtensionMinutes  int                  `json:"max_match_extension_minutes"`
+	ResultReporting           string               `json:"result_reporting"`
+	ResultQuorum              int                  `json:"result_quorum"`
+	ResultReportWindowSeconds int                  `json:"result_report_window_seconds"`
 }
 
 func (q *GameQueue) ToResp() *GameQueueResp {
 	return &GameQueueResp{
-		ID:                      q.ID,
-		GameID:                  q.GameID,
-		Name:                    q.Name,
-		LobbyEnabled:            q.LobbyEnabled,
-		LobbySize:               q.LobbySize,
-		MatchmakingStrategy:     q.MatchmakingStrategy,
-		MatchmakingMachineName:  q.MatchmakingMachineName,
-		MatchmakingMachinePorts: []int64(q.MatchmakingMachinePorts),
-		ELOStrategy:             q.ELOStrategy,
-		DefaultRating:           q.DefaultRating,
-		KFactor:                 q.KFactor,
-		MetadataEnabled:         q.MetadataEnabled,
+		ID:                        q.ID,
+		GameID:                    q.GameID,
+		Name:                      q.Name,
+		LobbyEnabled:              q.LobbyEnabled,
+		LobbySize:                 q.LobbySize,
+		MatchmakingStrategy:       q.MatchmakingStrategy,
+		MatchmakingMachineName:    q.MatchmakingMachineName,
+		MatchmakingMachinePorts:   []int64(q.MatchmakingMachinePorts),
+		ELOStrategy:               q.ELOStrategy,
+		DefaultRating:             q.DefaultRating,
+		KFactor:                   q.KFactor,
+		MetadataEnabled:           q.MetadataEnabled,
+		SeasonNumber:              q.SeasonNumber,
+		SeasonStartedAt:           q.SeasonStartedAt,
+		SeasonEndsAt:              q.SeasonEndsAt,
+		SeasonLengthDays:          q.SeasonLengthDays,
+		SeasonSoftReset:           q.SeasonSoftReset,
+		DecayGraceDays:            q.DecayGraceDays,
+		DecayPerWeek:              q.DecayPerWeek,
+		DecayFloor:                q.DecayFloor,
+		PlacementMatches:          q.PlacementMatches,
+		PlacementKMultiplier:      q.PlacementKMultiplier,
+		TeamCount:                 q.TeamCount,
+		TeamSize:                  q.TeamSize,
+		ReadyCheckSeconds:         q.ReadyCheckSeconds,
+		RatingWindowSteps:         q.RatingWindowSchedule(),
+		RatingWindowCap:           q.RatingWindowCap,
+		MaxPingMs:                 q.MaxPingMs,
+		MinPlayers:                q.MinPlayers,
+		FillTimeoutSeconds:        q.FillTimeoutSeconds,
+		PenaltyDeclinePoints:      q.PenaltyDeclinePoints,
+		PenaltyDodgePoints:        q.PenaltyDodgePoints,
+		PenaltyAbandonPoints:      q.PenaltyAbandonPoints,
+		PenaltyDecayPerHour:       q.PenaltyDecayPerHour,
+		PenaltyLockoutSteps:       q.PenaltyLockoutSchedule(),
+		RematchLookbackMinutes:    q.RematchLookbackMinutes,
+		RematchMaxWaitSeconds:     q.RematchMaxWaitSeconds,
+		HeartbeatGraceSeconds:     q.HeartbeatGraceSeconds,
+		MaxMatchDurationMinutes:   q.MaxMatchDurationMinutes,
+		MaxMatchExtensionMinutes:  q.MaxMatchExtensionMinutes,
+		ResultReporting:           q.ResultReporting,
+		ResultQuorum:              q.ResultQuorum,
+		ResultReportWindowSeconds: q.ResultReportWindowSeconds,
 	}
 }
 
 type CreateGameQueueParams struct {
-	Name                    string
-	LobbyEnabled            *bool
-	LobbySize               int
-	MatchmakingStrategy     string
-	MatchmakingMachineName  string
-	MatchmakingMachinePorts []int64
-	ELOStrategy             string
-	DefaultRating           int
-	KFactor                 int
-	MetadataEnabled         *bool
+	Name                      string
+	LobbyEnabled              *bool
+	LobbySize                 int
+	MatchmakingStrategy       string
+	MatchmakingMachineName    string
+	MatchmakingMachinePorts   []int64
+	ELOStrategy               string
+	DefaultRating             int
+	KFactor                   int
+	MetadataEnabled           *bool
+	SeasonEndsAt              *time.Time
+	SeasonLengthDays          int
+	SeasonSoftReset           *float64
+	DecayGraceDays            int
+	DecayPerWeek              int
+	DecayFloor                *int
+	PlacementMatches          int
+	PlacementKMultiplier      float64
+	TeamCount                 int
+	TeamSize                  int
+	ReadyCheckSeconds         int
+	RatingWindowSteps         []RatingWindowStep
+	RatingWindowCap           int
+	MaxPingMs                 int
+	MinPlayers                int
+	FillTimeoutSeconds        int
+	PenaltyDeclinePoints      int
+	PenaltyDodgePoints        int
+	PenaltyAbandonPoints      int
+	PenaltyDecayPerHour       *int
+	PenaltyLockoutSteps       []PenaltyLockoutStep
+	RematchLookbackMinutes    int
+	RematchMaxWaitSeconds     int
+	HeartbeatGraceSeconds     int
+	MaxMatchDurationMinutes   int
+	MaxMatchExtensionMinutes  int
+	ResultReporting           string
+	ResultQuorum              int
+	ResultReportWindowSeconds int
 }
 
 // applyQueueDefaults fills in defaults and validates strategy fields.
@@ -121,6 +400,7 @@ func applyQueueDefaults(p *CreateGameQueueParams) error {
 	if !slices.Contains(ELO_STRATEGIES, p.ELOStrategy) {
 		return errors.New("invalid elo strategy: " + p.ELOStrategy + " must be one of " + strings.Join(ELO_STRATEGIES, ", "))
 	}
+	lobbySizeSet := p.LobbySize != 0
 	if p.LobbySize == 0 {
 		p.LobbySize = 2
 	}
@@ -130,9 +410,182 @@ func applyQueueDefaults(p *CreateGameQueueParams) error {
 	if p.DefaultRating == 0 {
 		p.DefaultRating = 1000
 	}
+	if p.SeasonEndsAt != nil && !p.SeasonEndsAt.After(time.Now()) {
+		return errors.New("invalid season_ends_at: must be in the future")
+	}
+	if p.SeasonLengthDays < 0 {
+		return errors.New("invalid season_length_days: must not be negative")
+	}
+	if p.SeasonSoftReset == nil {
+		softReset := DefaultSeasonSoftReset
+		p.SeasonSoftReset = &softReset
+	}
+	if *p.SeasonSoftReset < 0 || *p.SeasonSoftReset > 1 {
+		return errors.New("invalid season_soft_reset: must be between 0 and 1")
+	}
+	if p.DecayGraceDays < 0 || p.DecayPerWeek < 0 {
+		return errors.New("invalid decay settings: decay_grace_days and decay_per_week must not be negative")
+	}
+	if p.DecayFloor == nil {
+		// Decay only ever pulls idle players back toward the starting
+		// rating by default, never below it.
+		floor := p.DefaultRating
+		p.DecayFloor = &floor
+	}
+	if p.PlacementMatches < 0 {
+		return errors.New("invalid placement_matches: must not be negative")
+	}
+	if p.PlacementKMultiplier == 0 {
+		p.PlacementKMultiplier = DefaultPlacementKMultiplier
+	}
+	if p.PlacementKMultiplier < 1 {
+		return errors.New("invalid placement_k_multiplier: must be at least 1")
+	}
+	lobbySize, err := validateTeams(p.TeamCount, p.TeamSize, p.LobbySize, lobbySizeSet)
+	if err != nil {
+		return err
+	}
+	p.LobbySize = lobbySize
+	if err := validateReadyCheck(p.ReadyCheckSeconds); err != nil {
+		return err
+	}
+	if p.RatingWindowSteps == nil {
+		p.RatingWindowSteps = DefaultRatingWindowSteps
+	}
+	if err := validateRatingWindow(p.RatingWindowSteps, p.RatingWindowCap); err != nil {
+		return err
+	}
+	if err := validateMaxPing(p.MaxPingMs); err != nil {
+		return err
+	}
+	if err := validateFillTimeout(p.MinPlayers, p.FillTimeoutSeconds, p.LobbySize, p.TeamCount); err != nil {
+		return err
+	}
+	if p.PenaltyDecayPerHour == nil {
+		decay := 1
+		p.PenaltyDecayPerHour = &decay
+	}
+	if p.PenaltyLockoutSteps == nil {
+		p.PenaltyLockoutSteps = DefaultPenaltyLockoutSteps
+	}
+	if err := validatePenalties(p.PenaltyDeclinePoints, p.PenaltyDodgePoints, p.PenaltyAbandonPoints, *p.PenaltyDecayPerHour, p.PenaltyLockoutSteps); err != nil {
+		return err
+	}
+	if err := validateRematch(p.RematchLookbackMinutes, p.RematchMaxWaitSeconds); err != nil {
+		return err
+	}
+	if err := validateHeartbeatGrace(p.HeartbeatGraceSeconds); err != nil {
+		return err
+	}
+	if p.MaxMatchDurationMinutes == 0 {
+		p.MaxMatchDurationMinutes = DefaultMaxMatchDurationMinutes
+	}
+	if err := validateMatchDuration(p.MaxMatchDurationMinutes, p.MaxMatchExtensionMinutes); err != nil {
+		return err
+	}
+	if p.ResultReporting == "" {
+		p.ResultReporting = RESULT_REPORTING_SERVER
+	}
+	if p.ResultReportWindowSeconds == 0 {
+		p.ResultReportWindowSeconds = DefaultResultReportWindowSeconds
+	}
+	if err := validateResultReporting(p.ResultReporting, p.ResultQuorum, p.ResultReportWindowSeconds, p.LobbySize); err != nil {
+		return err
+	}
 	return nil
 }
 
+func validateReadyCheck(seconds int) error {
+	if seconds < 0 || seconds > MaxReadyCheckSeconds {
+		return fmt.Errorf("invalid ready_check_seconds: must be between 0 and %d", MaxReadyCheckSeconds)
+	}
+	return nil
+}
+
+func validateMaxPing(ms int) error {
+	if ms < 0 || ms > MaxPingLimitMs {
+		return fmt.Errorf("invalid max_ping_ms: must be between 0 and %d", MaxPingLimitMs)
+	}
+	return nil
+}
+
+// validateFillTimeout checks the under-filled match settings against the
+// queue's lobby size and teams.
+func validateFillTimeout(minPlayers, fillTimeout, lobbySize, teamCount int) error {
+	if minPlayers < 0 || minPlayers > lobbySize {
+		return fmt.Errorf("invalid min_players: must be between 0 and lobby_size (%d)", lobbySize)
+	}
+	if minPlayers > 0 && teamCount > 0 {
+		return errors.New("invalid min_players: not supported on team queues")
+	}
+	if fillTimeout < 0 || fillTimeout > MaxFillTimeoutSeconds {
+		return fmt.Errorf("invalid fill_timeout_seconds: must be between 0 and %d", MaxFillTimeoutSeconds)
+	}
+	return nil
+}
+
+func validateRematch(lookbackMinutes, maxWaitSeconds int) error {
+	if lookbackMinutes < 0 || lookbackMinutes > MaxRematchLookbackMinutes {
+		return fmt.Errorf("invalid rematch_lookback_minutes: must be between 0 and %d", MaxRematchLookbackMinutes)
+	}
+	if maxWaitSeconds < 0 || maxWaitSeconds > MaxRematchWaitSeconds {
+		return fmt.Errorf("invalid rematch_max_wait_seconds: must be between 0 and %d", MaxRematchWaitSeconds)
+	}
+	return nil
+}
+
+func validateHeartbeatGrace(seconds int) error {
+	if seconds != 0 && (seconds < MinHeartbeatGraceSeconds || seconds > MaxHeartbeatGraceSeconds) {
+		return fmt.Errorf("invalid heartbeat_grace_seconds: must be 0 or between %d and %d", MinHeartbeatGraceSeconds, MaxHeartbeatGraceSeconds)
+	}
+	return nil
+}
+
+// validateMatchDuration checks a queue's match deadline, and that the
+// deadline with every extension taken still fits MaxMatchLengthMinutes.
+func validateMatchDuration(durationMinutes, extensionMinutes int) error {
+	if durationMinutes < 1 || durationMinutes > MaxMatchLengthMinutes {
+		return fmt.Errorf("invalid max_match_duration_minutes: must be between 1 and %d", MaxMatchLengthMinutes)
+	}
+	if extensionMinutes < 0 || durationMinutes+extensionMinutes > MaxMatchLengthMinutes {
+		return fmt.Errorf("invalid max_match_extension_minutes: must not be negative, and max_match_duration_minutes plus it must not exceed %d", MaxMatchLengthMinutes)
+	}
+	return nil
+}
+
+// validateResultReporting checks a queue's result reporting mode,
+// quorum and report window. A non-zero quorum must be a majority of the
+// lobby, so two conflicting outcomes can never both reach it.
+func validateResultReporting(mode string, quorum, window, lobbySize int) error {
+	if !slices.Contains(RESULT_REPORTING_MODES, mode) {
+		return errors.New("invalid result_reporting: " + mode + " must be one of " + strings.Join(RESULT_REPORTING_MODES, ", "))
+	}
+	if quorum != 0 && (quorum <= lobbySize/2 || quorum > lobbySize) {
+		return fmt.Errorf("invalid result_quorum: must be 0 (a majority) or between %d and lobby_size (%d)", lobbySize/2+1, lobbySize)
+	}
+	if window < 1 || window > MaxResultReportWindowSeconds {
+		return fmt.Errorf("invalid result_report_window_seconds: must be between 1 and %d", MaxResultReportWindowSeconds)
+	}
+	return nil
+}
+
+// validateTeams checks a team layout and returns the LobbySize it
+// implies. Teams are either off (both 0) or at least two teams of at
+// least one player. With teams on, an explicitly set lobbySize must
+// equal teamCount*teamSize; otherwise it's derived.
+func validateTeams(teamCount, teamSize, lobbySize int, lobbySizeSet bool) (int, error) {
+	if teamCount == 0 && teamSize == 0 {
+		return lobbySize, nil
+	}
+	if teamCount < 2 || teamSize < 1 {
+		return 0, errors.New("invalid team settings: team_count must be at least 2 and team_size at least 1, or both 0 to disable teams")
+	}
+	if lobbySizeSet && lobbySize != teamCount*teamSize {
+		return 0, fmt.Errorf("invalid team settings: lobby_size %d must equal team_count*team_size (%d)", lobbySize, teamCount*teamSize)
+	}
+	return teamCount * teamSize, nil
+}
+
 // queueFromParams builds a GameQueue struct (not yet persisted) from
 // validated params. Caller is responsible for setting GameID and Create()ing.
 //
@@ -152,19 +605,63 @@ func queueFromParams(p CreateGameQueueParams) *GameQueue {
 	if p.MetadataEnabled != nil {
 		metadataEnabled = *p.MetadataEnabled
 	}
-	return &GameQueue{
-		Name:                    p.Name,
-		CreatedAt:               time.Now().UTC(),
-		LobbyEnabled:            lobbyEnabled,
-		LobbySize:               p.LobbySize,
-		MatchmakingStrategy:     p.MatchmakingStrategy,
-		MatchmakingMachineName:  p.MatchmakingMachineName,
-		MatchmakingMachinePorts: pq.Int64Array(p.MatchmakingMachinePorts),
-		ELOStrategy:             p.ELOStrategy,
-		DefaultRating:           p.DefaultRating,
-		KFactor:                 p.KFactor,
-		MetadataEnabled:         metadataEnabled,
+	now := time.Now().UTC()
+	q := &GameQueue{
+		Name:                      p.Name,
+		CreatedAt:                 now,
+		LobbyEnabled:              lobbyEnabled,
+		LobbySize:                 p.LobbySize,
+		MatchmakingStrategy:       p.MatchmakingStrategy,
+		MatchmakingMachineName:    p.MatchmakingMachineName,
+		MatchmakingMachinePorts:   pq.Int64Array(p.MatchmakingMachinePorts),
+		ELOStrategy:               p.ELOStrategy,
+		DefaultRating:             p.DefaultRating,
+		KFactor:                   p.KFactor,
+		MetadataEnabled:           metadataEnabled,
+		SeasonLengthDays:          p.SeasonLengthDays,
+		SeasonSoftReset:           *p.SeasonSoftReset,
+		DecayGraceDays:            p.DecayGraceDays,
+		DecayPerWeek:              p.DecayPerWeek,
+		DecayFloor:                *p.DecayFloor,
+		PlacementMatches:          p.PlacementMatches,
+		PlacementKMultiplier:      p.PlacementKMultiplier,
+		TeamCount:                 p.TeamCount,
+		TeamSize:                  p.TeamSize,
+		ReadyCheckSeconds:         p.ReadyCheckSeconds,
+		RatingWindowSteps:         encodeRatingWindow(p.RatingWindowSteps),
+		RatingWindowCap:           p.RatingWindowCap,
+		MaxPingMs:                 p.MaxPingMs,
+		MinPlayers:                p.MinPlayers,
+		FillTimeoutSeconds:        p.FillTimeoutSeconds,
+		PenaltyDeclinePoints:      p.PenaltyDeclinePoints,
+		PenaltyDodgePoints:        p.PenaltyDodgePoints,
+		PenaltyAbandonPoints:      p.PenaltyAbandonPoints,
+		PenaltyDecayPerHour:       *p.PenaltyDecayPerHour,
+		PenaltyLockoutSteps:       encodePenaltyLockout(p.PenaltyLockoutSteps),
+		RematchLookbackMinutes:    p.RematchLookbackMinutes,
+		RematchMaxWaitSeconds:     p.RematchMaxWaitSeconds,
+		HeartbeatGraceSeconds:     p.HeartbeatGraceSeconds,
+		MaxMatchDurationMinutes:   p.MaxMatchDurationMinutes,
+		MaxMatchExtensionMinutes:  p.MaxMatchExtensionMinutes,
+		ResultReporting:           p.ResultReporting,
+		ResultQuorum:              p.ResultQuorum,
+		ResultReportWindowSeconds: p.ResultReportWindowSeconds,
 	}
+	if p.SeasonEndsAt != nil {
+		startSeason(q, *p.SeasonEndsAt, now)
+	}
+	return q
+}
+
+// startSeason schedules the end of q's current season, opening season 1
+// (starting now) if seasons were never enabled on this queue.
+func startSeason(q *GameQueue, endsAt, now time.Time) {
+	if q.SeasonNumber == 0 {
+		q.SeasonNumber = 1
+		q.SeasonStartedAt = &now
+	}
+	endsAt = endsAt.UTC()
+	q.SeasonEndsAt = &endsAt
 }
 
 // CreateGameQueue persists a new queue under an existing game. Caller
@@ -248,6 +745,57 @@ type UpdateGameQueueParams struct {
 	DefaultRating           int     `json:"default_rating"`
 	KFactor                 int     `json:"k_factor"`
 	MetadataEnabled         *bool   `json:"metadata_enabled"`
+	// SeasonEndsAt reschedules the end of the current season (opening
+	// season 1 if seasons were never enabled). Must be in the future.
+	SeasonEndsAt     *time.Time `json:"season_ends_at"`
+	SeasonLengthDays int        `json:"season_length_days"`
+	SeasonSoftReset  *float64   `json:"season_soft_reset"`
+	// Decay settings are pointers so they can be set back to 0 (e.g.
+	// decay_per_week 0 turns decay off).
+	DecayGraceDays *int `json:"decay_grace_days"`
+	DecayPerWeek   *int `json:"decay_per_week"`
+	DecayFloor     *int `json:"decay_floor"`
+	// PlacementMatches is a pointer so placements can be turned off (0).
+	PlacementMatches     *int    `json:"placement_matches"`
+	PlacementKMultiplier float64 `json:"placement_k_multiplier"`
+	// Team settings are pointers so teams can be switched off (both 0).
+	// Setting them re-derives LobbySize.
+	TeamCount *int `json:"team_count"`
+	TeamSize  *int `json:"team_size"`
+	// ReadyCheckSeconds is a pointer so the ready check can be turned
+	// off (0).
+	ReadyCheckSeconds *int `json:"ready_check_seconds"`
+	// RatingWindowSteps replaces the whole schedule when sent.
+	// RatingWindowCap is a pointer so the cap can be removed (0).
+	RatingWindowSteps []RatingWindowStep `json:"rating_window_steps"`
+	RatingWindowCap   *int               `json:"rating_window_cap"`
+	// MaxPingMs is a pointer so region filtering can be turned off (0).
+	MaxPingMs *int `json:"max_ping_ms"`
+	// Fill timeout settings are pointers so they can be turned off (0).
+	MinPlayers         *int `json:"min_players"`
+	FillTimeoutSeconds *int `json:"fill_timeout_seconds"`
+	// Penalty points and decay are pointers so they can be turned off
+	// (0). PenaltyLockoutSteps replaces the whole schedule when sent.
+	PenaltyDeclinePoints *int                 `json:"penalty_decline_points"`
+	PenaltyDodgePoints   *int                 `json:"penalty_dodge_points"`
+	PenaltyAbandonPoints *int                 `json:"penalty_abandon_points"`
+	PenaltyDecayPerHour  *int                 `json:"penalty_decay_per_hour"`
+	PenaltyLockoutSteps  []PenaltyLockoutStep `json:"penalty_lockout_steps"`
+	// Rematch settings are pointers so avoidance can be turned off (0).
+	RematchLookbackMinutes *int `json:"rematch_lookback_minutes"`
+	RematchMaxWaitSeconds  *int `json:"rematch_max_wait_seconds"`
+	// HeartbeatGraceSeconds is a pointer so heartbeats can be turned off
+	// (0).
+	HeartbeatGraceSeconds *int `json:"heartbeat_grace_seconds"`
+	// MaxMatchExtensionMinutes is a pointer so extensions can be turned
+	// off (0).
+	MaxMatchDurationMinutes  int  `json:"max_match_duration_minutes"`
+	MaxMatchExtensionMinutes *int `json:"max_match_extension_minutes"`
+	// ResultQuorum is a pointer so it can be set back to 0 (a
+	// majority).
+	ResultReporting           string `json:"result_reporting"`
+	ResultQuorum              *int   `json:"result_quorum"`
+	ResultReportWindowSeconds int    `json:"result_report_window_seconds"`
 }
 
 // applyQueueUpdate writes the non-zero fields from params onto q.
@@ -260,6 +808,142 @@ func applyQueueUpdate(q *GameQueue, params UpdateGameQueueParams) error {
 	if params.ELOStrategy != "" && !slices.Contains(ELO_STRATEGIES, params.ELOStrategy) {
 		return errors.New("invalid elo strategy: " + params.ELOStrategy + " must be one of " + strings.Join(ELO_STRATEGIES, ", "))
 	}
+	now := time.Now().UTC()
+	if params.SeasonEndsAt != nil && !params.SeasonEndsAt.After(now) {
+		return errors.New("invalid season_ends_at: must be in the future")
+	}
+	if params.SeasonLengthDays < 0 {
+		return errors.New("invalid season_length_days: must not be negative")
+	}
+	if params.SeasonSoftReset != nil && (*params.SeasonSoftReset < 0 || *params.SeasonSoftReset > 1) {
+		return errors.New("invalid season_soft_reset: must be between 0 and 1")
+	}
+	if (params.DecayGraceDays != nil && *params.DecayGraceDays < 0) || (params.DecayPerWeek != nil && *params.DecayPerWeek < 0) {
+		return errors.New("invalid decay settings: decay_grace_days and decay_per_week must not be negative")
+	}
+	if params.PlacementMatches != nil && *params.PlacementMatches < 0 {
+		return errors.New("invalid placement_matches: must not be negative")
+	}
+	if params.PlacementKMultiplier != 0 && params.PlacementKMultiplier < 1 {
+		return errors.New("invalid placement_k_multiplier: must be at least 1")
+	}
+	teamCount, teamSize := q.TeamCount, q.TeamSize
+	if params.TeamCount != nil {
+		teamCount = *params.TeamCount
+	}
+	if params.TeamSize != nil {
+		teamSize = *params.TeamSize
+	}
+	lobbySize := q.LobbySize
+	if params.LobbySize != 0 {
+		lobbySize = params.LobbySize
+	}
+	// A lobby_size sent alongside a team change must agree with it; a
+	// team change alone re-derives the lobby size.
+	teamsChanged := params.TeamCount != nil || params.TeamSize != nil
+	lobbySize, err := validateTeams(teamCount, teamSize, lobbySize, params.LobbySize != 0 || !teamsChanged)
+	if err != nil {
+		return err
+	}
+	if params.ReadyCheckSeconds != nil {
+		if err := validateReadyCheck(*params.ReadyCheckSeconds); err != nil {
+			return err
+		}
+	}
+	windowSteps, windowCap := q.RatingWindowSchedule(), q.RatingWindowCap
+	if params.RatingWindowSteps != nil {
+		windowSteps = params.RatingWindowSteps
+	}
+	if params.RatingWindowCap != nil {
+		windowCap = *params.RatingWindowCap
+	}
+	if params.RatingWindowSteps != nil || params.RatingWindowCap != nil {
+		if err := validateRatingWindow(windowSteps, windowCap); err != nil {
+			return err
+		}
+	}
+	if params.MaxPingMs != nil {
+		if err := validateMaxPing(*params.MaxPingMs); err != nil {
+			return err
+		}
+	}
+	// Checked whenever teams or the lobby size change too, so neither can
+	// leave MinPlayers out of range.
+	minPlayers, fillTimeout := q.MinPlayers, q.FillTimeoutSeconds
+	if params.MinPlayers != nil {
+		minPlayers = *params.MinPlayers
+	}
+	if params.FillTimeoutSeconds != nil {
+		fillTimeout = *params.FillTimeoutSeconds
+	}
+	if err := validateFillTimeout(minPlayers, fillTimeout, lobbySize, teamCount); err != nil {
+		return err
+	}
+	declinePoints, dodgePoints := q.PenaltyDeclinePoints, q.PenaltyDodgePoints
+	if params.PenaltyDeclinePoints != nil {
+		declinePoints = *params.PenaltyDeclinePoints
+	}
+	if params.PenaltyDodgePoints != nil {
+		dodgePoints = *params.PenaltyDodgePoints
+	}
+	abandonPoints := q.PenaltyAbandonPoints
+	if params.PenaltyAbandonPoints != nil {
+		abandonPoints = *params.PenaltyAbandonPoints
+	}
+	penaltyDecay, lockoutSteps := q.PenaltyDecayPerHour, q.PenaltyLockoutSchedule()
+	if params.PenaltyDecayPerHour != nil {
+		penaltyDecay = *params.PenaltyDecayPerHour
+	}
+	if params.PenaltyLockoutSteps != nil {
+		lockoutSteps = params.PenaltyLockoutSteps
+	}
+	if err := validatePenalties(declinePoints, dodgePoints, abandonPoints, penaltyDecay, lockoutSteps); err != nil {
+		return err
+	}
+	rematchLookback, rematchWait := q.RematchLookbackMinutes, q.RematchMaxWaitSeconds
+	if params.RematchLookbackMinutes != nil {
+		rematchLookback = *params.RematchLookbackMinutes
+	}
+	if params.RematchMaxWaitSeconds != nil {
+		rematchWait = *params.RematchMaxWaitSeconds
+	}
+	if err := validateRematch(rematchLookback, rematchWait); err != nil {
+		return err
+	}
+	heartbeatGrace := q.HeartbeatGraceSeconds
+	if params.HeartbeatGraceSeconds != nil {
+		heartbeatGrace = *params.HeartbeatGraceSeconds
+	}
+	if err := validateHeartbeatGrace(heartbeatGrace); err != nil {
+		return err
+	}
+	matchDuration := q.MaxMatchDurationMinutes
+	if params.MaxMatchDurationMinutes != 0 {
+		matchDuration = params.MaxMatchDurationMinutes
+	}
+	matchExtension := q.MaxMatchExtensionMinutes
+	if params.MaxMatchExtensionMinutes != nil {
+		matchExtension = *params.MaxMatchExtensionMinutes
+	}
+	if err := validateMatchDuration(matchDuration, matchExtension); err != nil {
+		return err
+	}
+	// Checked whenever the lobby size changes too, so a shrinking lobby
+	// can't leave the quorum out of reach.
+	resultReporting, resultQuorum := q.ResultReporting, q.ResultQuorum
+	if params.ResultReporting != "" {
+		resultReporting = params.ResultReporting
+	}
+	if params.ResultQuorum != nil {
+		resultQuorum = *params.ResultQuorum
+	}
+	reportWindow := q.ResultReportWindowSeconds
+	if params.ResultReportWindowSeconds != 0 {
+		reportWindow = params.ResultReportWindowSeconds
+	}
+	if err := validateResultReporting(resultReporting, resultQuorum, reportWindow, lobbySize); err != nil {
+		return err
+	}
 	if params.Name != "" {
 		q.Name = params.Name
 	}
@@ -290,6 +974,48 @@ func applyQueueUpdate(q *GameQueue, params UpdateGameQueueParams) error {
 	if params.MetadataEnabled != nil {
 		q.MetadataEnabled = *params.MetadataEnabled
 	}
+	if params.SeasonEndsAt != nil {
+		startSeason(q, *params.SeasonEndsAt, now)
+	}
+	if params.SeasonLengthDays != 0 {
+		q.SeasonLengthDays = params.SeasonLengthDays
+	}
+	if params.SeasonSoftReset != nil {
+		q.SeasonSoftReset = *params.SeasonSoftReset
+	}
+	if params.DecayGraceDays != nil {
+		q.DecayGraceDays = *params.DecayGraceDays
+	}
+	if params.DecayPerWeek != nil {
+		q.DecayPerWeek = *params.DecayPerWeek
+	}
+	if params.DecayFloor != nil {
+		q.DecayFloor = *params.DecayFloor
+	}
+	if params.PlacementMatches != nil {
+		q.PlacementMatches = *params.PlacementMatches
+	}
+	if params.PlacementKMultiplier != 0 {
+		q.PlacementKMultiplier = params.PlacementKMultiplier
+	}
+	q.TeamCount, q.TeamSize, q.LobbySize = teamCount, teamSize, lobbySize
+	if params.ReadyCheckSeconds != nil {
+		q.ReadyCheckSeconds = *params.ReadyCheckSeconds
+	}
+	q.RatingWindowSteps, q.RatingWindowCap = encodeRatingWindow(windowSteps), windowCap
+	if params.MaxPingMs != nil {
+		q.MaxPingMs = *params.MaxPingMs
+	}
+	q.MinPlayers, q.FillTimeoutSeconds = minPlayers, fillTimeout
+	q.PenaltyDeclinePoints, q.PenaltyDodgePoints = declinePoints, dodgePoints
+	q.PenaltyAbandonPoints = abandonPoints
+	q.PenaltyDecayPerHour, q.PenaltyLockoutSteps = penaltyDecay, encodePenaltyLockout(lockoutSteps)
+	q.RematchLookbackMinutes, q.RematchMaxWaitSeconds = rematchLookback, rematchWait
+	q.HeartbeatGraceSeconds = heartbeatGrace
+	q.MaxMatchDurationMinutes = matchDuration
+	q.MaxMatchExtensionMinutes = matchExtension
+	q.ResultReporting, q.ResultQuorum = resultReporting, resultQuorum
+	q.ResultReportWindowSeconds = reportWindow
 	return nil
 }
 
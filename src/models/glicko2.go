@@ -0,0 +1,204 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// GLICKO2_DEFAULT_DEVIATION is the rating deviation assigned to a new
+	// rating row, and the ceiling deviation grows back to with inactivity.
+	// 350 is Glickman's recommended value for an unrated player.
+	GLICKO2_DEFAULT_DEVIATION = 350.0
+	// GLICKO2_DEFAULT_VOLATILITY is the initial volatility (σ) for a new
+	// rating row.
+	GLICKO2_DEFAULT_VOLATILITY = 0.06
+	// GLICKO2_TAU constrains how quickly volatility can change between
+	// matches. Glickman suggests 0.3–1.2; smaller values are more
+	// conservative.
+	GLICKO2_TAU = 0.5
+	// GLICKO2_RATING_PERIOD is the idle time that counts as one empty
+	// rating period when inflating a player's deviation for inactivity.
+	GLICKO2_RATING_PERIOD = 7 * 24 * time.Hour
+
+	// glicko2Scale converts between the Glicko display scale and the
+	// internal Glicko-2 scale (μ, φ).
+	glicko2Scale = 173.7178
+	// glicko2Epsilon is the convergence tolerance for the volatility
+	// iteration.
+	glicko2Epsilon = 0.000001
+)
+
+// glicko2InflateDeviation returns the deviation a row would have after
+// sitting idle since lastUpdate: φ' = sqrt(φ² + t·σ²), where t is the
+// (fractional) number of rating periods elapsed. Capped at
+// GLICKO2_DEFAULT_DEVIATION so a long-absent player is treated no worse
+// than a brand new one.
+func glicko2InflateDeviation(deviation, volatility float64, lastUpdate, now time.Time) float64 {
+	if deviation <= 0 {
+		deviation = GLICKO2_DEFAULT_DEVIATION
+	}
+	elapsed := now.Sub(lastUpdate)
+	if elapsed <= 0 {
+		return deviation
+	}
+	periods := float64(elapsed) / float64(GLICKO2_RATING_PERIOD)
+	phi := deviation / glicko2Scale
+	inflated := math.Sqrt(phi*phi+periods*volatility*volatility) * glicko2Scale
+	return math.Min(inflated, GLICKO2_DEFAULT_DEVIATION)
+}
+
+// ApplyGlicko2 updates ratings for the non-guest players in a finished
+// match using Glicko-2. Must run inside a transaction (the caller's
+// MatchEnded tx) — like ApplyClassicElo it upserts missing rows and then
+// locks each one FOR UPDATE in player-ID order, so concurrent matches
+// that share players cannot deadlock.
+//
+// The match is treated as a single rating period for every participant.
+// Pairwise scores follow ApplyClassicElo (better placement wins, equal
+// placement ties; see MatchOutcome.pairScore). Each pairwise result is
+// weighted by 2/N so an N-player free-for-all carries the same total
+// evidence as a 1v1, mirroring classic Elo's K_eff = K * 2 / N.
+//
+// Before the update, each player's deviation is inflated for the rating
+// periods they sat idle (see glicko2InflateDeviation), so occasional
+// players return with wide uncertainty and move quickly, while regulars
+// converge to a narrow deviation and move slowly.
+//
+// The display rating is centered on the queue's DefaultRating rather
+// than the conventional 1500; Glicko-2 updates depend only on rating
+// differences, so the choice of center doesn't change any deltas.
+//
+// Returns one unsaved RatingChange per updated row, like ApplyClassicElo.
+func ApplyGlicko2(tx *gorm.DB, queue *GameQueue, playerIDs []string, outcome MatchOutcome) ([]RatingChange, error) {
+	nonGuests := ratedPlayerIDs(playerIDs)
+	if len(nonGuests) < 2 {
+		return nil, nil
+	}
+
+	for _, pid := range nonGuests {
+		row := &Rating{
+			PlayerID:    pid,
+			GameQueueID: queue.ID,
+			Rating:      queue.DefaultRating,
+			Deviation:   GLICKO2_DEFAULT_DEVIATION,
+			Volatility:  GLICKO2_DEFAULT_VOLATILITY,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	ratings := make([]*Rating, 0, len(nonGuests))
+	for _, pid := range nonGuests {
+		var r Rating
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&r, "player_id = ? AND game_queue_id = ?", pid, queue.ID).Error; err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, &r)
+	}
+
+	before := make([]int, len(ratings))
+	for i, r := range ratings {
+		before[i] = r.Rating
+	}
+	rateGlicko2(queue, ratings, outcome)
+
+	changes := make([]RatingChange, 0, len(ratings))
+	for i, r := range ratings {
+		if err := tx.Save(r).Error; err != nil {
+			return nil, err
+		}
+		changes = append(changes, newRatingChange(r, before[i], RatingChangeReasonMatch))
+	}
+	return changes, nil
+}
+
+// rateGlicko2 applies one match to ratings (in player-ID order) in
+// memory, like rateClassicElo.
+func rateGlicko2(queue *GameQueue, ratings []*Rating, outcome MatchOutcome) {
+	now := outcome.playedAt()
+	n := len(ratings)
+	weight := 2.0 / float64(n)
+	mu := make([]float64, n)
+	phi := make([]float64, n)
+	sigma := make([]float64, n)
+	for i, r := range ratings {
+		if r.Volatility <= 0 {
+			r.Volatility = GLICKO2_DEFAULT_VOLATILITY
+		}
+		mu[i] = float64(r.Rating-queue.DefaultRating) / glicko2Scale
+		phi[i] = glicko2InflateDeviation(r.Deviation, r.Volatility, r.UpdatedAt, now) / glicko2Scale
+		sigma[i] = r.Volatility
+	}
+
+	for i, r := range ratings {
+		var vInv, deltaSum float64
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+			s := outcome.pairScore(r.PlayerID, ratings[j].PlayerID)
+			g := 1.0 / math.Sqrt(1.0+3.0*phi[j]*phi[j]/(math.Pi*math.Pi))
+			e := 1.0 / (1.0 + math.Exp(-g*(mu[i]-mu[j])))
+			vInv += weight * g * g * e * (1.0 - e)
+			deltaSum += weight * g * (s - e)
+		}
+		v := 1.0 / vInv
+		delta := v * deltaSum
+
+		newSigma := glicko2Volatility(phi[i], sigma[i], v, delta)
+		phiStar := math.Sqrt(phi[i]*phi[i] + newSigma*newSigma)
+		newPhi := 1.0 / math.Sqrt(1.0/(phiStar*phiStar)+1.0/v)
+		newMu := mu[i] + newPhi*newPhi*deltaSum
+
+		r.Rating = queue.DefaultRating + int(math.Round(newMu*glicko2Scale))
+		r.Deviation = math.Min(newPhi*glicko2Scale, GLICKO2_DEFAULT_DEVIATION)
+		r.Volatility = newSigma
+		r.GamesPlayed++
+	}
+}
+
+// glicko2Volatility solves for the post-period volatility σ' using the
+// Illinois-algorithm iteration from step 5 of Glickman's Glicko-2 paper.
+// phi and sigma are the pre-period deviation (internal scale) and
+// volatility; v and delta are the estimated variance and improvement
+// from this period's results.
+func glicko2Volatility(phi, sigma, v, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		num := ex * (delta*delta - phi*phi - v - ex)
+		den := 2.0 * math.Pow(phi*phi+v+ex, 2)
+		return num/den - (x-a)/(GLICKO2_TAU*GLICKO2_TAU)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*GLICKO2_TAU) < 0 {
+			k++
+		}
+		B = a - k*GLICKO2_TAU
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > glicko2Epsilon {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
@@ -25,6 +25,10 @@ type MachineHost struct {
 	Status         string        `json:"status" gorm:"not null;default:'provisioning'"`
 	MaxSlots       int           `json:"max_slots" gorm:"not null"`
 	AllocatedPorts pq.Int64Array `json:"-" gorm:"type:bigint[];not null;default:'{}'"`
+	// Region is the provider location the VM runs in (one of
+	// Config.HCLOUDLocations). Hosts created before regions existed were
+	// all in nbg1.
+	Region string `json:"region" gorm:"not null;default:'nbg1'"`
 	// PublicHostname is "host-<MachineHost.ID>.<GameServerDomain>" — the
 	// FQDN that resolves to PublicIP via Cloudflare. Populated by the
 	// matchmaker on provisioning when the wildcard-TLS feature is enabled,
@@ -63,13 +67,14 @@ func SetMachineHostHostname(hostID, hostname, recordID string) error {
 		}).Error
 }
 
-func CreateMachineHost(providerID, publicIP, agentToken string, agentPort int64, maxSlots int) (*MachineHost, error) {
+func CreateMachineHost(providerID, publicIP, agentToken, region string, agentPort int64, maxSlots int) (*MachineHost, error) {
 	host := &MachineHost{
 		ProviderID: providerID,
 		PublicIP:   publicIP,
 		AgentPort:  agentPort,
 		AgentToken: agentToken,
 		Status:     MachineHostStatusProvisioning,
+		Region:     region,
 		MaxSlots:   maxSlots,
 	}
 	if err := server.S.DB.Create(host).Error; err != nil {
@@ -121,12 +126,12 @@ func CountAvailableSlots() (int64, error) {
 	return total, nil
 }
 
-// FindAvailableHost returns a ready host that has slot capacity and enough free ports in the
-// configured range to accommodate neededPorts more port allocations. Returns nil (no error)
-// if no host is currently available.
-func FindAvailableHost(neededPorts int, rangeStart, rangeEnd int64) (*MachineHost, error) {
+// FindAvailableHost returns a ready host in region that has slot capacity and enough free
+// ports in the configured range to accommodate neededPorts more port allocations. Returns nil
+// (no error) if no host is currently available there.
+func FindAvailableHost(region string, neededPorts int, rangeStart, rangeEnd int64) (*MachineHost, error) {
 	var hosts []MachineHost
-	if err := server.S.DB.Where("status = ?", MachineHostStatusReady).Find(&hosts).Error; err != nil {
+	if err := server.S.DB.Where("status = ? AND region = ?", MachineHostStatusReady, region).Find(&hosts).Error; err != nil {
 		return nil, err
 	}
 
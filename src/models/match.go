@@ -1,14 +1,18 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/andy98725/elo-service/src/server"
 	"github.com/andy98725/elo-service/src/util"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -41,11 +45,48 @@ type Match struct {
 	// spectate=false). The override is disable-only; a match cannot
 	// enable spectating on a non-spectate game. Stored so the spectator
 	// route doesn't have to re-derive it from game + lobby.
-	SpectateEnabled bool      `json:"spectate_enabled" gorm:"default:false"`
-	CreatedAt       time.Time `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt       time.Time `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	SpectateEnabled bool `json:"spectate_enabled" gorm:"default:false"`
+	// QueueKey is the composite queue key the match was paired from (see
+	// redis.QueueKey) — the queue's ID, plus the metadata fingerprint on
+	// segmented queues. Backfill draws replacements from the same
+	// sub-queue.
+	QueueKey string `json:"-"`
+	// Teams is the JSON-encoded team layout ([][]string of player IDs)
+	// the matchmaker assigned, for queues with TeamCount set. Null
+	// otherwise.
+	Teams json.RawMessage `json:"teams" gorm:"type:jsonb"`
+	// LastHeartbeatAt is when the game server last POSTed
+	// /match/heartbeat. Null until the first one.
+	LastHeartbeatAt *time.Time `json:"last_heartbeat_at"`
+	// ExtendedUntil is the deadline the game server pushed the match to
+	// through /match/extend. Null until the first extension.
+	ExtendedUntil *time.Time `json:"extended_until"`
+	// Leaves is the JSON-encoded []MatchLeave of players the game server
+	// reported gone before the match ended. Null until the first one.
+	Leaves json.RawMessage `json:"leaves" gorm:"type:jsonb"`
+	// Reports is the JSON-encoded []MatchReport players sent on a
+	// client-reported queue. Null until the first one.
+	Reports   json.RawMessage `json:"reports" gorm:"type:jsonb"`
+	CreatedAt time.Time       `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time       `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
 }
 
+// MatchLeave is one player leaving a running match before it ended, as
+// the game server reported it. Players who left are rated as losers
+// when the match ends (see MatchOutcome.WithAbandons).
+type MatchLeave struct {
+	PlayerID string    `json:"player_id"`
+	Reason   string    `json:"reason"`
+	LeftAt   time.Time `json:"left_at"`
+}
+
+const (
+	// DefaultLeaveReason is recorded when the game server gives none.
+	DefaultLeaveReason = "left"
+	// MaxLeaveReasonLength caps a leave's free-form reason.
+	MaxLeaveReasonLength = 64
+)
+
 type MatchResp struct {
 	ID             string     `json:"id"`
 	GameID         string     `json:"game_id"`
@@ -53,6 +94,8 @@ type MatchResp struct {
 	Players        []UserResp `json:"players"`
 	GuestIDs       []string   `json:"guest_ids"`
 	Status         string     `json:"status"`
+	// Teams is omitted for matches without a team layout.
+	Teams [][]string `json:"teams,omitempty"`
 }
 
 func (m *Match) ToResp() *MatchResp {
@@ -68,9 +111,447 @@ func (m *Match) ToResp() *MatchResp {
 		Players:       players,
 		GuestIDs:      m.GuestIDs,
 		Status:        m.Status,
+		Teams:         m.TeamLayout(),
+	}
+}
+
+// TeamLayout decodes Teams. Returns nil for matches without one.
+func (m *Match) TeamLayout() [][]string {
+	if len(m.Teams) == 0 {
+		return nil
+	}
+	var teams [][]string
+	if err := json.Unmarshal(m.Teams, &teams); err != nil {
+		slog.Warn("Failed to decode match teams", "error", err, "matchID", m.ID)
+		return nil
+	}
+	return teams
+}
+
+// TeamOf returns the index of playerID's team in TeamLayout, or -1.
+func (m *Match) TeamOf(playerID string) int {
+	for i, team := range m.TeamLayout() {
+		for _, id := range team {
+			if id == playerID {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// decodeLeaves decodes a stored []MatchLeave, logging and dropping
+// undecodable JSON.
+func decodeLeaves(raw json.RawMessage, matchID string) []MatchLeave {
+	if len(raw) == 0 {
+		return nil
+	}
+	var leaves []MatchLeave
+	if err := json.Unmarshal(raw, &leaves); err != nil {
+		slog.Warn("Failed to decode match leaves", "error", err, "matchID", matchID)
+		return nil
+	}
+	return leaves
+}
+
+// LeaveLog decodes Leaves, in the order they were reported.
+func (m *Match) LeaveLog() []MatchLeave {
+	return decodeLeaves(m.Leaves, m.ID)
+}
+
+// LeaveOf returns playerID's leave, or nil if they're still in the
+// match.
+func (m *Match) LeaveOf(playerID string) *MatchLeave {
+	for _, l := range m.LeaveLog() {
+		if l.PlayerID == playerID {
+			return &l
+		}
+	}
+	return nil
+}
+
+// MatchReport is one player's account of how a match on a
+// client-reported queue ended: a normalized MatchOutcome plus the
+// reason they gave.
+type MatchReport struct {
+	PlayerID       string             `json:"player_id"`
+	WinnerIDs      []string           `json:"winner_ids"`
+	Placements     map[string]int     `json:"placements,omitempty"`
+	Scores         map[string]float64 `json:"scores,omitempty"`
+	Teams          [][]string         `json:"teams,omitempty"`
+	TeamPlacements []int              `json:"team_placements,omitempty"`
+	Reason         string             `json:"reason"`
+	ReportedAt     time.Time          `json:"reported_at"`
+}
+
+// MaxReportReasonLength caps a report's free-form reason.
+const MaxReportReasonLength = 64
+
+// Outcome returns the outcome the report describes.
+func (r *MatchReport) Outcome() MatchOutcome {
+	return MatchOutcome{
+		WinnerIDs:      r.WinnerIDs,
+		Placements:     r.Placements,
+		Scores:         r.Scores,
+		Teams:          r.Teams,
+		TeamPlacements: r.TeamPlacements,
 	}
 }
 
+// MatchResultDisputed is the result recorded for a client-reported
+// match whose reports conflicted.
+const MatchResultDisputed = "disputed"
+
+// ReportVerdict is what a client-reported match's reports add up to.
+type ReportVerdict string
+
+const (
+	// ReportsPending: no outcome has reached the quorum yet, but one
+	// still could.
+	ReportsPending ReportVerdict = "pending"
+	// ReportsAgreed: enough reports agree to record the result, or a
+	// majority of those received had by the time the report window
+	// closed.
+	ReportsAgreed ReportVerdict = "agreed"
+	// ReportsDisputed: the reports conflict so that no outcome can
+	// reach the quorum any more, or the report window closed without a
+	// majority of the reports received agreeing.
+	ReportsDisputed ReportVerdict = "disputed"
+)
+
+// decodeReports decodes a stored []MatchReport, logging and dropping
+// undecodable JSON.
+func decodeReports(raw json.RawMessage, matchID string) []MatchReport {
+	if len(raw) == 0 {
+		return nil
+	}
+	var reports []MatchReport
+	if err := json.Unmarshal(raw, &reports); err != nil {
+		slog.Warn("Failed to decode match reports", "error", err, "matchID", matchID)
+		return nil
+	}
+	return reports
+}
+
+// ReportLog decodes Reports, in the order they were sent.
+func (m *Match) ReportLog() []MatchReport {
+	return decodeReports(m.Reports, m.ID)
+}
+
+// ReportOf returns playerID's report, or nil if they haven't sent one.
+func (m *Match) ReportOf(playerID string) *MatchReport {
+	for _, r := range m.ReportLog() {
+		if r.PlayerID == playerID {
+			return &r
+		}
+	}
+	return nil
+}
+
+// ResultQuorum is how many agreeing reports record the result of this
+// match: its queue's ResultQuorum, or a majority of its players when
+// that's 0, and never more than the match started with. Expects
+// Players and GameQueue to be loaded.
+func (m *Match) ResultQuorum() int {
+	players := len(m.PlayerIDs())
+	q := m.GameQueue.ResultQuorum
+	if q == 0 {
+		q = players/2 + 1
+	}
+	return min(q, players)
+}
+
+// ReportWindowEnds is when a pending match is settled from the reports
+// received so far: its queue's ResultReportWindowSeconds after the
+// first report. ok is false until someone has reported. Expects
+// GameQueue to be loaded.
+func (m *Match) ReportWindowEnds() (ends time.Time, ok bool) {
+	reports := m.ReportLog()
+	if len(reports) == 0 {
+		return time.Time{}, false
+	}
+	window := m.GameQueue.ResultReportWindowSeconds
+	if window == 0 {
+		window = DefaultResultReportWindowSeconds
+	}
+	return reports[0].ReportedAt.Add(time.Duration(window) * time.Second), true
+}
+
+// TallyReports groups the match's reports by agreeing outcome (see
+// MatchOutcome.Agrees). The match is agreed once a group reaches the
+// quorum, and the earliest report in that group is returned as the
+// result. It's disputed once the largest group couldn't reach the
+// quorum even if every player yet to report sided with it. A match
+// still pending when its report window has closed at now is settled
+// from the reports received: agreed on the largest group if it's a
+// majority of them, disputed otherwise. Expects Players and GameQueue
+// to be loaded.
+func (m *Match) TallyReports(now time.Time) (ReportVerdict, *MatchReport) {
+	playerIDs := m.PlayerIDs()
+	quorum := m.ResultQuorum()
+	reports := m.ReportLog()
+
+	largest, largestAt := 0, -1
+	for i := range reports {
+		outcome := reports[i].Outcome()
+		agreeing := 0
+		for j := range reports {
+			other := reports[j].Outcome()
+			if outcome.Agrees(&other, playerIDs) {
+				agreeing++
+			}
+		}
+		if agreeing >= quorum {
+			return ReportsAgreed, &reports[i]
+		}
+		if agreeing > largest {
+			largest, largestAt = agreeing, i
+		}
+	}
+	if largest+len(playerIDs)-len(reports) < quorum {
+		return ReportsDisputed, nil
+	}
+	if ends, ok := m.ReportWindowEnds(); ok && !now.Before(ends) {
+		if largest*2 > len(reports) {
+			return ReportsAgreed, &reports[largestAt]
+		}
+		return ReportsDisputed, nil
+	}
+	return ReportsPending, nil
+}
+
+// ErrNotClientReported is returned when a player reports the result of
+// a match whose queue takes results from its game server.
+var ErrNotClientReported = errors.New("this match's result is reported by its game server")
+
+// ErrClientReported is returned when a game server reports the result
+// of a match whose queue takes results from its players.
+var ErrClientReported = errors.New("this match's result is reported by its players")
+
+// RecordMatchReport stores playerID's report of how a running match on
+// a client-reported queue ended. outcome must already be normalized
+// against the match. Reports are final: a repeat returns the first
+// report and recorded=false. The verdict is the match's TallyReports
+// with this report counted.
+func RecordMatchReport(matchID, playerID string, outcome MatchOutcome, reason string, at time.Time) (report *MatchReport, recorded bool, verdict ReportVerdict, err error) {
+	if len(reason) > MaxReportReasonLength {
+		return nil, false, "", fmt.Errorf("invalid reason: must be at most %d characters", MaxReportReasonLength)
+	}
+
+	err = server.S.DB.Transaction(func(tx *gorm.DB) error {
+		var match Match
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Players").Preload("GameQueue").
+			First(&match, "id = ?", matchID).Error; err != nil {
+			return err
+		}
+		if !match.GameQueue.ClientReported() {
+			return ErrNotClientReported
+		}
+		if match.Status != MatchStatusStarted {
+			return fmt.Errorf("match %s is not underway", matchID)
+		}
+		if !slices.Contains(match.PlayerIDs(), playerID) {
+			return ErrNotMatchParticipant
+		}
+
+		if report = match.ReportOf(playerID); report == nil {
+			winnerIDs := outcome.WinnerIDs
+			if winnerIDs == nil {
+				winnerIDs = []string{}
+			}
+			reports := append(match.ReportLog(), MatchReport{
+				PlayerID:       playerID,
+				WinnerIDs:      winnerIDs,
+				Placements:     outcome.Placements,
+				Scores:         outcome.Scores,
+				Teams:          outcome.Teams,
+				TeamPlacements: outcome.TeamPlacements,
+				Reason:         reason,
+				ReportedAt:     at.UTC(),
+			})
+			encoded, err := json.Marshal(reports)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&match).Update("reports", json.RawMessage(encoded)).Error; err != nil {
+				return err
+			}
+			match.Reports = encoded
+			report, recorded = &reports[len(reports)-1], true
+		}
+		verdict, _ = match.TallyReports(at)
+		return nil
+	})
+	if err != nil {
+		return nil, false, "", err
+	}
+	if recorded {
+		slog.Info("Player reported match result", "matchID", matchID, "playerID", playerID, "verdict", verdict)
+	}
+	return report, recorded, verdict, nil
+}
+
+// leaverIDs returns the players in leaves.
+func leaverIDs(leaves []MatchLeave) []string {
+	ids := make([]string, len(leaves))
+	for i, l := range leaves {
+		ids[i] = l.PlayerID
+	}
+	return ids
+}
+
+// ErrNotMatchParticipant is returned when a player named by the game
+// server, or reporting a result, isn't one of the match's players.
+var ErrNotMatchParticipant = errors.New("player is not a participant in this match")
+
+// RecordMatchLeave appends a leave for playerID to a running match. A
+// player can only leave once: a repeat report returns the first leave
+// and recorded=false, so game servers can retry safely.
+func RecordMatchLeave(matchID, playerID, reason string, at time.Time) (leave *MatchLeave, recorded bool, err error) {
+	if reason == "" {
+		reason = DefaultLeaveReason
+	}
+	if len(reason) > MaxLeaveReasonLength {
+		return nil, false, fmt.Errorf("invalid reason: must be at most %d characters", MaxLeaveReasonLength)
+	}
+
+	err = server.S.DB.Transaction(func(tx *gorm.DB) error {
+		var match Match
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&match, "id = ?", matchID).Error; err != nil {
+			return err
+		}
+		if match.Status != MatchStatusStarted {
+			return fmt.Errorf("match %s is not underway", matchID)
+		}
+		if !slices.Contains(match.GuestIDs, playerID) {
+			var count int64
+			if err := tx.Table("match_players").
+				Where("match_id = ? AND user_id = ?", matchID, playerID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrNotMatchParticipant
+			}
+		}
+
+		if leave = match.LeaveOf(playerID); leave != nil {
+			return nil
+		}
+		leaves := append(match.LeaveLog(), MatchLeave{PlayerID: playerID, Reason: reason, LeftAt: at.UTC()})
+		encoded, err := json.Marshal(leaves)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&match).Update("leaves", json.RawMessage(encoded)).Error; err != nil {
+			return err
+		}
+		leave, recorded = &leaves[len(leaves)-1], true
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if recorded {
+		slog.Info("Player left match", "matchID", matchID, "playerID", playerID, "reason", reason)
+	}
+	return leave, recorded, nil
+}
+
+// PlayerIDs returns every player in the match: registered players
+// (Players must be preloaded), then guests.
+func (m *Match) PlayerIDs() []string {
+	ids := make([]string, 0, len(m.Players)+len(m.GuestIDs))
+	for _, p := range m.Players {
+		ids = append(ids, p.ID)
+	}
+	return append(ids, []string(m.GuestIDs)...)
+}
+
+// LastSignOfLife is when the game server was last known to be alive:
+// its last heartbeat, or the match start before the first one.
+func (m *Match) LastSignOfLife() time.Time {
+	if m.LastHeartbeatAt != nil {
+		return *m.LastHeartbeatAt
+	}
+	return m.CreatedAt
+}
+
+// RecordMatchHeartbeat stamps a running match's LastHeartbeatAt.
+func RecordMatchHeartbeat(matchID string, at time.Time) error {
+	return server.S.DB.Model(&Match{}).
+		Where("id = ? AND status = ?", matchID, MatchStatusStarted).
+		Update("last_heartbeat_at", at).Error
+}
+
+// Deadline is when GC ends the match as "timeout": its queue's
+// MaxMatchDurationMinutes after it started, or later if the game server
+// extended it. Expects GameQueue to be loaded.
+func (m *Match) Deadline() time.Time {
+	if m.ExtendedUntil != nil {
+		return *m.ExtendedUntil
+	}
+	minutes := m.GameQueue.MaxMatchDurationMinutes
+	if minutes <= 0 {
+		minutes = DefaultMaxMatchDurationMinutes
+	}
+	return m.CreatedAt.Add(time.Duration(minutes) * time.Minute)
+}
+
+// LatestDeadline is as far as extensions can push Deadline.
+func (m *Match) LatestDeadline() time.Time {
+	minutes := m.GameQueue.MaxMatchDurationMinutes
+	if minutes <= 0 {
+		minutes = DefaultMaxMatchDurationMinutes
+	}
+	minutes += m.GameQueue.MaxMatchExtensionMinutes
+	return m.CreatedAt.Add(time.Duration(minutes) * time.Minute)
+}
+
+// ErrMatchDeadlineMaxed is returned by ExtendMatchDeadline when the
+// match is already at its LatestDeadline.
+var ErrMatchDeadlineMaxed = errors.New("match deadline can't be extended further")
+
+// ErrMatchNotUnderway is returned by ExtendMatchDeadline when the match
+// ended before its deadline could be extended.
+var ErrMatchNotUnderway = errors.New("match is not underway")
+
+// ExtendMatchDeadline pushes a running match's deadline back by d,
+// stopping at LatestDeadline, and returns the new deadline. The deadline
+// is read and written under a row lock so concurrent extensions stack.
+// Expects GameQueue to be loaded.
+func ExtendMatchDeadline(m *Match, d time.Duration) (time.Time, error) {
+	var deadline time.Time
+	err := server.S.DB.Transaction(func(tx *gorm.DB) error {
+		var locked Match
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", m.ID).Error; err != nil {
+			return err
+		}
+		if locked.Status != MatchStatusStarted {
+			return ErrMatchNotUnderway
+		}
+		locked.GameQueue = m.GameQueue
+
+		deadline = locked.Deadline()
+		latest := locked.LatestDeadline()
+		if !deadline.Before(latest) {
+			return ErrMatchDeadlineMaxed
+		}
+		deadline = deadline.Add(d)
+		if deadline.After(latest) {
+			deadline = latest
+		}
+		deadline = deadline.UTC()
+		return tx.Model(&locked).Update("extended_until", deadline).Error
+	})
+	if err != nil {
+		return deadline, err
+	}
+	m.ExtendedUntil = &deadline
+	return deadline, nil
+}
+
 func (m *Match) ConnectionAddress() string {
 	if len(m.ServerInstance.HostPorts) > 0 {
 		return fmt.Sprintf("%s:%d", m.ServerInstance.MachineHost.PublicIP, m.ServerInstance.HostPorts[0])
@@ -86,7 +567,12 @@ func (m *Match) ConnectionAddress() string {
 // spectateEnabled is the resolved flag — caller is expected to have already
 // AND'd the game-level flag with any per-match override (lobby's spectate
 // param). This function does not re-validate.
-func MatchStarted(db *gorm.DB, gameID string, gameQueueID string, serverInstanceID string, authCode string, playerIDs []string, spectateEnabled bool) (*Match, error) {
+//
+// teams is the matchmaker's team layout, or nil for queues without teams.
+// queueKey is the composite queue key the players were paired from.
+// serverInstanceID is empty for client-reported queues, which run no
+// game server.
+func MatchStarted(db *gorm.DB, gameID string, gameQueueID string, queueKey string, serverInstanceID string, authCode string, playerIDs []string, teams [][]string, spectateEnabled bool) (*Match, error) {
 	var users []User
 	var guestIDs []string
 
@@ -101,6 +587,7 @@ func MatchStarted(db *gorm.DB, gameID string, gameQueueID string, serverInstance
 	match := &Match{
 		GameID:           gameID,
 		GameQueueID:      gameQueueID,
+		QueueKey:         queueKey,
 		ServerInstanceID: serverInstanceID,
 		Players:          users,
 		GuestIDs:         guestIDs,
@@ -108,15 +595,92 @@ func MatchStarted(db *gorm.DB, gameID string, gameQueueID string, serverInstance
 		Status:           "started",
 		SpectateEnabled:  spectateEnabled,
 	}
+	if len(teams) > 0 {
+		encoded, err := json.Marshal(teams)
+		if err != nil {
+			return nil, err
+		}
+		match.Teams = encoded
+	}
 
+	if serverInstanceID == "" {
+		// Client-reported queues start no game server. Leave the column
+		// NULL; an empty string isn't a server instance ID.
+		db = db.Omit("ServerInstanceID")
+	}
 	if err := db.Create(match).Error; err != nil {
 		return nil, err
 	}
 
-	slog.Info("Match started", "gameID", gameID, "gameQueueID", gameQueueID, "serverInstanceID", serverInstanceID, "playerIDs", playerIDs)
+	slog.Info("Match started", "gameID", gameID, "gameQueueID", gameQueueID, "serverInstanceID", serverInstanceID, "playerIDs", playerIDs, "teams", teams)
 	return match, nil
 }
 
+// BackfillMatch adds backfilled players to a running match and, for team
+// matches, stores the team layout teamsFor returns for it. The match row
+// is locked FOR UPDATE first, and teamsFor is called with the locked
+// match, so the layout is built from the current players and leaves.
+// Players who left keep their place in the match's players — they're
+// still rated as abandoners — but not their seat. Fails without changing
+// anything when the match is no longer underway or hasn't enough open
+// seats.
+func BackfillMatch(matchID string, playerIDs []string, teamsFor func(match *Match) [][]string) error {
+	return server.S.DB.Transaction(func(tx *gorm.DB) error {
+		var match Match
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&match, "id = ?", matchID).Error; err != nil {
+			return err
+		}
+		if match.Status != MatchStatusStarted {
+			return fmt.Errorf("match %s is not underway", matchID)
+		}
+		if err := tx.First(&match.GameQueue, "id = ?", match.GameQueueID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&match).Association("Players").Find(&match.Players); err != nil {
+			return err
+		}
+		if open := match.OpenSeats(); len(playerIDs) > open {
+			return fmt.Errorf("match %s has %d open seats, not %d", matchID, open, len(playerIDs))
+		}
+
+		updates := map[string]interface{}{}
+		if teams := teamsFor(&match); len(teams) > 0 {
+			encoded, err := json.Marshal(teams)
+			if err != nil {
+				return err
+			}
+			updates["teams"] = json.RawMessage(encoded)
+		}
+		var users []User
+		for _, playerID := range playerIDs {
+			if util.IsGuestID(playerID) {
+				match.GuestIDs = append(match.GuestIDs, playerID)
+			} else {
+				users = append(users, User{ID: playerID})
+			}
+		}
+		updates["guest_ids"] = match.GuestIDs
+		if err := tx.Model(&match).Updates(updates).Error; err != nil {
+			return err
+		}
+		if len(users) > 0 {
+			if err := tx.Model(&match).Association("Players").Append(users); err != nil {
+				return err
+			}
+		}
+
+		slog.Info("Match backfilled", "matchID", matchID, "playerIDs", playerIDs, "teams", updates["teams"])
+		return nil
+	})
+}
+
+// OpenSeats is how many more players the match can take: its queue's
+// LobbySize less the players still in it. A player who left gives up
+// their seat. GameQueue and Players must be loaded.
+func (m *Match) OpenSeats() int {
+	return max(m.GameQueue.LobbySize-len(m.PlayerIDs())+len(m.LeaveLog()), 0)
+}
+
 func matchQuery() *gorm.DB {
 	return server.S.DB.Preload("ServerInstance.MachineHost").Preload("Game").Preload("GameQueue").Preload("Players")
 }
@@ -127,6 +691,20 @@ func GetMatch(matchID string) (*Match, error) {
 	return &match, result.Error
 }
 
+// GetMatchesByID loads the matches with the given IDs in one query,
+// keyed by ID. IDs without a match are left out.
+func GetMatchesByID(ids []string) (map[string]*Match, error) {
+	var matches []Match
+	if err := matchQuery().Find(&matches, "id IN ?", ids).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*Match, len(matches))
+	for i := range matches {
+		byID[matches[i].ID] = &matches[i]
+	}
+	return byID, nil
+}
+
 func GetMatchByTokenID(tokenID string) (*Match, error) {
 	var match Match
 	result := matchQuery().First(&match, "auth_code = ?", tokenID)
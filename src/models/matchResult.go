@@ -1,7 +1,9 @@
 package models
 
 import (
+	"encoding/json"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/andy98725/elo-service/src/server"
@@ -11,14 +13,25 @@ import (
 )
 
 type MatchResult struct {
-	ID        string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	GameID    string         `json:"game_id" gorm:"not null"`
-	Game      Game           `json:"game" gorm:"foreignKey:GameID"`
-	Players   []User         `json:"players" gorm:"many2many:match_result_players;"`
-	GuestIDs  pq.StringArray `json:"guest_ids" gorm:"type:text[];default:'{}'"`
-	WinnerIDs pq.StringArray `json:"winner_ids" gorm:"type:text[];default:'{}'"`
-	Result    string         `json:"result" gorm:"not null"`
-	LogsKey   string         `json:"logs_key"`
+	ID     string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	GameID string `json:"game_id" gorm:"not null"`
+	Game   Game   `json:"game" gorm:"foreignKey:GameID"`
+	// GameQueueID is the queue the match was played in. Nil only for
+	// results that predate the column and couldn't be attributed to a
+	// queue by the backfill migration.
+	GameQueueID *string        `json:"game_queue_id" gorm:"index"`
+	GameQueue   *GameQueue     `json:"-" gorm:"foreignKey:GameQueueID;constraint:OnDelete:SET NULL"`
+	Players     []User         `json:"players" gorm:"many2many:match_result_players;"`
+	GuestIDs    pq.StringArray `json:"guest_ids" gorm:"type:text[];default:'{}'"`
+	WinnerIDs   pq.StringArray `json:"winner_ids" gorm:"type:text[];default:'{}'"`
+	Result      string         `json:"result" gorm:"not null"`
+	// Unrated marks results that don't count toward ratings: reported
+	// with adjust_ratings=false, or timed out by GC. Recorded regardless
+	// of the queue's strategy at the time, so a recalculation after
+	// switching an unranked queue to a rated strategy knows which
+	// results to replay.
+	Unrated bool   `json:"unrated" gorm:"not null;default:false"`
+	LogsKey string `json:"logs_key"`
 	// Artifacts is the list of artifact names the game server uploaded
 	// during this match (via POST /match/artifact). Names only — the
 	// per-artifact metadata (content_type, size, uploaded_at) lives in
@@ -27,17 +40,57 @@ type MatchResult struct {
 	// keeps an empty array. Used by /user/artifacts to filter quickly
 	// in SQL without touching S3.
 	Artifacts pq.StringArray `json:"artifacts" gorm:"type:text[];default:'{}'"`
-	CreatedAt time.Time      `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt time.Time      `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	// Teams is the JSON-encoded team assignment ([][]string of player
+	// IDs) the game server reported, and TeamPlacements the matching
+	// finishing position per team (1 = first; equal values are ties).
+	// Both are empty for matches reported with winner_ids only.
+	Teams          json.RawMessage `json:"teams" gorm:"type:jsonb"`
+	TeamPlacements pq.Int64Array   `json:"team_placements" gorm:"type:integer[];default:'{}'"`
+	// Placements (map of player ID → finishing position) and Scores (map
+	// of player ID → numeric score) are the JSON-encoded full ordering
+	// the game server reported. Null when the report carried neither.
+	Placements json.RawMessage `json:"placements" gorm:"type:jsonb"`
+	Scores     json.RawMessage `json:"scores" gorm:"type:jsonb"`
+	// Abandons is the JSON-encoded []MatchLeave of players who left the
+	// match before it ended. They're rated as losers (see RatedOutcome).
+	// Null when everyone stayed.
+	Abandons json.RawMessage `json:"abandons" gorm:"type:jsonb"`
+	// Reports is the JSON-encoded []MatchReport the players sent on a
+	// client-reported queue — the agreeing reports behind the result,
+	// or the conflicting ones behind a "disputed" one. Null for results
+	// reported by a game server.
+	Reports json.RawMessage `json:"reports" gorm:"type:jsonb"`
+	// RatingChanges are the per-player rating deltas this match caused.
+	// Only preloaded by GetMatchResult; empty for unrated matches.
+	RatingChanges []RatingChange `json:"rating_changes" gorm:"foreignKey:MatchResultID"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
 }
 
 type MatchResultResp struct {
-	ID        string     `json:"id"`
-	GameID    string     `json:"game_id"`
-	Players   []UserResp `json:"players"`
-	GuestIDs  []string   `json:"guest_ids"`
-	WinnerIDs []string   `json:"winner_ids"`
-	Result    string     `json:"result"`
+	ID          string     `json:"id"`
+	GameID      string     `json:"game_id"`
+	GameQueueID *string    `json:"game_queue_id"`
+	Players     []UserResp `json:"players"`
+	GuestIDs    []string   `json:"guest_ids"`
+	WinnerIDs   []string   `json:"winner_ids"`
+	Result      string     `json:"result"`
+	// Teams and TeamPlacements are omitted for matches reported with
+	// winner_ids only.
+	Teams          [][]string `json:"teams,omitempty"`
+	TeamPlacements []int64    `json:"team_placements,omitempty"`
+	// Placements and Scores are omitted unless the report included a
+	// full ordering or per-player scores.
+	Placements map[string]int     `json:"placements,omitempty"`
+	Scores     map[string]float64 `json:"scores,omitempty"`
+	// Abandons lists players who left before the match ended; omitted
+	// when everyone stayed.
+	Abandons []MatchLeave `json:"abandons,omitempty"`
+	// Reports lists what each player reported on a client-reported
+	// queue; omitted for results reported by a game server.
+	Reports []MatchReport `json:"reports,omitempty"`
+	// RatingChanges is only populated on the single-result endpoint.
+	RatingChanges []RatingChangeResp `json:"rating_changes,omitempty"`
 }
 
 func (m *MatchResult) ToResp() *MatchResultResp {
@@ -46,22 +99,71 @@ func (m *MatchResult) ToResp() *MatchResultResp {
 		playersResp[i] = *player.ToResp()
 	}
 
+	outcome := m.Outcome()
+
+	var ratingChanges []RatingChangeResp
+	if len(m.RatingChanges) > 0 {
+		ratingChanges = make([]RatingChangeResp, len(m.RatingChanges))
+		for i, c := range m.RatingChanges {
+			ratingChanges[i] = *c.ToResp()
+		}
+	}
+
 	return &MatchResultResp{
-		ID:        m.ID,
-		GameID:    m.GameID,
-		Players:   playersResp,
-		GuestIDs:  m.GuestIDs,
-		WinnerIDs: m.WinnerIDs,
-		Result:    m.Result,
+		ID:             m.ID,
+		GameID:         m.GameID,
+		GameQueueID:    m.GameQueueID,
+		Players:        playersResp,
+		GuestIDs:       m.GuestIDs,
+		WinnerIDs:      m.WinnerIDs,
+		Result:         m.Result,
+		Teams:          outcome.Teams,
+		TeamPlacements: m.TeamPlacements,
+		Placements:     outcome.Placements,
+		Scores:         outcome.Scores,
+		Abandons:       decodeLeaves(m.Abandons, m.ID),
+		Reports:        decodeReports(m.Reports, m.ID),
+		RatingChanges:  ratingChanges,
 	}
 }
 
+// Outcome decodes the stored result back into the MatchOutcome that
+// produced it. Undecodable JSON columns are logged and left empty
+// rather than failing the read.
+func (m *MatchResult) Outcome() MatchOutcome {
+	outcome := MatchOutcome{WinnerIDs: m.WinnerIDs}
+	decode := func(name string, raw json.RawMessage, into interface{}) {
+		if len(raw) == 0 {
+			return
+		}
+		if err := json.Unmarshal(raw, into); err != nil {
+			slog.Warn("Failed to decode match result column", "column", name, "error", err, "matchID", m.ID)
+		}
+	}
+	decode("teams", m.Teams, &outcome.Teams)
+	decode("placements", m.Placements, &outcome.Placements)
+	decode("scores", m.Scores, &outcome.Scores)
+	if len(m.TeamPlacements) > 0 {
+		outcome.TeamPlacements = make([]int, len(m.TeamPlacements))
+		for i, p := range m.TeamPlacements {
+			outcome.TeamPlacements[i] = int(p)
+		}
+	}
+	return outcome
+}
+
+// RatedOutcome is the outcome ratings are computed from: Outcome with
+// any abandoners ranked last.
+func (m *MatchResult) RatedOutcome() MatchOutcome {
+	return m.Outcome().WithAbandons(m.PlayerIDs(), leaverIDs(decodeLeaves(m.Abandons, m.ID)))
+}
+
 // MatchEnded is phase A of match completion. Writes the MatchResult,
 // flips the Match into cooldown (Match row stays alive so the auth_code
 // keeps resolving for post-result artifact uploads and server-authored
-// player_data writes), and applies rating updates — all in one
-// transaction so the rating delta cannot drift from the result that
-// justified it.
+// player_data writes), applies rating updates and appends their
+// RatingChange history rows — all in one transaction so the rating
+// delta cannot drift from the result that justified it.
 //
 // The container teardown (stop, free ports, delete Match row, mark SI
 // deleted) is phase B, run by the worker cooldown sweep after
@@ -77,7 +179,7 @@ func (m *MatchResult) ToResp() *MatchResultResp {
 // match_id stable across the whole lifecycle), so they coexist in
 // different tables for the duration of the cooldown. No PK conflict
 // because they're separate tables.
-func MatchEnded(matchID string, winnerIDs []string, result string, logsKey string, artifacts []string, adjustRatings bool) (*MatchResult, error) {
+func MatchEnded(matchID string, outcome MatchOutcome, result string, logsKey string, artifacts []string, adjustRatings bool) (*MatchResult, error) {
 	match, err := GetMatch(matchID)
 	if err != nil {
 		return nil, err
@@ -86,17 +188,51 @@ func MatchEnded(matchID string, winnerIDs []string, result string, logsKey strin
 	if artifacts == nil {
 		artifacts = []string{}
 	}
+	winnerIDs := outcome.WinnerIDs
+	if winnerIDs == nil {
+		winnerIDs = []string{}
+	}
 	matchResult := &MatchResult{
-		ID:        matchID,
-		GameID:    match.GameID,
-		Players:   match.Players,
-		GuestIDs:  match.GuestIDs,
-		WinnerIDs: winnerIDs,
-		Result:    result,
-		LogsKey:   logsKey,
-		Artifacts: pq.StringArray(artifacts),
+		ID:          matchID,
+		GameID:      match.GameID,
+		GameQueueID: &match.GameQueueID,
+		Players:     match.Players,
+		GuestIDs:    match.GuestIDs,
+		WinnerIDs:   winnerIDs,
+		Result:      result,
+		Unrated:     !adjustRatings,
+		LogsKey:     logsKey,
+		Artifacts:   pq.StringArray(artifacts),
+		Abandons:    match.Leaves,
+		Reports:     match.Reports,
+	}
+	if len(outcome.Teams) > 0 {
+		teams, err := json.Marshal(outcome.Teams)
+		if err != nil {
+			return nil, err
+		}
+		matchResult.Teams = teams
+		placements := make(pq.Int64Array, len(outcome.TeamPlacements))
+		for i, p := range outcome.TeamPlacements {
+			placements[i] = int64(p)
+		}
+		matchResult.TeamPlacements = placements
 	}
-	slog.Info("Match ended (phase A)", "matchID", matchID, "winnerIDs", winnerIDs, "result", result, "adjustRatings", adjustRatings)
+	if len(outcome.Placements) > 0 {
+		placements, err := json.Marshal(outcome.Placements)
+		if err != nil {
+			return nil, err
+		}
+		matchResult.Placements = placements
+	}
+	if len(outcome.Scores) > 0 {
+		scores, err := json.Marshal(outcome.Scores)
+		if err != nil {
+			return nil, err
+		}
+		matchResult.Scores = scores
+	}
+	slog.Info("Match ended (phase A)", "matchID", matchID, "winnerIDs", winnerIDs, "teams", outcome.Teams, "placements", outcome.Placements, "result", result, "adjustRatings", adjustRatings)
 
 	err = server.S.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(matchResult).Error; err != nil {
@@ -119,15 +255,25 @@ func MatchEnded(matchID string, winnerIDs []string, result string, logsKey strin
 			}
 		}
 
-		if adjustRatings && match.GameQueue.ELOStrategy == ELO_STRATEGY_CLASSIC {
+		if adjustRatings {
 			playerIDs := make([]string, 0, len(match.Players)+len(match.GuestIDs))
 			for _, p := range match.Players {
 				playerIDs = append(playerIDs, p.ID)
 			}
 			playerIDs = append(playerIDs, []string(match.GuestIDs)...)
-			if err := ApplyClassicElo(tx, &match.GameQueue, playerIDs, winnerIDs); err != nil {
+			rated := outcome.WithAbandons(playerIDs, leaverIDs(match.LeaveLog()))
+			changes, err := applyRatingStrategy(tx, &match.GameQueue, playerIDs, rated)
+			if err != nil {
 				return err
 			}
+			if len(changes) > 0 {
+				for i := range changes {
+					changes[i].MatchResultID = &matchResult.ID
+				}
+				if err := tx.Create(&changes).Error; err != nil {
+					return err
+				}
+			}
 		}
 
 		return nil
@@ -139,6 +285,20 @@ func MatchEnded(matchID string, winnerIDs []string, result string, logsKey strin
 	return matchResult, nil
 }
 
+// applyRatingStrategy dispatches to the queue's ELOStrategy. Unranked
+// (and unknown) strategies change nothing.
+func applyRatingStrategy(tx *gorm.DB, queue *GameQueue, playerIDs []string, outcome MatchOutcome) ([]RatingChange, error) {
+	switch queue.ELOStrategy {
+	case ELO_STRATEGY_CLASSIC:
+		return ApplyClassicElo(tx, queue, playerIDs, outcome)
+	case ELO_STRATEGY_GLICKO2:
+		return ApplyGlicko2(tx, queue, playerIDs, outcome)
+	case ELO_STRATEGY_TRUESKILL:
+		return ApplyTrueSkill(tx, queue, playerIDs, outcome)
+	}
+	return nil, nil
+}
+
 // FinalizeMatchTeardown is phase B of match completion: patches the
 // MatchResult with the now-final logs key and artifact list, deletes
 // the Match row (and its many2many join), in one transaction. Called
@@ -181,7 +341,9 @@ func FinalizeMatchTeardown(matchID string, logsKey string, artifacts []string) e
 
 func GetMatchResult(matchID string) (*MatchResult, error) {
 	var matchResult MatchResult
-	result := server.S.DB.Preload("Game").Preload("Players").First(&matchResult, "id = ?", matchID)
+	result := server.S.DB.Preload("Game").Preload("Players").
+		Preload("RatingChanges", func(db *gorm.DB) *gorm.DB { return db.Order("player_id ASC") }).
+		First(&matchResult, "id = ?", matchID)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -199,6 +361,25 @@ func GetMatchResultsOfGame(gameID string, page, pageSize int) ([]MatchResult, in
 	return matchResults, nextPage, nil
 }
 
+// GetDisputedMatchResultsOfGame returns the game's "disputed" results,
+// newest first, for its owner to resolve.
+func GetDisputedMatchResultsOfGame(gameID string, page, pageSize int) ([]MatchResult, int, error) {
+	var matchResults []MatchResult
+	offset := page * pageSize
+	result := server.S.DB.Preload("Game").Preload("Players").
+		Where("game_id = ? AND result = ?", gameID, MatchResultDisputed).
+		Order("created_at DESC, id ASC").
+		Offset(offset).Limit(pageSize).Find(&matchResults)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	nextPage := page + 1
+	if result.RowsAffected < int64(pageSize) {
+		nextPage = -1
+	}
+	return matchResults, nextPage, nil
+}
+
 // GetMatchResultsWithArtifactsForPlayer returns the player's match
 // results that have at least one uploaded artifact, optionally filtered
 // to a single game and/or a set of artifact names.
@@ -382,3 +563,99 @@ func IsUserMatchResultAdmin(userID string, matchResultID string) (bool, error) {
 
 	return false, nil
 }
+
+// PlayerIDs returns every participant: registered players (Players must
+// be preloaded), then guests.
+func (m *MatchResult) PlayerIDs() []string {
+	ids := make([]string, 0, len(m.Players)+len(m.GuestIDs))
+	for _, p := range m.Players {
+		ids = append(ids, p.ID)
+	}
+	return append(ids, []string(m.GuestIDs)...)
+}
+
+// GetRecentOpponents returns, for each of playerIDs who played in the
+// queue since `since`, the set of players they faced. Teammates on a
+// team match aren't opponents. Only results involving one of playerIDs
+// are read.
+func GetRecentOpponents(gameQueueID string, playerIDs []string, since time.Time) (map[string]map[string]bool, error) {
+	if len(playerIDs) == 0 {
+		return nil, nil
+	}
+	var users, guests []string
+	for _, id := range playerIDs {
+		if util.IsGuestID(id) {
+			guests = append(guests, id)
+		} else {
+			users = append(users, id)
+		}
+	}
+	var involves []string
+	var args []interface{}
+	if len(users) > 0 {
+		involves = append(involves, "EXISTS (SELECT 1 FROM match_result_players mrp WHERE mrp.match_result_id = match_results.id AND mrp.user_id IN ?)")
+		args = append(args, users)
+	}
+	if len(guests) > 0 {
+		cond, guestArgs := guestIDsOverlap(guests)
+		involves = append(involves, cond)
+		args = append(args, guestArgs...)
+	}
+
+	var results []MatchResult
+	err := server.S.DB.Preload("Players").
+		Where("game_queue_id = ? AND created_at >= ?", gameQueueID, since).
+		Where("("+strings.Join(involves, " OR ")+")", args...).
+		Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(playerIDs))
+	for _, id := range playerIDs {
+		wanted[id] = true
+	}
+	opponents := make(map[string]map[string]bool)
+	for i := range results {
+		team := make(map[string]int)
+		for t, members := range results[i].Outcome().Teams {
+			for _, id := range members {
+				team[id] = t + 1
+			}
+		}
+		ids := results[i].PlayerIDs()
+		for _, a := range ids {
+			if !wanted[a] {
+				continue
+			}
+			for _, b := range ids {
+				if a == b || (team[a] != 0 && team[a] == team[b]) {
+					continue
+				}
+				if opponents[a] == nil {
+					opponents[a] = make(map[string]bool)
+				}
+				opponents[a][b] = true
+			}
+		}
+	}
+	return opponents, nil
+}
+
+// guestIDsOverlap is a condition matching rows whose guest_ids hold any
+// of guestIDs. Postgres uses the array overlap operator; the SQLite test
+// harness stores guest_ids as TEXT, so there each ID is matched as a
+// substring (guest IDs are all the same length, so none contains
+// another).
+func guestIDsOverlap(guestIDs []string) (string, []interface{}) {
+	if server.S.DB.Dialector.Name() == "postgres" {
+		return "guest_ids && ?", []interface{}{pq.Array(guestIDs)}
+	}
+	conds := make([]string, len(guestIDs))
+	args := make([]interface{}, len(guestIDs))
+	for i, id := range guestIDs {
+		conds[i] = "guest_ids LIKE ?"
+		args[i] = "%" + id + "%"
+	}
+	return strings.Join(conds, " OR "), args
+}
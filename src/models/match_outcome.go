@@ -0,0 +1,357 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// MatchOutcome is what a game server reports about how a match ended.
+// WinnerIDs is always populated (possibly empty for a draw/abort).
+// Everything else is optional and adds detail on top of it:
+//   - Placements: each player's finishing position (1 = first, equal
+//     values tie), for full free-for-all orderings.
+//   - Scores: each player's numeric score, stored for display and used
+//     to derive Placements (higher is better) when none are reported.
+//   - Teams/TeamPlacements: the team layout and each team's finishing
+//     position, for team-aware rating strategies (trueskill).
+//   - PlayedAt: when the match was played. Zero means now; rating
+//     recalculation sets it so time-dependent strategies (glicko2's
+//     inactivity inflation) see the original timeline.
+//
+// Rating strategies read the outcome through placementOf/pairScore, so
+// a winner_ids-only report behaves exactly like "winners tie for 1st,
+// everyone else ties for 2nd".
+type MatchOutcome struct {
+	WinnerIDs      []string
+	Placements     map[string]int
+	Scores         map[string]float64
+	Teams          [][]string
+	TeamPlacements []int
+	PlayedAt       time.Time
+}
+
+// playedAt returns PlayedAt, defaulting to now.
+func (o *MatchOutcome) playedAt() time.Time {
+	if o.PlayedAt.IsZero() {
+		return time.Now()
+	}
+	return o.PlayedAt
+}
+
+// Normalize validates the outcome against the match's participants and
+// fills in whatever can be derived:
+//   - No Teams: the match's assigned layout, if its queue has teams.
+//   - Scores without Placements: players are ranked by score, highest
+//     first, with standard competition ranking for ties (1, 2, 2, 4).
+//   - TeamPlacements without Placements: each player inherits their
+//     team's placement.
+//   - Teams without TeamPlacements: each team takes its best member's
+//     placement if placements are known; otherwise the team holding any
+//     winner places 1st and every other team 2nd.
+//   - Placements without WinnerIDs: every 1st-place player becomes a
+//     winner, so winner-based consumers (MatchResult.WinnerIDs) see the
+//     same outcome. Reported WinnerIDs must all hold the best placement
+//     instead.
+//
+// Errors are prefixed "invalid " so handlers can map them to HTTP 400.
+func (o *MatchOutcome) Normalize(match *Match) error {
+	participants := make(map[string]bool, len(match.Players)+len(match.GuestIDs))
+	for _, p := range match.Players {
+		participants[p.ID] = true
+	}
+	for _, g := range match.GuestIDs {
+		participants[g] = true
+	}
+
+	for pid, p := range o.Placements {
+		if !participants[pid] {
+			return errors.New("invalid placements: " + pid + " is not a participant in this match")
+		}
+		if p < 1 {
+			return errors.New("invalid placements: placements start at 1")
+		}
+	}
+	for pid, score := range o.Scores {
+		if !participants[pid] {
+			return errors.New("invalid scores: " + pid + " is not a participant in this match")
+		}
+		if math.IsNaN(score) || math.IsInf(score, 0) {
+			return errors.New("invalid scores: " + pid + " has a non-finite score")
+		}
+	}
+
+	// Team queues hand the game server its layout up front; a report that
+	// doesn't restate the teams is read against that layout.
+	if len(o.Teams) == 0 {
+		o.Teams = match.TeamLayout()
+	}
+	if len(o.Teams) == 0 && len(o.TeamPlacements) > 0 {
+		return errors.New("invalid team_placements: teams are required")
+	}
+	seen := map[string]bool{}
+	for _, team := range o.Teams {
+		if len(team) == 0 {
+			return errors.New("invalid teams: empty team")
+		}
+		for _, pid := range team {
+			if !participants[pid] {
+				return errors.New("invalid teams: " + pid + " is not a participant in this match")
+			}
+			if seen[pid] {
+				return errors.New("invalid teams: " + pid + " is on more than one team")
+			}
+			seen[pid] = true
+		}
+	}
+	if len(o.Teams) > 0 {
+		// A player left off every team would go unrated by the
+		// team-aware strategies. Players who left are rated apart from
+		// the teams anyway (see WithAbandons), and a backfill may have
+		// given their slot to a replacement.
+		for _, pid := range match.PlayerIDs() {
+			if !seen[pid] && match.LeaveOf(pid) == nil {
+				return errors.New("invalid teams: " + pid + " is not on a team")
+			}
+		}
+	}
+	if len(o.TeamPlacements) > 0 {
+		if len(o.TeamPlacements) != len(o.Teams) {
+			return errors.New("invalid team_placements: must have one entry per team")
+		}
+		for _, p := range o.TeamPlacements {
+			if p < 1 {
+				return errors.New("invalid team_placements: placements start at 1")
+			}
+		}
+	}
+
+	if len(o.Placements) == 0 && len(o.Scores) > 0 {
+		o.Placements = placementsFromScores(o.Scores)
+	}
+	if len(o.Placements) == 0 && len(o.TeamPlacements) > 0 {
+		o.Placements = map[string]int{}
+		for i, team := range o.Teams {
+			for _, pid := range team {
+				o.Placements[pid] = o.TeamPlacements[i]
+			}
+		}
+	}
+
+	if len(o.Teams) > 0 && len(o.TeamPlacements) == 0 {
+		o.TeamPlacements = make([]int, len(o.Teams))
+		for i, team := range o.Teams {
+			best := math.MaxInt32
+			for _, pid := range team {
+				if p := o.placementOf(pid); p < best {
+					best = p
+				}
+			}
+			o.TeamPlacements[i] = best
+		}
+	}
+
+	if len(o.WinnerIDs) > 0 && len(o.Placements) > 0 {
+		best := math.MaxInt32
+		for _, p := range o.Placements {
+			best = min(best, p)
+		}
+		for _, w := range o.WinnerIDs {
+			if o.placementOf(w) != best {
+				return errors.New("invalid winner_ids: " + w + " does not hold the best placement")
+			}
+		}
+	}
+	if len(o.WinnerIDs) == 0 && len(o.Placements) > 0 {
+		for pid, p := range o.Placements {
+			if p == 1 {
+				o.WinnerIDs = append(o.WinnerIDs, pid)
+			}
+		}
+		sort.Strings(o.WinnerIDs)
+	}
+	return nil
+}
+
+// WithAbandons returns a copy of the outcome, for rating, in which
+// every player in abandoners finishes behind everyone who stayed,
+// whatever the report said about them. Abandoners are dropped from
+// WinnerIDs and placed last among playerIDs; on team matches they're
+// split off their team (or, replaced by a backfill, left off every
+// team) into teams of their own placed last, so their teammates' result
+// doesn't carry them. Players the report left
+// unplaced still finish ahead of abandoners.
+func (o MatchOutcome) WithAbandons(playerIDs, abandoners []string) MatchOutcome {
+	if len(abandoners) == 0 {
+		return o
+	}
+	left := make(map[string]bool, len(abandoners))
+	for _, pid := range abandoners {
+		left[pid] = true
+	}
+
+	out := o
+	out.WinnerIDs = make([]string, 0, len(o.WinnerIDs))
+	for _, w := range o.WinnerIDs {
+		if !left[w] {
+			out.WinnerIDs = append(out.WinnerIDs, w)
+		}
+	}
+
+	placements := make(map[string]int, len(playerIDs))
+	var unplaced []string
+	worst := 1
+	for _, pid := range playerIDs {
+		if left[pid] {
+			continue
+		}
+		p := o.placementOf(pid)
+		if p == math.MaxInt32 {
+			unplaced = append(unplaced, pid)
+			continue
+		}
+		placements[pid] = p
+		worst = max(worst, p)
+	}
+	for _, pid := range unplaced {
+		placements[pid] = worst + 1
+	}
+	for _, pid := range playerIDs {
+		if left[pid] {
+			placements[pid] = worst + 2
+		}
+	}
+	out.Placements = placements
+
+	if len(o.Teams) > 0 {
+		out.Teams = make([][]string, 0, len(o.Teams)+len(abandoners))
+		out.TeamPlacements = make([]int, 0, len(o.Teams)+len(abandoners))
+		var solo []string
+		worstTeam := 1
+		onTeam := make(map[string]bool, len(playerIDs))
+		for i, team := range o.Teams {
+			stayed := make([]string, 0, len(team))
+			for _, pid := range team {
+				onTeam[pid] = true
+				if left[pid] {
+					solo = append(solo, pid)
+				} else {
+					stayed = append(stayed, pid)
+				}
+			}
+			if len(stayed) == 0 {
+				continue
+			}
+			placement := 1
+			if i < len(o.TeamPlacements) {
+				placement = o.TeamPlacements[i]
+			}
+			out.Teams = append(out.Teams, stayed)
+			out.TeamPlacements = append(out.TeamPlacements, placement)
+			worstTeam = max(worstTeam, placement)
+		}
+		for _, pid := range playerIDs {
+			if left[pid] && !onTeam[pid] {
+				solo = append(solo, pid)
+			}
+		}
+		for _, pid := range solo {
+			out.Teams = append(out.Teams, []string{pid})
+			out.TeamPlacements = append(out.TeamPlacements, worstTeam+1)
+		}
+	}
+	return out
+}
+
+// Agrees reports whether o and other describe the same result for
+// playerIDs: every pair of players finishes in the same order (or ties
+// in both), and they're split into the same teams. Raw placement
+// numbers and scores may differ. Both outcomes should be normalized.
+func (o *MatchOutcome) Agrees(other *MatchOutcome, playerIDs []string) bool {
+	for i, a := range playerIDs {
+		for _, b := range playerIDs[i+1:] {
+			if o.pairScore(a, b) != other.pairScore(a, b) {
+				return false
+			}
+		}
+	}
+	team, otherTeam := teamIndex(o.Teams), teamIndex(other.Teams)
+	for i, a := range playerIDs {
+		for _, b := range playerIDs[i+1:] {
+			if sameTeam(team, a, b) != sameTeam(otherTeam, a, b) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// teamIndex maps each player in teams to the index of their team.
+func teamIndex(teams [][]string) map[string]int {
+	index := map[string]int{}
+	for i, team := range teams {
+		for _, pid := range team {
+			index[pid] = i
+		}
+	}
+	return index
+}
+
+// sameTeam reports whether a and b are on the same team in index.
+// Players on no team are only ever with themselves.
+func sameTeam(index map[string]int, a, b string) bool {
+	ta, okA := index[a]
+	tb, okB := index[b]
+	return okA && okB && ta == tb
+}
+
+// placementOf returns pid's finishing position. With explicit
+// placements, a player missing from the map is treated as finishing
+// behind everyone listed. Without them, winners place 1st and everyone
+// else 2nd.
+func (o *MatchOutcome) placementOf(pid string) int {
+	if len(o.Placements) > 0 {
+		if p, ok := o.Placements[pid]; ok {
+			return p
+		}
+		return math.MaxInt32
+	}
+	for _, w := range o.WinnerIDs {
+		if w == pid {
+			return 1
+		}
+	}
+	return 2
+}
+
+// pairScore is a's result against b for pairwise rating strategies:
+// 1 if a finished ahead, 0 if behind, 0.5 for a tie.
+func (o *MatchOutcome) pairScore(a, b string) float64 {
+	pa, pb := o.placementOf(a), o.placementOf(b)
+	switch {
+	case pa < pb:
+		return 1.0
+	case pa > pb:
+		return 0.0
+	default:
+		return 0.5
+	}
+}
+
+// placementsFromScores ranks players by descending score using standard
+// competition ranking: tied scores share a placement and the next
+// placement skips accordingly (1, 2, 2, 4).
+func placementsFromScores(scores map[string]float64) map[string]int {
+	out := make(map[string]int, len(scores))
+	for pid, s := range scores {
+		rank := 1
+		for _, other := range scores {
+			if other > s {
+				rank++
+			}
+		}
+		out[pid] = rank
+	}
+	return out
+}
@@ -189,12 +189,105 @@ func Migrate() error {
 				return nil
 			},
 		},
+		{
+			// match_results_game_queue_id records which queue each
+			// result came from, so a queue's ratings can be recalculated
+			// by replaying its results. Existing rows are attributed
+			// from their rating history where possible (exact for rated
+			// matches), then from the game's queue when the game only
+			// has one. Anything still ambiguous stays NULL and is left
+			// out of recalculations.
+			//
+			// Also adds unrated; past GC timeouts never adjusted
+			// ratings, so they're marked unrated up front.
+			ID: "match_results_game_queue_id",
+			Migrate: func(tx *gorm.DB) error {
+				tx.Exec(`SELECT pg_advisory_lock(42)`)
+				defer tx.Exec(`SELECT pg_advisory_unlock(42)`)
+
+				if !tx.Migrator().HasColumn(&MatchResult{}, "game_queue_id") {
+					if err := tx.Exec(`ALTER TABLE match_results ADD COLUMN game_queue_id uuid`).Error; err != nil {
+						return err
+					}
+				}
+				if !tx.Migrator().HasColumn(&MatchResult{}, "unrated") {
+					if err := tx.Exec(`ALTER TABLE match_results ADD COLUMN unrated boolean NOT NULL DEFAULT false`).Error; err != nil {
+						return err
+					}
+					if err := tx.Exec(`UPDATE match_results SET unrated = true WHERE result = 'timeout'`).Error; err != nil {
+						return err
+					}
+				}
+
+				if tx.Migrator().HasTable(&RatingChange{}) {
+					if err := tx.Exec(`
+						UPDATE match_results mr
+						SET game_queue_id = (
+							SELECT rc.game_queue_id FROM rating_changes rc
+							WHERE rc.match_result_id = mr.id
+							LIMIT 1
+						)
+						WHERE mr.game_queue_id IS NULL
+					`).Error; err != nil {
+						return err
+					}
+				}
+				return tx.Exec(`
+					UPDATE match_results mr
+					SET game_queue_id = (
+						SELECT gq.id FROM game_queues gq WHERE gq.game_id = mr.game_id
+					)
+					WHERE mr.game_queue_id IS NULL
+					  AND (SELECT COUNT(*) FROM game_queues gq WHERE gq.game_id = mr.game_id) = 1
+				`).Error
+			},
+		},
+		{
+			// ratings_games_played backfills each rating's games_played
+			// from the rated results its player has in the queue, so
+			// turning on placement matches doesn't treat established
+			// players as provisional. Only results that moved the
+			// player's rating (a rating_changes row) count, not unrated
+			// results or ones that couldn't be attributed to a queue.
+			// Databases from before rating history have no record of
+			// who was rated and are left at 0.
+			ID: "ratings_games_played",
+			Migrate: func(tx *gorm.DB) error {
+				tx.Exec(`SELECT pg_advisory_lock(42)`)
+				defer tx.Exec(`SELECT pg_advisory_unlock(42)`)
+
+				if !tx.Migrator().HasTable(&Rating{}) || !tx.Migrator().HasTable(&MatchResult{}) {
+					return nil
+				}
+				if !tx.Migrator().HasColumn(&Rating{}, "games_played") {
+					if err := tx.Exec(`ALTER TABLE ratings ADD COLUMN games_played integer NOT NULL DEFAULT 0`).Error; err != nil {
+						return err
+					}
+				}
+				if !tx.Migrator().HasTable(&RatingChange{}) {
+					return nil
+				}
+				return tx.Exec(`
+					UPDATE ratings r
+					SET games_played = (
+						SELECT COUNT(*) FROM match_results mr
+						WHERE mr.game_queue_id::text = r.game_queue_id::text
+						  AND NOT mr.unrated
+						  AND EXISTS (
+							SELECT 1 FROM rating_changes rc
+							WHERE rc.match_result_id::text = mr.id::text
+							  AND rc.player_id::text = r.player_id::text
+						  )
+					)
+				`).Error
+			},
+		},
 	})
 
 	if err := m.Migrate(); err != nil {
 		return err
 	}
-	if err := server.S.DB.AutoMigrate(&User{}, &Game{}, &GameQueue{}, &Match{}, &MatchResult{}, &MachineHost{}, &ServerInstance{}, &Rating{}, &PlayerGameEntry{}); err != nil {
+	if err := server.S.DB.AutoMigrate(&User{}, &Game{}, &GameQueue{}, &Match{}, &MatchResult{}, &MachineHost{}, &ServerInstance{}, &Rating{}, &RatingChange{}, &PlayerGameEntry{}, &Season{}, &SeasonStanding{}, &RatingRecalculation{}, &PlayerPenalty{}, &PlayerBlock{}); err != nil {
 		return err
 	}
 
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/andy98725/elo-service/src/server"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PlayerPenalty is a player's matchmaking penalty standing in one game,
+// keyed by (player, game) so a lockout earned in one of the game's queues
+// covers them all. Guests are penalized like anyone else, so PlayerID
+// carries no user foreign key.
+//
+// Points decay lazily: DecayedAt is the point up to which decay has been
+// charged, and whole hours since then each shed the queue's
+// PenaltyDecayPerHour. LockedUntil is when the player may queue again
+// (nil or past = not locked out).
+type PlayerPenalty struct {
+	PlayerID    string     `json:"player_id" gorm:"primaryKey"`
+	GameID      string     `json:"game_id" gorm:"primaryKey;type:uuid"`
+	Game        Game       `json:"-" gorm:"foreignKey:GameID;constraint:OnDelete:CASCADE"`
+	Points      int        `json:"points" gorm:"not null;default:0"`
+	DecayedAt   time.Time  `json:"-" gorm:"not null"`
+	LockedUntil *time.Time `json:"locked_until"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// Offenses that earn penalty points. Each queue sets its own points per
+// offense.
+const (
+	// OffenseDecline is declining, or not answering, a ready check.
+	OffenseDecline = "decline"
+	// OffenseDodge is leaving /match/join after being paired, before
+	// match_found was delivered.
+	OffenseDodge = "dodge"
+	// OffenseAbandon is leaving a running match, as reported by its game
+	// server.
+	OffenseAbandon = "abandon"
+)
+
+// PenaltyLockoutStep is one step of a queue's lockout schedule: a
+// player who reaches Points penalty points is locked out of the game's
+// matchmaking for LockoutSeconds.
+type PenaltyLockoutStep struct {
+	Points         int `json:"points"`
+	LockoutSeconds int `json:"lockout_seconds"`
+}
+
+// DefaultPenaltyLockoutSteps is the schedule for queues that don't set
+// their own: a minute at first, escalating to two hours for repeat
+// offenders.
+var DefaultPenaltyLockoutSteps = []PenaltyLockoutStep{
+	{Points: 2, LockoutSeconds: 60},
+	{Points: 4, LockoutSeconds: 300},
+	{Points: 6, LockoutSeconds: 1800},
+	{Points: 10, LockoutSeconds: 7200},
+}
+
+const (
+	// MaxPenaltyLockoutSteps caps the length of a lockout schedule.
+	MaxPenaltyLockoutSteps = 16
+	// MaxPenaltyLockoutSeconds caps a single lockout at a week.
+	MaxPenaltyLockoutSeconds = 7 * 24 * 3600
+	// MaxPenaltyPoints caps the points one offense can cost.
+	MaxPenaltyPoints = 100
+)
+
+// PENALTY_DECAY_PERIOD is the unit PenaltyDecayPerHour is charged in.
+const PENALTY_DECAY_PERIOD = time.Hour
+
+// validatePenalties checks a queue's penalty settings. Steps need
+// positive, strictly increasing points, and lockouts that never shorten
+// as points grow.
+func validatePenalties(declinePoints, dodgePoints, abandonPoints, decayPerHour int, steps []PenaltyLockoutStep) error {
+	if declinePoints < 0 || declinePoints > MaxPenaltyPoints {
+		return fmt.Errorf("invalid penalty_decline_points: must be between 0 and %d", MaxPenaltyPoints)
+	}
+	if dodgePoints < 0 || dodgePoints > MaxPenaltyPoints {
+		return fmt.Errorf("invalid penalty_dodge_points: must be between 0 and %d", MaxPenaltyPoints)
+	}
+	if abandonPoints < 0 || abandonPoints > MaxPenaltyPoints {
+		return fmt.Errorf("invalid penalty_abandon_points: must be between 0 and %d", MaxPenaltyPoints)
+	}
+	if decayPerHour < 0 {
+		return errors.New("invalid penalty_decay_per_hour: must not be negative")
+	}
+	if len(steps) == 0 || len(steps) > MaxPenaltyLockoutSteps {
+		return fmt.Errorf("invalid penalty_lockout_steps: must have between 1 and %d steps", MaxPenaltyLockoutSteps)
+	}
+	for i, step := range steps {
+		if step.Points <= 0 {
+			return errors.New("invalid penalty_lockout_steps: points must be positive")
+		}
+		if step.LockoutSeconds <= 0 || step.LockoutSeconds > MaxPenaltyLockoutSeconds {
+			return fmt.Errorf("invalid penalty_lockout_steps: lockout_seconds must be between 1 and %d", MaxPenaltyLockoutSeconds)
+		}
+		if i == 0 {
+			continue
+		}
+		if step.Points <= steps[i-1].Points {
+			return errors.New("invalid penalty_lockout_steps: points must strictly increase")
+		}
+		if step.LockoutSeconds < steps[i-1].LockoutSeconds {
+			return errors.New("invalid penalty_lockout_steps: lockout_seconds must not shrink as points grow")
+		}
+	}
+	return nil
+}
+
+// encodePenaltyLockout serializes a validated schedule for storage.
+func encodePenaltyLockout(steps []PenaltyLockoutStep) json.RawMessage {
+	encoded, _ := json.Marshal(steps)
+	return encoded
+}
+
+// PenaltyLockoutSchedule decodes the queue's lockout schedule, falling
+// back to DefaultPenaltyLockoutSteps when none is stored.
+func (q *GameQueue) PenaltyLockoutSchedule() []PenaltyLockoutStep {
+	if len(q.PenaltyLockoutSteps) == 0 {
+		return DefaultPenaltyLockoutSteps
+	}
+	var steps []PenaltyLockoutStep
+	if err := json.Unmarshal(q.PenaltyLockoutSteps, &steps); err != nil || len(steps) == 0 {
+		slog.Warn("Failed to decode penalty lockout schedule; using default", "error", err, "gameQueueID", q.ID)
+		return DefaultPenaltyLockoutSteps
+	}
+	return steps
+}
+
+// PenaltyPoints is what an offense costs in this queue; 0 = not
+// penalized.
+func (q *GameQueue) PenaltyPoints(offense string) int {
+	switch offense {
+	case OffenseDecline:
+		return q.PenaltyDeclinePoints
+	case OffenseDodge:
+		return q.PenaltyDodgePoints
+	case OffenseAbandon:
+		return q.PenaltyAbandonPoints
+	}
+	return 0
+}
+
+// penaltyLockout is how long reaching points locks a player out under
+// the queue's schedule: the lockout of the highest step reached, or 0.
+func penaltyLockout(queue *GameQueue, points int) time.Duration {
+	lockout := 0
+	for _, step := range queue.PenaltyLockoutSchedule() {
+		if points < step.Points {
+			break
+		}
+		lockout = step.LockoutSeconds
+	}
+	return time.Duration(lockout) * time.Second
+}
+
+// decay charges the whole hours of decay owed since DecayedAt at
+// perHour points an hour.
+func (p *PlayerPenalty) decay(perHour int, now time.Time) {
+	hours := int(now.Sub(p.DecayedAt) / PENALTY_DECAY_PERIOD)
+	if hours < 1 {
+		return
+	}
+	p.DecayedAt = p.DecayedAt.Add(time.Duration(hours) * PENALTY_DECAY_PERIOD)
+	if perHour > 0 {
+		p.Points = max(0, p.Points-hours*perHour)
+	}
+}
+
+// IsLockedOut reports whether the player is locked out at now.
+func (p *PlayerPenalty) IsLockedOut(now time.Time) bool {
+	return p.LockedUntil != nil && p.LockedUntil.After(now)
+}
+
+// GetPlayerPenalty returns a player's standing in queue's game as of now,
+// with decay at the queue's rate applied (not persisted). A player with
+// no record gets a clean one.
+func GetPlayerPenalty(queue *GameQueue, playerID string, now time.Time) (*PlayerPenalty, error) {
+	var p PlayerPenalty
+	err := server.S.DB.First(&p, "player_id = ? AND game_id = ?", playerID, queue.GameID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &PlayerPenalty{PlayerID: playerID, GameID: queue.GameID, DecayedAt: now}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.decay(queue.PenaltyDecayPerHour, now)
+	return &p, nil
+}
+
+// RecordPenalty charges a player for an offense in queue: their points
+// decay to now, grow by the queue's points for the offense, and any
+// lockout step reached extends LockedUntil (an existing longer lockout
+// is kept). Returns the updated standing, or nil when the queue doesn't
+// penalize the offense.
+func RecordPenalty(queue *GameQueue, playerID string, offense string, now time.Time) (*PlayerPenalty, error) {
+	points := queue.PenaltyPoints(offense)
+	if points <= 0 {
+		return nil, nil
+	}
+	var p PlayerPenalty
+	err := server.S.DB.Transaction(func(tx *gorm.DB) error {
+		row := &PlayerPenalty{PlayerID: playerID, GameID: queue.GameID, DecayedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&p, "player_id = ? AND game_id = ?", playerID, queue.GameID).Error; err != nil {
+			return err
+		}
+		p.decay(queue.PenaltyDecayPerHour, now)
+		p.Points += points
+		if lockout := penaltyLockout(queue, p.Points); lockout > 0 {
+			until := now.Add(lockout).UTC()
+			if !p.IsLockedOut(until) {
+				p.LockedUntil = &until
+			}
+		}
+		return tx.Model(&p).Updates(map[string]interface{}{
+			"points":       p.Points,
+			"decayed_at":   p.DecayedAt,
+			"locked_until": p.LockedUntil,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/andy98725/elo-service/src/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PlayerBlock records that PlayerID has blocked BlockedID. The
+// matchmaker never groups two players when either has blocked the
+// other, in any game. Guests can block and be blocked, so neither side
+// carries a user foreign key.
+type PlayerBlock struct {
+	PlayerID  string    `json:"player_id" gorm:"primaryKey"`
+	BlockedID string    `json:"blocked_id" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// MaxPlayerBlocks caps how many players one player can block.
+const MaxPlayerBlocks = 500
+
+// BlockPlayer adds blockedID to playerID's block list. Blocking someone
+// already blocked is a no-op returning the existing block. A user ID must
+// belong to an existing user; guest IDs are taken as given.
+func BlockPlayer(playerID, blockedID string) (*PlayerBlock, error) {
+	if blockedID == playerID {
+		return nil, errors.New("invalid player_id: cannot block yourself")
+	}
+	if !util.IsGuestID(blockedID) {
+		if _, err := GetById(blockedID); err != nil {
+			return nil, err
+		}
+	}
+	var existing PlayerBlock
+	err := server.S.DB.First(&existing, "player_id = ? AND blocked_id = ?", playerID, blockedID).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	var count int64
+	if err := server.S.DB.Model(&PlayerBlock{}).Where("player_id = ?", playerID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= MaxPlayerBlocks {
+		return nil, fmt.Errorf("invalid player_id: block list is full (at most %d players)", MaxPlayerBlocks)
+	}
+	block := &PlayerBlock{PlayerID: playerID, BlockedID: blockedID, CreatedAt: time.Now().UTC()}
+	if err := server.S.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(block).Error; err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// UnblockPlayer removes blockedID from playerID's block list. Returns
+// gorm.ErrRecordNotFound when they weren't blocked.
+func UnblockPlayer(playerID, blockedID string) error {
+	res := server.S.DB.Where("player_id = ? AND blocked_id = ?", playerID, blockedID).Delete(&PlayerBlock{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetPlayerBlocks returns the players playerID has blocked, newest
+// first.
+func GetPlayerBlocks(playerID string) ([]PlayerBlock, error) {
+	var blocks []PlayerBlock
+	err := server.S.DB.Where("player_id = ?", playerID).Order("created_at DESC").Find(&blocks).Error
+	return blocks, err
+}
+
+// GetBlockedPairs returns, for each of playerIDs involved in a block,
+// the set of players on the other side of it — whichever way round the
+// block goes.
+func GetBlockedPairs(playerIDs []string) (map[string]map[string]bool, error) {
+	if len(playerIDs) == 0 {
+		return nil, nil
+	}
+	var blocks []PlayerBlock
+	err := server.S.DB.
+		Where("player_id IN ? OR blocked_id IN ?", playerIDs, playerIDs).
+		Find(&blocks).Error
+	if err != nil {
+		return nil, err
+	}
+	pairs := make(map[string]map[string]bool)
+	add := func(a, b string) {
+		if pairs[a] == nil {
+			pairs[a] = make(map[string]bool)
+		}
+		pairs[a][b] = true
+	}
+	for _, b := range blocks {
+		add(b.PlayerID, b.BlockedID)
+		add(b.BlockedID, b.PlayerID)
+	}
+	return pairs, nil
+}
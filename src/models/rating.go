@@ -13,15 +13,33 @@ import (
 // ladder — a "ranked 1v1" queue and a "casual 2v2" queue under the same
 // game keep independent ratings, even for the same player. The queue's
 // DefaultRating / KFactor / ELOStrategy decide initial value and update
-// behavior; see ApplyClassicElo.
+// behavior; see ApplyClassicElo and ApplyGlicko2.
+//
+// Deviation and Volatility are only meaningful for glicko2 queues, and
+// Mu and Sigma only for trueskill queues; other strategies leave them at
+// their defaults. A Sigma of zero means the row has never been rated by
+// trueskill, and ApplyTrueSkill seeds it from Rating on first use.
+//
+// DecayedAt is the point up to which inactivity decay has been charged
+// (nil = never decayed). Decay writes leave UpdatedAt alone, so UpdatedAt
+// keeps meaning "last rated"; see ApplyRatingDecay.
+//
+// GamesPlayed counts rated matches in this queue under any strategy; it
+// drives the queue's placement (provisional) period.
 type Rating struct {
-	PlayerID    string    `json:"player_id" gorm:"primaryKey"`
-	Player      User      `json:"player" gorm:"foreignKey:PlayerID"`
-	GameQueueID string    `json:"game_queue_id" gorm:"primaryKey"`
-	GameQueue   GameQueue `json:"game_queue" gorm:"foreignKey:GameQueueID;constraint:OnDelete:CASCADE"`
-	Rating      int       `json:"rating" gorm:"not null"`
-	CreatedAt   time.Time `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	PlayerID    string     `json:"player_id" gorm:"primaryKey"`
+	Player      User       `json:"player" gorm:"foreignKey:PlayerID"`
+	GameQueueID string     `json:"game_queue_id" gorm:"primaryKey"`
+	GameQueue   GameQueue  `json:"game_queue" gorm:"foreignKey:GameQueueID;constraint:OnDelete:CASCADE"`
+	Rating      int        `json:"rating" gorm:"not null"`
+	Deviation   float64    `json:"deviation" gorm:"not null;default:350"`
+	Volatility  float64    `json:"volatility" gorm:"not null;default:0.06"`
+	Mu          float64    `json:"mu" gorm:"not null;default:0"`
+	Sigma       float64    `json:"sigma" gorm:"not null;default:0"`
+	GamesPlayed int        `json:"games_played" gorm:"not null;default:0"`
+	DecayedAt   *time.Time `json:"decayed_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
 }
 
 type RatingResp struct {
@@ -30,6 +48,11 @@ type RatingResp struct {
 	GameQueueID string        `json:"game_queue_id"`
 	GameQueue   GameQueueResp `json:"game_queue"`
 	Rating      int           `json:"rating"`
+	Deviation   float64       `json:"deviation"`
+	Mu          float64       `json:"mu"`
+	Sigma       float64       `json:"sigma"`
+	GamesPlayed int           `json:"games_played"`
+	Provisional bool          `json:"provisional"`
 }
 
 func (r *Rating) ToResp() *RatingResp {
@@ -39,18 +62,39 @@ func (r *Rating) ToResp() *RatingResp {
 		GameQueueID: r.GameQueueID,
 		GameQueue:   *r.GameQueue.ToResp(),
 		Rating:      r.Rating,
+		Deviation:   r.CurrentDeviation(time.Now()),
+		Mu:          r.Mu,
+		Sigma:       r.Sigma,
+		GamesPlayed: r.GamesPlayed,
+		Provisional: r.IsProvisional(&r.GameQueue),
 	}
 }
 
+// IsProvisional reports whether the player is still in the queue's
+// placement period.
+func (r *Rating) IsProvisional(queue *GameQueue) bool {
+	return r.GamesPlayed < queue.PlacementMatches
+}
+
+// CurrentDeviation returns the row's rating deviation as of now,
+// inflated for the Glicko-2 rating periods elapsed since the row was
+// last updated. The stored Deviation is only rewritten when the player
+// finishes a match, so reads use this to reflect uncertainty growing
+// with inactivity.
+func (r *Rating) CurrentDeviation(now time.Time) float64 {
+	return glicko2InflateDeviation(r.Deviation, r.Volatility, r.UpdatedAt, now)
+}
+
 // GetLeaderboard returns the top-rated players for a queue, paginated.
 // Ordered by rating descending, with player_id as a stable tiebreaker so
-// pages don't reshuffle on equal ratings. Preloads Player so the response
+// pages don't reshuffle on equal ratings. Provisional players (still in
+// their placement matches) are left off. Preloads Player so the response
 // can include usernames.
-func GetLeaderboard(gameQueueID string, page, pageSize int) ([]Rating, int, error) {
+func GetLeaderboard(queue *GameQueue, page, pageSize int) ([]Rating, int, error) {
 	var ratings []Rating
 	offset := page * pageSize
 	result := server.S.DB.Preload("Player").
-		Where("game_queue_id = ?", gameQueueID).
+		Where("game_queue_id = ? AND games_played >= ?", queue.ID, queue.PlacementMatches).
 		Order("rating DESC, player_id ASC").
 		Offset(offset).Limit(pageSize).
 		Find(&ratings)
@@ -115,6 +159,8 @@ func GetRating(playerID, gameQueueID string) (*Rating, error) {
 			PlayerID:    playerID,
 			GameQueueID: gameQueueID,
 			Rating:      queue.DefaultRating,
+			Deviation:   GLICKO2_DEFAULT_DEVIATION,
+			Volatility:  GLICKO2_DEFAULT_VOLATILITY,
 		}
 		if err := server.S.DB.Create(&rating).Error; err != nil {
 			return nil, err
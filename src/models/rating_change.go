@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/andy98725/elo-service/src/server"
+)
+
+const (
+	// RatingChangeReasonMatch marks a change applied by MatchEnded when a
+	// rated match finished.
+	RatingChangeReasonMatch = "match"
+
+	// bulkInsertBatchSize bounds the rows per INSERT when the worker
+	// writes history for a whole queue at once.
+	bulkInsertBatchSize = 500
+)
+
+// RatingChange is one append-only entry in a player's rating timeline:
+// the rating before and after a single update, and what caused it.
+// Written in the same transaction as the Rating row it describes, so
+// the history can never disagree with the current rating.
+//
+// MatchResultID is set for match-driven changes and nil for anything
+// that isn't tied to a specific match.
+type RatingChange struct {
+	ID            string       `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	PlayerID      string       `json:"player_id" gorm:"not null;index:idx_rating_change_player_queue"`
+	Player        User         `json:"-" gorm:"foreignKey:PlayerID;constraint:OnDelete:CASCADE"`
+	GameQueueID   string       `json:"game_queue_id" gorm:"not null;index:idx_rating_change_player_queue"`
+	GameQueue     GameQueue    `json:"-" gorm:"foreignKey:GameQueueID;constraint:OnDelete:CASCADE"`
+	MatchResultID *string      `json:"match_result_id" gorm:"index"`
+	MatchResult   *MatchResult `json:"-" gorm:"foreignKey:MatchResultID"`
+	Reason        string       `json:"reason" gorm:"not null"`
+	Before        int          `json:"before" gorm:"not null"`
+	After         int          `json:"after" gorm:"not null"`
+	Delta         int          `json:"delta" gorm:"not null"`
+	CreatedAt     time.Time    `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+type RatingChangeResp struct {
+	PlayerID      string    `json:"player_id"`
+	GameQueueID   string    `json:"game_queue_id"`
+	MatchResultID *string   `json:"match_result_id"`
+	Reason        string    `json:"reason"`
+	Before        int       `json:"before"`
+	After         int       `json:"after"`
+	Delta         int       `json:"delta"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (c *RatingChange) ToResp() *RatingChangeResp {
+	return &RatingChangeResp{
+		PlayerID:      c.PlayerID,
+		GameQueueID:   c.GameQueueID,
+		MatchResultID: c.MatchResultID,
+		Reason:        c.Reason,
+		Before:        c.Before,
+		After:         c.After,
+		Delta:         c.Delta,
+		CreatedAt:     c.CreatedAt,
+	}
+}
+
+// newRatingChange builds an unsaved history entry for a rating row that
+// moved from before to r.Rating. CreatedAt is stamped in Go for the same
+// reason as queueFromParams: the SQLite test harness stores
+// CURRENT_TIMESTAMP at second resolution, which would make the timeline
+// order ambiguous for back-to-back matches.
+func newRatingChange(r *Rating, before int, reason string) RatingChange {
+	return RatingChange{
+		PlayerID:    r.PlayerID,
+		GameQueueID: r.GameQueueID,
+		Reason:      reason,
+		Before:      before,
+		After:       r.Rating,
+		Delta:       r.Rating - before,
+		CreatedAt:   time.Now().UTC(),
+	}
+}
+
+// GetRatingHistory returns a player's rating changes in one queue, newest
+// first, paginated.
+func GetRatingHistory(playerID, gameQueueID string, page, pageSize int) ([]RatingChange, int, error) {
+	var changes []RatingChange
+	offset := page * pageSize
+	result := server.S.DB.
+		Where("player_id = ? AND game_queue_id = ?", playerID, gameQueueID).
+		Order("created_at DESC, id DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&changes)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	nextPage := page + 1
+	if result.RowsAffected < int64(pageSize) {
+		nextPage = -1
+	}
+	return changes, nextPage, nil
+}
@@ -0,0 +1,461 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/andy98725/elo-service/src/server"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	RatingRecalculationStatusPending   = "pending"
+	RatingRecalculationStatusRunning   = "running"
+	RatingRecalculationStatusCompleted = "completed"
+	RatingRecalculationStatusFailed    = "failed"
+
+	// RatingChangeReasonRecalculation marks the net adjustment an admin
+	// recalculation made to a player's rating.
+	RatingChangeReasonRecalculation = "recalculation"
+)
+
+// RatingRecalculationLease is how long a running job's claim lasts
+// without being renewed. The worker running it renews the lease well
+// inside that; a job whose lease has run out was left behind by a worker
+// that died.
+const RatingRecalculationLease = 2 * time.Minute
+
+var ErrRatingRecalculationNotFound = errors.New("recalculation not found")
+
+// RatingRecalculation is an admin-requested job that rebuilds a queue's
+// ratings by replaying its match results through the queue's current
+// rating strategy and settings. Created pending by the API and run by
+// the worker (see RecalculateQueueRatings); live progress is kept in
+// Redis while it runs, and Processed/Total are written here when it
+// finishes.
+//
+// Diff is the JSON-encoded []RatingDiffEntry of every rating the replay
+// changed. For a dry run it's the only output — nothing else is written.
+//
+// LeaseExpiresAt is when a running job's claim lapses unless the worker
+// running it renews it (see RenewRatingRecalculationLease).
+type RatingRecalculation struct {
+	ID             string          `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	GameQueueID    string          `json:"game_queue_id" gorm:"not null;index"`
+	GameQueue      GameQueue       `json:"-" gorm:"foreignKey:GameQueueID;constraint:OnDelete:CASCADE"`
+	RequestedByID  string          `json:"requested_by_id" gorm:"not null"`
+	DryRun         bool            `json:"dry_run" gorm:"not null;default:false"`
+	Status         string          `json:"status" gorm:"not null;index"`
+	Total          int             `json:"total" gorm:"not null;default:0"`
+	Processed      int             `json:"processed" gorm:"not null;default:0"`
+	Diff           json.RawMessage `json:"diff" gorm:"type:jsonb"`
+	Error          string          `json:"error"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	StartedAt      *time.Time      `json:"started_at"`
+	FinishedAt     *time.Time      `json:"finished_at"`
+	LeaseExpiresAt *time.Time      `json:"-"`
+}
+
+// RatingDiffEntry is one player's rating before and after a
+// recalculation.
+type RatingDiffEntry struct {
+	PlayerID string `json:"player_id"`
+	Before   int    `json:"before"`
+	After    int    `json:"after"`
+	Delta    int    `json:"delta"`
+}
+
+type RatingRecalculationResp struct {
+	ID          string            `json:"id"`
+	GameQueueID string            `json:"game_queue_id"`
+	DryRun      bool              `json:"dry_run"`
+	Status      string            `json:"status"`
+	Total       int               `json:"total"`
+	Processed   int               `json:"processed"`
+	Diff        []RatingDiffEntry `json:"diff"`
+	Error       string            `json:"error,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	StartedAt   *time.Time        `json:"started_at"`
+	FinishedAt  *time.Time        `json:"finished_at"`
+}
+
+func (r *RatingRecalculation) ToResp() *RatingRecalculationResp {
+	diff := []RatingDiffEntry{}
+	if len(r.Diff) > 0 {
+		_ = json.Unmarshal(r.Diff, &diff)
+	}
+	return &RatingRecalculationResp{
+		ID:          r.ID,
+		GameQueueID: r.GameQueueID,
+		DryRun:      r.DryRun,
+		Status:      r.Status,
+		Total:       r.Total,
+		Processed:   r.Processed,
+		Diff:        diff,
+		Error:       r.Error,
+		CreatedAt:   r.CreatedAt,
+		StartedAt:   r.StartedAt,
+		FinishedAt:  r.FinishedAt,
+	}
+}
+
+// CreateRatingRecalculation queues a recalculation of gameQueueID for
+// the worker.
+func CreateRatingRecalculation(gameQueueID, requestedByID string, dryRun bool) (*RatingRecalculation, error) {
+	job := &RatingRecalculation{
+		GameQueueID:   gameQueueID,
+		RequestedByID: requestedByID,
+		DryRun:        dryRun,
+		Status:        RatingRecalculationStatusPending,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := server.S.DB.Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func GetRatingRecalculation(id string) (*RatingRecalculation, error) {
+	var job RatingRecalculation
+	err := server.S.DB.First(&job, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRatingRecalculationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ClaimNextRatingRecalculation flips the oldest pending job to running,
+// leased for RatingRecalculationLease, and returns it, or nil when none
+// are pending. The conditional UPDATE makes the claim safe against
+// another worker racing for the same row.
+func ClaimNextRatingRecalculation() (*RatingRecalculation, error) {
+	for {
+		var job RatingRecalculation
+		err := server.S.DB.Where("status = ?", RatingRecalculationStatusPending).
+			Order("created_at ASC, id ASC").
+			First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().UTC()
+		lease := now.Add(RatingRecalculationLease)
+		res := server.S.DB.Model(&RatingRecalculation{}).
+			Where("id = ? AND status = ?", job.ID, RatingRecalculationStatusPending).
+			Updates(map[string]interface{}{
+				"status":           RatingRecalculationStatusRunning,
+				"started_at":       now,
+				"lease_expires_at": lease,
+			})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			job.Status = RatingRecalculationStatusRunning
+			job.StartedAt = &now
+			job.LeaseExpiresAt = &lease
+			return &job, nil
+		}
+	}
+}
+
+// RenewRatingRecalculationLease extends a running job's lease by
+// RatingRecalculationLease from now.
+func RenewRatingRecalculationLease(job *RatingRecalculation) error {
+	lease := time.Now().UTC().Add(RatingRecalculationLease)
+	if err := server.S.DB.Model(&RatingRecalculation{}).
+		Where("id = ? AND status = ?", job.ID, RatingRecalculationStatusRunning).
+		Update("lease_expires_at", lease).Error; err != nil {
+		return err
+	}
+	job.LeaseExpiresAt = &lease
+	return nil
+}
+
+// FailExpiredRatingRecalculations marks running jobs whose lease has run
+// out — left behind by a worker that died mid-replay — as failed. Jobs
+// another live worker is still renewing are left alone. The replay is a
+// single transaction, so an interrupted job changed nothing and can
+// simply be requested again.
+func FailExpiredRatingRecalculations() error {
+	now := time.Now().UTC()
+	return server.S.DB.Model(&RatingRecalculation{}).
+		Where("status = ? AND (lease_expires_at IS NULL OR lease_expires_at < ?)", RatingRecalculationStatusRunning, now).
+		Updates(map[string]interface{}{
+			"status":      RatingRecalculationStatusFailed,
+			"error":       "interrupted: the worker running it stopped",
+			"finished_at": now,
+		}).Error
+}
+
+// FinishRatingRecalculation records a job's outcome.
+func FinishRatingRecalculation(job *RatingRecalculation, processed, total int, diff []RatingDiffEntry, runErr error) error {
+	now := time.Now().UTC()
+	updates := map[string]interface{}{
+		"status":      RatingRecalculationStatusCompleted,
+		"processed":   processed,
+		"total":       total,
+		"finished_at": now,
+	}
+	if runErr != nil {
+		updates["status"] = RatingRecalculationStatusFailed
+		updates["error"] = runErr.Error()
+	} else {
+		if diff == nil {
+			diff = []RatingDiffEntry{}
+		}
+		raw, err := json.Marshal(diff)
+		if err != nil {
+			return err
+		}
+		updates["diff"] = raw
+	}
+	return server.S.DB.Model(job).Updates(updates).Error
+}
+
+// RecalculateQueueRatings rebuilds a queue's ratings from its history:
+//
+//  1. snapshots every rating row in the queue,
+//  2. resets each to the queue's starting state in memory,
+//  3. replays each rated MatchResult of the queue oldest-first through
+//     the queue's current ELOStrategy and settings, applying archived
+//     season soft resets at the points they originally happened, and
+//     stamping each row's UpdatedAt with its last replayed match so
+//     glicko2 inflation and inactivity decay keep the real timeline,
+//  4. diffs the result against the snapshot.
+//
+// The replay runs in memory, so a dry run takes no locks, writes nothing
+// and only returns the diff. A real run does it all in one transaction:
+// it locks the queue and every rating row in it (player-ID order, like
+// ApplyClassicElo) up front, writes the rebuilt rows once the replay is
+// done, and appends one "recalculation" RatingChange per player whose
+// rating moved; per-match history rows and match results are left as
+// they were. Inactivity decay isn't replayed — it resumes from each
+// player's last match on the next worker tick.
+//
+// A match in the queue that finishes during a real run blocks on the
+// row locks until the whole replay commits, then applies on top of the
+// rebuilt ratings; schedule large queues' recalculations off-peak.
+//
+// progress is called after each replayed result with (done, total).
+func RecalculateQueueRatings(gameQueueID string, dryRun bool, progress func(done, total int)) ([]RatingDiffEntry, int, error) {
+	var diff []RatingDiffEntry
+	var total int
+	run := func(tx *gorm.DB) error {
+		lock := func(q *gorm.DB) *gorm.DB {
+			if dryRun {
+				return q
+			}
+			return q.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+
+		var queue GameQueue
+		if err := lock(tx).First(&queue, "id = ?", gameQueueID).Error; err != nil {
+			return err
+		}
+
+		var existing []Rating
+		if err := lock(tx).
+			Where("game_queue_id = ?", queue.ID).
+			Order("player_id ASC").
+			Find(&existing).Error; err != nil {
+			return err
+		}
+		before := make(map[string]int, len(existing))
+		rows := make(map[string]*Rating, len(existing))
+		for i := range existing {
+			r := &existing[i]
+			before[r.PlayerID] = r.Rating
+			r.Rating = queue.DefaultRating
+			r.Deviation = GLICKO2_DEFAULT_DEVIATION
+			r.Volatility = GLICKO2_DEFAULT_VOLATILITY
+			r.Mu, r.Sigma = 0, 0
+			r.GamesPlayed = 0
+			r.DecayedAt = nil
+			rows[r.PlayerID] = r
+		}
+
+		var seasons []Season
+		if err := tx.Where("game_queue_id = ?", queue.ID).
+			Order("number ASC").
+			Find(&seasons).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&MatchResult{}).
+			Where("game_queue_id = ? AND unrated = ?", queue.ID, false).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		total = int(count)
+
+		resetSeasonsBefore := func(at time.Time) {
+			for len(seasons) > 0 && !seasons[0].EndedAt.After(at) {
+				for _, r := range rows {
+					softResetRating(r, float64(queue.DefaultRating), seasons[0].SoftReset)
+				}
+				seasons = seasons[1:]
+			}
+		}
+
+		done := 0
+		for offset := 0; offset < total; offset += bulkInsertBatchSize {
+			var batch []MatchResult
+			if err := tx.Preload("Players").
+				Where("game_queue_id = ? AND unrated = ?", queue.ID, false).
+				Order("created_at ASC, id ASC").
+				Offset(offset).Limit(bulkInsertBatchSize).
+				Find(&batch).Error; err != nil {
+				return err
+			}
+			for _, result := range batch {
+				resetSeasonsBefore(result.CreatedAt)
+				playerIDs := make([]string, 0, len(result.Players)+len(result.GuestIDs))
+				for _, p := range result.Players {
+					playerIDs = append(playerIDs, p.ID)
+				}
+				playerIDs = append(playerIDs, []string(result.GuestIDs)...)
+				outcome := result.RatedOutcome()
+				outcome.PlayedAt = result.CreatedAt
+
+				for _, r := range replayRatingStrategy(&queue, rows, playerIDs, outcome) {
+					r.UpdatedAt = result.CreatedAt
+				}
+
+				done++
+				if progress != nil {
+					progress(done, total)
+				}
+			}
+		}
+		resetSeasonsBefore(time.Now())
+
+		rebuilt := make([]*Rating, 0, len(rows))
+		for _, r := range rows {
+			rebuilt = append(rebuilt, r)
+		}
+		sort.Slice(rebuilt, func(i, j int) bool {
+			return rebuilt[i].PlayerID < rebuilt[j].PlayerID
+		})
+		var changes []RatingChange
+		for _, r := range rebuilt {
+			old, ok := before[r.PlayerID]
+			if !ok {
+				old = queue.DefaultRating
+			}
+			if old == r.Rating {
+				continue
+			}
+			diff = append(diff, RatingDiffEntry{
+				PlayerID: r.PlayerID,
+				Before:   old,
+				After:    r.Rating,
+				Delta:    r.Rating - old,
+			})
+			changes = append(changes, newRatingChange(r, old, RatingChangeReasonRecalculation))
+		}
+		if dryRun {
+			return nil
+		}
+
+		for _, r := range rebuilt {
+			if _, ok := before[r.PlayerID]; !ok {
+				if err := tx.Create(r).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Model(r).UpdateColumns(map[string]interface{}{
+				"rating":       r.Rating,
+				"deviation":    r.Deviation,
+				"volatility":   r.Volatility,
+				"mu":           r.Mu,
+				"sigma":        r.Sigma,
+				"games_played": r.GamesPlayed,
+				"decayed_at":   nil,
+				"updated_at":   r.UpdatedAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if len(changes) > 0 {
+			if err := tx.CreateInBatches(changes, bulkInsertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if dryRun {
+		err = run(server.S.DB)
+	} else {
+		err = server.S.DB.Transaction(run)
+	}
+	if err != nil {
+		return nil, total, err
+	}
+	return diff, total, nil
+}
+
+// replayRatingStrategy is applyRatingStrategy against rows held in
+// memory: it rates one result into rows, adding a starting row for any
+// rated player who has none yet, and returns the rows it rated.
+func replayRatingStrategy(queue *GameQueue, rows map[string]*Rating, playerIDs []string, outcome MatchOutcome) []*Rating {
+	rowFor := func(pid string) *Rating {
+		r, ok := rows[pid]
+		if !ok {
+			r = &Rating{
+				PlayerID:    pid,
+				GameQueueID: queue.ID,
+				Rating:      queue.DefaultRating,
+				Deviation:   GLICKO2_DEFAULT_DEVIATION,
+				Volatility:  GLICKO2_DEFAULT_VOLATILITY,
+				UpdatedAt:   outcome.playedAt(),
+			}
+			rows[pid] = r
+		}
+		return r
+	}
+
+	rowsFor := func(pids []string) []*Rating {
+		ratings := make([]*Rating, 0, len(pids))
+		for _, pid := range pids {
+			ratings = append(ratings, rowFor(pid))
+		}
+		return ratings
+	}
+
+	switch queue.ELOStrategy {
+	case ELO_STRATEGY_CLASSIC, ELO_STRATEGY_GLICKO2:
+		nonGuests := ratedPlayerIDs(playerIDs)
+		if len(nonGuests) < 2 {
+			return nil
+		}
+		ratings := rowsFor(nonGuests)
+		if queue.ELOStrategy == ELO_STRATEGY_CLASSIC {
+			rateClassicElo(queue, ratings, outcome)
+		} else {
+			rateGlicko2(queue, ratings, outcome)
+		}
+		return ratings
+	case ELO_STRATEGY_TRUESKILL:
+		teams, placements := trueSkillTeams(playerIDs, outcome)
+		if len(teams) < 2 {
+			return nil
+		}
+		ratings := rowsFor(ratedPlayerIDs(flattenTeams(teams)))
+		rateTrueSkill(queue, teams, placements, rows)
+		return ratings
+	}
+	return nil
+}
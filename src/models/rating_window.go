@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// RatingWindowStep is one step of a rating-matchmaking queue's window
+// schedule: once the longest-waiting entry has waited AfterSeconds, a
+// group may span up to MaxSpread rating points. MaxSpread 0 means
+// unbounded.
+type RatingWindowStep struct {
+	AfterSeconds int `json:"after_seconds"`
+	MaxSpread    int `json:"max_spread"`
+}
+
+// DefaultRatingWindowSteps is the schedule for queues that don't set
+// their own: tight at first, loose after 30s, unbounded after 60s so no
+// one starves on a sparse queue.
+var DefaultRatingWindowSteps = []RatingWindowStep{
+	{AfterSeconds: 0, MaxSpread: 100},
+	{AfterSeconds: 30, MaxSpread: 300},
+	{AfterSeconds: 60, MaxSpread: 0},
+}
+
+// MaxRatingWindowSteps caps the length of a window schedule.
+const MaxRatingWindowSteps = 16
+
+// validateRatingWindow checks a window schedule and cap. Steps start at
+// 0 seconds, wait times strictly increase, and the window never narrows
+// as the wait grows; an unbounded step must come last. A negative cap
+// is rejected; 0 means no cap.
+func validateRatingWindow(steps []RatingWindowStep, windowCap int) error {
+	if len(steps) == 0 || len(steps) > MaxRatingWindowSteps {
+		return fmt.Errorf("invalid rating_window_steps: must have between 1 and %d steps", MaxRatingWindowSteps)
+	}
+	if steps[0].AfterSeconds != 0 {
+		return errors.New("invalid rating_window_steps: the first step must start at after_seconds 0")
+	}
+	for i, step := range steps {
+		if step.MaxSpread < 0 {
+			return errors.New("invalid rating_window_steps: max_spread must not be negative")
+		}
+		if i == 0 {
+			continue
+		}
+		prev := steps[i-1]
+		if step.AfterSeconds <= prev.AfterSeconds {
+			return errors.New("invalid rating_window_steps: after_seconds must strictly increase")
+		}
+		if prev.MaxSpread == 0 {
+			return errors.New("invalid rating_window_steps: an unbounded step (max_spread 0) must be the last")
+		}
+		if step.MaxSpread != 0 && step.MaxSpread < prev.MaxSpread {
+			return errors.New("invalid rating_window_steps: max_spread must not shrink as the wait grows")
+		}
+	}
+	if windowCap < 0 {
+		return errors.New("invalid rating_window_cap: must not be negative")
+	}
+	return nil
+}
+
+// encodeRatingWindow serializes a validated schedule for storage.
+func encodeRatingWindow(steps []RatingWindowStep) json.RawMessage {
+	encoded, _ := json.Marshal(steps)
+	return encoded
+}
+
+// RatingWindowSchedule decodes the queue's window schedule, falling back
+// to DefaultRatingWindowSteps when none is stored.
+func (q *GameQueue) RatingWindowSchedule() []RatingWindowStep {
+	if len(q.RatingWindowSteps) == 0 {
+		return DefaultRatingWindowSteps
+	}
+	var steps []RatingWindowStep
+	if err := json.Unmarshal(q.RatingWindowSteps, &steps); err != nil || len(steps) == 0 {
+		slog.Warn("Failed to decode rating window schedule; using default", "error", err, "gameQueueID", q.ID)
+		return DefaultRatingWindowSteps
+	}
+	return steps
+}
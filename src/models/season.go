@@ -0,0 +1,307 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/andy98725/elo-service/src/server"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// RatingChangeReasonSeasonReset marks the soft reset applied to every
+	// rating in a queue when its season rolls over.
+	RatingChangeReasonSeasonReset = "season_reset"
+)
+
+var ErrSeasonNotFound = errors.New("season not found")
+
+// Season is an archived, finished season of a queue. The season currently
+// being played lives on the GameQueue (SeasonNumber / SeasonStartedAt /
+// SeasonEndsAt); a Season row is written only when it rolls over, together
+// with a SeasonStanding per ranked (non-provisional) player frozen at that
+// moment.
+type Season struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	GameQueueID string    `json:"game_queue_id" gorm:"not null;uniqueIndex:idx_season_queue_number"`
+	GameQueue   GameQueue `json:"-" gorm:"foreignKey:GameQueueID;constraint:OnDelete:CASCADE"`
+	Number      int       `json:"number" gorm:"not null;uniqueIndex:idx_season_queue_number"`
+	StartedAt   time.Time `json:"started_at" gorm:"not null"`
+	EndedAt     time.Time `json:"ended_at" gorm:"not null"`
+	SoftReset   float64   `json:"soft_reset" gorm:"not null"`
+	PlayerCount int       `json:"player_count" gorm:"not null;default:0"`
+}
+
+type SeasonResp struct {
+	Number      int       `json:"number"`
+	StartedAt   time.Time `json:"started_at"`
+	EndedAt     time.Time `json:"ended_at"`
+	SoftReset   float64   `json:"soft_reset"`
+	PlayerCount int       `json:"player_count"`
+}
+
+func (s *Season) ToResp() *SeasonResp {
+	return &SeasonResp{
+		Number:      s.Number,
+		StartedAt:   s.StartedAt,
+		EndedAt:     s.EndedAt,
+		SoftReset:   s.SoftReset,
+		PlayerCount: s.PlayerCount,
+	}
+}
+
+// SeasonStanding is one player's final placement in an archived season.
+// Rank uses competition ranking (equal ratings share a rank, the next
+// rank skips), so it stays meaningful when the leaderboard has ties.
+type SeasonStanding struct {
+	SeasonID  string  `json:"season_id" gorm:"primaryKey"`
+	Season    Season  `json:"-" gorm:"foreignKey:SeasonID;constraint:OnDelete:CASCADE"`
+	PlayerID  string  `json:"player_id" gorm:"primaryKey"`
+	Player    User    `json:"-" gorm:"foreignKey:PlayerID;constraint:OnDelete:CASCADE"`
+	Rank      int     `json:"rank" gorm:"not null;index"`
+	Rating    int     `json:"rating" gorm:"not null"`
+	Deviation float64 `json:"deviation" gorm:"not null"`
+}
+
+type SeasonStandingResp struct {
+	PlayerID  string  `json:"player_id"`
+	Username  string  `json:"username"`
+	Rank      int     `json:"rank"`
+	Rating    int     `json:"rating"`
+	Deviation float64 `json:"deviation"`
+}
+
+func (s *SeasonStanding) ToResp() *SeasonStandingResp {
+	return &SeasonStandingResp{
+		PlayerID:  s.PlayerID,
+		Username:  s.Player.Username,
+		Rank:      s.Rank,
+		Rating:    s.Rating,
+		Deviation: s.Deviation,
+	}
+}
+
+// seasonSoftReset pulls a value factor of the way back toward center:
+// 0 keeps it, 1 resets it to center.
+func seasonSoftReset(value, center, factor float64) float64 {
+	return center + (value-center)*(1-factor)
+}
+
+// GetQueuesDueForRollover returns the IDs of queues whose current season
+// has ended as of now.
+func GetQueuesDueForRollover(now time.Time) ([]string, error) {
+	var ids []string
+	err := server.S.DB.Model(&GameQueue{}).
+		Where("season_ends_at IS NOT NULL AND season_ends_at <= ?", now).
+		Order("season_ends_at ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// RolloverSeason ends the queue's current season if its deadline has
+// passed, in one transaction:
+//
+//  1. archives the leaderboard as a Season with one SeasonStanding per
+//     rated player past their placement matches (the provisional players
+//     GetLeaderboard leaves off aren't ranked here either),
+//  2. soft-resets every rating toward DefaultRating by SeasonSoftReset
+//     (trueskill μ moves the same way), recording a RatingChange with
+//     reason "season_reset" for each row that moved,
+//  3. opens the next season, ending SeasonLengthDays after the old end
+//     (or after now, if the worker was down long enough that the old
+//     schedule is already behind), or with no end when SeasonLengthDays
+//     is 0.
+//
+// The queue row is locked FOR UPDATE and the deadline re-checked, so
+// concurrent workers roll a season over at most once. The queue's rating
+// rows are locked too, so a match ending mid-rollover can't be
+// overwritten by the reset. Returns nil, nil
+// when there was nothing to do.
+//
+// The reset writes with UpdateColumns so UpdatedAt is untouched — a reset
+// isn't activity, and glicko2's inactivity inflation keys off UpdatedAt.
+func RolloverSeason(gameQueueID string, now time.Time) (*Season, error) {
+	var archived *Season
+	err := server.S.DB.Transaction(func(tx *gorm.DB) error {
+		var queue GameQueue
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&queue, "id = ?", gameQueueID).Error; err != nil {
+			return err
+		}
+		if queue.SeasonEndsAt == nil || queue.SeasonEndsAt.After(now) {
+			return nil
+		}
+
+		// Lock in player_id order, the order a match end locks its
+		// players' rows in, so the two can't deadlock.
+		var ratings []Rating
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("game_queue_id = ?", queue.ID).
+			Order("player_id ASC").
+			Find(&ratings).Error; err != nil {
+			return err
+		}
+
+		ranked := make([]Rating, 0, len(ratings))
+		for _, r := range ratings {
+			if !r.IsProvisional(&queue) {
+				ranked = append(ranked, r)
+			}
+		}
+		sort.SliceStable(ranked, func(i, j int) bool {
+			return ranked[i].Rating > ranked[j].Rating
+		})
+
+		endedAt := *queue.SeasonEndsAt
+		startedAt := queue.CreatedAt
+		if queue.SeasonStartedAt != nil {
+			startedAt = *queue.SeasonStartedAt
+		}
+		season := &Season{
+			GameQueueID: queue.ID,
+			Number:      queue.SeasonNumber,
+			StartedAt:   startedAt,
+			EndedAt:     endedAt,
+			SoftReset:   queue.SeasonSoftReset,
+			PlayerCount: len(ranked),
+		}
+		if err := tx.Create(season).Error; err != nil {
+			return err
+		}
+
+		standings := make([]SeasonStanding, len(ranked))
+		for i, r := range ranked {
+			rank := i + 1
+			if i > 0 && r.Rating == ranked[i-1].Rating {
+				rank = standings[i-1].Rank
+			}
+			standings[i] = SeasonStanding{
+				SeasonID:  season.ID,
+				PlayerID:  r.PlayerID,
+				Rank:      rank,
+				Rating:    r.Rating,
+				Deviation: r.CurrentDeviation(now),
+			}
+		}
+		if len(standings) > 0 {
+			if err := tx.CreateInBatches(standings, bulkInsertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		changes, err := softResetRatings(tx, ratings, float64(queue.DefaultRating), queue.SeasonSoftReset)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err := tx.CreateInBatches(changes, bulkInsertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		var nextEnd *time.Time
+		if queue.SeasonLengthDays > 0 {
+			length := time.Duration(queue.SeasonLengthDays) * 24 * time.Hour
+			end := endedAt.Add(length)
+			if !end.After(now) {
+				end = now.Add(length)
+			}
+			end = end.UTC()
+			nextEnd = &end
+		}
+		if err := tx.Model(&queue).Updates(map[string]interface{}{
+			"season_number":     queue.SeasonNumber + 1,
+			"season_started_at": endedAt,
+			"season_ends_at":    nextEnd,
+		}).Error; err != nil {
+			return err
+		}
+
+		archived = season
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return archived, nil
+}
+
+// softResetRatings pulls each row factor of the way back toward center
+// (trueskill μ too) and returns an unsaved season_reset RatingChange per
+// row whose rating moved. Writes with UpdateColumns, leaving UpdatedAt
+// alone.
+func softResetRatings(tx *gorm.DB, ratings []Rating, center, factor float64) ([]RatingChange, error) {
+	changes := make([]RatingChange, 0, len(ratings))
+	for i := range ratings {
+		r := &ratings[i]
+		before := r.Rating
+		softResetRating(r, center, factor)
+		updates := map[string]interface{}{"rating": r.Rating}
+		if r.Sigma > 0 {
+			updates["mu"] = r.Mu
+		}
+		if r.Rating == before && r.Sigma <= 0 {
+			continue
+		}
+		if err := tx.Model(r).UpdateColumns(updates).Error; err != nil {
+			return nil, err
+		}
+		if r.Rating != before {
+			changes = append(changes, newRatingChange(r, before, RatingChangeReasonSeasonReset))
+		}
+	}
+	return changes, nil
+}
+
+// GetSeasons returns a queue's archived seasons, most recent first.
+func GetSeasons(gameQueueID string) ([]Season, error) {
+	var seasons []Season
+	err := server.S.DB.Where("game_queue_id = ?", gameQueueID).
+		Order("number DESC").
+		Find(&seasons).Error
+	return seasons, err
+}
+
+// GetSeason returns one archived season of a queue by number.
+func GetSeason(gameQueueID string, number int) (*Season, error) {
+	var season Season
+	err := server.S.DB.First(&season, "game_queue_id = ? AND number = ?", gameQueueID, number).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSeasonNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &season, nil
+}
+
+// GetSeasonStandings returns an archived season's final leaderboard,
+// paginated, ordered by rank with player_id as a stable tiebreaker.
+func GetSeasonStandings(seasonID string, page, pageSize int) ([]SeasonStanding, int, error) {
+	var standings []SeasonStanding
+	offset := page * pageSize
+	result := server.S.DB.Preload("Player").
+		Where("season_id = ?", seasonID).
+		Order("rank ASC, player_id ASC").
+		Offset(offset).Limit(pageSize).
+		Find(&standings)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	nextPage := page + 1
+	if result.RowsAffected < int64(pageSize) {
+		nextPage = -1
+	}
+	return standings, nextPage, nil
+}
+
+// softResetRating applies a season soft reset to r in memory.
+func softResetRating(r *Rating, center, factor float64) {
+	r.Rating = int(math.Round(seasonSoftReset(float64(r.Rating), center, factor)))
+	if r.Sigma > 0 {
+		r.Mu = seasonSoftReset(r.Mu, center, factor)
+	}
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// TRUESKILL_DEFAULT_SIGMA is the skill uncertainty assigned to a
+	// player the first time they're rated in a trueskill queue. Chosen so
+	// the usual TrueSkill ratios (β = σ₀/2, τ = σ₀/100) land on the same
+	// ~1000-point scale as classic Elo.
+	TRUESKILL_DEFAULT_SIGMA = 250.0
+	// TRUESKILL_BETA is the per-match performance noise: the skill gap
+	// that gives the stronger side roughly a 76% win chance.
+	TRUESKILL_BETA = TRUESKILL_DEFAULT_SIGMA / 2
+	// TRUESKILL_TAU is the dynamics factor added to every player's σ
+	// before each update, so σ never collapses to zero and long-time
+	// players can still move.
+	TRUESKILL_TAU = TRUESKILL_DEFAULT_SIGMA / 100
+	// trueskillKappa floors the σ² shrink factor so a lopsided result
+	// cannot drive variance negative.
+	trueskillKappa = 0.0001
+)
+
+// ApplyTrueSkill updates ratings for the non-guest players in a finished
+// match using a team-aware Bayesian skill model (the Weng–Lin
+// Bradley–Terry full-pairing approximation of TrueSkill). Must run
+// inside a transaction (the caller's MatchEnded tx) — it upserts and
+// locks rating rows in player-ID order exactly like ApplyClassicElo, so
+// concurrent matches that share players cannot deadlock.
+//
+// Each player carries (μ, σ). A team's skill is the sum of its members'
+// μ with variance Σσ²; every pair of teams is compared by placement
+// (lower is better, equal is a tie), and each team's total surprise is
+// split across its members in proportion to their σ² — an uncertain
+// newcomer absorbs most of the swing, a well-established teammate
+// barely moves. Rating is kept in step with round(μ) so leaderboards
+// and the rating-window matchmaker keep working unchanged.
+//
+// Teams come from outcome.Teams/TeamPlacements. When the report has no
+// teams, every player is their own team placed per
+// MatchOutcome.placementOf — the same free-for-all reading
+// ApplyClassicElo uses. Guests
+// contribute a default (μ, σ) to their team's strength but are never
+// written back.
+//
+// Returns one unsaved RatingChange per updated row, like ApplyClassicElo.
+func ApplyTrueSkill(tx *gorm.DB, queue *GameQueue, playerIDs []string, outcome MatchOutcome) ([]RatingChange, error) {
+	teams, placements := trueSkillTeams(playerIDs, outcome)
+	if len(teams) < 2 {
+		return nil, nil
+	}
+	nonGuests := ratedPlayerIDs(flattenTeams(teams))
+	if len(nonGuests) == 0 {
+		return nil, nil
+	}
+
+	for _, pid := range nonGuests {
+		row := &Rating{
+			PlayerID:    pid,
+			GameQueueID: queue.ID,
+			Rating:      queue.DefaultRating,
+			Deviation:   GLICKO2_DEFAULT_DEVIATION,
+			Volatility:  GLICKO2_DEFAULT_VOLATILITY,
+			Mu:          float64(queue.DefaultRating),
+			Sigma:       TRUESKILL_DEFAULT_SIGMA,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make(map[string]*Rating, len(nonGuests))
+	before := make(map[string]int, len(nonGuests))
+	for _, pid := range nonGuests {
+		var r Rating
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&r, "player_id = ? AND game_queue_id = ?", pid, queue.ID).Error; err != nil {
+			return nil, err
+		}
+		rows[pid] = &r
+		before[pid] = r.Rating
+	}
+	rateTrueSkill(queue, teams, placements, rows)
+
+	changes := make([]RatingChange, 0, len(nonGuests))
+	for _, pid := range nonGuests {
+		if err := tx.Save(rows[pid]).Error; err != nil {
+			return nil, err
+		}
+		changes = append(changes, newRatingChange(rows[pid], before[pid], RatingChangeReasonMatch))
+	}
+	return changes, nil
+}
+
+// trueSkillTeams returns the teams and team placements ApplyTrueSkill
+// rates: the reported ones, or one team per player placed per
+// MatchOutcome.placementOf when the report has none.
+func trueSkillTeams(playerIDs []string, outcome MatchOutcome) ([][]string, []int) {
+	if len(outcome.Teams) > 0 {
+		return outcome.Teams, outcome.TeamPlacements
+	}
+	teams := make([][]string, 0, len(playerIDs))
+	placements := make([]int, 0, len(playerIDs))
+	for _, pid := range playerIDs {
+		teams = append(teams, []string{pid})
+		placements = append(placements, outcome.placementOf(pid))
+	}
+	return teams, placements
+}
+
+func flattenTeams(teams [][]string) []string {
+	var ids []string
+	for _, team := range teams {
+		ids = append(ids, team...)
+	}
+	return ids
+}
+
+// rateTrueSkill applies one match to the team members' rows in memory,
+// like rateClassicElo. Members without a row (guests) count at the
+// default (μ, σ) and aren't written.
+func rateTrueSkill(queue *GameQueue, teams [][]string, placements []int, rows map[string]*Rating) {
+	// Rows created by another strategy (or before this column existed)
+	// have no trueskill state yet — seed μ from their current rating.
+	for _, team := range teams {
+		for _, pid := range team {
+			if r, ok := rows[pid]; ok && r.Sigma <= 0 {
+				r.Mu = float64(r.Rating)
+				r.Sigma = TRUESKILL_DEFAULT_SIGMA
+			}
+		}
+	}
+
+	// Per-player prior variance including the dynamics term.
+	variance := func(pid string) float64 {
+		sigma := TRUESKILL_DEFAULT_SIGMA
+		if r, ok := rows[pid]; ok {
+			sigma = r.Sigma
+		}
+		return sigma*sigma + TRUESKILL_TAU*TRUESKILL_TAU
+	}
+	mean := func(pid string) float64 {
+		if r, ok := rows[pid]; ok {
+			return r.Mu
+		}
+		return float64(queue.DefaultRating)
+	}
+
+	k := len(teams)
+	teamMu := make([]float64, k)
+	teamVar := make([]float64, k)
+	for i, team := range teams {
+		for _, pid := range team {
+			teamMu[i] += mean(pid)
+			teamVar[i] += variance(pid)
+		}
+	}
+
+	omega := make([]float64, k)
+	delta := make([]float64, k)
+	for i := 0; i < k; i++ {
+		for q := 0; q < k; q++ {
+			if i == q {
+				continue
+			}
+			c := math.Sqrt(teamVar[i] + teamVar[q] + 2*TRUESKILL_BETA*TRUESKILL_BETA)
+			p := 1.0 / (1.0 + math.Exp((teamMu[q]-teamMu[i])/c))
+			var s float64
+			switch {
+			case placements[i] < placements[q]:
+				s = 1.0
+			case placements[i] > placements[q]:
+				s = 0.0
+			default:
+				s = 0.5
+			}
+			gamma := math.Sqrt(teamVar[i]) / c
+			omega[i] += teamVar[i] / c * (s - p)
+			delta[i] += gamma * teamVar[i] / (c * c) * p * (1 - p)
+		}
+	}
+
+	for i, team := range teams {
+		for _, pid := range team {
+			r, ok := rows[pid]
+			if !ok {
+				continue
+			}
+			v := variance(pid)
+			share := v / teamVar[i]
+			r.Mu += share * omega[i]
+			r.Sigma = math.Sqrt(v * math.Max(1-share*delta[i], trueskillKappa))
+			r.Rating = int(math.Round(r.Mu))
+			r.GamesPlayed++
+		}
+	}
+}
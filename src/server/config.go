@@ -1,9 +1,12 @@
 package server
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -30,6 +33,11 @@ type Config struct {
 	// kept failing. Guards against a permanently-broken agent leaking
 	// ports/rows.
 	MatchCooldownForceDeadline    time.Duration
+	// RatingMaintenanceInterval is how often the worker runs periodic
+	// rating upkeep (season rollovers, inactivity decay). Both jobs are
+	// keyed off wall-clock deadlines, so a coarse interval just delays
+	// them by at most one tick.
+	RatingMaintenanceInterval     time.Duration
 	FlyAPIHostname                string
 	FlyAPIKey                     string
 	FlyAppName                    string
@@ -44,11 +52,23 @@ type Config struct {
 	HCLOUDAgentPort               int64
 	HCLOUDPortRangeStart          int64
 	HCLOUDPortRangeEnd            int64
+	// HCLOUDLocations are the Hetzner locations (e.g. "nbg1", "ash") the
+	// matchmaker may place hosts in, which doubles as the list of regions
+	// clients report pings to. The first is the default region: the warm
+	// pool and lobby matches use it, as does any match whose players
+	// reported no pings. Empty means DefaultHCLOUDLocation only.
+	HCLOUDLocations               []string
 	AWSAccessKeyID                string
 	AWSSecretAccessKey            string
 	AWSRegion                     string
 	AWSBucketName                 string
 
+	// ConnectTokenSigningKey signs the per-player connect tokens game
+	// servers verify offline. Required: it's read from the base64
+	// Ed25519 seed in CONNECT_TOKEN_SIGNING_KEY, and the server refuses
+	// to start without a valid one.
+	ConnectTokenSigningKey ed25519.PrivateKey
+
 	// Wildcard-TLS feature: when all three are set, the matchmaker maintains
 	// a single *.${GameServerDomain} cert via Let's Encrypt DNS-01, creates
 	// per-host A records on host provisioning, and tells clients to connect
@@ -195,6 +215,17 @@ func InitConfig() (*Config, error) {
 		}
 	}
 
+	if v := os.Getenv("HCLOUD_LOCATIONS"); v != "" {
+		for _, loc := range strings.Split(v, ",") {
+			if loc = strings.TrimSpace(loc); loc != "" {
+				cfg.HCLOUDLocations = append(cfg.HCLOUDLocations, loc)
+			}
+		}
+	}
+	if len(cfg.HCLOUDLocations) == 0 {
+		cfg.HCLOUDLocations = []string{DefaultHCLOUDLocation}
+	}
+
 	if cfg.AWSAccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID"); cfg.AWSAccessKeyID == "" {
 		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID is not set")
 	}
@@ -211,6 +242,12 @@ func InitConfig() (*Config, error) {
 		return nil, fmt.Errorf("AWS_BUCKET_NAME is not set")
 	}
 
+	seed, err := base64.StdEncoding.DecodeString(os.Getenv("CONNECT_TOKEN_SIGNING_KEY"))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("CONNECT_TOKEN_SIGNING_KEY must be a base64 %d-byte Ed25519 seed", ed25519.SeedSize)
+	}
+	cfg.ConnectTokenSigningKey = ed25519.NewKeyFromSeed(seed)
+
 	// Wildcard-TLS optional config. All-or-nothing: if any of the three is
 	// set, the other two must be set too — otherwise we'd have inconsistent
 	// state (DNS records being created with no cert, or vice versa).
@@ -229,6 +266,11 @@ func InitConfig() (*Config, error) {
 	if cfg.CertEmail = os.Getenv("CERT_EMAIL"); cfg.CertEmail == "" && cfg.GameServerDomain != "" {
 		cfg.CertEmail = "admin@" + cfg.GameServerDomain
 	}
+	if rmi, err := time.ParseDuration(os.Getenv("RATING_MAINTENANCE_INTERVAL")); err == nil && rmi > 0 {
+		cfg.RatingMaintenanceInterval = rmi
+	} else {
+		cfg.RatingMaintenanceInterval = 5 * time.Minute
+	}
 	if cri, err := time.ParseDuration(os.Getenv("CERT_RENEWAL_INTERVAL")); err == nil && cri > 0 {
 		cfg.CertRenewalInterval = cri
 	} else {
@@ -240,6 +282,17 @@ func InitConfig() (*Config, error) {
 	return cfg, nil
 }
 
+// DefaultHCLOUDLocation is where hosts go when HCLOUD_LOCATIONS is unset.
+const DefaultHCLOUDLocation = "nbg1"
+
+// Regions returns the configured host locations, default region first.
+func (c *Config) Regions() []string {
+	if len(c.HCLOUDLocations) == 0 {
+		return []string{DefaultHCLOUDLocation}
+	}
+	return c.HCLOUDLocations
+}
+
 // WildcardTLSEnabled reports whether all three required env vars are set.
 // Callers branch on this to skip cert/DNS work when running locally or in
 // tests without the production secrets.
@@ -15,9 +15,10 @@ type MachineService interface {
 	// ValidateServerType is called at startup so a misconfigured
 	// HCLOUD_HOST_TYPE fails loudly instead of silently at first match.
 	ValidateServerType(ctx context.Context, serverType string) error
-	// CreateHost provisions a new host VM. When `tls` is non-nil, the
-	// host is brought up with Caddy + the wildcard cert pre-installed.
-	CreateHost(ctx context.Context, serverType string, agentPort int64, tls *hetzner.HostTLSOpts) (*hetzner.HostConnectionInfo, error)
+	// CreateHost provisions a new host VM in region (a provider location
+	// such as "nbg1"). When `tls` is non-nil, the host is brought up with
+	// Caddy + the wildcard cert pre-installed.
+	CreateHost(ctx context.Context, region, serverType string, agentPort int64, tls *hetzner.HostTLSOpts) (*hetzner.HostConnectionInfo, error)
 	DeleteHost(ctx context.Context, providerID string) error
 	// ListHosts returns the provider IDs of every game-host VM currently
 	// alive at the provider. Used by ReconcileLiveHosts to detect DB rows
@@ -0,0 +1,72 @@
+package matchmaking
+
+import "time"
+
+// blocksAny reports whether a player of e has blocked, or been blocked
+// by, any of players.
+func (s *QueueSnapshot) blocksAny(e QueueEntry, players []string) bool {
+	for _, id := range e.Players {
+		for _, other := range players {
+			if s.Blocks[id][other] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recentlyMet reports whether a player of a faced a player of b within
+// the queue's rematch lookback.
+func (s *QueueSnapshot) recentlyMet(a, b QueueEntry) bool {
+	for _, id := range a.Players {
+		for _, other := range b.Players {
+			if s.RecentOpponents[id][other] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rematchDue reports whether an entry has waited long enough to accept a
+// recent opponent. An unknown join time counts as long enough ago.
+func (s *QueueSnapshot) rematchDue(e QueueEntry) bool {
+	if s.Queue.RematchMaxWaitSeconds == 0 {
+		return false
+	}
+	waited, ok := s.Waited(e.ID)
+	return !ok || waited >= time.Duration(s.Queue.RematchMaxWaitSeconds)*time.Second
+}
+
+// CanGroup reports whether two entries may be put in the same match:
+// never when a player of one has blocked a player of the other, and not
+// when they met within the queue's rematch lookback — until both have
+// waited the queue's RematchMaxWaitSeconds.
+func (s *QueueSnapshot) CanGroup(a, b QueueEntry) bool {
+	if s.blocksAny(a, b.Players) {
+		return false
+	}
+	return !s.recentlyMet(a, b) || (s.rematchDue(a) && s.rematchDue(b))
+}
+
+// groupAllowed reports whether every pair of entries in group may share
+// a match. PairPlayers checks each strategy's groups with it, since a
+// registered strategy can't be trusted to honor CanGroup.
+func (s *QueueSnapshot) groupAllowed(group []QueueEntry) bool {
+	for i, e := range group {
+		if !s.fitsGroup(group[:i], e) {
+			return false
+		}
+	}
+	return true
+}
+
+// fitsGroup reports whether e may join every entry already in group.
+func (s *QueueSnapshot) fitsGroup(group []QueueEntry, e QueueEntry) bool {
+	for _, g := range group {
+		if !s.CanGroup(g, e) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package matchmaking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+)
+
+// BACKFILL_TTL is how long an unfilled backfill request stays open. A
+// game server that still wants players after that asks again.
+const BACKFILL_TTL = 10 * time.Minute
+
+// RequestBackfill opens a backfill request for a running match: the
+// matchmaker fills up to `players` seats from the match's own sub-queue,
+// ahead of forming new matches there, and sends the new players the
+// match's server. players can't exceed the match's open seats (see
+// Match.OpenSeats). team picks the team replacements join on team
+// matches (nil puts them on the teams with the fewest players left).
+// players = 0 withdraws an open request. A new request replaces the
+// previous one.
+func RequestBackfill(ctx context.Context, match *models.Match, players int, team *int) error {
+	if open := match.OpenSeats(); players < 0 || players > open {
+		return fmt.Errorf("invalid players: must be between 0 and %d, the match's open seats", open)
+	}
+	teamIndex := -1
+	if team != nil {
+		teams := match.TeamLayout()
+		if teams == nil {
+			return errors.New("invalid team: match has no teams")
+		}
+		if *team < 0 || *team >= len(teams) {
+			return fmt.Errorf("invalid team: must be between 0 and %d", len(teams)-1)
+		}
+		teamIndex = *team
+	}
+
+	composite := match.QueueKey
+	if composite == "" {
+		composite = match.GameQueueID
+	}
+	if players == 0 {
+		return server.S.Redis.DeleteBackfill(ctx, match.ID, composite)
+	}
+
+	region := match.ServerInstance.MachineHost.Region
+	if region == "" {
+		region = server.S.Config.Regions()[0]
+	}
+	rec := &extRedis.BackfillRecord{
+		MatchID:     match.ID,
+		GameQueueID: match.GameQueueID,
+		QueueID:     composite,
+		Region:      region,
+		Team:        teamIndex,
+		Players:     players,
+		RequestedAt: time.Now(),
+	}
+	if err := server.S.Redis.SetBackfill(ctx, rec, BACKFILL_TTL); err != nil {
+		return err
+	}
+	slog.Info("Backfill requested", "matchID", match.ID, "players", players, "composite", composite)
+	return server.S.Redis.PublishMatchmakingTrigger(ctx)
+}
+
+// fillBackfills serves a queue's open backfill requests, oldest first,
+// from the snapshot's entries: each takes the longest-waiting entries
+// that accept the match's region and fit its open seats, passing over
+// anyone blocked by or blocking a player in the match. A request may be
+// filled only partly; the rest stays open. Returns the entries left for
+// pairing.
+func fillBackfills(ctx context.Context, queue *models.GameQueue, composite string, snap *QueueSnapshot, backfills []*extRedis.BackfillRecord) []QueueEntry {
+	remaining := snap.Entries
+	ids := make([]string, len(backfills))
+	for i, rec := range backfills {
+		ids[i] = rec.MatchID
+	}
+	matches, err := models.GetMatchesByID(ids)
+	if err != nil {
+		slog.Error("Failed to load backfilling matches", "error", err, "composite", composite)
+		return remaining
+	}
+	for _, rec := range backfills {
+		match, ok := matches[rec.MatchID]
+		if !ok || match.Status != models.MatchStatusStarted {
+			// The match has ended; nobody should join it now.
+			server.S.Redis.DeleteBackfill(ctx, rec.MatchID, composite)
+			continue
+		}
+
+		var group []QueueEntry
+		seats := min(rec.Players, match.OpenSeats())
+		inMatch := match.PlayerIDs()
+		for _, e := range remaining {
+			if seats == 0 {
+				break
+			}
+			if len(e.Players) <= seats && snap.Accepts(e, rec.Region) && !snap.blocksAny(e, inMatch) && snap.fitsGroup(group, e) {
+				group = append(group, e)
+				seats -= len(e.Players)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+
+		if err := dequeueEntries(ctx, composite, group); err != nil {
+			slog.Error("Failed to remove backfill entries from queue", "error", err, "composite", composite)
+			return remaining
+		}
+		players := entryPlayers(group)
+		teamsFor := func(m *models.Match) [][]string {
+			return backfillTeams(m.TeamLayout(), leftIDs(m), group, rec.Team)
+		}
+		if err := models.BackfillMatch(match.ID, players, teamsFor); err != nil {
+			slog.Error("Failed to backfill match", "error", err, "matchID", match.ID)
+			requeueEntries(ctx, queue.ID, composite, group)
+			continue
+		}
+		if err := server.S.Redis.ReduceBackfill(ctx, rec, len(players)); err != nil {
+			slog.Warn("Failed to update backfill request", "error", err, "matchID", match.ID)
+		}
+		for _, player := range players {
+			server.S.Redis.PublishMatchReady(ctx, queue.ID, player, "match_"+match.ID)
+		}
+		remaining = withoutEntries(remaining, group)
+	}
+	return remaining
+}
+
+// backfillTeams places backfilled entries into a team layout: each joins
+// team, or the team with the fewest players still in it when team is -1.
+// A party stays together. On its team each player takes the slot of a
+// player who left, if one is still there, and otherwise joins the end.
+// Returns nil for matches without teams.
+func backfillTeams(teams [][]string, left []string, group []QueueEntry, team int) [][]string {
+	if teams == nil {
+		return nil
+	}
+	out := make([][]string, len(teams))
+	for i, t := range teams {
+		out[i] = slices.Clone(t)
+	}
+	vacated := func(id string) bool { return slices.Contains(left, id) }
+	staying := func(t []string) int {
+		n := 0
+		for _, id := range t {
+			if !vacated(id) {
+				n++
+			}
+		}
+		return n
+	}
+	for _, e := range group {
+		idx := team
+		if idx < 0 {
+			idx = 0
+			for i := range out {
+				if staying(out[i]) < staying(out[idx]) {
+					idx = i
+				}
+			}
+		}
+		for _, player := range e.Players {
+			if slot := slices.IndexFunc(out[idx], vacated); slot >= 0 {
+				out[idx][slot] = player
+			} else {
+				out[idx] = append(out[idx], player)
+			}
+		}
+	}
+	return out
+}
+
+// leftIDs returns the players the game server reported leaving match.
+func leftIDs(match *models.Match) []string {
+	var ids []string
+	for _, l := range match.LeaveLog() {
+		ids = append(ids, l.PlayerID)
+	}
+	return ids
+}
@@ -0,0 +1,39 @@
+package matchmaking
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBackfillTeams(t *testing.T) {
+	if teams := backfillTeams(nil, nil, []QueueEntry{solo("a")}, -1); teams != nil {
+		t.Errorf("expected no layout for a match without teams, got %v", teams)
+	}
+
+	layout := [][]string{{"a", "b"}, {"c"}}
+	party := QueueEntry{ID: "party", Players: []string{"p1", "p2"}}
+	got := backfillTeams(layout, nil, []QueueEntry{solo("d"), party}, -1)
+	if fmt.Sprint(got) != "[[a b p1 p2] [c d]]" {
+		t.Errorf("expected entries spread over the smallest teams, got %v", got)
+	}
+	if fmt.Sprint(layout) != "[[a b] [c]]" {
+		t.Error("backfillTeams must not modify the match's layout")
+	}
+
+	got = backfillTeams(layout, nil, []QueueEntry{solo("d"), solo("e")}, 0)
+	if fmt.Sprint(got) != "[[a b d e] [c]]" {
+		t.Errorf("expected both entries on team 0, got %v", got)
+	}
+
+	// Replacements take the slots of players who left, on the team
+	// they left short.
+	layout = [][]string{{"a", "b"}, {"c", "d"}}
+	got = backfillTeams(layout, []string{"c"}, []QueueEntry{solo("e")}, -1)
+	if fmt.Sprint(got) != "[[a b] [e d]]" {
+		t.Errorf("expected e in c's slot, got %v", got)
+	}
+	got = backfillTeams(layout, []string{"a", "b"}, []QueueEntry{party}, 0)
+	if fmt.Sprint(got) != "[[p1 p2] [c d]]" {
+		t.Errorf("expected the party in a's and b's slots, got %v", got)
+	}
+}
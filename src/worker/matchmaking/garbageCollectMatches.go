@@ -8,13 +8,13 @@ import (
 	"time"
 
 	"github.com/andy98725/elo-service/src/api/matchResults"
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
 	"github.com/andy98725/elo-service/src/models"
 	"github.com/andy98725/elo-service/src/server"
 )
 
 const (
-	MATCH_MAX_DURATION = 6 * time.Hour
-	GC_PAGE_SIZE       = 100
+	GC_PAGE_SIZE = 100
 )
 
 // ReconcileLiveHosts compares MachineHost rows in 'ready' state against
@@ -118,10 +118,17 @@ func GarbageCollectMatches(ctx context.Context) error {
 			return err
 		}
 
+		now := time.Now()
 		for _, match := range matches {
-			if time.Since(match.CreatedAt) > MATCH_MAX_DURATION {
-				slog.Info("Match timed out", "matchID", match.ID, "serverInstanceID", match.ServerInstanceID)
-				if _, err := matchResults.EndMatch(ctx, &match, []string{}, "timeout", false); err != nil {
+			if checkHeartbeat(ctx, &match, now) {
+				continue
+			}
+			if settleReportWindow(ctx, &match, now) {
+				continue
+			}
+			if now.After(match.Deadline()) {
+				slog.Info("Match timed out", "matchID", match.ID, "serverInstanceID", match.ServerInstanceID, "deadline", match.Deadline())
+				if _, err := matchResults.EndMatch(ctx, &match, models.MatchOutcome{WinnerIDs: []string{}}, "timeout", false); err != nil {
 					slog.Error("Failed to end timed-out match", "error", err, "matchID", match.ID)
 				}
 			}
@@ -131,6 +138,25 @@ func GarbageCollectMatches(ctx context.Context) error {
 	return nil
 }
 
+// settleReportWindow ends a client-reported match whose report window
+// has closed, from the reports received by then, and reports whether it
+// did. Matches nobody has reported yet run on to their deadline.
+func settleReportWindow(ctx context.Context, match *models.Match, now time.Time) bool {
+	if !match.GameQueue.ClientReported() {
+		return false
+	}
+	if ends, ok := match.ReportWindowEnds(); !ok || now.Before(ends) {
+		return false
+	}
+	verdict, err := matchResults.SettleReports(ctx, match, now)
+	if err != nil {
+		slog.Error("Failed to settle match reports", "error", err, "matchID", match.ID)
+		return false
+	}
+	slog.Info("Match report window closed", "matchID", match.ID, "verdict", verdict, "reports", len(match.ReportLog()))
+	return true
+}
+
 // SweepCooledMatches is phase B of the match-completion lifecycle:
 // finds every ServerInstance currently in cooldown whose result was
 // reported more than Config.MatchCooldownDuration ago and tears it
@@ -197,9 +223,20 @@ func CleanupExpiredPlayers(ctx context.Context) error {
 			}
 
 			if !alive {
+				// An expired party entry means the leader's connection is
+				// gone; end the rest of the party's searches with it.
+				if partyID, ok := extRedis.ParsePartyQueueEntry(playerID); ok {
+					if party, err := server.S.Redis.GetParty(ctx, partyID); err == nil && party.QueueID == queueID {
+						cancelPartySearch(ctx, party, party.LeaderID, "party search timed out")
+						recordAbandons(ctx, queueID, 1)
+						slog.Info("Removed expired party from queue", "partyID", partyID, "queueID", queueID)
+						continue
+					}
+				}
 				if err := server.S.Redis.RemovePlayerFromQueue(ctx, queueID, playerID); err != nil {
 					slog.Error("Failed to remove expired player from queue", "playerID", playerID, "queueID", queueID)
 				} else {
+					recordAbandons(ctx, queueID, 1)
 					slog.Info("Removed expired player from queue", "playerID", playerID, "queueID", queueID)
 				}
 			}
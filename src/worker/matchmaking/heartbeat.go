@@ -0,0 +1,65 @@
+package matchmaking
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/andy98725/elo-service/src/api/matchResults"
+	"github.com/andy98725/elo-service/src/external/hetzner"
+	"github.com/andy98725/elo-service/src/models"
+)
+
+const (
+	// A server whose container is still running gets this many grace
+	// periods of silence before its match is given up on; it may just be
+	// stalled on a slow tick or a network blip.
+	HEARTBEAT_UNRESPONSIVE_FACTOR = 3
+
+	REASON_SERVER_CRASHED      = "server_crashed"
+	REASON_SERVER_UNRESPONSIVE = "server_unresponsive"
+)
+
+// checkHeartbeat ends a match whose game server has gone quiet for longer
+// than its queue's heartbeat grace period, and reports whether it did.
+// The host agent is asked about the container first: a stopped container
+// ends the match as server_crashed straight away, while a running one is
+// given HEARTBEAT_UNRESPONSIVE_FACTOR grace periods before the match is
+// ended as server_unresponsive. Ratings are never touched either way.
+//
+// Matches with no server instance (nothing to heartbeat) and queues with
+// heartbeat_grace_seconds = 0 are skipped.
+func checkHeartbeat(ctx context.Context, match *models.Match, now time.Time) bool {
+	grace := time.Duration(match.GameQueue.HeartbeatGraceSeconds) * time.Second
+	if grace <= 0 || match.ServerInstanceID == "" {
+		return false
+	}
+	silent := now.Sub(match.LastSignOfLife())
+	if silent <= grace {
+		return false
+	}
+
+	host := match.ServerInstance.MachineHost
+	running, state, err := hetzner.ContainerHealth(ctx, host.PublicIP, host.AgentPort, host.AgentToken, match.ServerInstance.ContainerID)
+	reason := REASON_SERVER_UNRESPONSIVE
+	switch {
+	case err != nil:
+		// The agent couldn't say, which is no evidence of a crash; fall
+		// back to the longer unresponsive deadline.
+		slog.Warn("Failed to check container health of silent match", "error", err, "matchID", match.ID, "silent", silent)
+	case !running:
+		reason = REASON_SERVER_CRASHED
+		slog.Info("Game server crashed", "matchID", match.ID, "serverInstanceID", match.ServerInstanceID, "state", state, "silent", silent)
+	default:
+		slog.Warn("Game server missed heartbeats but is still running", "matchID", match.ID, "serverInstanceID", match.ServerInstanceID, "silent", silent)
+	}
+	if reason == REASON_SERVER_UNRESPONSIVE && silent <= grace*HEARTBEAT_UNRESPONSIVE_FACTOR {
+		return false
+	}
+
+	if _, err := matchResults.EndMatch(ctx, match, models.MatchOutcome{WinnerIDs: []string{}}, reason, false); err != nil {
+		slog.Error("Failed to end match with a silent server", "error", err, "matchID", match.ID, "reason", reason)
+		return false
+	}
+	return true
+}
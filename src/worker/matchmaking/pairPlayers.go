@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,63 +23,161 @@ import (
 const (
 	QUEUE_TTL              = 2 * time.Minute
 	QUEUE_REFRESH_INTERVAL = 30 * time.Second
-
-	// Rating-based matchmaking windows. A queued player will accept any
-	// opponent whose rating is within the window for their current wait
-	// time. The window expands in tiers so fresh entrants get tightly
-	// matched and long-waiting entrants don't starve.
-	RATING_WINDOW_TIGHT = 100
-	RATING_WINDOW_LOOSE = 300
-	RATING_TIER_TIGHT   = 30 * time.Second
-	RATING_TIER_LOOSE   = 60 * time.Second
 )
 
+// MAX_SEARCH_QUEUES caps how many sub-queues one search may cover.
+const MAX_SEARCH_QUEUES = 8
+
+var ErrPartyMultiQueue = errors.New("invalid search: a party searches one queue at a time")
+
 type JoinQueueResult struct {
 	QueueSize   int64
 	QueueID     string
 	GameQueueID string
+	Metadata    string
+	// EntryID is the queue-list entry the caller now owns: their own
+	// player ID, or the party entry when they lead a party. Empty for a
+	// party member who isn't the leader — they follow the leader's
+	// search instead of queueing themselves.
+	EntryID string
+	PartyID string
 }
 
-// JoinQueue places a player in the matchmaking queue for (gameID, queueID,
-// metadata). queueID may be empty — the game's default queue (oldest by
-// created_at) is used in that case.
+// SearchTarget is one sub-queue a search covers: a resolved GameQueue,
+// the metadata it's searched with ("" when the queue isn't
+// metadata-enabled), and their composite queue key.
+type SearchTarget struct {
+	Queue     *models.GameQueue
+	Metadata  string
+	Composite string
+}
+
+// ResolveSearch resolves the sub-queues a search covers: every pairing
+// of queueIDs with metadata values. An empty queueIDs searches the game's
+// default queue (oldest by created_at), and an empty metadata list the
+// base sub-queues. Metadata is dropped on queues without MetadataEnabled,
+// and pairings that land in the same sub-queue collapse into one.
+func ResolveSearch(gameID string, queueIDs []string, metadata []string) ([]SearchTarget, error) {
+	if len(queueIDs) == 0 {
+		queueIDs = []string{""}
+	}
+	if len(metadata) == 0 {
+		metadata = []string{""}
+	}
+	var targets []SearchTarget
+	seen := make(map[string]bool)
+	for _, queueID := range queueIDs {
+		queue, err := models.ResolveQueue(gameID, queueID)
+		if err != nil {
+			return nil, fmt.Errorf("queue not found: %w", err)
+		}
+		for _, m := range metadata {
+			if !queue.MetadataEnabled {
+				m = ""
+			}
+			composite := extRedis.QueueKey(queue.ID, m)
+			if seen[composite] {
+				continue
+			}
+			seen[composite] = true
+			targets = append(targets, SearchTarget{Queue: queue, Metadata: m, Composite: composite})
+		}
+	}
+	if len(targets) > MAX_SEARCH_QUEUES {
+		return nil, fmt.Errorf("invalid search: at most %d queues at once", MAX_SEARCH_QUEUES)
+	}
+	return targets, nil
+}
+
+// JoinQueue places a player in the matchmaking queue of every sub-queue
+// targets (from ResolveSearch) names, and returns one result per target.
 //
-// When the resolved queue's MetadataEnabled is true, distinct metadata
-// values land in distinct sub-queues — the segmentation feature
+// When a queue's MetadataEnabled is true, distinct metadata values land
+// in distinct sub-queues — the segmentation feature
 // (mode/region/version). Two consequences worth being aware of:
 //
-//  1. A single player CAN be in multiple sub-queues simultaneously by
-//     calling JoinQueue with different metadata. AddPlayerToQueueWithTTL
-//     only dedupes within one queue key. This is intentional: it lets a
-//     player search "casual OR ranked" at once. The first sub-queue to
-//     fill its lobby wins; orphan entries in the others time out via TTL.
+//  1. A single player CAN be in multiple sub-queues simultaneously: one
+//     search may cover several queues and metadata values, say "casual
+//     OR ranked". The entry's search set (see extRedis.SearchQueues)
+//     records every sub-queue it's in, and pairing it in one
+//     (dequeueEntries) atomically withdraws it from the rest, so a player
+//     never lands in two matches. Separate joins for the same player add
+//     to the same search set.
 //
 //  2. If the owner toggles MetadataEnabled off after players have queued
 //     with metadata, those entries linger in their sub-queues. PairPlayers
 //     iterates every "queue_*" key (including composite ones), so they
 //     still get matched as soon as a sub-queue reaches LobbySize, or
 //     expire via TTL. No manual cleanup needed.
-func JoinQueue(ctx context.Context, playerID string, gameID string, queueID string, metadata string) (*JoinQueueResult, error) {
-	queue, err := models.ResolveQueue(gameID, queueID)
-	if err != nil {
+//
+// A party leader's join enqueues the whole party as one entry (see
+// partyQueueEntry); other members only get the resolved queue back and
+// wait on match_ready for the leader's search. A party member's search
+// covers a single sub-queue (ErrPartyMultiQueue otherwise).
+//
+// pings (from ParsePings) replace whatever the player reported on an
+// earlier join; every party member reports their own.
+//
+// A player locked out of the game for penalties, or leading a party with
+// someone who is, is refused with a *LockoutError.
+func JoinQueue(ctx context.Context, playerID string, targets []SearchTarget, pings map[string]int) ([]*JoinQueueResult, error) {
+	// Every target is in the same game, and penalties are game-wide.
+	if err := checkLockout(ctx, targets[0].Queue, playerID); err != nil {
 		return nil, err
 	}
-
-	if !queue.MetadataEnabled {
-		metadata = ""
-	}
-	composite := extRedis.QueueKey(queue.ID, metadata)
-
-	if err := server.S.Redis.AddPlayerToQueueWithTTL(ctx, composite, playerID, QUEUE_TTL); err != nil {
+	if err := server.S.Redis.SetPlayerPings(ctx, playerID, pings, PINGS_TTL); err != nil {
 		return nil, err
 	}
 
-	size, err := server.S.Redis.GameQueueSize(ctx, composite)
-	if err != nil {
-		return nil, err
+	results := make([]*JoinQueueResult, 0, len(targets))
+	// On failure, withdraw whatever this search already queued.
+	rollback := func() {
+		for _, res := range results {
+			if res.EntryID != "" {
+				server.S.Redis.RemovePlayerFromQueue(ctx, res.QueueID, res.EntryID)
+			}
+		}
+	}
+	for _, target := range targets {
+		entry, partyID, follow, err := partyQueueEntry(ctx, playerID, target.Queue)
+		if err == nil && partyID != "" && len(targets) > 1 {
+			err = ErrPartyMultiQueue
+		}
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+		if !follow {
+			if err := server.S.Redis.AddPlayerToQueueWithTTL(ctx, target.Composite, entry, QUEUE_TTL); err != nil {
+				rollback()
+				return nil, err
+			}
+		} else {
+			entry = ""
+		}
+		if partyID != "" {
+			if !follow {
+				server.S.Redis.SetPartyQueue(ctx, partyID, target.Queue.ID, target.Composite)
+			}
+			server.S.Redis.RefreshParty(ctx, partyID, PARTY_TTL)
+		}
+		results = append(results, &JoinQueueResult{
+			QueueID:     target.Composite,
+			GameQueueID: target.Queue.ID,
+			Metadata:    target.Metadata,
+			EntryID:     entry,
+			PartyID:     partyID,
+		})
 	}
 
-	return &JoinQueueResult{QueueSize: size, QueueID: composite, GameQueueID: queue.ID}, nil
+	for _, res := range results {
+		size, err := server.S.Redis.QueuePlayerCount(ctx, res.QueueID)
+		if err != nil {
+			return nil, err
+		}
+		res.QueueSize = size
+	}
+	return results, nil
 }
 
 func QueueSize(ctx context.Context, gameID string, queueID string, metadata string) (int64, error) {
@@ -91,7 +191,7 @@ func QueueSize(ctx context.Context, gameID string, queueID string, metadata stri
 	}
 	composite := extRedis.QueueKey(queue.ID, metadata)
 
-	return server.S.Redis.GameQueueSize(ctx, composite)
+	return server.S.Redis.QueuePlayerCount(ctx, composite)
 }
 
 func LeaveQueue(ctx context.Context, playerID string, gameID string, queueID string, metadata string) error {
@@ -105,12 +205,48 @@ func LeaveQueue(ctx context.Context, playerID string, gameID string, queueID str
 	}
 	composite := extRedis.QueueKey(queue.ID, metadata)
 
-	return server.S.Redis.RemovePlayerFromQueue(ctx, composite, playerID)
+	entry, _, follow, err := partyQueueEntry(ctx, playerID, queue)
+	if err != nil {
+		return err
+	}
+	if follow {
+		return ErrNotPartyLeader
+	}
+	return CancelQueueEntry(ctx, composite, entry)
+}
+
+// CancelQueueEntry withdraws a queue entry on its owner's request. For a
+// party entry the other members' searches end too.
+func CancelQueueEntry(ctx context.Context, composite string, entry string) error {
+	partyID, ok := extRedis.ParsePartyQueueEntry(entry)
+	var party *extRedis.PartyRecord
+	if ok {
+		party, _ = server.S.Redis.GetParty(ctx, partyID)
+	}
+	if party == nil {
+		if err := server.S.Redis.RemovePlayerFromQueue(ctx, composite, entry); err != nil {
+			return err
+		}
+	} else {
+		if party.QueueID == "" {
+			return nil
+		}
+		cancelPartySearch(ctx, party, party.LeaderID, "party left the queue")
+	}
+	recordAbandons(ctx, composite, 1)
+	return nil
 }
 
+// QueueResult is one match_ready notification. A result with MatchID or
+// Error set is final. With a ready check, ProposalID (and ExpiresAt)
+// announce a proposed match the player must accept, and Requeued says
+// a failed proposal put them back in the queue; more results follow both.
 type QueueResult struct {
-	MatchID string
-	Error   error
+	MatchID    string
+	Error      error
+	ProposalID string
+	ExpiresAt  time.Time
+	Requeued   string
 }
 
 // NotifyOnReady subscribes the player to match_ready notifications scoped
@@ -122,18 +258,39 @@ func NotifyOnReady(ctx context.Context, playerID string, gameQueueID string, res
 		pubsub := server.S.Redis.WatchMatchReady(ctx, gameQueueID, playerID)
 		defer pubsub.Close()
 
+		send := func(res QueueResult) bool {
+			select {
+			case resultChan <- res:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
 		for msg := range pubsub.Channel() {
 			if strings.HasPrefix(msg.Payload, "error:") {
-				resultChan <- QueueResult{Error: errors.New(strings.TrimPrefix(msg.Payload, "error:"))}
+				send(QueueResult{Error: errors.New(strings.TrimPrefix(msg.Payload, "error:"))})
 				return
 			}
 			if strings.HasPrefix(msg.Payload, "match_") {
-				resultChan <- QueueResult{MatchID: strings.TrimPrefix(msg.Payload, "match_")}
+				send(QueueResult{MatchID: strings.TrimPrefix(msg.Payload, "match_")})
 				return
 			}
+			if rest, ok := strings.CutPrefix(msg.Payload, "proposed:"); ok {
+				id, expires, _ := strings.Cut(rest, ":")
+				unix, _ := strconv.ParseInt(expires, 10, 64)
+				if !send(QueueResult{ProposalID: id, ExpiresAt: time.Unix(unix, 0)}) {
+					return
+				}
+				continue
+			}
+			if reason, ok := strings.CutPrefix(msg.Payload, "requeued:"); ok {
+				if !send(QueueResult{Requeued: reason}) {
+					return
+				}
+			}
 		}
 
-		resultChan <- QueueResult{Error: fmt.Errorf("player %s not found in queue", playerID)}
+		send(QueueResult{Error: fmt.Errorf("player %s not found in queue", playerID)})
 	}()
 }
 
@@ -152,29 +309,72 @@ func notifyError(ctx context.Context, gameQueueID string, players []string, msg
 // queue carries the per-pool config (image, ports, etc.) and identifies
 // the GameQueue these players were paired in. composite is the full Redis
 // queue key (queue.ID, optionally with the metadata-hash suffix); used by
-// the at-capacity push-back to return players to the same sub-queue they
-// were popped from.
+// the at-capacity push-back to return entries to the same sub-queue they
+// were popped from. entries are pushed back whole, so a party stays one
+// unit.
+//
+// region is the provider location to run the match in; "" means the
+// default region (the first of Config.HCLOUDLocations). Only hosts in that
+// region are considered, and a new one is provisioned there if needed.
 //
 // spectateOverride is the lobby-side opt-out: pass &false to disable
 // spectating for this specific match even when the game has it enabled.
 // Pass nil (the matchmaking-queue path) to inherit the game flag as-is.
 // The override is disable-only — it cannot enable spectating on a game
 // where Game.SpectateEnabled is false.
-func StartMatch(ctx context.Context, game *models.Game, queue *models.GameQueue, composite string, players []string, spectateOverride *bool) error {
-	slog.Info("Starting match", "gameID", game.ID, "gameQueueID", queue.ID, "players", players)
+func StartMatch(ctx context.Context, game *models.Game, queue *models.GameQueue, composite string, entries []QueueEntry, region string, spectateOverride *bool) error {
+	return startMatch(ctx, game, queue, composite, entries, region, spectateOverride, nil)
+}
+
+// startMatch is StartMatch for groups the matchmaker paired: formed is
+// the group's queue statistics, recorded once its match is persisted.
+func startMatch(ctx context.Context, game *models.Game, queue *models.GameQueue, composite string, entries []QueueEntry, region string, spectateOverride *bool, formed *formedRecord) error {
+	cfg := server.S.Config
+	if region == "" {
+		region = cfg.Regions()[0]
+	}
+	players := entryPlayers(entries)
+	slog.Info("Starting match", "gameID", game.ID, "gameQueueID", queue.ID, "region", region, "players", players)
 
 	gamePorts := []int64(queue.MatchmakingMachinePorts)
-	if len(gamePorts) == 0 {
+	if len(gamePorts) == 0 && !queue.ClientReported() {
 		err := fmt.Errorf("queue %s has no ports configured; set matchmaking_machine_ports", queue.ID)
 		slog.Error("Cannot start match", "error", err)
 		notifyError(ctx, queue.ID, players, "server configuration error: no ports defined for this queue")
 		return err
 	}
 
-	cfg := server.S.Config
+	// Split into teams up front so the container, Match row and
+	// match_found all see the same layout. Players are reordered team by
+	// team.
+	var teams [][]string
+	if queue.HasTeams() {
+		ratings, err := models.GetRatingsForPlayers(queue.ID, players)
+		if err != nil {
+			notifyError(ctx, queue.ID, players, "internal error")
+			return fmt.Errorf("read ratings for team balance: %w", err)
+		}
+		if teams, err = balanceTeams(queue, entries, ratings); err != nil {
+			slog.Error("Failed to form teams", "error", err, "gameQueueID", queue.ID)
+			notifyError(ctx, queue.ID, players, "failed to form teams")
+			return err
+		}
+		players = make([]string, 0, len(players))
+		for _, team := range teams {
+			players = append(players, team...)
+		}
+	}
 
-	// Find a host with available capacity, or create one.
-	host, err := models.FindAvailableHost(len(gamePorts), cfg.HCLOUDPortRangeStart, cfg.HCLOUDPortRangeEnd)
+	if queue.ClientReported() {
+		if err := startClientReportedMatch(ctx, game, queue, composite, players, teams); err != nil {
+			return err
+		}
+		recordGroupFormed(ctx, composite, formed)
+		return nil
+	}
+
+	// Find a host in the region with available capacity, or create one.
+	host, err := models.FindAvailableHost(region, len(gamePorts), cfg.HCLOUDPortRangeStart, cfg.HCLOUDPortRangeEnd)
 	if err != nil {
 		slog.Error("Failed to find available host", "error", err)
 		notifyError(ctx, queue.ID, players, "failed to find available server host")
@@ -189,18 +389,18 @@ func StartMatch(ctx context.Context, game *models.Game, queue *models.GameQueue,
 		}
 		if count >= int64(cfg.HCLOUDMaxHosts) {
 			slog.Warn("At capacity: all hosts full and max count reached", "maxHosts", cfg.HCLOUDMaxHosts)
-			server.S.Redis.PushPlayersToQueue(ctx, composite, players)
+			requeueEntries(ctx, queue.ID, composite, entries)
 			return fmt.Errorf("at capacity: %d/%d hosts in use", count, cfg.HCLOUDMaxHosts)
 		}
 
-		slog.Info("No available host; provisioning new one")
+		slog.Info("No available host; provisioning new one", "region", region)
 		tlsOpts, err := buildHostTLSOpts()
 		if err != nil {
 			slog.Error("Failed to read wildcard cert; aborting host provision", "error", err)
 			notifyError(ctx, queue.ID, players, "wildcard cert unavailable")
 			return err
 		}
-		connInfo, err := server.S.Machines.CreateHost(ctx, cfg.HCLOUDHostType, cfg.HCLOUDAgentPort, tlsOpts)
+		connInfo, err := server.S.Machines.CreateHost(ctx, region, cfg.HCLOUDHostType, cfg.HCLOUDAgentPort, tlsOpts)
 		if err != nil {
 			slog.Error("Failed to provision host VM", "error", err)
 			notifyError(ctx, queue.ID, players, "failed to provision server host")
@@ -208,7 +408,7 @@ func StartMatch(ctx context.Context, game *models.Game, queue *models.GameQueue,
 		}
 
 		host, err = models.CreateMachineHost(
-			connInfo.ProviderID, connInfo.PublicIP, connInfo.AgentToken,
+			connInfo.ProviderID, connInfo.PublicIP, connInfo.AgentToken, region,
 			connInfo.AgentPort, cfg.HCLOUDMaxSlotsPerHost,
 		)
 		if err != nil {
@@ -255,6 +455,7 @@ func StartMatch(ctx context.Context, game *models.Game, queue *models.GameQueue,
 		HostPorts:  hostPorts,
 		Token:      authToken,
 		PlayerIDs:  players,
+		Teams:      teams,
 		SpectateID: spectateID,
 	})
 	if err != nil {
@@ -280,7 +481,7 @@ func StartMatch(ctx context.Context, game *models.Game, queue *models.GameQueue,
 		if spectateOverride != nil && !*spectateOverride {
 			spectateEnabled = false
 		}
-		match, err = models.MatchStarted(tx, game.ID, queue.ID, si.ID, authToken, players, spectateEnabled)
+		match, err = models.MatchStarted(tx, game.ID, queue.ID, composite, si.ID, authToken, players, teams, spectateEnabled)
 		if err != nil {
 			return fmt.Errorf("create match: %w", err)
 		}
@@ -292,6 +493,7 @@ func StartMatch(ctx context.Context, game *models.Game, queue *models.GameQueue,
 		notifyError(ctx, queue.ID, players, "internal error")
 		return err
 	}
+	recordGroupFormed(ctx, composite, formed)
 
 	// Kick off the spectator uploader. No-op when match.SpectateEnabled
 	// is false; runs in the background until EndMatch calls spectator.Stop.
@@ -304,10 +506,35 @@ func StartMatch(ctx context.Context, game *models.Game, queue *models.GameQueue,
 	return nil
 }
 
+// startClientReportedMatch is StartMatch for queues whose players report
+// the result themselves: peer-to-peer games with no game server, so no
+// host, container or spectating. The Match row still gets an auth code
+// so connect tokens have something to bind to.
+func startClientReportedMatch(ctx context.Context, game *models.Game, queue *models.GameQueue, composite string, players []string, teams [][]string) error {
+	authToken, err := hetzner.GenerateToken()
+	if err != nil {
+		notifyError(ctx, queue.ID, players, "internal error")
+		return fmt.Errorf("generate auth token: %w", err)
+	}
+	match, err := models.MatchStarted(server.S.DB, game.ID, queue.ID, composite, "", authToken, players, teams, false)
+	if err != nil {
+		slog.Error("Failed to persist match", "error", err)
+		notifyError(ctx, queue.ID, players, "internal error")
+		return err
+	}
+
+	for _, player := range players {
+		server.S.Redis.PublishMatchReady(ctx, queue.ID, player, "match_"+match.ID)
+	}
+	return nil
+}
+
 // PairPlayers walks every queue (including metadata-segmented sub-queues)
 // and pairs LobbySize players together, dispatching them via StartMatch.
-// Per-queue MatchmakingStrategy selects between FIFO ("random") and
-// rating-window pairing ("rating").
+// Per-queue MatchmakingStrategy selects the registered Strategy that
+// forms the groups: FIFO ("random") and rating-window pairing ("rating")
+// are built in. Running matches' backfill requests on a queue are filled
+// before any new group is formed from it.
 func PairPlayers(ctx context.Context) error {
 	keys, err := server.S.Redis.AllQueues(ctx)
 	if err != nil {
@@ -315,6 +542,11 @@ func PairPlayers(ctx context.Context) error {
 		return err
 	}
 
+	// Settle any ready checks whose deadline wake-up was missed.
+	if _, err := ResolveProposals(ctx); err != nil {
+		slog.Error("Failed to resolve match proposals", "error", err)
+	}
+
 	playerPaired := false
 	defer func() {
 		if playerPaired {
@@ -337,161 +569,334 @@ func PairPlayers(ctx context.Context) error {
 			continue
 		}
 
-		qs, err := server.S.Redis.GameQueueSize(ctx, composite)
+		strategy, ok := LookupStrategy(queue.MatchmakingStrategy)
+		if !ok {
+			slog.Warn("Unknown matchmaking strategy; pairing FIFO", "strategy", queue.MatchmakingStrategy, "gameQueueID", queue.ID)
+			strategy = fifoStrategy{}
+		}
+		// Open backfill requests are served first, and may take fewer
+		// players than a lobby.
+		backfills, err := server.S.Redis.Backfills(ctx, composite)
+		if err != nil {
+			slog.Error("Failed to read backfill requests", "error", err, "composite", composite)
+		}
+		minPlayers := queue.MinLobbySize()
+		if len(backfills) > 0 {
+			minPlayers = 1
+		}
+		snap, err := loadSnapshot(ctx, composite, queue, minPlayers)
 		if err != nil {
-			slog.Error("Failed to get queue size", "error", err, "composite", composite)
+			slog.Error("Failed to snapshot queue", "error", err, "composite", composite)
 			continue
 		}
-		slog.Debug("Pairing players", "composite", composite, "queueSize", qs, "strategy", queue.MatchmakingStrategy)
-
-		var paired bool
-		if queue.MatchmakingStrategy == models.MATCHMAKING_STRATEGY_RATING {
-			paired = pairByRating(ctx, composite, game, queue)
-		} else {
-			paired = pairFIFO(ctx, composite, game, queue)
+		if snap == nil {
+			continue
 		}
-		if paired {
-			playerPaired = true
+		if len(backfills) > 0 {
+			snap.Entries = fillBackfills(ctx, queue, composite, snap, backfills)
+			if len(entryPlayers(snap.Entries)) < queue.MinLobbySize() {
+				continue
+			}
+		}
+		slog.Debug("Pairing players", "composite", composite, "queueSize", len(snap.Entries), "strategy", queue.MatchmakingStrategy)
+
+		for _, group := range strategy.Pair(snap) {
+			if !snap.groupAllowed(group) {
+				slog.Warn("Strategy grouped players who can't share a match; skipping the group",
+					"strategy", queue.MatchmakingStrategy, "composite", composite, "entries", entryIDs(group))
+				continue
+			}
+			dequeued, err := dispatchGroup(ctx, game, queue, composite, group, snap.GroupRegion(group), newFormedRecord(snap, group))
+			if !dequeued {
+				break
+			}
+			// StartMatch already pushed entries back on capacity errors;
+			// other errors leave them out (they'll re-queue or time out).
+			if err == nil {
+				playerPaired = true
+			}
 		}
 	}
 
 	return nil
 }
 
-// pairFIFO is the original first-in-first-out pairing: pop LobbySize
-// players from the front of the queue and dispatch.
-func pairFIFO(ctx context.Context, composite string, game *models.Game, queue *models.GameQueue) bool {
-	paired := false
-	for queueSize, err := server.S.Redis.GameQueueSize(ctx, composite); err == nil && queueSize >= int64(queue.LobbySize); queueSize, err = server.S.Redis.GameQueueSize(ctx, composite) {
-		players, err := server.S.Redis.PopPlayersFromQueue(ctx, composite, queue.LobbySize)
-		if err != nil {
-			slog.Error("Failed to pop players from queue", "error", err, "composite", composite)
-			break
+// fifoStrategy is the original first-in-first-out pairing: starting from
+// the front of the queue, take entries until they add up to LobbySize
+// players. An entry that doesn't fit the seats left (a party bigger than
+// the remainder) keeps its place for the next match. If the entries
+// behind the head can't complete its lobby, the next entry gets to anchor
+// one instead, so a solo player waiting alone doesn't hold up a party
+// that fills a lobby by itself. Each anchor fills its lobby from the
+// entries sharing one of its regions, trying its best region first; past
+// the queue's fill timeout an anchor that can't fill its lobby starts
+// short with everyone it could take. Entries that can't be grouped with
+// those already taken (see CanGroup) are passed over.
+type fifoStrategy struct{}
+
+func (fifoStrategy) Pair(snap *QueueSnapshot) [][]QueueEntry {
+	var groups [][]QueueEntry
+	remaining := snap.Entries
+	for {
+		group := fifoGroup(snap, remaining)
+		if group == nil {
+			return groups
 		}
+		groups = append(groups, group)
+		remaining = withoutEntries(remaining, group)
+	}
+}
 
-		if len(players) < queue.LobbySize {
-			server.S.Redis.PushPlayersToQueue(ctx, composite, players)
-			slog.Info("Not enough players, putting them back in queue", "composite", composite, "players", players)
-			break
+// fifoGroup returns the first group fifoStrategy can form from entries,
+// or nil.
+func fifoGroup(snap *QueueSnapshot, entries []QueueEntry) []QueueEntry {
+	for _, anchor := range entries {
+		for _, region := range snap.AcceptableRegions(anchor) {
+			pool := snap.inRegion(entries, region)
+			i := slices.IndexFunc(pool, func(e QueueEntry) bool { return e.ID == anchor.ID })
+			if group := fillLobby(snap, pool, i); group != nil && teamsFit(snap.Queue, group) {
+				return group
+			}
 		}
+	}
+	return nil
+}
 
-		if err := StartMatch(ctx, game, queue, composite, players, nil); err != nil {
-			continue
+// fillLobby builds a group of LobbySize players around entries[anchor],
+// adding the other entries in order wherever they fit and may join the
+// group. When they fall short, the group is returned anyway if
+// ShortGroupDue allows it, else nil.
+func fillLobby(snap *QueueSnapshot, entries []QueueEntry, anchor int) []QueueEntry {
+	seats := snap.Queue.LobbySize - len(entries[anchor].Players)
+	if seats < 0 {
+		return nil
+	}
+	group := []QueueEntry{entries[anchor]}
+	for i, e := range entries {
+		if seats == 0 {
+			break
 		}
-		paired = true
+		if i != anchor && len(e.Players) <= seats && snap.fitsGroup(group, e) {
+			group = append(group, e)
+			seats -= len(e.Players)
+		}
+	}
+	if seats > 0 && !snap.ShortGroupDue(group) {
+		return nil
 	}
-	return paired
+	return group
 }
 
+// unboundedRatingWindow stands in for a step with no spread limit.
+const unboundedRatingWindow = 1 << 20
+
 // ratingWindow returns the rating-difference tolerance a player will
-// accept after waiting for `waited`. Tiered: tight at first, loose after
-// 30s, unbounded after 60s so no one starves on a sparse queue.
-func ratingWindow(waited time.Duration) int {
-	switch {
-	case waited < RATING_TIER_TIGHT:
-		return RATING_WINDOW_TIGHT
-	case waited < RATING_TIER_LOOSE:
-		return RATING_WINDOW_LOOSE
-	default:
-		return 1 << 20
+// accept after waiting for `waited`: the spread of the last step of the
+// queue's schedule they've reached, clamped to RatingWindowCap when set.
+// The window only widens with time, so fresh entrants get tightly matched
+// and long-waiting entrants don't starve.
+func ratingWindow(queue *models.GameQueue, waited time.Duration) int {
+	window := unboundedRatingWindow
+	for _, step := range queue.RatingWindowSchedule() {
+		if waited < time.Duration(step.AfterSeconds)*time.Second {
+			break
+		}
+		window = step.MaxSpread
+		if window == 0 {
+			window = unboundedRatingWindow
+		}
+	}
+	if queue.RatingWindowCap > 0 && window > queue.RatingWindowCap {
+		window = queue.RatingWindowCap
 	}
+	return window
 }
 
-// pairByRating groups queued players by rating closeness within their
-// wait-expanding tolerance window. Seed is the longest-waiting player; the
-// LobbySize-1 closest-rated others form the candidate group, which is
-// accepted only if max-min rating <= seed's window. Players that don't
-// fit are deferred to the next pass, where their window will be larger.
-func pairByRating(ctx context.Context, composite string, game *models.Game, queue *models.GameQueue) bool {
-	paired := false
-	for {
-		ids, err := server.S.Redis.AllPlayersInQueue(ctx, composite)
-		if err != nil {
-			slog.Error("Failed to read queue for rating MM", "error", err, "composite", composite)
-			return paired
-		}
-		if len(ids) < queue.LobbySize {
-			return paired
-		}
+// fullyExpandedWait is a wait long enough to reach the last step of the
+// queue's window schedule.
+func fullyExpandedWait(queue *models.GameQueue) time.Duration {
+	steps := queue.RatingWindowSchedule()
+	return time.Duration(steps[len(steps)-1].AfterSeconds) * time.Second
+}
 
-		joinTimes, err := server.S.Redis.QueueJoinTimes(ctx, composite)
-		if err != nil {
-			slog.Error("Failed to read queue join times", "error", err, "composite", composite)
-			return paired
-		}
-		ratings, err := models.GetRatingsForPlayers(queue.ID, ids)
-		if err != nil {
-			slog.Error("Failed to read player ratings", "error", err, "gameQueueID", queue.ID)
-			return paired
+// ratingStrategy groups queued entries by rating closeness within their
+// wait-expanding tolerance window. A party is one entry rated at its
+// members' average. Seed is the longest-waiting entry (skipping any that
+// the rest of the queue can't complete a lobby around); the closest-rated
+// others that fit the remaining seats fill out LobbySize players, and the
+// group is accepted only if max-min rating <= seed's window. Once the
+// seed's group doesn't fit, the rest are deferred to the next pass, where
+// their windows will be larger. The seed's group is drawn from entries
+// sharing one of its regions, best region first; the first that fits the
+// window wins. Past the queue's fill timeout a seed that can't fill a
+// lobby within its window starts short with every entry there that keeps
+// the group inside it. Closer-rated entries that can't be grouped with
+// the seed's group (see CanGroup) are skipped for the next closest.
+type ratingStrategy struct{}
+
+func (ratingStrategy) Pair(snap *QueueSnapshot) [][]QueueEntry {
+	queue := snap.Queue
+	cands := make([]cand, 0, len(snap.Entries))
+	for _, e := range snap.Entries {
+		waited, ok := snap.Waited(e.ID)
+		if !ok {
+			// No recorded join time — treat as fully expanded
+			// so a stale entry doesn't get stuck waiting forever.
+			waited = fullyExpandedWait(queue)
 		}
+		cands = append(cands, cand{entry: e, rating: snap.EntryRating(e), waited: waited})
+	}
+	// Seed = longest-waiting entry that can anchor a full lobby. Its
+	// window decides admissibility.
+	sort.SliceStable(cands, func(i, j int) bool {
+		return cands[i].waited > cands[j].waited
+	})
 
-		now := time.Now()
-		type cand struct {
-			id     string
-			rating int
-			waited time.Duration
-		}
-		cands := make([]cand, 0, len(ids))
-		for _, id := range ids {
-			r, ok := ratings[id]
-			if !ok {
-				r = queue.DefaultRating
+	var groups [][]QueueEntry
+	for {
+		var seed cand
+		var regionGroups [][]cand
+		for _, c := range cands {
+			if regionGroups = seedGroups(snap, cands, c, ratingWindow(queue, c.waited)); len(regionGroups) > 0 {
+				seed = c
+				break
 			}
-			var waited time.Duration
-			if ts, ok := joinTimes[id]; ok {
-				waited = now.Sub(time.Unix(ts, 0))
-			} else {
-				// No recorded join time — treat as fully expanded
-				// so a stale entry doesn't get stuck waiting forever.
-				waited = RATING_TIER_LOOSE
+		}
+		if len(regionGroups) == 0 {
+			return groups
+		}
+		window := ratingWindow(queue, seed.waited)
+
+		var group []cand
+		spread := 0
+		for _, g := range regionGroups {
+			if spread = ratingSpread(g); spread <= window {
+				group = g
+				break
 			}
-			cands = append(cands, cand{id: id, rating: r, waited: waited})
+		}
+		if group == nil {
+			slog.Debug("No group within rating window; deferring",
+				"gameQueueID", queue.ID, "seedWaited", seed.waited, "window", window, "spread", spread)
+			return groups
 		}
 
-		// Seed = longest-waiting player. Their window decides admissibility.
-		sort.Slice(cands, func(i, j int) bool {
-			return cands[i].waited > cands[j].waited
-		})
-		seed := cands[0]
-		window := ratingWindow(seed.waited)
-
-		// Among the rest, take the LobbySize-1 closest in rating to the seed.
-		others := cands[1:]
-		sort.Slice(others, func(i, j int) bool {
-			return abs(others[i].rating-seed.rating) < abs(others[j].rating-seed.rating)
-		})
-		group := append([]cand{seed}, others[:queue.LobbySize-1]...)
+		entries := candEntries(group)
+		groups = append(groups, entries)
+		cands = withoutCands(cands, entries)
+	}
+}
 
-		minR, maxR := group[0].rating, group[0].rating
-		for _, c := range group[1:] {
-			if c.rating < minR {
-				minR = c.rating
-			}
-			if c.rating > maxR {
-				maxR = c.rating
+// seedGroups returns the lobby rateGroup fills around seed in each of
+// its regions where one can be formed, best region first.
+func seedGroups(snap *QueueSnapshot, cands []cand, seed cand, window int) [][]cand {
+	var out [][]cand
+	for _, region := range snap.AcceptableRegions(seed.entry) {
+		pool := make([]cand, 0, len(cands))
+		for _, c := range cands {
+			if snap.Accepts(c.entry, region) {
+				pool = append(pool, c)
 			}
 		}
-		if maxR-minR > window {
-			slog.Debug("No group within rating window; deferring",
-				"composite", composite, "seedWaited", seed.waited, "window", window, "spread", maxR-minR)
-			return paired
+		i := slices.IndexFunc(pool, func(c cand) bool { return c.entry.ID == seed.entry.ID })
+		if group := rateGroup(snap, pool, i, window); group != nil && teamsFit(snap.Queue, candEntries(group)) {
+			out = append(out, group)
 		}
+	}
+	return out
+}
 
-		groupIDs := make([]string, len(group))
-		for i, c := range group {
-			groupIDs[i] = c.id
+// ratingSpread is max-min rating across a group.
+func ratingSpread(group []cand) int {
+	minR, maxR := group[0].rating, group[0].rating
+	for _, c := range group[1:] {
+		minR = min(minR, c.rating)
+		maxR = max(maxR, c.rating)
+	}
+	return maxR - minR
+}
+
+// cand is a queue entry as ratingStrategy sees it: a party is rated at its
+// members' average.
+type cand struct {
+	entry  QueueEntry
+	rating int
+	waited time.Duration
+}
+
+// withoutCands returns cands minus the given entries, keeping order.
+func withoutCands(cands []cand, taken []QueueEntry) []cand {
+	ids := make(map[string]bool, len(taken))
+	for _, e := range taken {
+		ids[e.ID] = true
+	}
+	rest := make([]cand, 0, len(cands))
+	for _, c := range cands {
+		if !ids[c.entry.ID] {
+			rest = append(rest, c)
 		}
-		if err := server.S.Redis.RemovePlayersFromQueue(ctx, composite, groupIDs); err != nil {
-			slog.Error("Failed to remove paired players from queue", "error", err, "composite", composite)
-			return paired
+	}
+	return rest
+}
+
+func candEntries(cands []cand) []QueueEntry {
+	entries := make([]QueueEntry, len(cands))
+	for i, c := range cands {
+		entries[i] = c.entry
+	}
+	return entries
+}
+
+// rateGroup fills a lobby around cands[seed] with the closest-rated other
+// entries that fit the seats left and may join the group. When that
+// lobby can't be filled within window and ShortGroupDue allows it, the
+// group is refilled from only the entries that keep it inside window and
+// returned short. Otherwise returns the full lobby whatever its spread,
+// or nil when there's none.
+func rateGroup(snap *QueueSnapshot, cands []cand, seed int, window int) []cand {
+	if snap.Queue.LobbySize < len(cands[seed].entry.Players) {
+		return nil
+	}
+	others := make([]cand, 0, len(cands)-1)
+	others = append(others, cands[:seed]...)
+	others = append(others, cands[seed+1:]...)
+	sort.SliceStable(others, func(i, j int) bool {
+		return abs(others[i].rating-cands[seed].rating) < abs(others[j].rating-cands[seed].rating)
+	})
+	full, seats := closestGroup(snap, cands[seed], others, unboundedRatingWindow)
+	if seats > 0 {
+		full = nil
+	}
+	if full != nil && ratingSpread(full) <= window || snap.Queue.MinPlayers == 0 {
+		return full
+	}
+	if short, seats := closestGroup(snap, cands[seed], others, window); seats == 0 || snap.ShortGroupDue(candEntries(short)) {
+		return short
+	}
+	return full
+}
+
+// closestGroup adds others, closest-rated first, to a group around seed
+// wherever they fit the seats left, may join the group and keep its
+// spread within window. Returns the group and the seats it left empty.
+func closestGroup(snap *QueueSnapshot, seed cand, others []cand, window int) ([]cand, int) {
+	seats := snap.Queue.LobbySize - len(seed.entry.Players)
+	group := []cand{seed}
+	members := []QueueEntry{seed.entry}
+	minR, maxR := seed.rating, seed.rating
+	for _, c := range others {
+		if seats == 0 {
+			break
 		}
-		if err := StartMatch(ctx, game, queue, composite, groupIDs, nil); err != nil {
-			// StartMatch already pushed players back on capacity errors;
-			// other errors leave them out (they'll re-queue or time out).
+		if len(c.entry.Players) > seats || max(maxR, c.rating)-min(minR, c.rating) > window || !snap.fitsGroup(members, c.entry) {
 			continue
 		}
-		paired = true
+		group = append(group, c)
+		members = append(members, c.entry)
+		minR, maxR = min(minR, c.rating), max(maxR, c.rating)
+		seats -= len(c.entry.Players)
 	}
+	return group, seats
 }
 
 func abs(x int) int {
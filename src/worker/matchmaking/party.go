@@ -0,0 +1,369 @@
+package matchmaking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/google/uuid"
+)
+
+const (
+	// PARTY_TTL is how long an untouched party lives. Every party
+	// mutation and queue join pushes it back out.
+	PARTY_TTL = 6 * time.Hour
+	// PARTY_MAX_SIZE caps party membership independent of any queue; a
+	// party also has to fit the LobbySize of whichever queue it joins.
+	PARTY_MAX_SIZE = 16
+)
+
+var (
+	ErrNotPartyLeader = errors.New("only the party leader can do that")
+	ErrPartyInQueue   = errors.New("party is searching for a match")
+)
+
+// QueueEntry is one unit in a matchmaking queue: a single player, or a
+// party that must be placed together. ID is the value stored in the
+// Redis queue list; Players lists who it stands for, in party join
+// order.
+type QueueEntry struct {
+	ID      string
+	Players []string
+	// Searches holds, once the entry is claimed, the other queues it was
+	// searching and its join time in each, so a dispatch that can't
+	// start can put it back in all of them.
+	Searches map[string]int64
+}
+
+// SoloEntries wraps individual players as queue entries, for callers like
+// the lobby flow that dispatch to StartMatch without going through the
+// queue list.
+func SoloEntries(playerIDs []string) []QueueEntry {
+	entries := make([]QueueEntry, len(playerIDs))
+	for i, id := range playerIDs {
+		entries[i] = QueueEntry{ID: id, Players: []string{id}}
+	}
+	return entries
+}
+
+func entryIDs(entries []QueueEntry) []string {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+// entryPlayers flattens entries into the match's player list. Party
+// members stay adjacent so a team split over the list keeps them
+// together.
+func entryPlayers(entries []QueueEntry) []string {
+	var players []string
+	for _, e := range entries {
+		players = append(players, e.Players...)
+	}
+	return players
+}
+
+// loadQueueEntries reads a queue list in order and resolves each party
+// entry to its members. Entries for parties that have since disbanded are
+// dropped from the queue.
+func loadQueueEntries(ctx context.Context, composite string) ([]QueueEntry, error) {
+	ids, err := server.S.Redis.AllPlayersInQueue(ctx, composite)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]QueueEntry, 0, len(ids))
+	for _, id := range ids {
+		partyID, ok := extRedis.ParsePartyQueueEntry(id)
+		if !ok {
+			entries = append(entries, QueueEntry{ID: id, Players: []string{id}})
+			continue
+		}
+		members, err := server.S.Redis.PartyMemberIDs(ctx, partyID)
+		if err != nil {
+			return nil, err
+		}
+		if len(members) == 0 {
+			slog.Info("Dropping queue entry for disbanded party", "partyID", partyID, "composite", composite)
+			server.S.Redis.RemovePlayerFromQueue(ctx, composite, id)
+			continue
+		}
+		entries = append(entries, QueueEntry{ID: id, Players: members})
+	}
+	return entries, nil
+}
+
+// dequeueEntries claims paired entries: it removes them from the queue
+// list, and from any other queue they were searching (recording those
+// in each entry's Searches), and marks their parties as no longer
+// searching. Returns extRedis.ErrQueueEntryTaken, claiming none of
+// them, when another queue got to one first.
+func dequeueEntries(ctx context.Context, composite string, entries []QueueEntry) error {
+	searches, err := server.S.Redis.ClaimQueueEntries(ctx, composite, entryIDs(entries))
+	if err != nil {
+		return err
+	}
+	for i, e := range entries {
+		entries[i].Searches = searches[e.ID]
+		if partyID, ok := extRedis.ParsePartyQueueEntry(e.ID); ok {
+			server.S.Redis.SetPartyQueue(ctx, partyID, "", "")
+		}
+	}
+	return nil
+}
+
+// requeueEntries undoes dequeueEntries after a dispatch that couldn't
+// start, putting the entries back at the end of the queue and in every
+// other queue they were searching.
+func requeueEntries(ctx context.Context, gameQueueID, composite string, entries []QueueEntry) {
+	if err := server.S.Redis.PushPlayersToQueue(ctx, composite, entryIDs(entries), QUEUE_TTL); err != nil {
+		slog.Error("Failed to push entries back to queue", "error", err, "composite", composite)
+		return
+	}
+	for _, e := range entries {
+		returnToSearches(ctx, e.ID, e.Searches)
+		if partyID, ok := extRedis.ParsePartyQueueEntry(e.ID); ok {
+			server.S.Redis.SetPartyQueue(ctx, partyID, gameQueueID, composite)
+		}
+	}
+}
+
+// returnToSearches puts a requeued entry back in the other queues it was
+// searching when it was claimed. Failures are only logged: the entry is
+// already back in the queue that paired it.
+func returnToSearches(ctx context.Context, entry string, searches map[string]int64) {
+	if err := server.S.Redis.ReturnToSearches(ctx, entry, searches, QUEUE_TTL); err != nil {
+		slog.Warn("Failed to return entry to its other queues", "error", err, "entry", entry)
+	}
+}
+
+// cancelPartySearch pulls a searching party out of its queue and tells
+// every member except `except` why, ending their /match/join sessions.
+// No-op when the party isn't searching.
+func cancelPartySearch(ctx context.Context, party *extRedis.PartyRecord, except string, reason string) {
+	if party.QueueID == "" {
+		return
+	}
+	entry := extRedis.PartyQueueEntry(party.ID)
+	if err := server.S.Redis.RemovePlayerFromQueue(ctx, party.QueueID, entry); err != nil && !errors.Is(err, extRedis.ErrPlayerNotInQueue) {
+		slog.Warn("Failed to remove party from queue", "error", err, "partyID", party.ID, "queueID", party.QueueID)
+	}
+	server.S.Redis.SetPartyQueue(ctx, party.ID, "", "")
+
+	members, err := server.S.Redis.PartyMemberIDs(ctx, party.ID)
+	if err != nil {
+		slog.Warn("Failed to read party members", "error", err, "partyID", party.ID)
+		return
+	}
+	notify := make([]string, 0, len(members))
+	for _, id := range members {
+		if id != except {
+			notify = append(notify, id)
+		}
+	}
+	notifyError(ctx, party.GameQueueID, notify, reason)
+}
+
+// isPartySearching reports whether the party's queue entry is still live.
+// The queue fields on the record can outlast an entry that timed out.
+func isPartySearching(ctx context.Context, party *extRedis.PartyRecord) (bool, error) {
+	if party.QueueID == "" {
+		return false, nil
+	}
+	return server.S.Redis.IsPlayerConnectionAlive(ctx, party.QueueID, extRedis.PartyQueueEntry(party.ID))
+}
+
+type PartyInfo struct {
+	Party   *extRedis.PartyRecord
+	Members []extRedis.PartyMember
+	Invites []string
+}
+
+func loadPartyInfo(ctx context.Context, partyID string) (*PartyInfo, error) {
+	party, err := server.S.Redis.GetParty(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := server.S.Redis.PartyMembers(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	invites, err := server.S.Redis.PartyInvites(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	searching, err := isPartySearching(ctx, party)
+	if err != nil {
+		return nil, err
+	}
+	if !searching {
+		party.QueueID, party.GameQueueID = "", ""
+	}
+	return &PartyInfo{Party: party, Members: members, Invites: invites}, nil
+}
+
+// CreateParty starts a party led by playerID. Returns
+// extRedis.ErrAlreadyInParty if they're already in one.
+func CreateParty(ctx context.Context, playerID, displayName string) (*PartyInfo, error) {
+	rec := &extRedis.PartyRecord{
+		ID:        uuid.New().String(),
+		LeaderID:  playerID,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := server.S.Redis.CreateParty(ctx, rec, displayName, PARTY_TTL); err != nil {
+		return nil, err
+	}
+	return loadPartyInfo(ctx, rec.ID)
+}
+
+// GetPlayerParty returns the party playerID belongs to, or
+// extRedis.ErrPartyNotFound when they aren't in one.
+func GetPlayerParty(ctx context.Context, playerID string) (*PartyInfo, error) {
+	partyID, err := server.S.Redis.PlayerParty(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if partyID == "" {
+		return nil, extRedis.ErrPartyNotFound
+	}
+	return loadPartyInfo(ctx, partyID)
+}
+
+// PlayerPartyInvites returns the parties that have invited playerID.
+func PlayerPartyInvites(ctx context.Context, playerID string) ([]*PartyInfo, error) {
+	ids, err := server.S.Redis.PlayerPartyInvites(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*PartyInfo, 0, len(ids))
+	for _, id := range ids {
+		info, err := loadPartyInfo(ctx, id)
+		if errors.Is(err, extRedis.ErrPartyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// InviteToParty lets the leader invite inviteeID. Inviting someone who's
+// already a member is a no-op.
+func InviteToParty(ctx context.Context, leaderID, partyID, inviteeID string) (*PartyInfo, error) {
+	info, err := loadPartyInfo(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	if info.Party.LeaderID != leaderID {
+		return nil, ErrNotPartyLeader
+	}
+	for _, m := range info.Members {
+		if m.ID == inviteeID {
+			return info, nil
+		}
+	}
+	if len(info.Members) >= PARTY_MAX_SIZE {
+		return nil, extRedis.ErrPartyFull
+	}
+	if err := server.S.Redis.InviteToParty(ctx, partyID, inviteeID, PARTY_TTL); err != nil {
+		return nil, err
+	}
+	server.S.Redis.RefreshParty(ctx, partyID, PARTY_TTL)
+	return loadPartyInfo(ctx, partyID)
+}
+
+// JoinParty accepts an invite. Refused with ErrPartyInQueue while the
+// party is searching: its queue entry was sized when the leader joined.
+func JoinParty(ctx context.Context, playerID, displayName, partyID string) (*PartyInfo, error) {
+	party, err := server.S.Redis.GetParty(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	searching, err := isPartySearching(ctx, party)
+	if err != nil {
+		return nil, err
+	}
+	if searching {
+		return nil, ErrPartyInQueue
+	}
+	if err := server.S.Redis.JoinParty(ctx, partyID, playerID, displayName, PARTY_MAX_SIZE, PARTY_TTL); err != nil {
+		return nil, err
+	}
+	server.S.Redis.RefreshParty(ctx, partyID, PARTY_TTL)
+	return loadPartyInfo(ctx, partyID)
+}
+
+// LeaveParty removes playerID from the party. When the leader leaves the
+// party is disbanded. Either way a party that was searching is pulled out
+// of its queue and the remaining members' searches end, since the group
+// that queued no longer exists. Returns whether the party was disbanded.
+func LeaveParty(ctx context.Context, playerID, partyID string) (bool, error) {
+	party, err := server.S.Redis.GetParty(ctx, partyID)
+	if err != nil {
+		return false, err
+	}
+	current, err := server.S.Redis.PlayerParty(ctx, playerID)
+	if err != nil {
+		return false, err
+	}
+	if current != partyID {
+		return false, extRedis.ErrPartyNotFound
+	}
+
+	disband := party.LeaderID == playerID
+	reason := "party member left"
+	if disband {
+		reason = "party disbanded"
+	}
+	cancelPartySearch(ctx, party, playerID, reason)
+
+	if disband {
+		return true, server.S.Redis.DeleteParty(ctx, partyID)
+	}
+	if err := server.S.Redis.RemovePartyMember(ctx, partyID, playerID); err != nil {
+		return false, err
+	}
+	server.S.Redis.RefreshParty(ctx, partyID, PARTY_TTL)
+	return false, nil
+}
+
+// partyQueueEntry resolves what JoinQueue should enqueue for playerID.
+// Solo players enqueue themselves. A party leader enqueues the whole
+// party, provided it fits the queue's LobbySize (and TeamSize: a party
+// always shares a team). Other members don't enqueue anything
+// (follow=true): they wait on match_ready for the leader's search.
+func partyQueueEntry(ctx context.Context, playerID string, queue *models.GameQueue) (entry string, partyID string, follow bool, err error) {
+	partyID, err = server.S.Redis.PlayerParty(ctx, playerID)
+	if err != nil || partyID == "" {
+		return playerID, "", false, err
+	}
+	party, err := server.S.Redis.GetParty(ctx, partyID)
+	if errors.Is(err, extRedis.ErrPartyNotFound) {
+		return playerID, "", false, nil
+	}
+	if err != nil {
+		return "", "", false, err
+	}
+	if party.LeaderID != playerID {
+		return "", partyID, true, nil
+	}
+	members, err := server.S.Redis.PartyMemberIDs(ctx, partyID)
+	if err != nil {
+		return "", "", false, err
+	}
+	if len(members) > queue.LobbySize {
+		return "", "", false, fmt.Errorf("party of %d does not fit this queue's lobby size of %d", len(members), queue.LobbySize)
+	}
+	if queue.HasTeams() && len(members) > queue.TeamSize {
+		return "", "", false, fmt.Errorf("party of %d does not fit this queue's team size of %d", len(members), queue.TeamSize)
+	}
+	return extRedis.PartyQueueEntry(partyID), partyID, false, nil
+}
@@ -0,0 +1,70 @@
+package matchmaking
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+)
+
+// LockoutError refuses a queue join while a player — the caller, or a
+// member of the party they lead — is locked out of the game's
+// matchmaking for penalties.
+type LockoutError struct {
+	PlayerID string
+	Until    time.Time
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("locked out of matchmaking until %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+// checkLockout returns a *LockoutError when playerID, or anyone in their
+// party, is locked out of queue's game.
+func checkLockout(ctx context.Context, queue *models.GameQueue, playerID string) error {
+	players := []string{playerID}
+	if partyID, err := server.S.Redis.PlayerParty(ctx, playerID); err == nil && partyID != "" {
+		if members, err := server.S.Redis.PartyMemberIDs(ctx, partyID); err == nil && len(members) > 0 {
+			players = members
+		}
+	}
+	now := time.Now()
+	for _, id := range players {
+		penalty, err := models.GetPlayerPenalty(queue, id, now)
+		if err != nil {
+			return err
+		}
+		if penalty.IsLockedOut(now) {
+			return &LockoutError{PlayerID: id, Until: *penalty.LockedUntil}
+		}
+	}
+	return nil
+}
+
+// penalize charges a player for an offense in queue. Failures are only
+// logged: a penalty never holds up matchmaking.
+func penalize(queue *models.GameQueue, playerID string, offense string) {
+	penalty, err := models.RecordPenalty(queue, playerID, offense, time.Now())
+	if err != nil {
+		slog.Warn("Failed to record penalty", "error", err, "playerID", playerID, "offense", offense, "gameQueueID", queue.ID)
+		return
+	}
+	if penalty != nil {
+		slog.Info("Penalty recorded", "playerID", playerID, "offense", offense, "points", penalty.Points, "lockedUntil", penalty.LockedUntil)
+	}
+}
+
+// PenalizeDodge charges a player who left /match/join after being paired
+// into match, before they received match_found.
+func PenalizeDodge(match *models.Match, playerID string) {
+	penalize(&match.GameQueue, playerID, models.OffenseDodge)
+}
+
+// PenalizeAbandon charges a player whose game server reported them
+// leaving the match while it was running.
+func PenalizeAbandon(match *models.Match, playerID string) {
+	penalize(&match.GameQueue, playerID, models.OffenseAbandon)
+}
@@ -0,0 +1,194 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+)
+
+const (
+	// WAIT_SAMPLES is how many recent wait times a queue's estimate is
+	// drawn from.
+	WAIT_SAMPLES = 100
+	// QUEUE_STATS_RETENTION bounds how far back queue statistics reach,
+	// and how long an idle queue's estimate survives.
+	QUEUE_STATS_RETENTION = 24 * time.Hour
+)
+
+// WaitEstimate is how long recent entries in a queue waited before being
+// matched, in seconds.
+type WaitEstimate struct {
+	MedianSeconds int64 `json:"estimated_wait_seconds"`
+	P90Seconds    int64 `json:"p90_wait_seconds"`
+	Samples       int   `json:"wait_samples"`
+}
+
+// formedRecord is one formed match as logged for queue statistics.
+type formedRecord struct {
+	Entries int     `json:"entries"`
+	Players int     `json:"players"`
+	Spread  int     `json:"spread"`
+	Waits   []int64 `json:"waits"`
+}
+
+// newFormedRecord summarizes a group paired from snap for the queue's
+// statistics. It's recorded by recordGroupFormed once the group's match
+// has started.
+func newFormedRecord(snap *QueueSnapshot, group []QueueEntry) *formedRecord {
+	rec := &formedRecord{Entries: len(group), Players: len(entryPlayers(group))}
+	minR, maxR := snap.EntryRating(group[0]), snap.EntryRating(group[0])
+	for _, e := range group {
+		r := snap.EntryRating(e)
+		minR, maxR = min(minR, r), max(maxR, r)
+		if waited, ok := snap.Waited(e.ID); ok {
+			rec.Waits = append(rec.Waits, int64(waited/time.Second))
+		}
+	}
+	rec.Spread = maxR - minR
+	return rec
+}
+
+// recordGroupFormed feeds a paired group whose match just started into
+// the queue's statistics: each entry's wait updates the sub-queue's
+// estimate, and the group is logged for the owner's stats. No-op for a
+// nil rec (matches not formed by the matchmaker, like lobbies). Best
+// effort — failures are logged and otherwise ignored.
+func recordGroupFormed(ctx context.Context, composite string, rec *formedRecord) {
+	if rec == nil {
+		return
+	}
+	if len(rec.Waits) > 0 {
+		samples, err := server.S.Redis.PushQueueWaits(ctx, composite, rec.Waits, WAIT_SAMPLES, QUEUE_STATS_RETENTION)
+		if err != nil {
+			slog.Warn("Failed to record queue wait times", "error", err, "composite", composite)
+		} else if est := estimateWait(samples); est != nil {
+			if err := server.S.Redis.SetQueueWaitEstimate(ctx, composite, est.MedianSeconds, est.P90Seconds, est.Samples, QUEUE_STATS_RETENTION); err != nil {
+				slog.Warn("Failed to store queue wait estimate", "error", err, "composite", composite)
+			}
+		}
+	}
+
+	gameQueueID := extRedis.ParseQueueKey(composite)
+	encoded, _ := json.Marshal(rec)
+	if err := server.S.Redis.RecordMatchFormed(ctx, gameQueueID, time.Now(), string(encoded), QUEUE_STATS_RETENTION); err != nil {
+		slog.Warn("Failed to record formed match", "error", err, "gameQueueID", gameQueueID)
+	}
+}
+
+// recordAbandons counts queue entries that left a queue without being
+// matched. Best effort.
+func recordAbandons(ctx context.Context, composite string, count int) {
+	gameQueueID := extRedis.ParseQueueKey(composite)
+	if err := server.S.Redis.RecordQueueAbandons(ctx, gameQueueID, time.Now(), count, QUEUE_STATS_RETENTION); err != nil {
+		slog.Warn("Failed to record queue abandonment", "error", err, "gameQueueID", gameQueueID)
+	}
+}
+
+// estimateWait summarizes wait samples, or returns nil when there are none.
+func estimateWait(samples []int64) *WaitEstimate {
+	if len(samples) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	return &WaitEstimate{
+		MedianSeconds: percentile(sorted, 50),
+		P90Seconds:    percentile(sorted, 90),
+		Samples:       len(sorted),
+	}
+}
+
+// percentile is the nearest-rank p-th percentile of sorted samples.
+func percentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// QueueWaitEstimate returns the current wait estimate for (gameID,
+// queueID, metadata), resolved like QueueSize. Returns nil when the queue
+// hasn't formed a match recently enough to estimate from.
+func QueueWaitEstimate(ctx context.Context, gameID string, queueID string, metadata string) (*WaitEstimate, error) {
+	queue, err := models.ResolveQueue(gameID, queueID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !queue.MetadataEnabled {
+		metadata = ""
+	}
+	return WaitEstimateFor(ctx, extRedis.QueueKey(queue.ID, metadata))
+}
+
+// WaitEstimateFor reads the stored estimate for a composite queue key.
+func WaitEstimateFor(ctx context.Context, composite string) (*WaitEstimate, error) {
+	median, p90, samples, err := server.S.Redis.QueueWaitEstimate(ctx, composite)
+	if err != nil || samples == 0 {
+		return nil, err
+	}
+	return &WaitEstimate{MedianSeconds: median, P90Seconds: p90, Samples: samples}, nil
+}
+
+// QueueStats summarizes a queue's matchmaking over a recent window,
+// across all of its sub-queues. Abandonment counts queue entries (a
+// party counts once) that left — withdrew, timed out, or failed a ready
+// check — without being matched.
+type QueueStats struct {
+	GameQueueID         string  `json:"game_queue_id"`
+	WindowHours         int     `json:"window_hours"`
+	MatchesFormed       int     `json:"matches_formed"`
+	MatchesPerHour      float64 `json:"matches_per_hour"`
+	PlayersMatched      int     `json:"players_matched"`
+	AverageRatingSpread float64 `json:"average_rating_spread"`
+	EntriesAbandoned    int64   `json:"entries_abandoned"`
+	AbandonmentRate     float64 `json:"abandonment_rate"`
+	MedianWaitSeconds   int64   `json:"median_wait_seconds"`
+	P90WaitSeconds      int64   `json:"p90_wait_seconds"`
+}
+
+// MaxQueueStatsWindowHours is the widest window GetQueueStats covers.
+const MaxQueueStatsWindowHours = int(QUEUE_STATS_RETENTION / time.Hour)
+
+// GetQueueStats builds a queue's statistics over the last windowHours.
+func GetQueueStats(ctx context.Context, gameQueueID string, windowHours int) (*QueueStats, error) {
+	since := time.Now().Add(-time.Duration(windowHours) * time.Hour)
+	records, err := server.S.Redis.MatchesFormedSince(ctx, gameQueueID, since)
+	if err != nil {
+		return nil, err
+	}
+	abandoned, err := server.S.Redis.QueueAbandonsSince(ctx, gameQueueID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &QueueStats{GameQueueID: gameQueueID, WindowHours: windowHours, EntriesAbandoned: abandoned}
+	var waits []int64
+	spreadTotal, entries := 0, 0
+	for _, raw := range records {
+		var rec formedRecord
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			continue
+		}
+		stats.MatchesFormed++
+		stats.PlayersMatched += rec.Players
+		spreadTotal += rec.Spread
+		entries += rec.Entries
+		waits = append(waits, rec.Waits...)
+	}
+	stats.MatchesPerHour = float64(stats.MatchesFormed) / float64(windowHours)
+	if stats.MatchesFormed > 0 {
+		stats.AverageRatingSpread = float64(spreadTotal) / float64(stats.MatchesFormed)
+	}
+	if total := int64(entries) + abandoned; total > 0 {
+		stats.AbandonmentRate = float64(abandoned) / float64(total)
+	}
+	if est := estimateWait(waits); est != nil {
+		stats.MedianWaitSeconds, stats.P90WaitSeconds = est.MedianSeconds, est.P90Seconds
+	}
+	return stats, nil
+}
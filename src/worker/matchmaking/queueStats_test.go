@@ -0,0 +1,25 @@
+package matchmaking
+
+import "testing"
+
+func TestEstimateWait(t *testing.T) {
+	if est := estimateWait(nil); est != nil {
+		t.Errorf("expected no estimate without samples, got %+v", est)
+	}
+	est := estimateWait([]int64{40, 20})
+	if est.MedianSeconds != 20 || est.P90Seconds != 40 || est.Samples != 2 {
+		t.Errorf("unexpected estimate for two samples: %+v", est)
+	}
+
+	samples := make([]int64, 0, 100)
+	for i := int64(100); i >= 1; i-- {
+		samples = append(samples, i)
+	}
+	est = estimateWait(samples)
+	if est.MedianSeconds != 50 || est.P90Seconds != 90 {
+		t.Errorf("expected median 50 and p90 90 over 1..100, got %+v", est)
+	}
+	if samples[0] != 100 {
+		t.Error("estimateWait must not reorder the caller's samples")
+	}
+}
@@ -0,0 +1,294 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/google/uuid"
+)
+
+// READY_CHECK_GRACE is how long a proposal record outlives its deadline,
+// so the worker can still resolve it if its wake-up runs late.
+const READY_CHECK_GRACE = 30 * time.Second
+
+// proposalEntry is a paired queue entry held by a ready check. JoinedAt
+// is its original join time, restored if it goes back to the queue, and
+// Searches the other queues it was searching (see QueueEntry).
+type proposalEntry struct {
+	ID       string           `json:"id"`
+	Players  []string         `json:"players"`
+	JoinedAt int64            `json:"joined_at,omitempty"`
+	Searches map[string]int64 `json:"searches,omitempty"`
+}
+
+// dispatchGroup takes a paired group out of the queue and either starts
+// its match in region or, for queues with a ready check, proposes it to
+// the players first. formed is recorded in the queue's statistics if the
+// match starts. dequeued is false when the group couldn't be taken out
+// of the queue, in which case nothing was dispatched.
+func dispatchGroup(ctx context.Context, game *models.Game, queue *models.GameQueue, composite string, group []QueueEntry, region string, formed *formedRecord) (dequeued bool, err error) {
+	var joinTimes map[string]int64
+	if queue.ReadyCheckSeconds > 0 {
+		if joinTimes, err = server.S.Redis.QueueJoinTimes(ctx, composite); err != nil {
+			slog.Error("Failed to read queue join times", "error", err, "composite", composite)
+			return false, err
+		}
+	}
+	if err := dequeueEntries(ctx, composite, group); err != nil {
+		if errors.Is(err, extRedis.ErrQueueEntryTaken) {
+			// Paired in another queue since the snapshot; the next
+			// pass pairs this queue afresh.
+			slog.Info("Paired entry was taken by another queue", "composite", composite)
+			return false, err
+		}
+		slog.Error("Failed to remove paired entries from queue", "error", err, "composite", composite)
+		return false, err
+	}
+	if queue.ReadyCheckSeconds > 0 {
+		return true, proposeMatch(ctx, queue, composite, group, region, joinTimes, formed)
+	}
+	return true, startMatch(ctx, game, queue, composite, group, region, nil, formed)
+}
+
+// proposeMatch opens a ready check for a paired group and tells each
+// player. A timer wakes the worker at the deadline so no-shows are
+// dropped on time.
+func proposeMatch(ctx context.Context, queue *models.GameQueue, composite string, group []QueueEntry, region string, joinTimes map[string]int64, formed *formedRecord) error {
+	entries := make([]proposalEntry, len(group))
+	for i, e := range group {
+		entries[i] = proposalEntry{ID: e.ID, Players: e.Players, JoinedAt: joinTimes[e.ID], Searches: e.Searches}
+	}
+	encoded, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	stats, err := json.Marshal(formed)
+	if err != nil {
+		return err
+	}
+
+	window := time.Duration(queue.ReadyCheckSeconds) * time.Second
+	rec := &extRedis.ProposalRecord{
+		ID:          uuid.New().String(),
+		GameQueueID: queue.ID,
+		QueueID:     composite,
+		Region:      region,
+		ExpiresAt:   time.Now().Add(window),
+		Entries:     string(encoded),
+		Stats:       string(stats),
+	}
+	players := entryPlayers(group)
+	if err := server.S.Redis.CreateProposal(ctx, rec, window+READY_CHECK_GRACE); err != nil {
+		slog.Error("Failed to store match proposal", "error", err, "gameQueueID", queue.ID)
+		requeueEntries(ctx, queue.ID, composite, group)
+		return err
+	}
+	slog.Info("Proposing match", "proposalID", rec.ID, "gameQueueID", queue.ID, "players", players)
+
+	msg := fmt.Sprintf("proposed:%s:%d", rec.ID, rec.ExpiresAt.Unix())
+	for _, player := range players {
+		server.S.Redis.PublishMatchReady(ctx, queue.ID, player, msg)
+	}
+	time.AfterFunc(window, func() {
+		server.S.Redis.PublishReadyCheckTrigger(context.Background())
+	})
+	return nil
+}
+
+// RespondToProposal records playerID's accept or decline and wakes the
+// worker to act on it. Returns extRedis.ErrProposalNotFound when the
+// proposal is already resolved or doesn't include the player.
+func RespondToProposal(ctx context.Context, proposalID, playerID string, accept bool) error {
+	rec, err := server.S.Redis.GetProposal(ctx, proposalID)
+	if err != nil {
+		return err
+	}
+	entries, err := decodeProposalEntries(rec)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, e := range entries {
+		for _, id := range e.Players {
+			found = found || id == playerID
+		}
+	}
+	if !found {
+		return extRedis.ErrProposalNotFound
+	}
+	ttl := time.Until(rec.ExpiresAt) + READY_CHECK_GRACE
+	if err := server.S.Redis.RespondToProposal(ctx, proposalID, playerID, accept, ttl); err != nil {
+		return err
+	}
+	return server.S.Redis.PublishReadyCheckTrigger(ctx)
+}
+
+func decodeProposalEntries(rec *extRedis.ProposalRecord) ([]proposalEntry, error) {
+	var entries []proposalEntry
+	if err := json.Unmarshal([]byte(rec.Entries), &entries); err != nil {
+		return nil, fmt.Errorf("decode proposal %s: %w", rec.ID, err)
+	}
+	return entries, nil
+}
+
+// decodeProposalStats returns the queue statistics stored with a
+// proposal, or nil when it has none.
+func decodeProposalStats(rec *extRedis.ProposalRecord) *formedRecord {
+	var formed *formedRecord
+	if rec.Stats != "" {
+		if err := json.Unmarshal([]byte(rec.Stats), &formed); err != nil {
+			slog.Warn("Failed to decode proposal stats", "error", err, "proposalID", rec.ID)
+			return nil
+		}
+	}
+	return formed
+}
+
+// ResolveProposals settles every open ready check that can be settled:
+// once everyone has accepted the match starts; once anyone declines, or
+// the deadline passes, the proposal is dropped. Proposals still waiting
+// on answers are left alone. requeued reports whether any entries went
+// back to a queue, in which case the caller should pair again.
+func ResolveProposals(ctx context.Context) (requeued bool, err error) {
+	ids, err := server.S.Redis.ProposalIDs(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		back, err := resolveProposal(ctx, id)
+		if err != nil {
+			slog.Error("Failed to resolve match proposal", "error", err, "proposalID", id)
+		}
+		requeued = requeued || back
+	}
+	return requeued, nil
+}
+
+func resolveProposal(ctx context.Context, id string) (requeued bool, err error) {
+	rec, err := server.S.Redis.GetProposal(ctx, id)
+	if errors.Is(err, extRedis.ErrProposalNotFound) {
+		// The record outlived its grace period; just drop the index.
+		_, err = server.S.Redis.ClaimProposal(ctx, id)
+		return false, err
+	}
+	if err != nil {
+		return false, err
+	}
+	entries, err := decodeProposalEntries(rec)
+	if err != nil {
+		return false, err
+	}
+	responses, err := server.S.Redis.ProposalResponses(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	allAccepted, anyDeclined := true, false
+	for _, e := range entries {
+		for _, p := range e.Players {
+			accepted, answered := responses[p]
+			allAccepted = allAccepted && accepted
+			anyDeclined = anyDeclined || (answered && !accepted)
+		}
+	}
+	if !allAccepted && !anyDeclined && time.Now().Before(rec.ExpiresAt) {
+		return false, nil
+	}
+
+	claimed, err := server.S.Redis.ClaimProposal(ctx, id)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	queue, err := models.GetGameQueue(rec.GameQueueID)
+	if err != nil {
+		return false, err
+	}
+	group := make([]QueueEntry, len(entries))
+	for i, e := range entries {
+		group[i] = QueueEntry{ID: e.ID, Players: e.Players, Searches: e.Searches}
+	}
+	if allAccepted {
+		game, err := models.GetGame(queue.GameID)
+		if err != nil {
+			notifyError(ctx, queue.ID, entryPlayers(group), "internal error")
+			return false, err
+		}
+		return false, startMatch(ctx, game, queue, rec.QueueID, group, rec.Region, nil, decodeProposalStats(rec))
+	}
+	return failProposal(ctx, queue, rec, entries, responses, anyDeclined), nil
+}
+
+// failProposal ends a ready check that didn't pass. Entries whose players
+// all accepted go back to the front of the queue with their original join
+// times, and back into every other queue they were searching; every other
+// entry is dropped, each player told why, and players who declined or
+// didn't answer are charged the queue's decline penalty. Returns whether
+// anything was requeued.
+func failProposal(ctx context.Context, queue *models.GameQueue, rec *extRedis.ProposalRecord, entries []proposalEntry, responses map[string]bool, declined bool) bool {
+	var keep []string
+	var keepPlayers []string
+	dropped := 0
+	joinedAt := make(map[string]int64)
+	searches := make(map[string]map[string]int64)
+	for _, e := range entries {
+		ready := true
+		for _, p := range e.Players {
+			ready = ready && responses[p]
+		}
+		if ready {
+			keep = append(keep, e.ID)
+			keepPlayers = append(keepPlayers, e.Players...)
+			if e.JoinedAt != 0 {
+				joinedAt[e.ID] = e.JoinedAt
+			}
+			searches[e.ID] = e.Searches
+			continue
+		}
+		dropped++
+		for _, p := range e.Players {
+			accepted, answered := responses[p]
+			switch {
+			case !answered:
+				penalize(queue, p, models.OffenseDecline)
+				notifyError(ctx, rec.GameQueueID, []string{p}, "ready check timed out")
+			case !accepted:
+				penalize(queue, p, models.OffenseDecline)
+				notifyError(ctx, rec.GameQueueID, []string{p}, "match declined")
+			default:
+				notifyError(ctx, rec.GameQueueID, []string{p}, "party member did not accept")
+			}
+		}
+	}
+	recordAbandons(ctx, rec.QueueID, dropped)
+	if len(keep) == 0 {
+		return false
+	}
+
+	if err := server.S.Redis.PushPlayersToQueueFront(ctx, rec.QueueID, keep, joinedAt, QUEUE_TTL); err != nil {
+		slog.Error("Failed to return accepted entries to queue", "error", err, "queueID", rec.QueueID)
+		notifyError(ctx, rec.GameQueueID, keepPlayers, "internal error")
+		return false
+	}
+	for _, id := range keep {
+		returnToSearches(ctx, id, searches[id])
+		if partyID, ok := extRedis.ParsePartyQueueEntry(id); ok {
+			server.S.Redis.SetPartyQueue(ctx, partyID, rec.GameQueueID, rec.QueueID)
+		}
+	}
+	reason := "another player did not accept in time"
+	if declined {
+		reason = "another player declined"
+	}
+	for _, p := range keepPlayers {
+		server.S.Redis.PublishMatchReady(ctx, rec.GameQueueID, p, "requeued:"+reason)
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package matchmaking
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andy98725/elo-service/src/server"
+)
+
+// PINGS_TTL is how long a player's reported pings are kept after they
+// join a queue. Generous, since pings only change when they rejoin.
+const PINGS_TTL = time.Hour
+
+const (
+	// maxPingEntries bounds the `pings` join parameter.
+	maxPingEntries = 32
+	// maxReportedPingMs is the largest ping a client may report.
+	maxReportedPingMs = 60000
+	// unreachablePing stands in for a region a player didn't report.
+	unreachablePing = 1 << 30
+)
+
+// ParsePings reads the `pings` join parameter: comma-separated
+// region:milliseconds pairs, e.g. "nbg1:35,ash:120". Regions the server
+// doesn't host in are dropped. An empty string is no pings.
+func ParsePings(raw string) (map[string]int, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	pairs := strings.Split(raw, ",")
+	if len(pairs) > maxPingEntries {
+		return nil, fmt.Errorf("invalid pings: at most %d regions", maxPingEntries)
+	}
+	regions := server.S.Config.Regions()
+	pings := make(map[string]int, len(pairs))
+	for _, pair := range pairs {
+		region, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || region == "" {
+			return nil, errors.New("invalid pings: expected region:milliseconds pairs")
+		}
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms < 0 || ms > maxReportedPingMs {
+			return nil, fmt.Errorf("invalid pings: %q must be between 0 and %d milliseconds", region, maxReportedPingMs)
+		}
+		if slices.Contains(regions, region) {
+			pings[region] = ms
+		}
+	}
+	return pings, nil
+}
+
+// regions returns the configured regions, default first.
+func (s *QueueSnapshot) regions() []string {
+	if len(s.Regions) == 0 {
+		return []string{server.DefaultHCLOUDLocation}
+	}
+	return s.Regions
+}
+
+// worstPing is the highest ping any of players reported to region.
+// Players who reported no pings at all don't count; a player who did but
+// left region out is taken to be unable to reach it.
+func (s *QueueSnapshot) worstPing(players []string, region string) int {
+	worst := 0
+	for _, id := range players {
+		pings, ok := s.Pings[id]
+		if !ok {
+			continue
+		}
+		ms, ok := pings[region]
+		if !ok {
+			ms = unreachablePing
+		}
+		worst = max(worst, ms)
+	}
+	return worst
+}
+
+// bestRegions orders candidates by worstPing over players, lowest first;
+// ties keep their configured order.
+func (s *QueueSnapshot) bestRegions(players []string, candidates []string) []string {
+	ordered := slices.Clone(candidates)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return s.worstPing(players, ordered[i]) < s.worstPing(players, ordered[j])
+	})
+	return ordered
+}
+
+// playerAccepts reports whether a player may be matched in region: any
+// region if they reported no pings or the queue has no MaxPingMs, else
+// the regions within MaxPingMs — or, if none is, their best region.
+func (s *QueueSnapshot) playerAccepts(playerID, region string) bool {
+	pings, ok := s.Pings[playerID]
+	if !ok || s.Queue.MaxPingMs == 0 {
+		return true
+	}
+	if ms, ok := pings[region]; ok && ms <= s.Queue.MaxPingMs {
+		return true
+	}
+	for _, r := range s.regions() {
+		if ms, ok := pings[r]; ok && ms <= s.Queue.MaxPingMs {
+			return false
+		}
+	}
+	return s.bestRegions([]string{playerID}, s.regions())[0] == region
+}
+
+// AcceptableRegions returns the regions an entry may be matched in, best
+// first: those every member accepts. A party whose members have no
+// region in common settles for the one with the lowest worst-case ping.
+func (s *QueueSnapshot) AcceptableRegions(e QueueEntry) []string {
+	if cached, ok := s.acceptable[e.ID]; ok {
+		return cached
+	}
+	var accepted []string
+	for _, region := range s.regions() {
+		all := true
+		for _, id := range e.Players {
+			all = all && s.playerAccepts(id, region)
+		}
+		if all {
+			accepted = append(accepted, region)
+		}
+	}
+	if len(accepted) == 0 {
+		accepted = s.bestRegions(e.Players, s.regions())[:1]
+	}
+	accepted = s.bestRegions(e.Players, accepted)
+	if s.acceptable == nil {
+		s.acceptable = make(map[string][]string)
+	}
+	s.acceptable[e.ID] = accepted
+	return accepted
+}
+
+// Accepts reports whether an entry may be matched in region.
+func (s *QueueSnapshot) Accepts(e QueueEntry, region string) bool {
+	return slices.Contains(s.AcceptableRegions(e), region)
+}
+
+// inRegion returns the entries that accept region, keeping order.
+func (s *QueueSnapshot) inRegion(entries []QueueEntry, region string) []QueueEntry {
+	out := make([]QueueEntry, 0, len(entries))
+	for _, e := range entries {
+		if s.Accepts(e, region) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// GroupRegion picks where a paired group plays: of the regions every
+// entry accepts, the one with the lowest worst-case ping, ties going to
+// the configured order. A group with no region in common (only possible
+// from a Strategy that ignores regions) is placed the same way across
+// every region.
+func (s *QueueSnapshot) GroupRegion(group []QueueEntry) string {
+	var common []string
+	for _, region := range s.regions() {
+		all := true
+		for _, e := range group {
+			all = all && s.Accepts(e, region)
+		}
+		if all {
+			common = append(common, region)
+		}
+	}
+	if len(common) == 0 {
+		common = s.regions()
+	}
+	return s.bestRegions(entryPlayers(group), common)[0]
+}
@@ -0,0 +1,211 @@
+package matchmaking
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+)
+
+// Strategy decides who plays whom in a queue. PairPlayers hands it a
+// snapshot of one (sub-)queue and dispatches the groups it returns, in
+// order. Each group must add up to exactly Queue.LobbySize players — or
+// fewer, on queues with a fill timeout, once ShortGroupDue allows it —
+// and no entry may appear in more than one group; entries left out stay
+// queued for the next pass. Entries CanGroup rejects (blocked
+// players, recent opponents) shouldn't share a group. Pair must not
+// touch Redis or the database — everything it may look at is in the
+// snapshot — so a strategy can be unit tested on a hand-built
+// QueueSnapshot.
+type Strategy interface {
+	Pair(snap *QueueSnapshot) [][]QueueEntry
+}
+
+// QueueSnapshot is one queue as a Strategy sees it. Strategies should
+// only group entries that share a region (see AcceptableRegions);
+// PairPlayers runs each group in the best region it has in common.
+type QueueSnapshot struct {
+	Queue *models.GameQueue
+	// Metadata is the sub-queue's metadata fingerprint, "" for the
+	// queue's base sub-queue. The raw metadata isn't recoverable from
+	// it; it only tells sub-queues apart.
+	Metadata string
+	// Entries is the queue list in order, oldest first.
+	Entries []QueueEntry
+	// JoinedAt holds each entry's join time by entry ID. An entry
+	// missing from it has an unknown join time.
+	JoinedAt map[string]time.Time
+	// Ratings holds each rated player's current rating by player ID.
+	// Unrated players and guests are absent; treat them as
+	// Queue.DefaultRating.
+	Ratings map[string]int
+	// Regions are the regions matches can run in, default first. Empty
+	// means the default region only.
+	Regions []string
+	// Pings holds each player's reported round-trip times by player ID,
+	// then region. Players who reported none are absent and accept any
+	// region. See AcceptableRegions.
+	Pings map[string]map[string]int
+	// Blocks holds, by player ID, the players each queued player has
+	// blocked or been blocked by. See CanGroup.
+	Blocks map[string]map[string]bool
+	// RecentOpponents holds, by player ID, the players each queued
+	// player faced within the queue's rematch lookback. Empty when the
+	// queue doesn't avoid rematches. See CanGroup.
+	RecentOpponents map[string]map[string]bool
+	// Now is the time the snapshot was taken.
+	Now time.Time
+
+	acceptable map[string][]string
+}
+
+// Waited returns how long an entry has been queued, or ok=false when
+// its join time is unknown.
+func (s *QueueSnapshot) Waited(entryID string) (waited time.Duration, ok bool) {
+	joined, ok := s.JoinedAt[entryID]
+	if !ok {
+		return 0, false
+	}
+	return s.Now.Sub(joined), true
+}
+
+// ShortGroupDue reports whether group may start short of
+// Queue.LobbySize: the queue has a fill timeout and at least
+// Queue.MinPlayers of the group's players have each waited it out.
+// Strategies should only fall back on a short group once they can't
+// fill a lobby.
+func (s *QueueSnapshot) ShortGroupDue(group []QueueEntry) bool {
+	queue := s.Queue
+	if queue.MinPlayers == 0 {
+		return false
+	}
+	timeout := time.Duration(queue.FillTimeoutSeconds) * time.Second
+	due := 0
+	for _, e := range group {
+		// An unknown join time counts as long enough ago.
+		if waited, ok := s.Waited(e.ID); !ok || waited >= timeout {
+			due += len(e.Players)
+		}
+	}
+	return due >= queue.MinPlayers
+}
+
+// EntryRating is the entry's rating: a party is rated at its members'
+// average.
+func (s *QueueSnapshot) EntryRating(e QueueEntry) int {
+	total := 0
+	for _, id := range e.Players {
+		r, ok := s.Ratings[id]
+		if !ok {
+			r = s.Queue.DefaultRating
+		}
+		total += r
+	}
+	return total / len(e.Players)
+}
+
+var strategies = map[string]Strategy{}
+
+// RegisterStrategy makes a Strategy available to queues under name, and
+// adds name to models.MATCHMAKING_STRATEGIES (which already lists the
+// built-ins) so queues can select it. Call it from an init function; it
+// panics on a duplicate name.
+func RegisterStrategy(name string, s Strategy) {
+	if _, dup := strategies[name]; dup {
+		panic(fmt.Sprintf("matchmaking strategy %q registered twice", name))
+	}
+	strategies[name] = s
+	if !slices.Contains(models.MATCHMAKING_STRATEGIES, name) {
+		models.MATCHMAKING_STRATEGIES = append(models.MATCHMAKING_STRATEGIES, name)
+	}
+}
+
+// LookupStrategy returns the Strategy registered under name.
+func LookupStrategy(name string) (Strategy, bool) {
+	s, ok := strategies[name]
+	return s, ok
+}
+
+func init() {
+	RegisterStrategy(models.MATCHMAKING_STRATEGY_RANDOM, fifoStrategy{})
+	RegisterStrategy(models.MATCHMAKING_STRATEGY_RATING, ratingStrategy{})
+}
+
+// loadSnapshot reads a queue's entries, join times and ratings. Returns
+// nil when fewer than minPlayers are queued — the queue's MinLobbySize,
+// unless a backfill request could use fewer — since nothing can be
+// formed, so there's no point reading the rest.
+func loadSnapshot(ctx context.Context, composite string, queue *models.GameQueue, minPlayers int) (*QueueSnapshot, error) {
+	entries, err := loadQueueEntries(ctx, composite)
+	if err != nil {
+		return nil, fmt.Errorf("read queue: %w", err)
+	}
+	players := entryPlayers(entries)
+	if len(players) == 0 || len(players) < minPlayers {
+		return nil, nil
+	}
+
+	joinTimes, err := server.S.Redis.QueueJoinTimes(ctx, composite)
+	if err != nil {
+		return nil, fmt.Errorf("read queue join times: %w", err)
+	}
+	joinedAt := make(map[string]time.Time, len(joinTimes))
+	for id, ts := range joinTimes {
+		joinedAt[id] = time.Unix(ts, 0)
+	}
+	ratings, err := models.GetRatingsForPlayers(queue.ID, players)
+	if err != nil {
+		return nil, fmt.Errorf("read player ratings: %w", err)
+	}
+	pings, err := server.S.Redis.PlayerPings(ctx, players)
+	if err != nil {
+		return nil, fmt.Errorf("read player pings: %w", err)
+	}
+
+	blocks, err := models.GetBlockedPairs(players)
+	if err != nil {
+		return nil, fmt.Errorf("read player blocks: %w", err)
+	}
+	now := time.Now()
+	var opponents map[string]map[string]bool
+	if queue.RematchLookbackMinutes > 0 {
+		since := now.Add(-time.Duration(queue.RematchLookbackMinutes) * time.Minute)
+		if opponents, err = models.GetRecentOpponents(queue.ID, players, since); err != nil {
+			return nil, fmt.Errorf("read recent opponents: %w", err)
+		}
+	}
+
+	_, metadata, _ := strings.Cut(composite, extRedis.MetadataSeparator)
+	return &QueueSnapshot{
+		Queue:           queue,
+		Metadata:        metadata,
+		Entries:         entries,
+		JoinedAt:        joinedAt,
+		Ratings:         ratings,
+		Regions:         server.S.Config.Regions(),
+		Pings:           pings,
+		Blocks:          blocks,
+		RecentOpponents: opponents,
+		Now:             now,
+	}, nil
+}
+
+// withoutEntries returns entries minus the ones in group, keeping order.
+func withoutEntries(entries []QueueEntry, group []QueueEntry) []QueueEntry {
+	taken := make(map[string]bool, len(group))
+	for _, e := range group {
+		taken[e.ID] = true
+	}
+	rest := make([]QueueEntry, 0, len(entries))
+	for _, e := range entries {
+		if !taken[e.ID] {
+			rest = append(rest, e)
+		}
+	}
+	return rest
+}
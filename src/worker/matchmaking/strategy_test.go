@@ -0,0 +1,300 @@
+package matchmaking
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+)
+
+func solo(id string) QueueEntry {
+	return QueueEntry{ID: id, Players: []string{id}}
+}
+
+func groupIDs(groups [][]QueueEntry) [][]string {
+	out := make([][]string, len(groups))
+	for i, g := range groups {
+		out[i] = entryIDs(g)
+	}
+	return out
+}
+
+func TestBuiltinStrategiesRegistered(t *testing.T) {
+	for _, name := range []string{models.MATCHMAKING_STRATEGY_RANDOM, models.MATCHMAKING_STRATEGY_RATING} {
+		if _, ok := LookupStrategy(name); !ok {
+			t.Errorf("strategy %q not registered", name)
+		}
+		if !slices.Contains(models.MATCHMAKING_STRATEGIES, name) {
+			t.Errorf("strategy %q missing from MATCHMAKING_STRATEGIES", name)
+		}
+	}
+}
+
+type firstTwoStrategy struct{}
+
+func (firstTwoStrategy) Pair(snap *QueueSnapshot) [][]QueueEntry {
+	return [][]QueueEntry{snap.Entries[:2]}
+}
+
+func TestRegisterStrategy(t *testing.T) {
+	const name = "test-first-two"
+	prev := models.MATCHMAKING_STRATEGIES
+	t.Cleanup(func() {
+		delete(strategies, name)
+		models.MATCHMAKING_STRATEGIES = prev
+	})
+
+	RegisterStrategy(name, firstTwoStrategy{})
+	if !slices.Contains(models.MATCHMAKING_STRATEGIES, name) {
+		t.Fatalf("expected a registered strategy to become selectable, got %v", models.MATCHMAKING_STRATEGIES)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a duplicate registration to panic")
+		}
+	}()
+	RegisterStrategy(name, firstTwoStrategy{})
+}
+
+func TestFIFOStrategy(t *testing.T) {
+	party := QueueEntry{ID: "party:p", Players: []string{"p1", "p2", "p3"}}
+	snap := &QueueSnapshot{
+		Queue:   &models.GameQueue{LobbySize: 2},
+		Entries: []QueueEntry{solo("a"), party, solo("b"), solo("c"), solo("d"), solo("e")},
+	}
+	got := groupIDs(fifoStrategy{}.Pair(snap))
+	want := [][]string{{"a", "b"}, {"c", "d"}}
+	if !slices.EqualFunc(got, want, slices.Equal[[]string]) {
+		t.Errorf("expected %v, got %v (the oversized party is skipped, e waits)", want, got)
+	}
+
+	// A party that fills a lobby by itself isn't held up by a lone
+	// solo at the head of the queue.
+	snap = &QueueSnapshot{
+		Queue:   &models.GameQueue{LobbySize: 3},
+		Entries: []QueueEntry{solo("a"), party},
+	}
+	got = groupIDs(fifoStrategy{}.Pair(snap))
+	if len(got) != 1 || !slices.Equal(got[0], []string{"party:p"}) {
+		t.Errorf("expected the party alone, got %v", got)
+	}
+}
+
+func TestRatingStrategy(t *testing.T) {
+	var rating ratingStrategy
+	now := time.Now()
+	queue := &models.GameQueue{
+		LobbySize:         2,
+		DefaultRating:     1000,
+		RatingWindowSteps: []byte(`[{"after_seconds":0,"max_spread":100},{"after_seconds":60,"max_spread":400}]`),
+	}
+	snap := &QueueSnapshot{
+		Queue:   queue,
+		Entries: []QueueEntry{solo("a"), solo("b"), solo("c"), solo("d")},
+		JoinedAt: map[string]time.Time{
+			"a": now.Add(-10 * time.Second),
+			"b": now.Add(-5 * time.Second),
+			"c": now.Add(-4 * time.Second),
+			"d": now.Add(-3 * time.Second),
+		},
+		Ratings: map[string]int{"a": 1500, "b": 1000, "c": 1550, "d": 1040},
+		Now:     now,
+	}
+	got := groupIDs(rating.Pair(snap))
+	want := [][]string{{"a", "c"}, {"b", "d"}}
+	if !slices.EqualFunc(got, want, slices.Equal[[]string]) {
+		t.Errorf("expected closest-rated pairs %v, got %v", want, got)
+	}
+
+	// 300 apart: deferred until the seed's wait reaches the wider step.
+	snap.Entries = []QueueEntry{solo("a"), solo("b")}
+	snap.Ratings = map[string]int{"a": 1300}
+	if got := rating.Pair(snap); len(got) != 0 {
+		t.Errorf("expected no group inside the tight window, got %v", groupIDs(got))
+	}
+	snap.JoinedAt["a"] = now.Add(-90 * time.Second)
+	if got := rating.Pair(snap); len(got) != 1 {
+		t.Errorf("expected a group once the window widened, got %v", groupIDs(got))
+	}
+
+	// The cap bounds even an unbounded step.
+	queue.RatingWindowSteps = nil
+	queue.RatingWindowCap = 200
+	snap.JoinedAt["a"] = now.Add(-time.Hour)
+	if got := rating.Pair(snap); len(got) != 0 {
+		t.Errorf("expected the cap to hold the group back, got %v", groupIDs(got))
+	}
+}
+
+func TestRegionAwarePairing(t *testing.T) {
+	pings := map[string]map[string]int{
+		"eu1": {"nbg1": 30, "ash": 150},
+		"us1": {"nbg1": 200, "ash": 40},
+		"eu2": {"nbg1": 60, "ash": 130},
+		"us2": {"nbg1": 180, "ash": 50},
+	}
+	queue := &models.GameQueue{LobbySize: 2, DefaultRating: 1000, MaxPingMs: 100}
+	snap := &QueueSnapshot{
+		Queue:   queue,
+		Entries: []QueueEntry{solo("eu1"), solo("us1"), solo("eu2"), solo("us2")},
+		Regions: []string{"nbg1", "ash"},
+		Pings:   pings,
+	}
+	got := groupIDs(fifoStrategy{}.Pair(snap))
+	want := [][]string{{"eu1", "eu2"}, {"us1", "us2"}}
+	if !slices.EqualFunc(got, want, slices.Equal[[]string]) {
+		t.Fatalf("expected players grouped by region %v, got %v", want, got)
+	}
+	for i, region := range []string{"nbg1", "ash"} {
+		group := []QueueEntry{solo(want[i][0]), solo(want[i][1])}
+		if got := snap.GroupRegion(group); got != region {
+			t.Errorf("expected %v to play in %s, got %s", want[i], region, got)
+		}
+	}
+
+	// A player who reported no pings fits anywhere.
+	snap = &QueueSnapshot{
+		Queue:   queue,
+		Entries: []QueueEntry{solo("us1"), solo("anyone")},
+		Regions: []string{"nbg1", "ash"},
+		Pings:   pings,
+	}
+	if got := groupIDs(fifoStrategy{}.Pair(snap)); len(got) != 1 {
+		t.Errorf("expected the region-agnostic player to be matched, got %v", got)
+	}
+	if got := snap.GroupRegion(snap.Entries); got != "ash" {
+		t.Errorf("expected the group in ash, got %s", got)
+	}
+
+	// Without a ping limit anyone is grouped, in the region with the
+	// lowest worst-case ping.
+	snap = &QueueSnapshot{
+		Queue:   &models.GameQueue{LobbySize: 2, DefaultRating: 1000},
+		Entries: []QueueEntry{solo("eu1"), solo("us1")},
+		Regions: []string{"nbg1", "ash"},
+		Pings:   pings,
+	}
+	if got := groupIDs(fifoStrategy{}.Pair(snap)); len(got) != 1 {
+		t.Fatalf("expected a region-blind group, got %v", got)
+	}
+	if got := snap.GroupRegion(snap.Entries); got != "ash" {
+		t.Errorf("expected ash (worst ping 150 vs 200), got %s", got)
+	}
+
+	// Rating pairing skips a closer-rated player in another region.
+	var rating ratingStrategy
+	snap = &QueueSnapshot{
+		Queue:   queue,
+		Entries: []QueueEntry{solo("eu1"), solo("us1"), solo("eu2")},
+		Ratings: map[string]int{"eu1": 1000, "us1": 1010, "eu2": 1080},
+		Regions: []string{"nbg1", "ash"},
+		Pings:   pings,
+		Now:     time.Now(),
+	}
+	got = groupIDs(rating.Pair(snap))
+	if len(got) != 1 || !slices.Equal(got[0], []string{"eu1", "eu2"}) {
+		t.Errorf("expected eu1 with eu2, got %v", got)
+	}
+}
+
+func TestFillTimeout(t *testing.T) {
+	now := time.Now()
+	queue := &models.GameQueue{LobbySize: 8, MinPlayers: 3, FillTimeoutSeconds: 30, DefaultRating: 1000}
+	snap := &QueueSnapshot{
+		Queue:   queue,
+		Entries: []QueueEntry{solo("a"), solo("b"), solo("c"), solo("d")},
+		JoinedAt: map[string]time.Time{
+			"a": now.Add(-40 * time.Second),
+			"b": now.Add(-35 * time.Second),
+			"c": now.Add(-10 * time.Second),
+			"d": now.Add(-5 * time.Second),
+		},
+		Now: now,
+	}
+	// Only two players have waited out the timeout.
+	var fifo fifoStrategy
+	if got := fifo.Pair(snap); len(got) != 0 {
+		t.Errorf("expected no short match before MinPlayers waited, got %v", groupIDs(got))
+	}
+
+	snap.JoinedAt["c"] = now.Add(-31 * time.Second)
+	got := groupIDs(fifo.Pair(snap))
+	if len(got) != 1 || !slices.Equal(got[0], []string{"a", "b", "c", "d"}) {
+		t.Errorf("expected everyone queued in one short match, got %v", got)
+	}
+
+	// Rating pairing starts the short match too, still within the window.
+	var rating ratingStrategy
+	snap.Ratings = map[string]int{"a": 1000, "b": 1020, "c": 1050, "d": 1090}
+	got = groupIDs(rating.Pair(snap))
+	if len(got) != 1 || len(got[0]) != 4 {
+		t.Errorf("expected a four-player rating match, got %v", got)
+	}
+	// An outlier outside the window stays queued without holding the
+	// others back, even with the window capped.
+	snap.Ratings["d"] = 1400
+	queue.RatingWindowCap = 200
+	got = groupIDs(rating.Pair(snap))
+	if len(got) != 1 || !slices.Equal(got[0], []string{"a", "b", "c"}) {
+		t.Errorf("expected a, b and c matched without d, got %v", got)
+	}
+
+	// So does a blocked player.
+	snap.Blocks = map[string]map[string]bool{"a": {"d": true}, "d": {"a": true}}
+	got = groupIDs(fifo.Pair(snap))
+	if len(got) != 1 || !slices.Equal(got[0], []string{"a", "b", "c"}) {
+		t.Errorf("expected a, b and c matched without d, got %v", got)
+	}
+}
+
+func TestPairingAvoidance(t *testing.T) {
+	var fifo fifoStrategy
+	var rating ratingStrategy
+	now := time.Now()
+	queue := &models.GameQueue{LobbySize: 2, DefaultRating: 1000, RematchMaxWaitSeconds: 60}
+	snap := &QueueSnapshot{
+		Queue:   queue,
+		Entries: []QueueEntry{solo("a"), solo("b"), solo("c"), solo("d")},
+		JoinedAt: map[string]time.Time{
+			"a": now.Add(-30 * time.Second),
+			"b": now.Add(-20 * time.Second),
+			"c": now.Add(-10 * time.Second),
+			"d": now.Add(-5 * time.Second),
+		},
+		Ratings:         map[string]int{"a": 1000, "b": 1010, "c": 1020, "d": 1030},
+		Blocks:          map[string]map[string]bool{"a": {"c": true}, "c": {"a": true}},
+		RecentOpponents: map[string]map[string]bool{"a": {"b": true}, "b": {"a": true}},
+		Now:             now,
+	}
+	want := [][]string{{"a", "d"}, {"b", "c"}}
+	if got := groupIDs(fifo.Pair(snap)); !slices.EqualFunc(got, want, slices.Equal[[]string]) {
+		t.Errorf("fifo: expected a kept from b (rematch) and c (blocked), %v, got %v", want, got)
+	}
+	if got := groupIDs(rating.Pair(snap)); !slices.EqualFunc(got, want, slices.Equal[[]string]) {
+		t.Errorf("rating: expected %v, got %v", want, got)
+	}
+
+	// Once both have waited out the rematch limit, a rematch beats
+	// waiting on; a block never lifts.
+	snap.Entries = []QueueEntry{solo("a"), solo("b"), solo("c")}
+	snap.JoinedAt["a"] = now.Add(-2 * time.Minute)
+	snap.JoinedAt["b"] = now.Add(-90 * time.Second)
+	snap.JoinedAt["c"] = now.Add(-2 * time.Minute)
+	got := groupIDs(fifo.Pair(snap))
+	if len(got) != 1 || !slices.Equal(got[0], []string{"a", "b"}) {
+		t.Errorf("expected the rematch once both waited, got %v", got)
+	}
+	queue.RematchMaxWaitSeconds = 0
+	if got := fifo.Pair(snap); len(got) != 1 || !slices.Equal(entryIDs(got[0]), []string{"b", "c"}) {
+		t.Errorf("expected no rematch with no max wait, got %v", groupIDs(got))
+	}
+
+	// A strategy's groups are checked the same way before dispatch.
+	if snap.groupAllowed([]QueueEntry{solo("b"), solo("a"), solo("c")}) {
+		t.Error("expected a group holding a blocked pair to be refused")
+	}
+	if !snap.groupAllowed([]QueueEntry{solo("b"), solo("c")}) {
+		t.Error("expected b and c allowed together")
+	}
+}
@@ -0,0 +1,146 @@
+package matchmaking
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/andy98725/elo-service/src/models"
+)
+
+// teamUnit is a queue entry as team assignment sees it: its players must
+// share a team, and rating is the sum of their ratings.
+type teamUnit struct {
+	players []string
+	rating  int
+}
+
+// balanceTeams splits a paired group into queue.TeamCount teams of at
+// most queue.TeamSize players, keeping every entry (so every party) on a
+// single team and minimising the spread between team rating totals.
+// Players without a rating count at the queue's DefaultRating. Returns
+// nil for queues without teams.
+//
+// Entries are placed largest (then highest-rated) first onto the
+// lowest-rated team with room, backtracking when a party doesn't fit;
+// equal-sized entries are then swapped between teams while that narrows
+// the spread.
+func balanceTeams(queue *models.GameQueue, entries []QueueEntry, ratings map[string]int) ([][]string, error) {
+	if !queue.HasTeams() {
+		return nil, nil
+	}
+	units := make([]teamUnit, len(entries))
+	for i, e := range entries {
+		total := 0
+		for _, id := range e.Players {
+			r, ok := ratings[id]
+			if !ok {
+				r = queue.DefaultRating
+			}
+			total += r
+		}
+		units[i] = teamUnit{players: e.Players, rating: total}
+	}
+	sort.SliceStable(units, func(i, j int) bool {
+		if len(units[i].players) != len(units[j].players) {
+			return len(units[i].players) > len(units[j].players)
+		}
+		return units[i].rating > units[j].rating
+	})
+
+	assign := make([]int, len(units))
+	seats := make([]int, queue.TeamCount)
+	totals := make([]int, queue.TeamCount)
+	if !packTeams(units, 0, assign, seats, totals, queue.TeamSize) {
+		return nil, fmt.Errorf("cannot split %d entries into %d teams of %d", len(entries), queue.TeamCount, queue.TeamSize)
+	}
+	improveTeams(units, assign, totals)
+
+	teams := make([][]string, queue.TeamCount)
+	for i := range teams {
+		teams[i] = []string{}
+	}
+	for i, u := range units {
+		teams[assign[i]] = append(teams[assign[i]], u.players...)
+	}
+	return teams, nil
+}
+
+// packTeams places units[next:] depth-first, trying the lowest-rated team
+// with room first. Only the first empty team is tried for each unit —
+// empty teams are interchangeable, so the others add nothing.
+func packTeams(units []teamUnit, next int, assign, seats, totals []int, teamSize int) bool {
+	if next == len(units) {
+		return true
+	}
+	u := units[next]
+	order := make([]int, len(seats))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return totals[order[a]] < totals[order[b]]
+	})
+	triedEmpty := false
+	for _, t := range order {
+		if seats[t]+len(u.players) > teamSize {
+			continue
+		}
+		if seats[t] == 0 {
+			if triedEmpty {
+				continue
+			}
+			triedEmpty = true
+		}
+		assign[next] = t
+		seats[t] += len(u.players)
+		totals[t] += u.rating
+		if packTeams(units, next+1, assign, seats, totals, teamSize) {
+			return true
+		}
+		seats[t] -= len(u.players)
+		totals[t] -= u.rating
+	}
+	return false
+}
+
+// improveTeams swaps equal-sized units between teams while any swap
+// lowers the gap between the two teams involved. Swapping equal sizes
+// keeps every team's seat count, so the packing stays valid.
+func improveTeams(units []teamUnit, assign, totals []int) {
+	for improved := true; improved; {
+		improved = false
+		for i := range units {
+			for j := i + 1; j < len(units); j++ {
+				a, b := assign[i], assign[j]
+				if a == b || len(units[i].players) != len(units[j].players) {
+					continue
+				}
+				delta := units[i].rating - units[j].rating
+				before := abs(totals[a] - totals[b])
+				after := abs((totals[a] - delta) - (totals[b] + delta))
+				if after < before {
+					assign[i], assign[j] = b, a
+					totals[a] -= delta
+					totals[b] += delta
+					improved = true
+				}
+			}
+		}
+	}
+}
+
+// teamsFit reports whether group can be packed into the queue's teams
+// with every entry kept whole. Always true for queues without teams.
+func teamsFit(queue *models.GameQueue, group []QueueEntry) bool {
+	if !queue.HasTeams() {
+		return true
+	}
+	units := make([]teamUnit, len(group))
+	for i, e := range group {
+		units[i] = teamUnit{players: e.Players}
+	}
+	sort.SliceStable(units, func(i, j int) bool {
+		return len(units[i].players) > len(units[j].players)
+	})
+	return packTeams(units, 0, make([]int, len(units)), make([]int, queue.TeamCount), make([]int, queue.TeamCount), queue.TeamSize)
+}
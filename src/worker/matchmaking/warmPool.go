@@ -10,7 +10,8 @@ import (
 
 // MaintainWarmPool ensures at least HCLOUDWarmSlots container slots are
 // available across ready VMs. It provisions new VMs as needed, up to
-// HCLOUDMaxHosts. A no-op when HCLOUDWarmSlots is 0.
+// HCLOUDMaxHosts. A no-op when HCLOUDWarmSlots is 0. Warm VMs go in the
+// default region; matches elsewhere provision on demand.
 func MaintainWarmPool(ctx context.Context) error {
 	cfg := server.S.Config
 	if cfg.HCLOUDWarmSlots <= 0 {
@@ -22,6 +23,7 @@ func MaintainWarmPool(ctx context.Context) error {
 		return err
 	}
 
+	region := cfg.Regions()[0]
 	for available < int64(cfg.HCLOUDWarmSlots) {
 		count, err := models.CountMachineHosts()
 		if err != nil {
@@ -41,14 +43,14 @@ func MaintainWarmPool(ctx context.Context) error {
 			slog.Error("Warm pool: failed to read wildcard cert", "error", err)
 			return err
 		}
-		connInfo, err := server.S.Machines.CreateHost(ctx, cfg.HCLOUDHostType, cfg.HCLOUDAgentPort, tlsOpts)
+		connInfo, err := server.S.Machines.CreateHost(ctx, region, cfg.HCLOUDHostType, cfg.HCLOUDAgentPort, tlsOpts)
 		if err != nil {
 			slog.Error("Warm pool: failed to provision VM", "error", err)
 			return err
 		}
 
 		host, err := models.CreateMachineHost(
-			connInfo.ProviderID, connInfo.PublicIP, connInfo.AgentToken,
+			connInfo.ProviderID, connInfo.PublicIP, connInfo.AgentToken, region,
 			connInfo.AgentPort, cfg.HCLOUDMaxSlotsPerHost,
 		)
 		if err != nil {
@@ -0,0 +1,35 @@
+package ratings
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+)
+
+// DecayRatings applies inactivity decay in every queue that has it
+// enabled (see models.ApplyRatingDecay). Best-effort per queue, like
+// RolloverSeasons.
+func DecayRatings(ctx context.Context) error {
+	now := time.Now().UTC()
+	queueIDs, err := models.GetQueuesWithDecay()
+	if err != nil {
+		return err
+	}
+
+	for _, queueID := range queueIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n, err := models.ApplyRatingDecay(queueID, now)
+		if err != nil {
+			slog.Error("Failed to decay ratings", "queueID", queueID, "error", err)
+			continue
+		}
+		if n > 0 {
+			slog.Info("Decayed idle ratings", "queueID", queueID, "players", n)
+		}
+	}
+	return nil
+}
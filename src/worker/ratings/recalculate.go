@@ -0,0 +1,80 @@
+package ratings
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+)
+
+// recalculationProgressInterval throttles progress writes to Redis.
+const recalculationProgressInterval = time.Second
+
+// RunPendingRecalculations fails jobs whose worker died, then claims and
+// runs queued rating recalculations one at a time until none are left.
+func RunPendingRecalculations(ctx context.Context) error {
+	if err := models.FailExpiredRatingRecalculations(); err != nil {
+		return err
+	}
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		job, err := models.ClaimNextRatingRecalculation()
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return nil
+		}
+		runRecalculation(ctx, job)
+	}
+}
+
+func runRecalculation(ctx context.Context, job *models.RatingRecalculation) {
+	slog.Info("Starting rating recalculation", "jobID", job.ID, "queueID", job.GameQueueID, "dryRun", job.DryRun)
+
+	var processed int
+	var lastReport time.Time
+	progress := func(done, total int) {
+		processed = done
+		if done != total && time.Since(lastReport) < recalculationProgressInterval {
+			return
+		}
+		lastReport = time.Now()
+		if err := server.S.Redis.SetRecalculationProgress(ctx, job.ID, done, total); err != nil {
+			slog.Warn("Failed to publish recalculation progress", "jobID", job.ID, "error", err)
+		}
+	}
+
+	// Keep the job's lease alive while the replay runs, so other workers
+	// don't take it for abandoned.
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(models.RatingRecalculationLease / 4)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				if err := models.RenewRatingRecalculationLease(job); err != nil {
+					slog.Warn("Failed to renew recalculation lease", "jobID", job.ID, "error", err)
+				}
+			}
+		}
+	}()
+	diff, total, err := models.RecalculateQueueRatings(job.GameQueueID, job.DryRun, progress)
+	close(done)
+	if err != nil {
+		slog.Error("Rating recalculation failed", "jobID", job.ID, "queueID", job.GameQueueID, "error", err)
+	} else {
+		processed = total
+		slog.Info("Finished rating recalculation", "jobID", job.ID, "queueID", job.GameQueueID, "dryRun", job.DryRun, "matches", total, "changed", len(diff))
+	}
+	if ferr := models.FinishRatingRecalculation(job, processed, total, diff, err); ferr != nil {
+		slog.Error("Failed to record recalculation result", "jobID", job.ID, "error", ferr)
+	}
+}
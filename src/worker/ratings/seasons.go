@@ -0,0 +1,37 @@
+package ratings
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+)
+
+// RolloverSeasons ends every season whose deadline has passed, archiving
+// its standings and soft-resetting the queue's ratings (see
+// models.RolloverSeason). Best-effort per queue: one failing rollover
+// logs and moves on so the rest of the sweep proceeds, and is retried on
+// the next tick.
+func RolloverSeasons(ctx context.Context) error {
+	now := time.Now().UTC()
+	queueIDs, err := models.GetQueuesDueForRollover(now)
+	if err != nil {
+		return err
+	}
+
+	for _, queueID := range queueIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		season, err := models.RolloverSeason(queueID, now)
+		if err != nil {
+			slog.Error("Failed to roll over season", "queueID", queueID, "error", err)
+			continue
+		}
+		if season != nil {
+			slog.Info("Rolled over season", "queueID", queueID, "season", season.Number, "players", season.PlayerCount)
+		}
+	}
+	return nil
+}
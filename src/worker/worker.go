@@ -7,6 +7,7 @@ import (
 
 	"github.com/andy98725/elo-service/src/server"
 	"github.com/andy98725/elo-service/src/worker/matchmaking"
+	"github.com/andy98725/elo-service/src/worker/ratings"
 )
 
 // This can be moved to its own app eventually.
@@ -20,8 +21,19 @@ func RunWorker(ctx context.Context, shutdown chan struct{}) {
 	garbageCollectionPubsub := server.S.Redis.SubscribeGarbageCollectionTrigger(ctx)
 	defer garbageCollectionPubsub.Close()
 
+	// Ready checks resolve on their own channel, unthrottled: a player's
+	// answer or a proposal deadline must never be dropped the way a
+	// too-soon pairing trigger is.
+	readyCheckPubsub := server.S.Redis.SubscribeReadyCheckTrigger(ctx)
+	defer readyCheckPubsub.Close()
+
+	recalculationPubsub := server.S.Redis.SubscribeRecalculationTrigger(ctx)
+	defer recalculationPubsub.Close()
+
 	pairingCh := matchmakingPubsub.Channel()
 	gcCh := garbageCollectionPubsub.Channel()
+	readyCheckCh := readyCheckPubsub.Channel()
+	recalculationCh := recalculationPubsub.Channel()
 
 	// Run each once at start
 	server.S.Redis.PublishMatchmakingTrigger(ctx)
@@ -34,16 +46,19 @@ func RunWorker(ctx context.Context, shutdown chan struct{}) {
 
 	// TODO: Bump go version and use "golang.org/x/time/rate" package
 	var lastPairing, lastGC time.Time
-	runPairing := func() {
-		if time.Since(lastPairing) < server.S.Config.MatchmakingPairingMinInterval {
-			return
-		}
+	pair := func() {
 		lastPairing = time.Now()
 
 		if err := matchmaking.PairPlayers(ctx); err != nil {
 			slog.Error("Failed to pair players", "error", err)
 		}
 	}
+	runPairing := func() {
+		if time.Since(lastPairing) < server.S.Config.MatchmakingPairingMinInterval {
+			return
+		}
+		pair()
+	}
 	runGC := func() {
 		if time.Since(lastGC) < server.S.Config.MatchmakingGCMinInterval {
 			return
@@ -92,6 +107,55 @@ func RunWorker(ctx context.Context, shutdown chan struct{}) {
 		certTickCh = t.C
 	}
 
+	// Rating maintenance tick. Season rollovers and inactivity decay are
+	// driven by wall-clock time rather than pubsub triggers, so they need
+	// their own timer.
+	// A zero interval (e.g. a hand-built Config in tests) disables it.
+	var ratingTickCh <-chan time.Time
+	runRatingMaintenance := func() {
+		if err := ratings.RolloverSeasons(ctx); err != nil {
+			slog.Error("Failed to roll over seasons", "error", err)
+		}
+		// After rollover, so a season's archived standings are the
+		// ratings as they stood at its deadline.
+		if err := ratings.DecayRatings(ctx); err != nil {
+			slog.Error("Failed to decay ratings", "error", err)
+		}
+	}
+	if server.S.Config.RatingMaintenanceInterval > 0 {
+		t := time.NewTicker(server.S.Config.RatingMaintenanceInterval)
+		defer t.Stop()
+		ratingTickCh = t.C
+		runRatingMaintenance()
+	}
+
+	// Rating recalculations replay a queue's whole history and can take a
+	// while, so they run on their own goroutine instead of stalling
+	// pairing. Triggers coalesce: one pending wake-up is enough, since
+	// RunPendingRecalculations drains every queued job.
+	recalculationWake := make(chan struct{}, 1)
+	wakeRecalculation := func() {
+		select {
+		case recalculationWake <- struct{}{}:
+		default:
+		}
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-shutdown:
+				return
+			case <-recalculationWake:
+				if err := ratings.RunPendingRecalculations(ctx); err != nil {
+					slog.Error("Failed to run rating recalculations", "error", err)
+				}
+			}
+		}
+	}()
+	wakeRecalculation()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -102,6 +166,20 @@ func RunWorker(ctx context.Context, shutdown chan struct{}) {
 			runPairing()
 		case <-gcCh:
 			runGC()
+		case <-readyCheckCh:
+			requeued, err := matchmaking.ResolveProposals(ctx)
+			if err != nil {
+				slog.Error("Failed to resolve match proposals", "error", err)
+			}
+			// Requeued players should be paired again right away, even
+			// if a pairing pass just ran.
+			if requeued {
+				pair()
+			}
+		case <-recalculationCh:
+			wakeRecalculation()
+		case <-ratingTickCh:
+			runRatingMaintenance()
 		case <-certTickCh:
 			if err := server.S.Cert.EnsureFresh(ctx); err != nil {
 				slog.Error("Failed to refresh wildcard cert", "error", err)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -190,6 +191,35 @@ type MatchFound struct {
 	ServerHost  string
 	ServerPorts []int64
 	MatchID     string
+	// ConnectTokens maps player ID to the connect token that player was
+	// sent. One socket only sees its own; AddTokens merges the rest.
+	ConnectTokens map[string]string
+}
+
+// AddTokens merges the connect tokens another participant of the same
+// match received, so JoinContainer can join every player through m.
+func (m *MatchFound) AddTokens(other MatchFound) {
+	for id, tok := range other.ConnectTokens {
+		m.ConnectTokens[id] = tok
+	}
+}
+
+// connectTokenPlayer reads the player_id claim out of a connect token
+// without verifying it — the game server does that.
+func connectTokenPlayer(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		PlayerID string `json:"player_id"`
+	}
+	json.Unmarshal(raw, &claims)
+	return claims.PlayerID
 }
 
 // AwaitMatchFound reads from a /match/join (or post-/start lobby) WS
@@ -221,6 +251,7 @@ func AwaitMatchFound(t *testing.T, ws *websocket.Conn, label string) MatchFound
 		case "match_found":
 			host, _ := resp["server_host"].(string)
 			matchID, _ := resp["match_id"].(string)
+			connectToken, _ := resp["connect_token"].(string)
 			rawPorts, _ := resp["server_ports"].([]interface{})
 			ports := make([]int64, 0, len(rawPorts))
 			for _, p := range rawPorts {
@@ -228,11 +259,17 @@ func AwaitMatchFound(t *testing.T, ws *websocket.Conn, label string) MatchFound
 					ports = append(ports, int64(f))
 				}
 			}
-			if host == "" || matchID == "" || len(ports) == 0 {
+			playerID := connectTokenPlayer(connectToken)
+			if host == "" || matchID == "" || len(ports) == 0 || playerID == "" {
 				t.Fatalf("%s: malformed match_found payload: %+v", label, resp)
 			}
 			t.Logf("%s: match_found host=%s ports=%v matchID=%s", label, host, ports, matchID)
-			return MatchFound{ServerHost: host, ServerPorts: ports, MatchID: matchID}
+			return MatchFound{
+				ServerHost:    host,
+				ServerPorts:   ports,
+				MatchID:       matchID,
+				ConnectTokens: map[string]string{playerID: connectToken},
+			}
 		case "error":
 			t.Fatalf("%s: matchmaking error: %v", label, resp["error"])
 		default:
@@ -268,6 +305,7 @@ func PairTwoGuests(t *testing.T, baseURL, gameID, label1, label2, token1, token2
 		ws2.Close()
 		t.Fatalf("expected same match_id on both sockets, got %s and %s", m1.MatchID, m2.MatchID)
 	}
+	m1.AddTokens(m2)
 	return m1, ws1, ws2
 }
 
@@ -284,10 +322,11 @@ func PairTwoGuests(t *testing.T, baseURL, gameID, label1, label2, token1, token2
 // addresses. JoinContainer routes both shapes — IP → http://, hostname →
 // https:// with SNI — so callers don't have to think about it.
 
-// JoinContainer announces the player ID to the game container's /join
-// endpoint. The example-server expects the player ID as the request
-// body. Once both expected players have joined, the example simulates
-// a 3s game and POSTs /result/report on its own.
+// JoinContainer announces a player to the game container's /join
+// endpoint. The example-server expects the player's connect token as the
+// request body, so mf must carry it (see AddTokens). Once both expected
+// players have joined, the example simulates a 3s game and POSTs
+// /result/report on its own.
 //
 // Picks the right transport based on the shape of mf.ServerHost: a
 // hostname under .gs.elomm.net → https:// + SNI through Caddy; a raw
@@ -295,7 +334,11 @@ func PairTwoGuests(t *testing.T, baseURL, gameID, label1, label2, token1, token2
 // hand — the hostname/IP mismatch is a known gotcha.
 func JoinContainer(t *testing.T, mf MatchFound, playerID string) {
 	t.Helper()
-	status, body := PostToGameHost(t, mf, "/join", "text/plain", []byte(playerID))
+	connectToken, ok := mf.ConnectTokens[playerID]
+	if !ok {
+		t.Fatalf("join container: no connect token for %s", playerID)
+	}
+	status, body := PostToGameHost(t, mf, "/join", "text/plain", []byte(connectToken))
 	if status != http.StatusOK {
 		t.Fatalf("join container %s:%d: status=%d body=%s", mf.ServerHost, mf.ServerPorts[0], status, body)
 	}
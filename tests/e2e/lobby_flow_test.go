@@ -99,6 +99,7 @@ func TestLobbyFlow(t *testing.T) {
 	if m1.MatchID != m2.MatchID {
 		t.Fatalf("expected same match_id on host/joiner, got %s and %s", m1.MatchID, m2.MatchID)
 	}
+	m1.AddTokens(m2)
 	t.Logf("Match found at %s after %s", m1.ServerHost, time.Since(connectionStart))
 
 	// Confirm the lobby is gone from /lobby/find.
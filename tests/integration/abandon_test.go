@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+)
+
+// TestReportLeave records a player leaving a running match and checks
+// the leave is idempotent, restricted to participants, and surfaced to
+// the player on /games/:gameID/match/me.
+func TestReportLeave(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "leave", models.ELO_STRATEGY_CLASSIC, 2)
+	matchID, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+	leaveURL := h.BaseURL() + "/match/leave"
+
+	resp := DoReq(t, "POST", leaveURL, map[string]interface{}{"player_id": ids[0], "reason": "disconnected"}, authCode, http.StatusOK)
+	leave, _ := resp["leave"].(map[string]interface{})
+	if resp["match_id"] != matchID || resp["recorded"] != true || leave["player_id"] != ids[0] || leave["reason"] != "disconnected" {
+		t.Fatalf("unexpected leave response: %+v", resp)
+	}
+	resp = DoReq(t, "POST", leaveURL, map[string]interface{}{"player_id": ids[0], "reason": "kicked"}, authCode, http.StatusOK)
+	if leave, _ := resp["leave"].(map[string]interface{}); resp["recorded"] != false || leave["reason"] != "disconnected" {
+		t.Errorf("expected the repeat to return the first leave, got %+v", resp)
+	}
+
+	_, outsiderID := GuestLogin(t, h.BaseURL(), "outsider")
+	DoReq(t, "POST", leaveURL, map[string]interface{}{"player_id": outsiderID}, authCode, http.StatusBadRequest)
+	DoReq(t, "POST", leaveURL, map[string]interface{}{}, authCode, http.StatusBadRequest)
+	DoReq(t, "POST", leaveURL, map[string]interface{}{"player_id": ids[1]}, "not-a-real-code", http.StatusUnauthorized)
+
+	meURL := fmt.Sprintf("%s/games/%s/match/me", h.BaseURL(), gameID)
+	mine := DoReq(t, "GET", meURL, nil, tokens[0], http.StatusOK)
+	matches, _ := mine["matches"].([]interface{})
+	if len(matches) != 1 {
+		t.Fatalf("expected the match still listed for the player who left, got %+v", mine)
+	}
+	m := matches[0].(map[string]interface{})
+	if left, _ := m["left"].(map[string]interface{}); left["reason"] != "disconnected" || m["connect_token"] != nil {
+		t.Errorf("expected the leave and no connect token, got %+v", m)
+	}
+	theirs := DoReq(t, "GET", meURL, nil, tokens[1], http.StatusOK)
+	if m := theirs["matches"].([]interface{})[0].(map[string]interface{}); m["left"] != nil || m["connect_token"] == nil {
+		t.Errorf("expected the remaining player unaffected, got %+v", m)
+	}
+}
+
+// TestAbandonerRatedAsLoser reports the player who left as the winner
+// and checks ratings treat them as the loser anyway.
+func TestAbandonerRatedAsLoser(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "abandon", models.ELO_STRATEGY_CLASSIC, 2)
+	matchID, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+
+	DoReq(t, "POST", h.BaseURL()+"/match/leave", map[string]interface{}{"player_id": ids[0]}, authCode, http.StatusOK)
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id": authCode, "winner_ids": []string{ids[0]}, "reason": "completed",
+	}, "", http.StatusOK)
+
+	result := DoReq(t, "GET", fmt.Sprintf("%s/results/%s", h.BaseURL(), matchID), nil, tokens[1], http.StatusOK)
+	abandons, _ := result["abandons"].([]interface{})
+	if len(abandons) != 1 || abandons[0].(map[string]interface{})["player_id"] != ids[0] || abandons[0].(map[string]interface{})["reason"] != models.DefaultLeaveReason {
+		t.Errorf("expected the abandon on the result, got %+v", result)
+	}
+	byPlayer := map[string]float64{}
+	for _, c := range result["rating_changes"].([]interface{}) {
+		cm := c.(map[string]interface{})
+		byPlayer[cm["player_id"].(string)] = cm["delta"].(float64)
+	}
+	if byPlayer[ids[0]] >= 0 || byPlayer[ids[1]] <= 0 {
+		t.Errorf("expected the abandoner to lose rating and the other player to gain, got %v", byPlayer)
+	}
+}
+
+// TestAbandonerSplitFromTeam checks a player who left a winning team
+// loses rating while their teammates still win.
+func TestAbandonerSplitFromTeam(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "abteam", models.ELO_STRATEGY_TRUESKILL, 4)
+	_, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+
+	DoReq(t, "POST", h.BaseURL()+"/match/leave", map[string]interface{}{"player_id": ids[1]}, authCode, http.StatusOK)
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":        authCode,
+		"teams":           [][]string{{ids[0], ids[1]}, {ids[2], ids[3]}},
+		"team_placements": []int{1, 2},
+		"reason":          "completed",
+	}, "", http.StatusOK)
+
+	for i, token := range tokens {
+		rating := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, token, http.StatusOK)["rating"].(float64)
+		if i == 0 && rating <= 1000 {
+			t.Errorf("expected the teammate who stayed to win, got %v", rating)
+		}
+		if i != 0 && rating >= 1000 {
+			t.Errorf("expected player %d to lose, got %v", i, rating)
+		}
+	}
+}
+
+// TestAbandonPenalty checks leaving a running match costs the queue's
+// abandon points.
+func TestAbandonPenalty(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "abpen", models.ELO_STRATEGY_CLASSIC, 2)
+	if err := server.S.DB.Model(&models.GameQueue{}).Where("id = ?", queueID).
+		Update("penalty_abandon_points", 2).Error; err != nil {
+		t.Fatalf("set abandon points: %v", err)
+	}
+	_, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+
+	DoReq(t, "POST", h.BaseURL()+"/match/leave", map[string]interface{}{"player_id": ids[0]}, authCode, http.StatusOK)
+	DoReq(t, "POST", h.BaseURL()+"/match/leave", map[string]interface{}{"player_id": ids[0]}, authCode, http.StatusOK)
+
+	lockoutURL := fmt.Sprintf("%s/match/lockout?gameID=%s", h.BaseURL(), gameID)
+	if lockout := DoReq(t, "GET", lockoutURL, nil, tokens[0], http.StatusOK); lockout["locked"] != true || lockout["points"] != float64(2) {
+		t.Errorf("expected one abandon charged, got %+v", lockout)
+	}
+	if clean := DoReq(t, "GET", lockoutURL, nil, tokens[1], http.StatusOK); clean["points"] != float64(0) {
+		t.Errorf("expected the player who stayed unpenalized, got %+v", clean)
+	}
+}
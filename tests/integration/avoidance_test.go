@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBlockList(t *testing.T) {
+	h := NewHarness(t)
+
+	blocker, _ := GuestLogin(t, h.BaseURL(), "blocker")
+	_, blockedID := GuestLogin(t, h.BaseURL(), "blocked")
+	blockURL := h.BaseURL() + "/user/blocks/" + blockedID
+
+	DoReq(t, "PUT", blockURL, nil, blocker, http.StatusOK)
+	DoReq(t, "PUT", blockURL, nil, blocker, http.StatusOK)
+	list := DoReq(t, "GET", h.BaseURL()+"/user/blocks", nil, blocker, http.StatusOK)
+	blocks, _ := list["blocks"].([]interface{})
+	if len(blocks) != 1 || blocks[0].(map[string]interface{})["blocked_id"] != blockedID {
+		t.Fatalf("expected one block, got %+v", list)
+	}
+
+	// An unknown user can't be blocked.
+	DoReq(t, "PUT", h.BaseURL()+"/user/blocks/00000000-0000-0000-0000-000000000000", nil, blocker, http.StatusNotFound)
+
+	DoReq(t, "DELETE", blockURL, nil, blocker, http.StatusOK)
+	DoReq(t, "DELETE", blockURL, nil, blocker, http.StatusNotFound)
+	list = DoReq(t, "GET", h.BaseURL()+"/user/blocks", nil, blocker, http.StatusOK)
+	if blocks, _ := list["blocks"].([]interface{}); len(blocks) != 0 {
+		t.Errorf("expected the block removed, got %+v", list)
+	}
+}
+
+func TestBlockYourself(t *testing.T) {
+	h := NewHarness(t)
+
+	token, id := GuestLogin(t, h.BaseURL(), "narcissus")
+	DoReq(t, "PUT", h.BaseURL()+"/user/blocks/"+id, nil, token, http.StatusBadRequest)
+}
+
+// TestBlockedPlayersNotPaired queues a player with someone who blocked
+// them, then a third player, and checks the pair that isn't blocked is
+// the one matched.
+func TestBlockedPlayersNotPaired(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "blockowner", "blockowner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "blockowner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "BlockGame", 2)
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s", h.BaseURL(), game["id"])
+
+	token1, _ := GuestLogin(t, h.BaseURL(), "bp1")
+	token2, id2 := GuestLogin(t, h.BaseURL(), "bp2")
+	token3, _ := GuestLogin(t, h.BaseURL(), "bp3")
+	DoReq(t, "PUT", h.BaseURL()+"/user/blocks/"+id2, nil, token1, http.StatusOK)
+
+	ws1 := WebsocketConnect(t, joinURL, token1)
+	defer ws1.Close()
+	readQueueJoined(t, ws1)
+	ws2 := WebsocketConnect(t, joinURL, token2)
+	defer ws2.Close()
+	readQueueJoined(t, ws2)
+	TriggerMatchmaking(t)
+	time.Sleep(300 * time.Millisecond)
+	if size := QueueSize(t, h.BaseURL(), token1, game["id"].(string)); size != 2 {
+		t.Fatalf("expected blocked players not to be matched, queue size %v", size)
+	}
+
+	ws3 := WebsocketConnect(t, joinURL, token3)
+	defer ws3.Close()
+	readQueueJoined(t, ws3)
+	TriggerMatchmaking(t)
+	awaitStatus(t, ws1, "match_found")
+	awaitStatus(t, ws3, "match_found")
+	if awaitMatchFound(ws2, time.Second) {
+		t.Error("expected the blocked player to keep waiting")
+	}
+}
+
+// TestRematchAvoidance plays a match between two players, requeues them
+// with a third, and checks they're kept apart.
+func TestRematchAvoidance(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "rematch", "rematch@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "rematch@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "RematchGame", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "norematch", map[string]interface{}{
+		"rematch_lookback_minutes": 30, "rematch_max_wait_seconds": 600,
+	})
+	if q["rematch_lookback_minutes"] != float64(30) || q["rematch_max_wait_seconds"] != float64(600) {
+		t.Fatalf("expected the rematch settings echoed, got %+v", q)
+	}
+	DoReq(t, "PUT", fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, q["id"]),
+		map[string]interface{}{"rematch_lookback_minutes": 100000}, ownerToken, http.StatusBadRequest)
+	queueID := q["id"].(string)
+
+	token1, id1 := GuestLogin(t, h.BaseURL(), "rm1")
+	token2, id2 := GuestLogin(t, h.BaseURL(), "rm2")
+	token3, _ := GuestLogin(t, h.BaseURL(), "rm3")
+	_, authCode := startSyntheticMatch(t, gameID, queueID, []string{id1, id2})
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id": authCode, "winner_ids": []string{id1}, "reason": "completed",
+	}, "", http.StatusOK)
+
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s&queueID=%s", h.BaseURL(), gameID, queueID)
+	ws1 := WebsocketConnect(t, joinURL, token1)
+	defer ws1.Close()
+	readQueueJoined(t, ws1)
+	ws2 := WebsocketConnect(t, joinURL, token2)
+	defer ws2.Close()
+	readQueueJoined(t, ws2)
+	TriggerMatchmaking(t)
+	time.Sleep(300 * time.Millisecond)
+	if size := QueueSizeWithQueue(t, h.BaseURL(), token1, gameID, queueID); size != 2 {
+		t.Fatalf("expected recent opponents not to be rematched, queue size %v", size)
+	}
+
+	ws3 := WebsocketConnect(t, joinURL, token3)
+	defer ws3.Close()
+	readQueueJoined(t, ws3)
+	TriggerMatchmaking(t)
+	awaitStatus(t, ws1, "match_found")
+	awaitStatus(t, ws3, "match_found")
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/gorilla/websocket"
+)
+
+// TestBackfill starts a 1v1, has the game server report a player leaving
+// and ask for one backfill player, and checks the next player to queue
+// is sent to the running server and added to the match.
+func TestBackfill(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "backfill", "backfill@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "backfill@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "BackfillGame", 2)
+	gameID := game["id"].(string)
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s", h.BaseURL(), gameID)
+	backfillURL := h.BaseURL() + "/match/backfill"
+
+	var conns []*websocket.Conn
+	var players []string
+	for _, name := range []string{"bf1", "bf2"} {
+		token, id := GuestLogin(t, h.BaseURL(), name)
+		ws := WebsocketConnect(t, joinURL, token)
+		defer ws.Close()
+		readQueueJoined(t, ws)
+		conns = append(conns, ws)
+		players = append(players, id)
+	}
+	TriggerMatchmaking(t)
+	var found map[string]interface{}
+	for _, ws := range conns {
+		found = awaitStatus(t, ws, "match_found")
+	}
+	matchID := found["match_id"].(string)
+	match, err := models.GetMatch(matchID)
+	if err != nil {
+		t.Fatalf("get match: %v", err)
+	}
+	authCode := match.AuthCode
+
+	DoReq(t, "POST", backfillURL, map[string]interface{}{"players": 1}, "", http.StatusUnauthorized)
+	DoReq(t, "POST", backfillURL, map[string]interface{}{"players": 3}, authCode, http.StatusBadRequest)
+	DoReq(t, "POST", backfillURL, map[string]interface{}{"players": 1, "team": 0}, authCode, http.StatusBadRequest)
+	// Both seats are taken until someone leaves.
+	DoReq(t, "POST", backfillURL, map[string]interface{}{"players": 1}, authCode, http.StatusBadRequest)
+	DoReq(t, "POST", h.BaseURL()+"/match/leave", map[string]interface{}{"player_id": players[0]}, authCode, http.StatusOK)
+	DoReq(t, "POST", backfillURL, map[string]interface{}{"players": 2}, authCode, http.StatusBadRequest)
+	DoReq(t, "POST", backfillURL, map[string]interface{}{"players": 1}, authCode, http.StatusOK)
+	status := DoReq(t, "GET", backfillURL, nil, authCode, http.StatusOK)
+	if status["players_requested"] != float64(1) {
+		t.Fatalf("expected one open backfill seat, got %+v", status)
+	}
+
+	token, guestID := GuestLogin(t, h.BaseURL(), "bf3")
+	ws := WebsocketConnect(t, joinURL, token)
+	defer ws.Close()
+	readQueueJoined(t, ws)
+	TriggerMatchmaking(t)
+	joined := awaitStatus(t, ws, "match_found")
+	if joined["match_id"] != matchID || joined["server_host"] != found["server_host"] ||
+		fmt.Sprint(joined["server_ports"]) != fmt.Sprint(found["server_ports"]) {
+		t.Fatalf("expected the backfill player sent to the running server %+v, got %+v", found, joined)
+	}
+
+	status = DoReq(t, "GET", backfillURL, nil, authCode, http.StatusOK)
+	ids, _ := status["player_ids"].([]interface{})
+	if status["players_requested"] != float64(0) || len(ids) != 3 || !slices.Contains(ids, interface{}(guestID)) {
+		t.Fatalf("expected the backfill player added and the request closed, got %+v", status)
+	}
+	if size := QueueSize(t, h.BaseURL(), ownerToken, gameID); size != 0 {
+		t.Errorf("expected the backfill player out of the queue, queue size %v", size)
+	}
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report",
+		map[string]interface{}{"token_id": authCode, "winner_ids": []string{}, "reason": "draw"}, "", http.StatusOK)
+	// Cooldown is off in tests, so the ended match is already gone.
+	DoReq(t, "POST", backfillURL, map[string]interface{}{"players": 1}, authCode, http.StatusUnauthorized)
+}
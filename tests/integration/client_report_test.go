@@ -0,0 +1,244 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/andy98725/elo-service/src/worker/matchmaking"
+	"github.com/gorilla/websocket"
+)
+
+// setupClientReportedGame is setupRatedGame on a queue whose players
+// report results themselves, needing quorum agreeing reports, plus a
+// synthetic match between every player. Returns the owner's token too.
+func setupClientReportedGame(t *testing.T, h *Harness, suffix string, n, quorum int) (gameID, matchID, ownerToken string, tokens, ids []string) {
+	t.Helper()
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, suffix, models.ELO_STRATEGY_CLASSIC, n)
+	if err := server.S.DB.Model(&models.GameQueue{}).Where("id = ?", queueID).
+		Updates(map[string]interface{}{"result_reporting": models.RESULT_REPORTING_CLIENTS, "result_quorum": quorum}).Error; err != nil {
+		t.Fatalf("set result reporting: %v", err)
+	}
+	ownerToken, _ = LoginUser(t, h.BaseURL(), "tso"+suffix+"@example.com", "pass")
+	matchID, _ = startSyntheticMatch(t, gameID, queueID, ids)
+	return gameID, matchID, ownerToken, tokens, ids
+}
+
+func TestResultReportingValidation(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "rrowner", "rrowner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "rrowner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "ReportingGame", 2)
+	gameID := game["id"].(string)
+
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "p2p", map[string]interface{}{"lobby_size": 4})
+	if q["result_reporting"] != models.RESULT_REPORTING_SERVER || q["result_quorum"] != float64(0) {
+		t.Fatalf("expected server reporting by default, got %+v", q)
+	}
+	if q["result_report_window_seconds"] != float64(models.DefaultResultReportWindowSeconds) {
+		t.Errorf("expected the default report window, got %+v", q["result_report_window_seconds"])
+	}
+
+	queueURL := fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, q["id"])
+	DoReq(t, "PUT", queueURL, map[string]interface{}{"result_reporting": "referee"}, ownerToken, http.StatusBadRequest)
+	DoReq(t, "PUT", queueURL, map[string]interface{}{"result_quorum": 2}, ownerToken, http.StatusBadRequest)
+	DoReq(t, "PUT", queueURL, map[string]interface{}{"result_quorum": 5}, ownerToken, http.StatusBadRequest)
+	DoReq(t, "PUT", queueURL, map[string]interface{}{"result_report_window_seconds": models.MaxResultReportWindowSeconds + 1}, ownerToken, http.StatusBadRequest)
+	updated := DoReq(t, "PUT", queueURL, map[string]interface{}{
+		"result_reporting": models.RESULT_REPORTING_CLIENTS, "result_quorum": 3,
+	}, ownerToken, http.StatusOK)
+	if updated["result_reporting"] != models.RESULT_REPORTING_CLIENTS || updated["result_quorum"] != float64(3) {
+		t.Errorf("expected client reporting with a quorum of 3, got %+v", updated)
+	}
+	// Shrinking the lobby can't leave the quorum out of reach.
+	DoReq(t, "PUT", queueURL, map[string]interface{}{"lobby_size": 2}, ownerToken, http.StatusBadRequest)
+}
+
+// TestClientReportQuorum records a result once two of three players
+// agree, even though they phrased it differently.
+func TestClientReportQuorum(t *testing.T) {
+	h := NewHarness(t)
+	_, matchID, _, tokens, ids := setupClientReportedGame(t, h, "quorum", 3, 2)
+	reportURL := fmt.Sprintf("%s/matches/%s/report", h.BaseURL(), matchID)
+
+	// A server report can't bypass the quorum.
+	match, err := models.GetMatch(matchID)
+	if err != nil {
+		t.Fatalf("get match: %v", err)
+	}
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id": match.AuthCode, "winner_ids": []string{ids[2]},
+	}, "", http.StatusForbidden)
+
+	resp := DoReq(t, "POST", reportURL, map[string]interface{}{"winner_ids": []string{ids[0]}, "reason": "completed"}, tokens[0], http.StatusOK)
+	if resp["status"] != string(models.ReportsPending) || resp["recorded"] != true || resp["reports"] != float64(1) || resp["quorum"] != float64(2) {
+		t.Fatalf("expected the first report pending, got %+v", resp)
+	}
+	resp = DoReq(t, "POST", reportURL, map[string]interface{}{"winner_ids": []string{ids[1]}}, tokens[0], http.StatusOK)
+	if report, _ := resp["report"].(map[string]interface{}); resp["recorded"] != false || report["reason"] != "completed" {
+		t.Errorf("expected the repeat to return the first report, got %+v", resp)
+	}
+
+	outsiderToken, _ := GuestLogin(t, h.BaseURL(), "outsider")
+	DoReq(t, "POST", reportURL, map[string]interface{}{"winner_ids": []string{ids[0]}}, outsiderToken, http.StatusForbidden)
+	DoReq(t, "POST", reportURL, map[string]interface{}{"placements": map[string]int{"g_nobody": 1}}, tokens[1], http.StatusBadRequest)
+
+	resp = DoReq(t, "POST", reportURL, map[string]interface{}{
+		"placements": map[string]int{ids[0]: 1, ids[1]: 3, ids[2]: 3}, "reason": "completed",
+	}, tokens[1], http.StatusOK)
+	if resp["status"] != string(models.ReportsAgreed) {
+		t.Fatalf("expected the second agreeing report to reach the quorum, got %+v", resp)
+	}
+	DoReq(t, "POST", reportURL, map[string]interface{}{"winner_ids": []string{ids[2]}}, tokens[2], http.StatusNotFound)
+
+	result, err := models.GetMatchResult(matchID)
+	if err != nil {
+		t.Fatalf("expected a match result: %v", err)
+	}
+	if result.Result != "completed" || result.Unrated || len(result.WinnerIDs) != 1 || result.WinnerIDs[0] != ids[0] {
+		t.Errorf("expected %s to win a rated match, got %+v", ids[0], result)
+	}
+	if reports := result.ToResp().Reports; len(reports) != 2 {
+		t.Errorf("expected both reports on the result, got %+v", reports)
+	}
+	byPlayer := map[string]int{}
+	for _, c := range result.RatingChanges {
+		byPlayer[c.PlayerID] = c.Delta
+	}
+	if byPlayer[ids[0]] <= 0 || byPlayer[ids[1]] >= 0 {
+		t.Errorf("expected ratings updated for the agreed result, got %v", byPlayer)
+	}
+}
+
+// TestClientReportDispute has both players claim the win and checks the
+// match ends disputed, unrated, and listed for the game owner.
+func TestClientReportDispute(t *testing.T) {
+	h := NewHarness(t)
+	gameID, matchID, ownerToken, tokens, ids := setupClientReportedGame(t, h, "dispute", 2, 0)
+	reportURL := fmt.Sprintf("%s/matches/%s/report", h.BaseURL(), matchID)
+
+	DoReq(t, "POST", reportURL, map[string]interface{}{"winner_ids": []string{ids[0]}}, tokens[0], http.StatusOK)
+	resp := DoReq(t, "POST", reportURL, map[string]interface{}{"winner_ids": []string{ids[1]}}, tokens[1], http.StatusOK)
+	if resp["status"] != string(models.ReportsDisputed) {
+		t.Fatalf("expected conflicting reports to dispute the match, got %+v", resp)
+	}
+
+	result, err := models.GetMatchResult(matchID)
+	if err != nil {
+		t.Fatalf("expected a match result: %v", err)
+	}
+	if result.Result != models.MatchResultDisputed || !result.Unrated || len(result.RatingChanges) != 0 {
+		t.Errorf("expected an unrated disputed result, got %+v", result)
+	}
+	for _, token := range tokens {
+		if rating := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, token, http.StatusOK)["rating"].(float64); rating != 1000 {
+			t.Errorf("expected ratings untouched, got %v", rating)
+		}
+	}
+
+	disputedURL := fmt.Sprintf("%s/game/%s/results/disputed", h.BaseURL(), gameID)
+	listed := DoReq(t, "GET", disputedURL, nil, ownerToken, http.StatusOK)
+	results, _ := listed["matchResults"].([]interface{})
+	if len(results) != 1 {
+		t.Fatalf("expected the disputed match listed, got %+v", listed)
+	}
+	disputed := results[0].(map[string]interface{})
+	if reports, _ := disputed["reports"].([]interface{}); disputed["id"] != matchID || len(reports) != 2 {
+		t.Errorf("expected the match with both reports, got %+v", disputed)
+	}
+	DoReq(t, "GET", disputedURL, nil, tokens[0], http.StatusForbidden)
+}
+
+// TestClientReportWindow has only the winner of a 1v1 report and checks
+// the silent loser can't hold the result up past the report window.
+func TestClientReportWindow(t *testing.T) {
+	h := NewHarness(t)
+	_, matchID, _, tokens, ids := setupClientReportedGame(t, h, "window", 2, 0)
+	reportURL := fmt.Sprintf("%s/matches/%s/report", h.BaseURL(), matchID)
+
+	resp := DoReq(t, "POST", reportURL, map[string]interface{}{"winner_ids": []string{ids[0]}, "reason": "completed"}, tokens[0], http.StatusOK)
+	if resp["status"] != string(models.ReportsPending) || resp["quorum"] != float64(2) {
+		t.Fatalf("expected the lone report pending a majority of 2, got %+v", resp)
+	}
+	if err := matchmaking.GarbageCollectMatches(context.Background()); err != nil {
+		t.Fatalf("gc: %v", err)
+	}
+	if _, err := models.GetMatchResult(matchID); err == nil {
+		t.Fatal("expected the match to keep running inside the report window")
+	}
+
+	// Backdate the report past the window.
+	match, err := models.GetMatch(matchID)
+	if err != nil {
+		t.Fatalf("get match: %v", err)
+	}
+	reports := match.ReportLog()
+	reports[0].ReportedAt = reports[0].ReportedAt.Add(-time.Duration(models.DefaultResultReportWindowSeconds+1) * time.Second)
+	encoded, _ := json.Marshal(reports)
+	if err := server.S.DB.Model(&models.Match{}).Where("id = ?", matchID).Update("reports", json.RawMessage(encoded)).Error; err != nil {
+		t.Fatalf("backdate report: %v", err)
+	}
+	if err := matchmaking.GarbageCollectMatches(context.Background()); err != nil {
+		t.Fatalf("gc: %v", err)
+	}
+
+	result, err := models.GetMatchResult(matchID)
+	if err != nil {
+		t.Fatalf("expected the report window to settle the match: %v", err)
+	}
+	if result.Result != "completed" || result.Unrated || len(result.WinnerIDs) != 1 || result.WinnerIDs[0] != ids[0] {
+		t.Errorf("expected %s to win a rated match, got %+v", ids[0], result)
+	}
+}
+
+func TestClientReportOnServerQueue(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "srvq", models.ELO_STRATEGY_CLASSIC, 2)
+	matchID, _ := startSyntheticMatch(t, gameID, queueID, ids)
+
+	DoReq(t, "POST", fmt.Sprintf("%s/matches/%s/report", h.BaseURL(), matchID),
+		map[string]interface{}{"winner_ids": []string{ids[0]}}, tokens[0], http.StatusForbidden)
+}
+
+// TestClientReportedMatchmaking pairs two players on a client-reported
+// queue and checks no game server is started for them.
+func TestClientReportedMatchmaking(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "p2powner", "p2powner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "p2powner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "PeerGame", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "p2p", map[string]interface{}{
+		"result_reporting": models.RESULT_REPORTING_CLIENTS, "matchmaking_machine_ports": []int64{},
+	})
+
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s&queueID=%s", h.BaseURL(), gameID, q["id"])
+	conns := make([]*websocket.Conn, 2)
+	for i := range conns {
+		token, _ := GuestLogin(t, h.BaseURL(), fmt.Sprintf("peer%d", i))
+		conns[i] = WebsocketConnect(t, joinURL, token)
+		defer conns[i].Close()
+		readQueueJoined(t, conns[i])
+	}
+	TriggerMatchmaking(t)
+
+	for _, ws := range conns {
+		found := awaitStatus(t, ws, "match_found")
+		if players, _ := found["player_ids"].([]interface{}); len(players) != 2 || found["result_reporting"] != models.RESULT_REPORTING_CLIENTS {
+			t.Errorf("expected the peers and client reporting on match_found, got %+v", found)
+		}
+		if _, ok := found["server_host"]; ok || found["connect_token"] == nil {
+			t.Errorf("expected a connect token and no server, got %+v", found)
+		}
+	}
+	if h.Machines.ActiveContainers() != 0 {
+		t.Errorf("expected no game server started, got %d containers", h.Machines.ActiveContainers())
+	}
+}
@@ -1,24 +1,59 @@
 package integration
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/andy98725/elo-service/src/api/auth"
 	"github.com/andy98725/elo-service/src/models"
 	"github.com/andy98725/elo-service/src/server"
+	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/websocket"
 )
 
+// verifyConnectToken checks a connect token the way a game server
+// would: against the public key from the JWKS endpoint, with no call
+// back to the platform per token.
+func verifyConnectToken(t *testing.T, baseURL, tokenString string) *auth.ConnectClaims {
+	t.Helper()
+	jwks := DoReq(t, "GET", baseURL+"/.well-known/jwks.json", nil, "", http.StatusOK)
+	keys, _ := jwks["keys"].([]interface{})
+	if len(keys) != 1 {
+		t.Fatalf("expected one published key, got %+v", jwks)
+	}
+	jwk := keys[0].(map[string]interface{})
+	if jwk["kty"] != "OKP" || jwk["crv"] != "Ed25519" || jwk["alg"] != "EdDSA" {
+		t.Fatalf("unexpected key shape: %+v", jwk)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(jwk["x"].(string))
+	if err != nil || len(x) != ed25519.PublicKeySize {
+		t.Fatalf("bad public key %q: %v", jwk["x"], err)
+	}
+
+	claims := &auth.ConnectClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(tok *jwt.Token) (interface{}, error) {
+		if tok.Header["kid"] != jwk["kid"] {
+			return nil, fmt.Errorf("kid %v not published", tok.Header["kid"])
+		}
+		return ed25519.PublicKey(x), nil
+	})
+	if err != nil || !token.Valid {
+		t.Fatalf("connect token doesn't verify against the JWKS: %v", err)
+	}
+	if token.Method != jwt.SigningMethodEdDSA {
+		t.Errorf("expected an EdDSA token, got %v", token.Method.Alg())
+	}
+	return claims
+}
+
 // TestMatchFoundIncludesConnectToken pairs two guests via the matchmaking
-// WS and asserts each receives a connect_token equal to its own player id.
-// Locks down the phase-1 contract: connect_token is the per-player join
-// credential the client passes to the game server, but its value still
-// equals the player id while the protocol concept is being rolled out.
-// Phase 2 will swap the value to a generated secret without changing this
-// test's assertion that the field exists and is non-empty per recipient.
+// WS and asserts each receives a connect_token signed for its own player
+// and the match, bound to the match's auth code through its audience.
 func TestMatchFoundIncludesConnectToken(t *testing.T) {
 	h := NewHarness(t)
 
@@ -29,6 +64,7 @@ func TestMatchFoundIncludesConnectToken(t *testing.T) {
 
 	g1Token, g1ID := GuestLogin(t, h.BaseURL(), "ct1")
 	g2Token, g2ID := GuestLogin(t, h.BaseURL(), "ct2")
+	names := map[string]string{g1ID: "ct1", g2ID: "ct2"}
 
 	ws1 := WebsocketConnect(t, fmt.Sprintf("%s/match/join?gameID=%s", h.BaseURL(), gameID), g1Token)
 	defer ws1.Close()
@@ -44,6 +80,7 @@ func TestMatchFoundIncludesConnectToken(t *testing.T) {
 
 	type recv struct {
 		expectedID   string
+		matchID      string
 		connectToken string
 		err          error
 	}
@@ -63,7 +100,8 @@ func TestMatchFoundIncludesConnectToken(t *testing.T) {
 			}
 			if resp["status"] == "match_found" {
 				ct, _ := resp["connect_token"].(string)
-				results <- recv{expectedID: expectedID, connectToken: ct}
+				matchID, _ := resp["match_id"].(string)
+				results <- recv{expectedID: expectedID, matchID: matchID, connectToken: ct}
 				return
 			}
 			if resp["status"] == "error" {
@@ -80,20 +118,30 @@ func TestMatchFoundIncludesConnectToken(t *testing.T) {
 		if r.err != nil {
 			t.Fatalf("ws read for %s: %v", r.expectedID, r.err)
 		}
-		if r.connectToken == "" {
-			t.Errorf("%s: match_found missing connect_token", r.expectedID)
+		if r.connectToken == "" || r.connectToken == r.expectedID {
+			t.Fatalf("%s: expected a signed connect_token, got %q", r.expectedID, r.connectToken)
+		}
+		match, err := models.GetMatch(r.matchID)
+		if err != nil {
+			t.Fatalf("get match: %v", err)
+		}
+		claims := verifyConnectToken(t, h.BaseURL(), r.connectToken)
+		if claims.PlayerID != r.expectedID || claims.MatchID != r.matchID || claims.DisplayName != names[r.expectedID] {
+			t.Errorf("%s: unexpected claims %+v", r.expectedID, claims)
 		}
-		if r.connectToken != r.expectedID {
-			t.Errorf("%s: connect_token = %q, want %q (phase 1 = playerID)",
-				r.expectedID, r.connectToken, r.expectedID)
+		if claims.Audience != auth.MatchAudience(match.AuthCode) {
+			t.Errorf("%s: audience %q isn't bound to the match's auth code", r.expectedID, claims.Audience)
+		}
+		if exp := time.Unix(claims.ExpiresAt, 0); exp.After(time.Now().Add(auth.CONNECT_TOKEN_TIMEOUT)) || exp.Before(time.Now()) {
+			t.Errorf("%s: expected a short-lived token, expires %v", r.expectedID, exp)
 		}
 	}
 }
 
 // TestActiveMatchIncludesConnectToken covers the reconnect endpoint
 // (/games/:gameID/match/me) — its response shape mirrors match_found and
-// must also carry connect_token so a client that drops the original WS
-// can still reach the game server.
+// must also carry a signed connect_token so a client that drops the
+// original WS can still reach the game server.
 func TestActiveMatchIncludesConnectToken(t *testing.T) {
 	h := NewHarness(t)
 
@@ -146,8 +194,15 @@ func TestActiveMatchIncludesConnectToken(t *testing.T) {
 	}
 	m := matches[0].(map[string]interface{})
 	ct, _ := m["connect_token"].(string)
-	if ct != g1ID {
-		t.Errorf("connect_token = %q, want %q", ct, g1ID)
+	claims := verifyConnectToken(t, h.BaseURL(), ct)
+	if claims.PlayerID != g1ID || claims.MatchID != m["match_id"] || claims.DisplayName != "ctme1" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if _, err := auth.ValidateConnectToken(ct); err != nil {
+		t.Errorf("platform-side validation failed: %v", err)
+	}
+	if _, err := auth.ValidateConnectToken(ct[:len(ct)-4] + "AAAA"); err == nil {
+		t.Error("expected a tampered token rejected")
 	}
 }
 
@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/andy98725/elo-service/src/worker/ratings"
+)
+
+// idleRatings backdates every rating in the queue so it looks like the
+// last match was played `ago`.
+func idleRatings(t *testing.T, queueID string, ago time.Duration) {
+	t.Helper()
+	if err := server.S.DB.Model(&models.Rating{}).
+		Where("game_queue_id = ?", queueID).
+		UpdateColumn("updated_at", time.Now().UTC().Add(-ago)).Error; err != nil {
+		t.Fatalf("backdate ratings: %v", err)
+	}
+}
+
+// TestRatingDecayAfterGracePeriod plays one rated 1v1, idles both players
+// past the grace period, and checks the winner decays by whole weeks down
+// to the floor with a decay history entry, the loser (already below the
+// floor) is untouched, and a second sweep charges nothing more.
+func TestRatingDecayAfterGracePeriod(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "decay", models.ELO_STRATEGY_CLASSIC, 2)
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "tsodecay@example.com", "pass")
+
+	updated := DoReq(t, "PUT", fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, queueID), map[string]interface{}{
+		"decay_grace_days": 14,
+		"decay_per_week":   5,
+	}, ownerToken, http.StatusOK)
+	if updated["decay_floor"].(float64) != 1000 {
+		t.Fatalf("expected decay floor to default to default_rating, got %+v", updated)
+	}
+
+	_, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"winner_ids": []string{ids[0]},
+		"reason":     "completed",
+	}, "", http.StatusOK)
+
+	// Still inside the grace period: nothing happens.
+	idleRatings(t, queueID, 10*24*time.Hour)
+	if err := ratings.DecayRatings(context.Background()); err != nil {
+		t.Fatalf("DecayRatings: %v", err)
+	}
+	resp := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	if resp["rating"].(float64) != 1016 {
+		t.Fatalf("expected no decay within grace, got %v", resp["rating"])
+	}
+
+	// Two full weeks past grace (plus change): 1016 - 2*5 = 1006.
+	idleRatings(t, queueID, 14*24*time.Hour+2*models.RATING_DECAY_PERIOD+time.Hour)
+	if err := ratings.DecayRatings(context.Background()); err != nil {
+		t.Fatalf("DecayRatings: %v", err)
+	}
+	resp = DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	if resp["rating"].(float64) != 1006 {
+		t.Errorf("expected winner decayed to 1006, got %v", resp["rating"])
+	}
+	resp = DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[1], http.StatusOK)
+	if resp["rating"].(float64) != 984 {
+		t.Errorf("expected loser below the floor to stay at 984, got %v", resp["rating"])
+	}
+
+	history := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s/history", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	entries, _ := history["history"].([]interface{})
+	if len(entries) != 2 {
+		t.Fatalf("expected match + decay history entries, got %+v", history)
+	}
+	latest := entries[0].(map[string]interface{})
+	if latest["reason"] != models.RatingChangeReasonDecay || latest["delta"].(float64) != -10 {
+		t.Errorf("expected latest entry to be a -10 decay, got %+v", latest)
+	}
+
+	// Already charged through the current week: a re-run is a no-op.
+	if err := ratings.DecayRatings(context.Background()); err != nil {
+		t.Fatalf("DecayRatings: %v", err)
+	}
+	history = DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s/history", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	if n := len(history["history"].([]interface{})); n != 2 {
+		t.Errorf("expected no additional decay entries, got %d", n)
+	}
+
+	// A long absence is clamped at the floor.
+	idleRatings(t, queueID, 52*models.RATING_DECAY_PERIOD)
+	if err := server.S.DB.Model(&models.Rating{}).
+		Where("game_queue_id = ?", queueID).
+		UpdateColumn("decayed_at", nil).Error; err != nil {
+		t.Fatalf("reset decayed_at: %v", err)
+	}
+	if err := ratings.DecayRatings(context.Background()); err != nil {
+		t.Fatalf("DecayRatings: %v", err)
+	}
+	resp = DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	if resp["rating"].(float64) != 1000 {
+		t.Errorf("expected winner clamped at floor 1000, got %v", resp["rating"])
+	}
+}
+
+// TestRatingDecayValidation covers the 400 for negative decay settings
+// and that decay is off unless decay_per_week is set.
+func TestRatingDecayValidation(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "decayowner", "decayowner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "decayowner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "DecayValidationGame", 2)
+	gameID := game["id"].(string)
+	queueID := DefaultQueueID(t, game)
+
+	DoReq(t, "PUT", fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, queueID), map[string]interface{}{
+		"decay_per_week": -1,
+	}, ownerToken, http.StatusBadRequest)
+
+	created := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "decaying", map[string]interface{}{
+		"default_rating": 1500,
+		"decay_per_week": 10,
+	})
+	if created["decay_floor"].(float64) != 1500 || created["decay_grace_days"].(float64) != 0 {
+		t.Errorf("expected floor 1500 and no grace, got %+v", created)
+	}
+
+	queueIDs, err := models.GetQueuesWithDecay()
+	if err != nil {
+		t.Fatalf("GetQueuesWithDecay: %v", err)
+	}
+	if len(queueIDs) != 1 || queueIDs[0] != created["id"] {
+		t.Errorf("expected only the decaying queue, got %v", queueIDs)
+	}
+}
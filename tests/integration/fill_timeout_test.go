@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/gorilla/websocket"
+)
+
+func TestFillTimeoutSettings(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "fillcfg", "fillcfg@example.com", "pass")
+	token, _ := LoginUser(t, h.BaseURL(), "fillcfg@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), token, "FillCfgGame", 2)
+	gameID := game["id"].(string)
+	queueURL := fmt.Sprintf("%s/game/%s/queue", h.BaseURL(), gameID)
+
+	for name, body := range map[string]map[string]interface{}{
+		"above lobby": {"name": "above", "lobby_size": 4, "min_players": 5},
+		"teams":       {"name": "teams", "team_count": 2, "team_size": 2, "min_players": 2},
+		"long":        {"name": "long", "lobby_size": 4, "min_players": 2, "fill_timeout_seconds": 4000},
+	} {
+		body["matchmaking_machine_ports"] = []int64{8080}
+		if resp := DoReq(t, "POST", queueURL, body, token, http.StatusBadRequest); resp == nil {
+			t.Errorf("%s: expected 400", name)
+		}
+	}
+
+	q := CreateGameQueue(t, h.BaseURL(), token, gameID, "party", map[string]interface{}{
+		"lobby_size": 8, "min_players": 2, "fill_timeout_seconds": 30,
+	})
+	if q["min_players"].(float64) != 2 || q["fill_timeout_seconds"].(float64) != 30 {
+		t.Fatalf("expected the fill timeout settings, got %+v", q)
+	}
+	updateURL := fmt.Sprintf("%s/%s", queueURL, q["id"])
+	DoReq(t, "PUT", updateURL, map[string]interface{}{"lobby_size": 1}, token, http.StatusBadRequest)
+	off := DoReq(t, "PUT", updateURL, map[string]interface{}{"min_players": 0}, token, http.StatusOK)
+	if off["min_players"].(float64) != 0 || off["fill_timeout_seconds"].(float64) != 30 {
+		t.Errorf("expected min_players turned off and the timeout kept, got %+v", off)
+	}
+}
+
+// TestFillTimeoutMatch queues two players in a metadata sub-queue of a
+// four-player queue with min_players 2. Nothing starts until both have
+// waited out the fill timeout; then they're matched together, and a
+// player in another sub-queue isn't pulled in.
+func TestFillTimeoutMatch(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "fill", "fill@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "fill@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "FillGame", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "ffa", map[string]interface{}{
+		"lobby_size": 4, "min_players": 2, "fill_timeout_seconds": 30, "metadata_enabled": true,
+	})
+	queueID := q["id"].(string)
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s&queueID=%s&metadata=", h.BaseURL(), gameID, queueID)
+
+	join := func(name, metadata string) (*websocket.Conn, string) {
+		token, id := GuestLogin(t, h.BaseURL(), name)
+		ws := WebsocketConnect(t, joinURL+metadata, token)
+		readQueueJoined(t, ws)
+		return ws, id
+	}
+	ws1, id1 := join("fill1", "casual")
+	defer ws1.Close()
+	ws2, id2 := join("fill2", "casual")
+	defer ws2.Close()
+	other, otherID := join("fill3", "ranked")
+	defer other.Close()
+
+	TriggerMatchmaking(t)
+	time.Sleep(300 * time.Millisecond)
+	casual := extRedis.QueueKey(queueID, "casual")
+	if size, _ := server.S.Redis.QueuePlayerCount(context.Background(), casual); size != 2 {
+		t.Fatalf("expected both players still waiting for a full lobby, queue size %d", size)
+	}
+
+	// Backdate both joins at once, so no pass sees only one of them.
+	past := time.Now().Add(-40 * time.Second).Unix()
+	ranked := extRedis.QueueKey(queueID, "ranked")
+	server.S.Redis.Client.HSet(context.Background(), "qjoined_"+casual, id1, past, id2, past)
+	server.S.Redis.Client.HSet(context.Background(), "qjoined_"+ranked, otherID, past)
+	TriggerMatchmaking(t)
+
+	var matchID string
+	for _, ws := range []*websocket.Conn{ws1, ws2} {
+		found := awaitStatus(t, ws, "match_found")
+		if matchID != "" && found["match_id"] != matchID {
+			t.Fatalf("expected both players in one match")
+		}
+		matchID = found["match_id"].(string)
+	}
+	match, err := models.GetMatch(matchID)
+	if err != nil {
+		t.Fatalf("get match: %v", err)
+	}
+	if len(match.GuestIDs) != 2 {
+		t.Errorf("expected a two-player match, got %v", match.GuestIDs)
+	}
+	if size, _ := server.S.Redis.QueuePlayerCount(context.Background(), ranked); size != 1 {
+		t.Errorf("expected the ranked player left waiting alone, queue size %d", size)
+	}
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
 	"log/slog"
 	"net/http/httptest"
@@ -83,6 +84,8 @@ func NewHarness(t *testing.T) *Harness {
 			HCLOUDPortRangeEnd:    11000,
 			HCLOUDAgentPort:       8080,
 			HCLOUDHostType:        "cx23",
+
+			ConnectTokenSigningKey: ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)),
 		},
 		Logger:   slog.New(slog.NewJSONHandler(os.Stdout, nil)),
 		DB:       db,
@@ -107,6 +110,8 @@ func NewHarness(t *testing.T) *Harness {
 	// the publish lands with no listener — the worker never wakes and the
 	// match is never paired, surfacing as a flaky 5-second timeout.
 	waitForSubscriber(t, redisClient, extredis.MatchmakingTriggerChannel, 2*time.Second)
+	waitForSubscriber(t, redisClient, extredis.RecalculationTriggerChannel, 2*time.Second)
+	waitForSubscriber(t, redisClient, extredis.ReadyCheckTriggerChannel, 2*time.Second)
 
 	h := &Harness{
 		T:         t,
@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/andy98725/elo-service/src/worker/matchmaking"
+)
+
+// setupHeartbeatMatch starts a real (mock-container) match on a game
+// whose queue has the given heartbeat grace, and returns it with its
+// auth code.
+func setupHeartbeatMatch(t *testing.T, h *Harness, graceSeconds int) (*models.Match, string) {
+	t.Helper()
+	gameID, _, _, _, _, authCode := setupMatchedGame(t, h)
+	if err := server.S.DB.Model(&models.GameQueue{}).Where("game_id = ?", gameID).
+		Update("heartbeat_grace_seconds", graceSeconds).Error; err != nil {
+		t.Fatalf("set heartbeat grace: %v", err)
+	}
+	match, err := models.GetMatchByTokenID(authCode)
+	if err != nil {
+		t.Fatalf("match not found: %v", err)
+	}
+	return match, authCode
+}
+
+// silenceFor backdates a match so its server was last heard from d ago.
+func silenceFor(t *testing.T, matchID string, d time.Duration) {
+	t.Helper()
+	at := time.Now().Add(-d)
+	if err := server.S.DB.Model(&models.Match{}).Where("id = ?", matchID).
+		Updates(map[string]interface{}{"created_at": at, "last_heartbeat_at": at}).Error; err != nil {
+		t.Fatalf("backdate match: %v", err)
+	}
+}
+
+func TestHeartbeatEndpoint(t *testing.T) {
+	h := NewHarness(t)
+	match, authCode := setupHeartbeatMatch(t, h, 30)
+
+	resp := DoReq(t, "POST", h.BaseURL()+"/match/heartbeat", nil, authCode, http.StatusOK)
+	if resp["match_id"] != match.ID || resp["heartbeat_grace_seconds"] != float64(30) {
+		t.Errorf("unexpected heartbeat response: %+v", resp)
+	}
+	updated, err := models.GetMatch(match.ID)
+	if err != nil {
+		t.Fatalf("GetMatch: %v", err)
+	}
+	if updated.LastHeartbeatAt == nil || time.Since(*updated.LastHeartbeatAt) > time.Minute {
+		t.Errorf("expected last_heartbeat_at recorded, got %v", updated.LastHeartbeatAt)
+	}
+
+	DoReq(t, "POST", h.BaseURL()+"/match/heartbeat", nil, "not-a-real-code", http.StatusUnauthorized)
+	DoReq(t, "POST", h.BaseURL()+"/match/heartbeat", nil, "", http.StatusUnauthorized)
+}
+
+func TestHeartbeatGraceValidation(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "hbowner", "hbowner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "hbowner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "HeartbeatGame", 2)
+	gameID := game["id"].(string)
+
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "heartbeat", map[string]interface{}{
+		"heartbeat_grace_seconds": 60,
+	})
+	if q["heartbeat_grace_seconds"] != float64(60) {
+		t.Fatalf("expected heartbeat_grace_seconds echoed, got %+v", q)
+	}
+	queueURL := fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, q["id"])
+	DoReq(t, "PUT", queueURL, map[string]interface{}{"heartbeat_grace_seconds": 1}, ownerToken, http.StatusBadRequest)
+	DoReq(t, "PUT", queueURL, map[string]interface{}{"heartbeat_grace_seconds": 100000}, ownerToken, http.StatusBadRequest)
+	updated := DoReq(t, "PUT", queueURL, map[string]interface{}{"heartbeat_grace_seconds": 0}, ownerToken, http.StatusOK)
+	if updated["heartbeat_grace_seconds"] != float64(0) {
+		t.Errorf("expected heartbeats turned off, got %+v", updated)
+	}
+}
+
+// TestHeartbeatCrashedServer kills the match's container and checks GC
+// ends the match as server_crashed as soon as the grace period passes.
+func TestHeartbeatCrashedServer(t *testing.T) {
+	h := NewHarness(t)
+	match, _ := setupHeartbeatMatch(t, h, 30)
+
+	h.Machines.CrashContainer(match.ServerInstance.ContainerID)
+	silenceFor(t, match.ID, 45*time.Second)
+	if err := matchmaking.GarbageCollectMatches(context.Background()); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+
+	result, err := models.GetMatchResult(match.ID)
+	if err != nil {
+		t.Fatalf("expected a match result: %v", err)
+	}
+	if result.Result != matchmaking.REASON_SERVER_CRASHED {
+		t.Errorf("expected result %q, got %q", matchmaking.REASON_SERVER_CRASHED, result.Result)
+	}
+	if h.Machines.ActiveContainers() != 0 {
+		t.Errorf("expected the crashed container stopped, got %d active", h.Machines.ActiveContainers())
+	}
+}
+
+// TestHeartbeatSlowServer leaves the container running and checks GC
+// waits out the longer unresponsive deadline before giving up on it.
+func TestHeartbeatSlowServer(t *testing.T) {
+	h := NewHarness(t)
+	match, _ := setupHeartbeatMatch(t, h, 30)
+	ctx := context.Background()
+
+	silenceFor(t, match.ID, 45*time.Second)
+	if err := matchmaking.GarbageCollectMatches(ctx); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	if _, err := models.GetMatchResult(match.ID); err == nil {
+		t.Fatal("expected a running server to be given longer than one grace period")
+	}
+
+	silenceFor(t, match.ID, 100*time.Second)
+	if err := matchmaking.GarbageCollectMatches(ctx); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	result, err := models.GetMatchResult(match.ID)
+	if err != nil {
+		t.Fatalf("expected a match result: %v", err)
+	}
+	if result.Result != matchmaking.REASON_SERVER_UNRESPONSIVE {
+		t.Errorf("expected result %q, got %q", matchmaking.REASON_SERVER_UNRESPONSIVE, result.Result)
+	}
+}
+
+// TestHeartbeatKeepsMatchAlive checks a recent heartbeat outweighs an old
+// match start.
+func TestHeartbeatKeepsMatchAlive(t *testing.T) {
+	h := NewHarness(t)
+	match, authCode := setupHeartbeatMatch(t, h, 30)
+
+	h.Machines.CrashContainer(match.ServerInstance.ContainerID)
+	server.S.DB.Model(&models.Match{}).Where("id = ?", match.ID).Update("created_at", time.Now().Add(-time.Hour))
+	DoReq(t, "POST", h.BaseURL()+"/match/heartbeat", nil, authCode, http.StatusOK)
+	if err := matchmaking.GarbageCollectMatches(context.Background()); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	if _, err := models.GetMatchResult(match.ID); err == nil {
+		t.Error("expected a match that just heartbeated to stay up")
+	}
+}
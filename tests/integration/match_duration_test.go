@@ -0,0 +1,137 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/andy98725/elo-service/src/worker/matchmaking"
+)
+
+// setupDurationQueue creates a game with a queue whose matches last
+// durationMinutes and may be extended by extensionMinutes, and starts a
+// synthetic match in it.
+func setupDurationQueue(t *testing.T, h *Harness, durationMinutes, extensionMinutes int) (matchID, authCode string) {
+	t.Helper()
+	RegisterUser(t, h.BaseURL(), "mdowner", "mdowner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "mdowner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "DurationGame", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "timed", map[string]interface{}{
+		"max_match_duration_minutes": durationMinutes, "max_match_extension_minutes": extensionMinutes,
+	})
+
+	_, id1 := GuestLogin(t, h.BaseURL(), "md1")
+	_, id2 := GuestLogin(t, h.BaseURL(), "md2")
+	return startSyntheticMatch(t, gameID, q["id"].(string), []string{id1, id2})
+}
+
+func TestMatchDurationValidation(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "mdvowner", "mdvowner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "mdvowner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "DurationValidation", 2)
+	gameID := game["id"].(string)
+
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "defaults", nil)
+	if q["max_match_duration_minutes"] != float64(models.DefaultMaxMatchDurationMinutes) || q["max_match_extension_minutes"] != float64(0) {
+		t.Fatalf("expected the default match duration, got %+v", q)
+	}
+
+	queueURL := fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, q["id"])
+	DoReq(t, "PUT", queueURL, map[string]interface{}{"max_match_duration_minutes": -1}, ownerToken, http.StatusBadRequest)
+	DoReq(t, "PUT", queueURL, map[string]interface{}{"max_match_duration_minutes": models.MaxMatchLengthMinutes + 1}, ownerToken, http.StatusBadRequest)
+	DoReq(t, "PUT", queueURL, map[string]interface{}{"max_match_extension_minutes": models.MaxMatchLengthMinutes}, ownerToken, http.StatusBadRequest)
+	updated := DoReq(t, "PUT", queueURL, map[string]interface{}{
+		"max_match_duration_minutes": 3, "max_match_extension_minutes": 60,
+	}, ownerToken, http.StatusOK)
+	if updated["max_match_duration_minutes"] != float64(3) || updated["max_match_extension_minutes"] != float64(60) {
+		t.Errorf("expected the new match duration, got %+v", updated)
+	}
+}
+
+func TestExtendMatch(t *testing.T) {
+	h := NewHarness(t)
+	matchID, authCode := setupDurationQueue(t, h, 5, 10)
+
+	match, err := models.GetMatch(matchID)
+	if err != nil {
+		t.Fatalf("GetMatch: %v", err)
+	}
+	if want := match.CreatedAt.Add(5 * time.Minute); !match.Deadline().Equal(want) {
+		t.Fatalf("expected deadline %v, got %v", want, match.Deadline())
+	}
+
+	extendURL := h.BaseURL() + "/match/extend"
+	DoReq(t, "POST", extendURL, map[string]interface{}{"minutes": 0}, authCode, http.StatusBadRequest)
+	DoReq(t, "POST", extendURL, map[string]interface{}{"minutes": 4}, "not-a-real-code", http.StatusUnauthorized)
+
+	resp := DoReq(t, "POST", extendURL, map[string]interface{}{"minutes": 4}, authCode, http.StatusOK)
+	deadline, _ := time.Parse(time.RFC3339, resp["deadline"].(string))
+	if want := match.CreatedAt.Add(9 * time.Minute); deadline.Sub(want).Abs() > time.Second {
+		t.Errorf("expected deadline %v after extending, got %v", want, deadline)
+	}
+
+	// Past the cap the extension is cut short, then refused.
+	resp = DoReq(t, "POST", extendURL, map[string]interface{}{"minutes": 30}, authCode, http.StatusOK)
+	deadline, _ = time.Parse(time.RFC3339, resp["deadline"].(string))
+	if want := match.CreatedAt.Add(15 * time.Minute); deadline.Sub(want).Abs() > time.Second {
+		t.Errorf("expected deadline capped at %v, got %v", want, deadline)
+	}
+	DoReq(t, "POST", extendURL, map[string]interface{}{"minutes": 1}, authCode, http.StatusConflict)
+}
+
+func TestExtendMatchNotAllowed(t *testing.T) {
+	h := NewHarness(t)
+	_, authCode := setupDurationQueue(t, h, 5, 0)
+
+	DoReq(t, "POST", h.BaseURL()+"/match/extend", map[string]interface{}{"minutes": 1}, authCode, http.StatusConflict)
+}
+
+// TestGarbageCollectQueueDeadline checks GC ends matches at their
+// queue's deadline rather than a global one.
+func TestGarbageCollectQueueDeadline(t *testing.T) {
+	h := NewHarness(t)
+	matchID, _ := setupDurationQueue(t, h, 5, 10)
+
+	server.S.DB.Model(&models.Match{}).Where("id = ?", matchID).Update("created_at", time.Now().Add(-4*time.Minute))
+	if err := matchmaking.GarbageCollectMatches(context.Background()); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	if _, err := models.GetMatchResult(matchID); err == nil {
+		t.Fatal("expected a match inside its deadline to keep running")
+	}
+
+	server.S.DB.Model(&models.Match{}).Where("id = ?", matchID).Update("created_at", time.Now().Add(-6*time.Minute))
+	if err := matchmaking.GarbageCollectMatches(context.Background()); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	result, err := models.GetMatchResult(matchID)
+	if err != nil {
+		t.Fatalf("expected the match ended at its deadline: %v", err)
+	}
+	if result.Result != "timeout" {
+		t.Errorf("expected result timeout, got %q", result.Result)
+	}
+}
+
+// TestGarbageCollectExtendedMatch checks an extended deadline outlasts
+// the queue's.
+func TestGarbageCollectExtendedMatch(t *testing.T) {
+	h := NewHarness(t)
+	matchID, authCode := setupDurationQueue(t, h, 5, 10)
+
+	DoReq(t, "POST", h.BaseURL()+"/match/extend", map[string]interface{}{"minutes": 10}, authCode, http.StatusOK)
+	server.S.DB.Model(&models.Match{}).Where("id = ?", matchID).Update("created_at", time.Now().Add(-6*time.Minute))
+	if err := matchmaking.GarbageCollectMatches(context.Background()); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	if _, err := models.GetMatchResult(matchID); err == nil {
+		t.Error("expected the extended match to keep running past the queue deadline")
+	}
+}
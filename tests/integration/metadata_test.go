@@ -36,7 +36,7 @@ func createMetadataEnabledGame(t *testing.T, baseURL, token, name string, lobbyS
 // player landed in some queue. The exact players_in_queue count is racy
 // (the worker can pair between AddPlayer and GameQueueSize on a 10 ms
 // matchmaking interval), so we only check the status.
-func readQueueJoined(t *testing.T, ws *websocket.Conn) {
+func readQueueJoined(t *testing.T, ws *websocket.Conn) map[string]interface{} {
 	t.Helper()
 	ws.SetReadDeadline(time.Now().Add(3 * time.Second))
 	_, msg, err := ws.ReadMessage()
@@ -48,6 +48,7 @@ func readQueueJoined(t *testing.T, ws *websocket.Conn) {
 	if resp["status"] != "queue_joined" {
 		t.Fatalf("expected queue_joined, got %+v", resp)
 	}
+	return resp
 }
 
 // awaitMatchFound blocks until match_found arrives or the deadline passes.
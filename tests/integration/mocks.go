@@ -72,12 +72,14 @@ type MockMachineService struct {
 
 	// CreateFn / DeleteFn let individual tests override behavior (e.g. to
 	// inject errors). Nil = use the default in-memory implementation.
-	CreateFn func(ctx context.Context, serverType string, agentPort int64, tls *hetzner.HostTLSOpts) (*hetzner.HostConnectionInfo, error)
+	CreateFn func(ctx context.Context, region, serverType string, agentPort int64, tls *hetzner.HostTLSOpts) (*hetzner.HostConnectionInfo, error)
 	DeleteFn func(ctx context.Context, providerID string) error
 	// LastTLSOpts is the TLS opts the last CreateHost call received.
 	// Tests use it to assert wildcard-TLS plumbing (or to confirm absence
 	// when the feature is off, in which case it stays nil).
 	LastTLSOpts *hetzner.HostTLSOpts
+	// LastRegion is the region the last CreateHost call provisioned in.
+	LastRegion string
 
 	agentServer *http.Server
 	agentPort   int
@@ -159,10 +161,12 @@ func NewMockMachineService() *MockMachineService {
 		switch {
 		case sub == "health" && r.Method == http.MethodGet:
 			m.mu.Lock()
-			alive := m.containers[containerID]
+			alive, exists := m.containers[containerID]
 			m.mu.Unlock()
 			if alive {
 				w.WriteHeader(http.StatusOK)
+			} else if exists {
+				http.Error(w, "container not running (exited, exit code 137, oom killed false)", http.StatusServiceUnavailable)
 			} else {
 				http.Error(w, "no such container", http.StatusNotFound)
 			}
@@ -202,13 +206,14 @@ func (m *MockMachineService) ValidateServerType(ctx context.Context, serverType
 	return nil
 }
 
-func (m *MockMachineService) CreateHost(ctx context.Context, serverType string, agentPort int64, tls *hetzner.HostTLSOpts) (*hetzner.HostConnectionInfo, error) {
+func (m *MockMachineService) CreateHost(ctx context.Context, region, serverType string, agentPort int64, tls *hetzner.HostTLSOpts) (*hetzner.HostConnectionInfo, error) {
 	m.mu.Lock()
 	m.LastTLSOpts = tls
+	m.LastRegion = region
 	m.mu.Unlock()
 
 	if m.CreateFn != nil {
-		return m.CreateFn(ctx, serverType, agentPort, tls)
+		return m.CreateFn(ctx, region, serverType, agentPort, tls)
 	}
 
 	id := m.nextHost.Add(1)
@@ -287,6 +292,17 @@ func (m *MockMachineService) SpectateBuffer(spectateID string) *MockSpectateBuff
 	return m.spectateBuffers[spectateID]
 }
 
+// CrashContainer marks a game container as exited without removing it,
+// the way a game server that panics leaves its container behind until
+// the matchmaker stops it. Health checks answer 503 from then on.
+func (m *MockMachineService) CrashContainer(containerID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.containers[containerID]; ok {
+		m.containers[containerID] = false
+	}
+}
+
 // ActiveContainers returns the number of game containers the mock agent
 // has been asked to start and not stop. This is the per-match counter:
 // matches add a container, match-end / GC removes it.
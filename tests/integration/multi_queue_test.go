@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	extRedis "github.com/andy98725/elo-service/src/external/redis"
+	"github.com/andy98725/elo-service/src/server"
+)
+
+// TestMultiQueueSearch has one player search two queues over one
+// connection, pairs them in one of them, and checks they're withdrawn
+// from the other.
+func TestMultiQueueSearch(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "multi", "multi@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "multi@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "MultiGame", 2)
+	gameID := game["id"].(string)
+	primaryID := game["queues"].([]interface{})[0].(map[string]interface{})["id"].(string)
+	ranked := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "ranked", map[string]interface{}{
+		"lobby_size": 2, "metadata_enabled": true,
+	})
+	rankedID := ranked["id"].(string)
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s", h.BaseURL(), gameID)
+
+	token, searcher := GuestLogin(t, h.BaseURL(), "multi1")
+	ws := WebsocketConnect(t, fmt.Sprintf("%s&queueID=%s&queueID=%s&metadata=eu", joinURL, primaryID, rankedID), token)
+	defer ws.Close()
+	joined := readQueueJoined(t, ws)
+	queues, _ := joined["queues"].([]interface{})
+	if len(queues) != 2 {
+		t.Fatalf("expected the search to cover two queues, got %+v", joined)
+	}
+	rankedKey := extRedis.QueueKey(rankedID, "eu")
+	if keys, _ := server.S.Redis.SearchQueues(context.Background(), searcher); len(keys) != 2 {
+		t.Fatalf("expected the searcher in both queues, got %v", keys)
+	}
+
+	token2, _ := GuestLogin(t, h.BaseURL(), "multi2")
+	ws2 := WebsocketConnect(t, fmt.Sprintf("%s&queueID=%s", joinURL, primaryID), token2)
+	defer ws2.Close()
+	readQueueJoined(t, ws2)
+	TriggerMatchmaking(t)
+
+	found := awaitStatus(t, ws, "match_found")
+	if found["queue_id"] != primaryID {
+		t.Errorf("expected a match from the primary queue, got %+v", found)
+	}
+	awaitStatus(t, ws2, "match_found")
+	if size, _ := server.S.Redis.QueuePlayerCount(context.Background(), rankedKey); size != 0 {
+		t.Errorf("expected the searcher withdrawn from the ranked queue, queue size %d", size)
+	}
+	if keys, _ := server.S.Redis.SearchQueues(context.Background(), searcher); len(keys) != 0 {
+		t.Errorf("expected the search cleared, got %v", keys)
+	}
+}
+
+// TestMultiQueueClaim checks an entry can only be claimed by one of the
+// queues it's searching, and that a failed dispatch can put it back in
+// the others.
+func TestMultiQueueClaim(t *testing.T) {
+	NewHarness(t)
+	ctx := context.Background()
+
+	for _, queue := range []string{"claim-a", "claim-b"} {
+		if err := server.S.Redis.AddPlayerToQueueWithTTL(ctx, queue, "claimer", time.Minute); err != nil {
+			t.Fatalf("add to %s: %v", queue, err)
+		}
+	}
+	searches, err := server.S.Redis.ClaimQueueEntries(ctx, "claim-b", []string{"claimer"})
+	if err != nil {
+		t.Fatalf("first claim: %v", err)
+	}
+	if others := searches["claimer"]; len(others) != 1 || others["claim-a"] == 0 {
+		t.Errorf("expected the other search returned with its join time, got %v", searches)
+	}
+	_, err = server.S.Redis.ClaimQueueEntries(ctx, "claim-a", []string{"claimer"})
+	if !errors.Is(err, extRedis.ErrQueueEntryTaken) {
+		t.Fatalf("expected the second claim refused, got %v", err)
+	}
+	if players, _ := server.S.Redis.AllPlayersInQueue(ctx, "claim-a"); len(players) != 0 {
+		t.Errorf("expected the entry gone from the other queue, got %v", players)
+	}
+
+	if err := server.S.Redis.ReturnToSearches(ctx, "claimer", searches["claimer"], time.Minute); err != nil {
+		t.Fatalf("return to searches: %v", err)
+	}
+	if players, _ := server.S.Redis.AllPlayersInQueue(ctx, "claim-a"); len(players) != 1 {
+		t.Errorf("expected the entry back in the other queue, got %v", players)
+	}
+	if keys, _ := server.S.Redis.SearchQueues(ctx, "claimer"); len(keys) != 1 || keys[0] != "claim-a" {
+		t.Errorf("expected the search set restored, got %v", keys)
+	}
+}
+
+func TestMultiQueueSearchLimit(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "multilimit", "multilimit@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "multilimit@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "MultiLimitGame", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "modes", map[string]interface{}{
+		"lobby_size": 2, "metadata_enabled": true,
+	})
+
+	params := url.Values{"gameID": {gameID}, "queueID": {q["id"].(string)}}
+	for i := range 9 {
+		params.Add("metadata", fmt.Sprintf("mode%d", i))
+	}
+	token, _ := GuestLogin(t, h.BaseURL(), "multilimit1")
+	ws := WebsocketConnect(t, h.BaseURL()+"/match/join?"+params.Encode(), token)
+	defer ws.Close()
+	if msg := awaitError(t, ws); !strings.HasPrefix(msg, "invalid search") {
+		t.Errorf("expected the search refused, got %q", msg)
+	}
+}
@@ -0,0 +1,268 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/gorilla/websocket"
+)
+
+// awaitStatus reads matchmaking frames until one with the wanted status
+// arrives, skipping heartbeats. Fails on an error frame or timeout.
+func awaitStatus(t *testing.T, ws *websocket.Conn, want string) map[string]interface{} {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for %q: %v", want, err)
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			continue
+		}
+		if resp["status"] == want {
+			return resp
+		}
+		if resp["status"] == "error" {
+			t.Fatalf("waiting for %q, got error: %v", want, resp["error"])
+		}
+	}
+}
+
+// formParty has leader create a party and every other token join it.
+func formParty(t *testing.T, baseURL, leaderToken string, memberTokens []string, memberIDs []string) string {
+	t.Helper()
+	party := DoReq(t, "POST", baseURL+"/party", nil, leaderToken, http.StatusOK)
+	partyID := party["id"].(string)
+	for i, token := range memberTokens {
+		DoReq(t, "POST", fmt.Sprintf("%s/party/%s/invite", baseURL, partyID), map[string]string{
+			"player_id": memberIDs[i],
+		}, leaderToken, http.StatusOK)
+		DoReq(t, "POST", fmt.Sprintf("%s/party/%s/join", baseURL, partyID), nil, token, http.StatusOK)
+	}
+	return partyID
+}
+
+func TestPartyLifecycle(t *testing.T) {
+	h := NewHarness(t)
+
+	leaderToken, leaderID := GuestLogin(t, h.BaseURL(), "partylead")
+	memberToken, memberID := GuestLogin(t, h.BaseURL(), "partymember")
+	otherToken, otherID := GuestLogin(t, h.BaseURL(), "partyother")
+
+	party := DoReq(t, "POST", h.BaseURL()+"/party", nil, leaderToken, http.StatusOK)
+	partyID := party["id"].(string)
+	if party["leader_id"] != leaderID || len(party["members"].([]interface{})) != 1 {
+		t.Fatalf("expected a one-member party led by the creator, got %+v", party)
+	}
+	DoReq(t, "POST", h.BaseURL()+"/party", nil, leaderToken, http.StatusConflict)
+
+	partyURL := fmt.Sprintf("%s/party/%s", h.BaseURL(), partyID)
+	DoReq(t, "POST", partyURL+"/join", nil, memberToken, http.StatusForbidden)
+	DoReq(t, "POST", partyURL+"/invite", map[string]string{"player_id": otherID}, memberToken, http.StatusForbidden)
+
+	DoReq(t, "POST", partyURL+"/invite", map[string]string{"player_id": memberID}, leaderToken, http.StatusOK)
+	invites := DoReq(t, "GET", h.BaseURL()+"/party/invites", nil, memberToken, http.StatusOK)
+	if list, _ := invites["invites"].([]interface{}); len(list) != 1 || list[0].(map[string]interface{})["id"] != partyID {
+		t.Fatalf("expected one invite to %s, got %+v", partyID, invites)
+	}
+
+	joined := DoReq(t, "POST", partyURL+"/join", nil, memberToken, http.StatusOK)
+	members := joined["members"].([]interface{})
+	if len(members) != 2 || members[0].(map[string]interface{})["id"] != leaderID || members[1].(map[string]interface{})["id"] != memberID {
+		t.Fatalf("expected [leader, member], got %+v", members)
+	}
+	mine := DoReq(t, "GET", h.BaseURL()+"/party", nil, memberToken, http.StatusOK)
+	if mine["id"] != partyID || mine["searching"] != false {
+		t.Errorf("expected member to see the idle party, got %+v", mine)
+	}
+	invites = DoReq(t, "GET", h.BaseURL()+"/party/invites", nil, memberToken, http.StatusOK)
+	if list, _ := invites["invites"].([]interface{}); len(list) != 0 {
+		t.Errorf("expected accepted invite to be consumed, got %+v", list)
+	}
+
+	DoReq(t, "POST", partyURL+"/invite", map[string]string{"player_id": otherID}, leaderToken, http.StatusOK)
+	left := DoReq(t, "POST", partyURL+"/leave", nil, memberToken, http.StatusOK)
+	if left["status"] != "left" {
+		t.Errorf("expected member leave to report left, got %+v", left)
+	}
+	DoReq(t, "GET", h.BaseURL()+"/party", nil, memberToken, http.StatusNotFound)
+
+	disbanded := DoReq(t, "POST", partyURL+"/leave", nil, leaderToken, http.StatusOK)
+	if disbanded["status"] != "disbanded" {
+		t.Errorf("expected leader leave to disband, got %+v", disbanded)
+	}
+	DoReq(t, "GET", h.BaseURL()+"/party", nil, leaderToken, http.StatusNotFound)
+	DoReq(t, "POST", partyURL+"/join", nil, otherToken, http.StatusNotFound)
+}
+
+// TestPartyQueuesTogether queues a party of two behind a solo player in a
+// 3-player game and checks all three land in the same match, with the
+// party members adjacent in the match's player list.
+func TestPartyQueuesTogether(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "partyowner", "partyowner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "partyowner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "PartyGame", 3)
+	gameID := game["id"].(string)
+
+	soloToken, soloID := GuestLogin(t, h.BaseURL(), "partysolo")
+	leaderToken, leaderID := GuestLogin(t, h.BaseURL(), "partylead")
+	memberToken, memberID := GuestLogin(t, h.BaseURL(), "partymember")
+	partyID := formParty(t, h.BaseURL(), leaderToken, []string{memberToken}, []string{memberID})
+
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s", h.BaseURL(), gameID)
+	soloWS := WebsocketConnect(t, joinURL, soloToken)
+	defer soloWS.Close()
+	readQueueJoined(t, soloWS)
+
+	leaderWS := WebsocketConnect(t, joinURL, leaderToken)
+	defer leaderWS.Close()
+	joined := awaitStatus(t, leaderWS, "queue_joined")
+	if joined["party_id"] != partyID || joined["players_in_queue"].(float64) != 3 {
+		t.Fatalf("expected the party to queue as two players, got %+v", joined)
+	}
+	memberWS := WebsocketConnect(t, joinURL, memberToken)
+	defer memberWS.Close()
+	awaitStatus(t, memberWS, "queue_joined")
+
+	if mine := DoReq(t, "GET", h.BaseURL()+"/party", nil, memberToken, http.StatusOK); mine["searching"] != true {
+		t.Errorf("expected party to be searching, got %+v", mine)
+	}
+
+	TriggerMatchmaking(t)
+
+	matchIDs := map[string]bool{}
+	for _, ws := range []*websocket.Conn{soloWS, leaderWS, memberWS} {
+		found := awaitStatus(t, ws, "match_found")
+		matchIDs[found["match_id"].(string)] = true
+	}
+	if len(matchIDs) != 1 {
+		t.Fatalf("expected everyone in one match, got %v", matchIDs)
+	}
+
+	var match *models.Match
+	for id := range matchIDs {
+		m, err := models.GetMatch(id)
+		if err != nil {
+			t.Fatalf("GetMatch: %v", err)
+		}
+		match = m
+	}
+	// Every player here is a guest, so GuestIDs is the full player list
+	// in dispatch order.
+	order := map[string]int{}
+	for i, p := range match.GuestIDs {
+		order[p] = i
+	}
+	if _, ok := order[soloID]; !ok || len(order) != 3 {
+		t.Fatalf("expected solo + party in the match, got %v", order)
+	}
+	if d := order[leaderID] - order[memberID]; d != 1 && d != -1 {
+		t.Errorf("expected party members adjacent in the player list, got %v", order)
+	}
+}
+
+// TestPartyNotBlockedBySoloAhead checks a party that fills a lobby on its
+// own is matched even when a solo player who can't complete a lobby with
+// it is ahead in the queue.
+func TestPartyNotBlockedBySoloAhead(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "partyowner2", "partyowner2@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "partyowner2@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "PartyDuoGame", 2)
+	gameID := game["id"].(string)
+
+	soloToken, _ := GuestLogin(t, h.BaseURL(), "duosolo")
+	leaderToken, _ := GuestLogin(t, h.BaseURL(), "duolead")
+	memberToken, memberID := GuestLogin(t, h.BaseURL(), "duomember")
+	formParty(t, h.BaseURL(), leaderToken, []string{memberToken}, []string{memberID})
+
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s", h.BaseURL(), gameID)
+	soloWS := WebsocketConnect(t, joinURL, soloToken)
+	defer soloWS.Close()
+	readQueueJoined(t, soloWS)
+	memberWS := WebsocketConnect(t, joinURL, memberToken)
+	defer memberWS.Close()
+	awaitStatus(t, memberWS, "queue_joined")
+	leaderWS := WebsocketConnect(t, joinURL, leaderToken)
+	defer leaderWS.Close()
+	awaitStatus(t, leaderWS, "queue_joined")
+
+	TriggerMatchmaking(t)
+
+	leaderMatch := awaitStatus(t, leaderWS, "match_found")
+	memberMatch := awaitStatus(t, memberWS, "match_found")
+	if leaderMatch["match_id"] != memberMatch["match_id"] {
+		t.Errorf("expected party in one match, got %v and %v", leaderMatch["match_id"], memberMatch["match_id"])
+	}
+	if size := QueueSize(t, h.BaseURL(), soloToken, gameID); size != 1 {
+		t.Errorf("expected the solo player still queued, got %v", size)
+	}
+}
+
+// TestPartySearchEndsWhenMemberLeaves covers the oversize-party rejection
+// and that a member leaving mid-search pulls the party out of the queue
+// and ends the leader's session.
+func TestPartySearchEndsWhenMemberLeaves(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "partyowner3", "partyowner3@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "partyowner3@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "PartyLeaveGame", 3)
+	gameID := game["id"].(string)
+
+	leaderToken, _ := GuestLogin(t, h.BaseURL(), "leavelead")
+	aToken, aID := GuestLogin(t, h.BaseURL(), "leavea")
+	bToken, bID := GuestLogin(t, h.BaseURL(), "leaveb")
+	cToken, cID := GuestLogin(t, h.BaseURL(), "leavec")
+	partyID := formParty(t, h.BaseURL(), leaderToken, []string{aToken, bToken, cToken}, []string{aID, bID, cID})
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s", h.BaseURL(), gameID)
+
+	// Four players can't fit a 3-player lobby.
+	ws := WebsocketConnect(t, joinURL, leaderToken)
+	ws.SetReadDeadline(time.Now().Add(3 * time.Second))
+	var resp map[string]interface{}
+	if err := ws.ReadJSON(&resp); err != nil || resp["status"] != "error" {
+		t.Fatalf("expected oversize party to be rejected, got %+v (%v)", resp, err)
+	}
+	ws.Close()
+
+	// Down to two, the party queues but can't fill the lobby on its own.
+	DoReq(t, "POST", fmt.Sprintf("%s/party/%s/leave", h.BaseURL(), partyID), nil, bToken, http.StatusOK)
+	DoReq(t, "POST", fmt.Sprintf("%s/party/%s/leave", h.BaseURL(), partyID), nil, cToken, http.StatusOK)
+
+	leaderWS := WebsocketConnect(t, joinURL, leaderToken)
+	defer leaderWS.Close()
+	awaitStatus(t, leaderWS, "queue_joined")
+	if size := QueueSize(t, h.BaseURL(), leaderToken, gameID); size != 2 {
+		t.Fatalf("expected the party of two queued, got %v", size)
+	}
+	DoReq(t, "POST", fmt.Sprintf("%s/party/%s/join", h.BaseURL(), partyID), nil, bToken, http.StatusConflict)
+
+	DoReq(t, "POST", fmt.Sprintf("%s/party/%s/leave", h.BaseURL(), partyID), nil, aToken, http.StatusOK)
+
+	leaderWS.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		var frame map[string]interface{}
+		if err := leaderWS.ReadJSON(&frame); err != nil {
+			t.Fatalf("expected an error frame after the member left: %v", err)
+		}
+		if frame["status"] == "error" {
+			if frame["error"] != "party member left" {
+				t.Errorf("unexpected error: %v", frame["error"])
+			}
+			break
+		}
+	}
+	if size := QueueSize(t, h.BaseURL(), leaderToken, gameID); size != 0 {
+		t.Errorf("expected the party out of the queue, got %v", size)
+	}
+}
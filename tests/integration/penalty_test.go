@@ -0,0 +1,144 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/gorilla/websocket"
+)
+
+func TestPenaltySettings(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "pencfg", "pencfg@example.com", "pass")
+	token, _ := LoginUser(t, h.BaseURL(), "pencfg@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), token, "PenaltyCfgGame", 2)
+	gameID := game["id"].(string)
+	queueURL := fmt.Sprintf("%s/game/%s/queue", h.BaseURL(), gameID)
+
+	for name, body := range map[string]map[string]interface{}{
+		"points":  {"name": "points", "penalty_decline_points": 1000},
+		"abandon": {"name": "abandon", "penalty_abandon_points": -1},
+		"decay":   {"name": "decay", "penalty_decay_per_hour": -1},
+		"steps": {"name": "steps", "penalty_lockout_steps": []map[string]int{
+			{"points": 4, "lockout_seconds": 60}, {"points": 2, "lockout_seconds": 300},
+		}},
+	} {
+		body["matchmaking_machine_ports"] = []int64{8080}
+		if resp := DoReq(t, "POST", queueURL, body, token, http.StatusBadRequest); resp == nil {
+			t.Errorf("%s: expected 400", name)
+		}
+	}
+
+	q := CreateGameQueue(t, h.BaseURL(), token, gameID, "penalized", map[string]interface{}{"penalty_dodge_points": 3})
+	steps, _ := q["penalty_lockout_steps"].([]interface{})
+	if q["penalty_dodge_points"].(float64) != 3 || q["penalty_decay_per_hour"].(float64) != 1 || len(steps) != len(models.DefaultPenaltyLockoutSteps) {
+		t.Fatalf("expected the penalty settings with defaults, got %+v", q)
+	}
+	updated := DoReq(t, "PUT", fmt.Sprintf("%s/%s", queueURL, q["id"]), map[string]interface{}{
+		"penalty_decay_per_hour": 0,
+		"penalty_lockout_steps":  []map[string]int{{"points": 5, "lockout_seconds": 600}},
+	}, token, http.StatusOK)
+	steps, _ = updated["penalty_lockout_steps"].([]interface{})
+	if updated["penalty_decay_per_hour"].(float64) != 0 || len(steps) != 1 || updated["penalty_dodge_points"].(float64) != 3 {
+		t.Errorf("expected the schedule replaced and decay off, got %+v", updated)
+	}
+}
+
+// TestPenaltyDeclineLockout has a player decline a ready check on a queue
+// whose first lockout step they reach in one offense, and checks they're
+// locked out of the game while the player who accepted isn't.
+func TestPenaltyDeclineLockout(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "pendecl", "pendecl@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "pendecl@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "PenaltyGame", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "ready", map[string]interface{}{
+		"ready_check_seconds": 10, "penalty_decline_points": 2,
+	})
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s&queueID=%s", h.BaseURL(), gameID, q["id"])
+	lockoutURL := fmt.Sprintf("%s/match/lockout?gameID=%s", h.BaseURL(), gameID)
+
+	decliner, _ := GuestLogin(t, h.BaseURL(), "pend1")
+	accepter, _ := GuestLogin(t, h.BaseURL(), "pend2")
+	ws1 := WebsocketConnect(t, joinURL, decliner)
+	defer ws1.Close()
+	readQueueJoined(t, ws1)
+	ws2 := WebsocketConnect(t, joinURL, accepter)
+	defer ws2.Close()
+	readQueueJoined(t, ws2)
+	TriggerMatchmaking(t)
+	awaitStatus(t, ws1, "match_proposed")
+	awaitStatus(t, ws2, "match_proposed")
+	ws2.WriteMessage(websocket.TextMessage, []byte("/accept"))
+	awaitStatus(t, ws2, "accepted")
+	ws1.WriteMessage(websocket.TextMessage, []byte("/decline"))
+	if msg := awaitError(t, ws1); msg != "match declined" {
+		t.Fatalf("expected the decliner dropped, got %q", msg)
+	}
+	awaitStatus(t, ws2, "requeued")
+
+	lockout := DoReq(t, "GET", lockoutURL, nil, decliner, http.StatusOK)
+	if lockout["locked"] != true || lockout["points"] != float64(2) {
+		t.Fatalf("expected the decliner locked out, got %+v", lockout)
+	}
+	if secs := lockout["lockout_seconds"].(float64); secs < 55 || secs > 61 {
+		t.Errorf("expected a one-minute lockout, got %v seconds", secs)
+	}
+	if clean := DoReq(t, "GET", lockoutURL, nil, accepter, http.StatusOK); clean["locked"] != false || clean["points"] != float64(0) {
+		t.Errorf("expected the accepting player unpenalized, got %+v", clean)
+	}
+
+	// The lockout covers every queue in the game, not just the one the
+	// offense happened in.
+	rejoin := WebsocketConnect(t, fmt.Sprintf("%s/match/join?gameID=%s", h.BaseURL(), gameID), decliner)
+	defer rejoin.Close()
+	if msg := awaitError(t, rejoin); !strings.HasPrefix(msg, "locked out of matchmaking") {
+		t.Errorf("expected the rejoin refused, got %q", msg)
+	}
+}
+
+// TestPenaltyDecay checks points decay by the hour and an old lockout
+// no longer applies.
+func TestPenaltyDecay(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "pendecay", "pendecay@example.com", "pass")
+	token, _ := LoginUser(t, h.BaseURL(), "pendecay@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), token, "PenaltyDecayGame", 2)
+	q := CreateGameQueue(t, h.BaseURL(), token, game["id"].(string), "decaying", map[string]interface{}{
+		"penalty_dodge_points": 5, "penalty_decay_per_hour": 1,
+	})
+	queue, err := models.GetGameQueue(q["id"].(string))
+	if err != nil {
+		t.Fatalf("get queue: %v", err)
+	}
+
+	now := time.Now()
+	if _, err := models.RecordPenalty(queue, "pen-player", models.OffenseDodge, now.Add(-3*time.Hour)); err != nil {
+		t.Fatalf("record penalty: %v", err)
+	}
+	penalty, err := models.GetPlayerPenalty(queue, "pen-player", now)
+	if err != nil {
+		t.Fatalf("get penalty: %v", err)
+	}
+	if penalty.Points != 2 || penalty.IsLockedOut(now) {
+		t.Errorf("expected 2 points left and the lockout over, got %d points, locked until %v", penalty.Points, penalty.LockedUntil)
+	}
+
+	// A second offense stacks on the decayed points: 2 + 5 reaches the
+	// 6-point step's half-hour lockout.
+	penalty, err = models.RecordPenalty(queue, "pen-player", models.OffenseDodge, now)
+	if err != nil {
+		t.Fatalf("record penalty: %v", err)
+	}
+	if penalty.Points != 7 || !penalty.IsLockedOut(now.Add(29*time.Minute)) || penalty.IsLockedOut(now.Add(31*time.Minute)) {
+		t.Errorf("expected 7 points and a 30 minute lockout, got %d points, locked until %v", penalty.Points, penalty.LockedUntil)
+	}
+}
@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/gorilla/websocket"
+)
+
+// TestQueueWaitEstimateAndStats matches two players who waited 20s and
+// 40s, then has a third join and leave. The wait estimate reaches
+// /match/size and the next joiner's queue_joined; the owner's stats count
+// the match, its rating spread, and the abandonment.
+func TestQueueWaitEstimateAndStats(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "qstats", "qstats@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "qstats@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "StatsGame", 2)
+	gameID := game["id"].(string)
+	// A narrow starting window keeps the pair apart until their joins are
+	// backdated.
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "stats", map[string]interface{}{
+		"matchmaking_strategy": "rating",
+		"rating_window_steps":  []map[string]int{{"after_seconds": 0, "max_spread": 50}, {"after_seconds": 15, "max_spread": 500}},
+	})
+	queueID := q["id"].(string)
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s&queueID=%s", h.BaseURL(), gameID, queueID)
+	sizeURL := fmt.Sprintf("%s/match/size?gameID=%s&queueID=%s", h.BaseURL(), gameID, queueID)
+	statsURL := fmt.Sprintf("%s/game/%s/queue/%s/stats", h.BaseURL(), gameID, queueID)
+
+	if size := DoReq(t, "GET", sizeURL, nil, ownerToken, http.StatusOK); size["estimated_wait_seconds"] != nil {
+		t.Fatalf("expected no estimate before any match, got %+v", size)
+	}
+
+	var conns []*websocket.Conn
+	var ids, tokens []string
+	for i, rating := range []int{1000, 1100} {
+		name := fmt.Sprintf("qstatsp%d", i)
+		RegisterUser(t, h.BaseURL(), name, name+"@example.com", "pass")
+		token, id := LoginUser(t, h.BaseURL(), name+"@example.com", "pass")
+		if _, err := models.GetRating(id, queueID); err != nil {
+			t.Fatalf("GetRating: %v", err)
+		}
+		server.S.DB.Model(&models.Rating{}).
+			Where("player_id = ? AND game_queue_id = ?", id, queueID).
+			UpdateColumn("rating", rating)
+		ws := WebsocketConnect(t, joinURL, token)
+		defer ws.Close()
+		readQueueJoined(t, ws)
+		conns = append(conns, ws)
+		ids, tokens = append(ids, id), append(tokens, token)
+	}
+	// Backdate both joins at once, so no pass sees only one of them.
+	now := time.Now()
+	server.S.Redis.Client.HSet(context.Background(), "qjoined_"+queueID,
+		ids[0], now.Add(-40*time.Second).Unix(),
+		ids[1], now.Add(-20*time.Second).Unix())
+	TriggerMatchmaking(t)
+	for _, ws := range conns {
+		awaitStatus(t, ws, "match_found")
+	}
+
+	// Join times are whole seconds, so a wait may read a second long.
+	about := func(v interface{}, want float64) bool {
+		f, _ := v.(float64)
+		return f >= want && f <= want+1
+	}
+	size := DoReq(t, "GET", sizeURL, nil, ownerToken, http.StatusOK)
+	if !about(size["estimated_wait_seconds"], 20) || !about(size["p90_wait_seconds"], 40) {
+		t.Errorf("expected a 20s median and 40s p90, got %+v", size)
+	}
+
+	token, _ := GuestLogin(t, h.BaseURL(), "qstatsleaver")
+	ws := WebsocketConnect(t, joinURL, token)
+	defer ws.Close()
+	joined := readQueueJoined(t, ws)
+	if !about(joined["estimated_wait_seconds"], 20) {
+		t.Errorf("expected queue_joined to carry the estimate, got %+v", joined)
+	}
+	ws.WriteMessage(websocket.TextMessage, []byte("/disconnect"))
+	awaitStatus(t, ws, "disconnected")
+
+	stats := DoReq(t, "GET", statsURL, nil, ownerToken, http.StatusOK)
+	if stats["matches_formed"] != float64(1) || stats["players_matched"] != float64(2) ||
+		stats["average_rating_spread"] != float64(100) || stats["entries_abandoned"] != float64(1) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if rate := stats["abandonment_rate"].(float64); rate < 0.33 || rate > 0.34 {
+		t.Errorf("expected one abandonment in three entries, got %v", rate)
+	}
+	if !about(stats["median_wait_seconds"], 20) || !about(stats["p90_wait_seconds"], 40) {
+		t.Errorf("unexpected wait stats: %+v", stats)
+	}
+
+	DoReq(t, "GET", statsURL+"?window_hours=48", nil, ownerToken, http.StatusBadRequest)
+	DoReq(t, "GET", statsURL, nil, tokens[0], http.StatusForbidden)
+}
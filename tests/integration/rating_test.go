@@ -0,0 +1,450 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+)
+
+// setQueueELOStrategy flips every queue under gameID to the given rating
+// strategy. MatchEnded reads the strategy off the match's preloaded
+// queue at report time, so this can be called after pairing.
+func setQueueELOStrategy(t *testing.T, gameID, strategy string) {
+	t.Helper()
+	if err := server.S.DB.Model(&models.GameQueue{}).
+		Where("game_id = ?", gameID).
+		Update("elo_strategy", strategy).Error; err != nil {
+		t.Fatalf("set elo_strategy: %v", err)
+	}
+}
+
+// TestGlicko2RatingUpdate reports a 1v1 result on a glicko2 queue and
+// checks the winner gains, the loser drops, and both deviations shrink
+// from the 350 starting value. The leaderboard must surface deviation.
+func TestGlicko2RatingUpdate(t *testing.T) {
+	h := NewHarness(t)
+	gameID, p1Token, p1ID, p2Token, _, authCode := setupMatchedRegisteredGame(t, h, "glicko")
+	setQueueELOStrategy(t, gameID, models.ELO_STRATEGY_GLICKO2)
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"winner_ids": []string{p1ID},
+		"reason":     "completed",
+	}, "", http.StatusOK)
+
+	winner := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, p1Token, http.StatusOK)
+	loser := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, p2Token, http.StatusOK)
+
+	if winner["rating"].(float64) <= 1000 {
+		t.Errorf("expected winner rating above 1000, got %v", winner["rating"])
+	}
+	if loser["rating"].(float64) >= 1000 {
+		t.Errorf("expected loser rating below 1000, got %v", loser["rating"])
+	}
+	// Symmetric starting state → symmetric deltas.
+	if winner["rating"].(float64)-1000 != 1000-loser["rating"].(float64) {
+		t.Errorf("expected symmetric deltas, got winner=%v loser=%v", winner["rating"], loser["rating"])
+	}
+	for name, resp := range map[string]map[string]interface{}{"winner": winner, "loser": loser} {
+		dev, ok := resp["deviation"].(float64)
+		if !ok || dev >= models.GLICKO2_DEFAULT_DEVIATION || dev <= 0 {
+			t.Errorf("expected %s deviation in (0, 350), got %v", name, resp["deviation"])
+		}
+	}
+
+	board := DoReq(t, "GET", fmt.Sprintf("%s/game/%s/leaderboard", h.BaseURL(), gameID), nil, "", http.StatusOK)
+	entries, _ := board["leaderboard"].([]interface{})
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 leaderboard entries, got %+v", board)
+	}
+	top := entries[0].(map[string]interface{})
+	if top["player_id"] != p1ID {
+		t.Errorf("expected winner first on leaderboard, got %v", top["player_id"])
+	}
+	if _, ok := top["deviation"].(float64); !ok {
+		t.Errorf("expected deviation on leaderboard entry, got %+v", top)
+	}
+}
+
+// TestGlicko2DeviationGrowsWithInactivity backdates a rating row and
+// checks that the reported deviation widens with idle time, capped at
+// the default.
+func TestGlicko2DeviationGrowsWithInactivity(t *testing.T) {
+	h := NewHarness(t)
+	gameID, p1Token, p1ID, _, _, authCode := setupMatchedRegisteredGame(t, h, "glickoidle")
+	setQueueELOStrategy(t, gameID, models.ELO_STRATEGY_GLICKO2)
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"winner_ids": []string{p1ID},
+		"reason":     "completed",
+	}, "", http.StatusOK)
+
+	fresh := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, p1Token, http.StatusOK)
+	freshDev := fresh["deviation"].(float64)
+
+	if err := server.S.DB.Model(&models.Rating{}).
+		Where("player_id = ?", p1ID).
+		UpdateColumn("updated_at", time.Now().Add(-52*models.GLICKO2_RATING_PERIOD)).Error; err != nil {
+		t.Fatalf("backdate rating: %v", err)
+	}
+
+	idle := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, p1Token, http.StatusOK)
+	idleDev := idle["deviation"].(float64)
+	if idleDev <= freshDev {
+		t.Errorf("expected deviation to grow after a year idle: fresh=%v idle=%v", freshDev, idleDev)
+	}
+	if idleDev > models.GLICKO2_DEFAULT_DEVIATION {
+		t.Errorf("expected deviation capped at %v, got %v", models.GLICKO2_DEFAULT_DEVIATION, idleDev)
+	}
+}
+
+// TestCreateQueueAcceptsGlicko2 verifies the new strategy value passes
+// queue validation.
+func TestCreateQueueAcceptsGlicko2(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "glickoowner", "glickoowner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "glickoowner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "GlickoQueueGame", 2)
+	gameID := game["id"].(string)
+
+	created := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "ranked", map[string]interface{}{
+		"elo_strategy": "glicko2",
+	})
+	if created["elo_strategy"] != "glicko2" {
+		t.Errorf("expected elo_strategy=glicko2, got %v", created["elo_strategy"])
+	}
+}
+
+// startSyntheticMatch inserts a started Match with no ServerInstance for
+// the given players and returns its ID and auth code. EndMatch runs its
+// degenerate inline phase-A-then-B path for these, so rating tests can
+// cover arbitrary team layouts without driving the matchmaker.
+func startSyntheticMatch(t *testing.T, gameID, queueID string, playerIDs []string) (matchID, authCode string) {
+	t.Helper()
+	authCode = "auth-" + t.Name()
+	match, err := models.MatchStarted(server.S.DB, gameID, queueID, queueID, "", authCode, playerIDs, nil, false)
+	if err != nil {
+		t.Fatalf("MatchStarted: %v", err)
+	}
+	return match.ID, authCode
+}
+
+// setupRatedGame registers an owner plus n players and creates a game
+// whose primary queue uses the given rating strategy.
+func setupRatedGame(t *testing.T, h *Harness, suffix, strategy string, n int) (gameID, queueID string, tokens, ids []string) {
+	t.Helper()
+	RegisterUser(t, h.BaseURL(), "tso"+suffix, "tso"+suffix+"@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "tso"+suffix+"@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "TSGame"+suffix, n)
+	gameID = game["id"].(string)
+	queueID = DefaultQueueID(t, game)
+	setQueueELOStrategy(t, gameID, strategy)
+
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("ts%d%s", i, suffix)
+		RegisterUser(t, h.BaseURL(), name, name+"@example.com", "pass")
+		token, id := LoginUser(t, h.BaseURL(), name+"@example.com", "pass")
+		tokens = append(tokens, token)
+		ids = append(ids, id)
+	}
+	return
+}
+
+// TestTrueSkillTeamResult reports a 2v2 with explicit teams and checks
+// both winners gain, both losers drop, sigma shrinks, and the
+// MatchResult echoes the team layout.
+func TestTrueSkillTeamResult(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "team", models.ELO_STRATEGY_TRUESKILL, 4)
+	matchID, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":        authCode,
+		"teams":           [][]string{{ids[0], ids[1]}, {ids[2], ids[3]}},
+		"team_placements": []int{1, 2},
+		"reason":          "completed",
+	}, "", http.StatusOK)
+
+	for i, token := range tokens {
+		resp := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, token, http.StatusOK)
+		rating := resp["rating"].(float64)
+		if i < 2 && rating <= 1000 {
+			t.Errorf("expected winner %d above 1000, got %v", i, rating)
+		}
+		if i >= 2 && rating >= 1000 {
+			t.Errorf("expected loser %d below 1000, got %v", i, rating)
+		}
+		if sigma, _ := resp["sigma"].(float64); sigma <= 0 || sigma >= models.TRUESKILL_DEFAULT_SIGMA {
+			t.Errorf("expected player %d sigma in (0, %v), got %v", i, models.TRUESKILL_DEFAULT_SIGMA, resp["sigma"])
+		}
+	}
+
+	mr := DoReq(t, "GET", fmt.Sprintf("%s/results/%s", h.BaseURL(), matchID), nil, tokens[0], http.StatusOK)
+	teams, _ := mr["teams"].([]interface{})
+	if len(teams) != 2 {
+		t.Errorf("expected 2 teams on match result, got %+v", mr)
+	}
+	winners, _ := mr["winner_ids"].([]interface{})
+	if len(winners) != 2 {
+		t.Errorf("expected winner_ids derived from first-place team, got %v", mr["winner_ids"])
+	}
+}
+
+// TestTrueSkillRejectsUnknownTeamMember verifies a team listing a
+// player outside the match, or teams leaving a participant out, are
+// rejected before anything is written.
+func TestTrueSkillRejectsUnknownTeamMember(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, _, ids := setupRatedGame(t, h, "bad", models.ELO_STRATEGY_TRUESKILL, 2)
+	_, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":        authCode,
+		"teams":           [][]string{{ids[0]}, {"not-a-player"}},
+		"team_placements": []int{1, 2},
+	}, "", http.StatusBadRequest)
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":        authCode,
+		"teams":           [][]string{{ids[0]}, {ids[1]}},
+		"team_placements": []int{1},
+	}, "", http.StatusBadRequest)
+
+	resp := DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":        authCode,
+		"teams":           [][]string{{ids[0]}},
+		"team_placements": []int{1},
+	}, "", http.StatusBadRequest)
+	if msg, _ := resp["message"].(string); msg != "invalid teams: "+ids[1]+" is not on a team" {
+		t.Errorf("expected the uncovered participant named, got %+v", resp)
+	}
+}
+
+// TestRatingHistoryRecordsMatchDeltas reports a rated classic match and
+// checks the change shows up both in each player's timeline and in the
+// match result's rating_changes section, with matching deltas.
+func TestRatingHistoryRecordsMatchDeltas(t *testing.T) {
+	h := NewHarness(t)
+	gameID, p1Token, p1ID, p2Token, p2ID, authCode := setupMatchedRegisteredGame(t, h, "hist")
+	setQueueELOStrategy(t, gameID, models.ELO_STRATEGY_CLASSIC)
+
+	var match models.Match
+	if err := server.S.DB.Where("auth_code = ?", authCode).First(&match).Error; err != nil {
+		t.Fatalf("find match: %v", err)
+	}
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"winner_ids": []string{p1ID},
+		"reason":     "completed",
+	}, "", http.StatusOK)
+
+	hist := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s/history", h.BaseURL(), gameID), nil, p1Token, http.StatusOK)
+	entries, _ := hist["history"].([]interface{})
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 history entry, got %+v", hist)
+	}
+	e := entries[0].(map[string]interface{})
+	if e["match_result_id"] != match.ID {
+		t.Errorf("expected match_result_id=%s, got %v", match.ID, e["match_result_id"])
+	}
+	if e["before"].(float64) != 1000 || e["after"].(float64) != 1016 || e["delta"].(float64) != 16 {
+		t.Errorf("expected 1000 → 1016 (+16), got %+v", e)
+	}
+	if e["reason"] != models.RatingChangeReasonMatch {
+		t.Errorf("expected reason=%q, got %v", models.RatingChangeReasonMatch, e["reason"])
+	}
+
+	loserHist := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s/history", h.BaseURL(), gameID), nil, p2Token, http.StatusOK)
+	loserEntries, _ := loserHist["history"].([]interface{})
+	if len(loserEntries) != 1 || loserEntries[0].(map[string]interface{})["delta"].(float64) != -16 {
+		t.Errorf("expected one -16 entry for loser, got %+v", loserHist)
+	}
+
+	result := DoReq(t, "GET", fmt.Sprintf("%s/results/%s", h.BaseURL(), match.ID), nil, p1Token, http.StatusOK)
+	changes, _ := result["rating_changes"].([]interface{})
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 rating_changes on result, got %+v", result)
+	}
+	byPlayer := map[string]float64{}
+	for _, c := range changes {
+		cm := c.(map[string]interface{})
+		byPlayer[cm["player_id"].(string)] = cm["delta"].(float64)
+	}
+	if byPlayer[p1ID] != 16 || byPlayer[p2ID] != -16 {
+		t.Errorf("expected deltas +16/-16, got %v", byPlayer)
+	}
+}
+
+// TestRatingHistoryEmptyForUnrankedQueue verifies unrated matches leave
+// no history behind and the result omits rating_changes.
+func TestRatingHistoryEmptyForUnrankedQueue(t *testing.T) {
+	h := NewHarness(t)
+	gameID, p1Token, p1ID, _, _, authCode := setupMatchedRegisteredGame(t, h, "histunr")
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"winner_ids": []string{p1ID},
+	}, "", http.StatusOK)
+
+	hist := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s/history", h.BaseURL(), gameID), nil, p1Token, http.StatusOK)
+	if entries, _ := hist["history"].([]interface{}); len(entries) != 0 {
+		t.Errorf("expected empty history for unranked queue, got %+v", hist)
+	}
+	if hist["nextPage"].(float64) != -1 {
+		t.Errorf("expected nextPage=-1, got %v", hist["nextPage"])
+	}
+}
+
+// TestPlacementsRewardSecondPlace reports a 4-player free-for-all with a
+// full ordering on a classic queue: 2nd place must gain rating and
+// finish above 3rd, which must finish above 4th.
+func TestPlacementsRewardSecondPlace(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "ffa", models.ELO_STRATEGY_CLASSIC, 4)
+	matchID, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"placements": map[string]int{ids[0]: 1, ids[1]: 2, ids[2]: 3, ids[3]: 4},
+		"reason":     "completed",
+	}, "", http.StatusOK)
+
+	ratings := make([]float64, 4)
+	for i, token := range tokens {
+		resp := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, token, http.StatusOK)
+		ratings[i] = resp["rating"].(float64)
+	}
+	if !(ratings[0] > ratings[1] && ratings[1] > 1000 && ratings[2] < 1000 && ratings[2] > ratings[3]) {
+		t.Errorf("expected strictly ordered ratings with 2nd above 1000, got %v", ratings)
+	}
+
+	mr := DoReq(t, "GET", fmt.Sprintf("%s/results/%s", h.BaseURL(), matchID), nil, tokens[0], http.StatusOK)
+	placements, _ := mr["placements"].(map[string]interface{})
+	if len(placements) != 4 || placements[ids[1]].(float64) != 2 {
+		t.Errorf("expected placements echoed on result, got %+v", mr["placements"])
+	}
+	winners, _ := mr["winner_ids"].([]interface{})
+	if len(winners) != 1 || winners[0] != ids[0] {
+		t.Errorf("expected winner_ids derived from 1st place, got %v", mr["winner_ids"])
+	}
+}
+
+// TestScoresDerivePlacementsWithTies reports scores only. The two tied
+// top scorers share 1st (and both become winners); the others rank by
+// score behind them.
+func TestScoresDerivePlacementsWithTies(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "score", models.ELO_STRATEGY_CLASSIC, 4)
+	matchID, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id": authCode,
+		"scores":   map[string]float64{ids[0]: 50, ids[1]: 50, ids[2]: 20, ids[3]: 5},
+	}, "", http.StatusOK)
+
+	mr := DoReq(t, "GET", fmt.Sprintf("%s/results/%s", h.BaseURL(), matchID), nil, tokens[0], http.StatusOK)
+	placements, _ := mr["placements"].(map[string]interface{})
+	want := map[string]float64{ids[0]: 1, ids[1]: 1, ids[2]: 3, ids[3]: 4}
+	for pid, p := range want {
+		if placements[pid] != p {
+			t.Errorf("expected %s placed %v, got %v", pid, p, placements[pid])
+		}
+	}
+	if winners, _ := mr["winner_ids"].([]interface{}); len(winners) != 2 {
+		t.Errorf("expected both tied top scorers as winners, got %v", mr["winner_ids"])
+	}
+	scores, _ := mr["scores"].(map[string]interface{})
+	if scores[ids[2]] != float64(20) {
+		t.Errorf("expected scores echoed on result, got %+v", mr["scores"])
+	}
+}
+
+// TestPlacementsRejectOutsider verifies placements naming a player who
+// wasn't in the match, or contradicting winner_ids, are rejected with
+// 400.
+func TestPlacementsRejectOutsider(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, _, ids := setupRatedGame(t, h, "pbad", models.ELO_STRATEGY_CLASSIC, 2)
+	_, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"placements": map[string]int{ids[0]: 1, "someone-else": 2},
+	}, "", http.StatusBadRequest)
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"placements": map[string]int{ids[0]: 0, ids[1]: 1},
+	}, "", http.StatusBadRequest)
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"winner_ids": []string{ids[0]},
+		"placements": map[string]int{ids[0]: 2, ids[1]: 1},
+	}, "", http.StatusBadRequest)
+}
+
+// TestPlacementMatchesBoostKAndHideProvisional enables a 2-match
+// placement period on a classic queue: provisional players move with a
+// doubled K, carry provisional=true, and stay off the leaderboard until
+// they've played their placements.
+func TestPlacementMatchesBoostKAndHideProvisional(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "placement", models.ELO_STRATEGY_CLASSIC, 2)
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "tsoplacement@example.com", "pass")
+	DoReq(t, "PUT", fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, queueID), map[string]interface{}{
+		"placement_matches": 2,
+	}, ownerToken, http.StatusOK)
+
+	playMatch := func(n int) {
+		authCode := fmt.Sprintf("auth-placement-%d", n)
+		if _, err := models.MatchStarted(server.S.DB, gameID, queueID, queueID, "", authCode, ids, nil, false); err != nil {
+			t.Fatalf("MatchStarted: %v", err)
+		}
+		DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+			"token_id":   authCode,
+			"winner_ids": []string{ids[0]},
+			"reason":     "completed",
+		}, "", http.StatusOK)
+	}
+
+	// K=32 doubled to 64 while provisional: 1000 → 1032 / 968.
+	playMatch(1)
+	winner := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	if winner["rating"].(float64) != 1032 {
+		t.Errorf("expected boosted +32 during placements, got %v", winner["rating"])
+	}
+	if winner["provisional"] != true || winner["games_played"].(float64) != 1 {
+		t.Errorf("expected provisional after 1 of 2 placements, got %+v", winner)
+	}
+	board := DoReq(t, "GET", fmt.Sprintf("%s/game/%s/leaderboard", h.BaseURL(), gameID), nil, "", http.StatusOK)
+	if entries, _ := board["leaderboard"].([]interface{}); len(entries) != 0 {
+		t.Errorf("expected provisional players hidden from leaderboard, got %+v", entries)
+	}
+
+	playMatch(2)
+	winner = DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	if winner["provisional"] != false || winner["games_played"].(float64) != 2 {
+		t.Errorf("expected placements complete after 2 matches, got %+v", winner)
+	}
+	board = DoReq(t, "GET", fmt.Sprintf("%s/game/%s/leaderboard", h.BaseURL(), gameID), nil, "", http.StatusOK)
+	if entries, _ := board["leaderboard"].([]interface{}); len(entries) != 2 {
+		t.Errorf("expected both players on leaderboard after placements, got %+v", entries)
+	}
+
+	// Past placements the normal K applies: a third win moves less than
+	// a provisional one would.
+	before := winner["rating"].(float64)
+	playMatch(3)
+	winner = DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	if gain := winner["rating"].(float64) - before; gain <= 0 || gain > 16 {
+		t.Errorf("expected unboosted gain in (0, 16], got %v", gain)
+	}
+}
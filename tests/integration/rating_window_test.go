@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/gorilla/websocket"
+)
+
+func TestRatingWindowSettings(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "rwcfg", "rwcfg@example.com", "pass")
+	token, _ := LoginUser(t, h.BaseURL(), "rwcfg@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), token, "WindowCfgGame", 2)
+	gameID := game["id"].(string)
+	queueURL := fmt.Sprintf("%s/game/%s/queue", h.BaseURL(), gameID)
+
+	q := CreateGameQueue(t, h.BaseURL(), token, gameID, "defaults", nil)
+	if steps, _ := q["rating_window_steps"].([]interface{}); len(steps) != len(models.DefaultRatingWindowSteps) {
+		t.Errorf("expected the default schedule, got %+v", q["rating_window_steps"])
+	}
+
+	for name, steps := range map[string][]map[string]int{
+		"empty":       {},
+		"late start":  {{"after_seconds": 10, "max_spread": 100}},
+		"not sorted":  {{"after_seconds": 0, "max_spread": 100}, {"after_seconds": 0, "max_spread": 200}},
+		"narrowing":   {{"after_seconds": 0, "max_spread": 200}, {"after_seconds": 30, "max_spread": 100}},
+		"after open":  {{"after_seconds": 0, "max_spread": 0}, {"after_seconds": 30, "max_spread": 100}},
+		"neg. spread": {{"after_seconds": 0, "max_spread": -5}},
+	} {
+		body := map[string]interface{}{"name": name, "rating_window_steps": steps, "matchmaking_machine_ports": []int64{8080}}
+		if resp := DoReq(t, "POST", queueURL, body, token, http.StatusBadRequest); resp == nil {
+			t.Errorf("%s: expected 400", name)
+		}
+	}
+	DoReq(t, "POST", queueURL, map[string]interface{}{
+		"name": "neg cap", "rating_window_cap": -1, "matchmaking_machine_ports": []int64{8080},
+	}, token, http.StatusBadRequest)
+
+	updateURL := fmt.Sprintf("%s/%s", queueURL, q["id"])
+	updated := DoReq(t, "PUT", updateURL, map[string]interface{}{
+		"rating_window_steps": []map[string]int{{"after_seconds": 0, "max_spread": 50}, {"after_seconds": 120, "max_spread": 500}},
+		"rating_window_cap":   400,
+	}, token, http.StatusOK)
+	steps, _ := updated["rating_window_steps"].([]interface{})
+	if len(steps) != 2 || updated["rating_window_cap"].(float64) != 400 {
+		t.Fatalf("expected the new schedule and cap, got %+v", updated)
+	}
+	kept := DoReq(t, "PUT", updateURL, map[string]interface{}{"rating_window_cap": 0}, token, http.StatusOK)
+	if steps, _ := kept["rating_window_steps"].([]interface{}); len(steps) != 2 || kept["rating_window_cap"].(float64) != 0 {
+		t.Errorf("expected the cap removed and the schedule kept, got %+v", kept)
+	}
+}
+
+// TestRatingWindowCapNeverUnbounded queues two players 300 points apart
+// who have waited well past the default schedule's unbounded step. With a
+// cap of 100 they stay apart; removing the cap lets them match.
+func TestRatingWindowCapNeverUnbounded(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "rwcap", "rwcap@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "rwcap@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "WindowCapGame", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "capped", map[string]interface{}{
+		"matchmaking_strategy": models.MATCHMAKING_STRATEGY_RATING,
+		"rating_window_cap":    100,
+	})
+	queueID := q["id"].(string)
+
+	var conns []*websocket.Conn
+	for i, rating := range []int{1000, 1300} {
+		name := fmt.Sprintf("rwcapp%d", i)
+		RegisterUser(t, h.BaseURL(), name, name+"@example.com", "pass")
+		token, id := LoginUser(t, h.BaseURL(), name+"@example.com", "pass")
+		if _, err := models.GetRating(id, queueID); err != nil {
+			t.Fatalf("GetRating: %v", err)
+		}
+		if err := server.S.DB.Model(&models.Rating{}).
+			Where("player_id = ? AND game_queue_id = ?", id, queueID).
+			UpdateColumn("rating", rating).Error; err != nil {
+			t.Fatalf("seed rating: %v", err)
+		}
+		ws := WebsocketConnect(t, fmt.Sprintf("%s/match/join?gameID=%s&queueID=%s", h.BaseURL(), gameID, queueID), token)
+		defer ws.Close()
+		readQueueJoined(t, ws)
+		conns = append(conns, ws)
+
+		// Backdate the join so the default schedule is fully expanded.
+		server.S.Redis.Client.HSet(context.Background(), "qjoined_"+queueID, id, time.Now().Add(-5*time.Minute).Unix())
+	}
+
+	TriggerMatchmaking(t)
+	time.Sleep(300 * time.Millisecond)
+	if size := QueueSizeWithQueue(t, h.BaseURL(), ownerToken, gameID, queueID); size != 2 {
+		t.Fatalf("expected the cap to keep a 300-point spread apart, queue size %v", size)
+	}
+
+	DoReq(t, "PUT", fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, queueID),
+		map[string]interface{}{"rating_window_cap": 0}, ownerToken, http.StatusOK)
+	TriggerMatchmaking(t)
+	for _, ws := range conns {
+		awaitStatus(t, ws, "match_found")
+	}
+}
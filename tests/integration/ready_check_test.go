@@ -0,0 +1,181 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/worker/matchmaking"
+	"github.com/gorilla/websocket"
+)
+
+// awaitError blocks until the next error status and returns its message.
+func awaitError(t *testing.T, ws *websocket.Conn) string {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for an error: %v", err)
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			continue
+		}
+		if resp["status"] == "error" {
+			return resp["error"].(string)
+		}
+		if resp["status"] == "match_found" {
+			t.Fatalf("waiting for an error, got match_found")
+		}
+	}
+}
+
+// setupReadyCheckQueue creates a 2-player queue with the given ready
+// check and returns its /match/join URL.
+func setupReadyCheckQueue(t *testing.T, h *Harness, name string, seconds int) string {
+	t.Helper()
+	RegisterUser(t, h.BaseURL(), name, name+"@example.com", "pass")
+	token, _ := LoginUser(t, h.BaseURL(), name+"@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), token, name+"Game", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), token, gameID, "ready", map[string]interface{}{
+		"ready_check_seconds": seconds,
+	})
+	if q["ready_check_seconds"].(float64) != float64(seconds) {
+		t.Fatalf("expected ready_check_seconds %d, got %+v", seconds, q)
+	}
+	return fmt.Sprintf("%s/match/join?gameID=%s&queueID=%s", h.BaseURL(), gameID, q["id"])
+}
+
+func TestReadyCheckSettings(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "rcfg", "rcfg@example.com", "pass")
+	token, _ := LoginUser(t, h.BaseURL(), "rcfg@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), token, "ReadyCfgGame", 2)
+	gameID := game["id"].(string)
+	queueURL := fmt.Sprintf("%s/game/%s/queue", h.BaseURL(), gameID)
+
+	DoReq(t, "POST", queueURL, map[string]interface{}{
+		"name": "too long", "ready_check_seconds": 1000, "matchmaking_machine_ports": []int64{8080},
+	}, token, http.StatusBadRequest)
+	q := CreateGameQueue(t, h.BaseURL(), token, gameID, "ready", map[string]interface{}{"ready_check_seconds": 15})
+	updateURL := fmt.Sprintf("%s/%s", queueURL, q["id"])
+	DoReq(t, "PUT", updateURL, map[string]interface{}{"ready_check_seconds": -1}, token, http.StatusBadRequest)
+	off := DoReq(t, "PUT", updateURL, map[string]interface{}{"ready_check_seconds": 0}, token, http.StatusOK)
+	if off["ready_check_seconds"].(float64) != 0 {
+		t.Errorf("expected the ready check turned off, got %+v", off)
+	}
+}
+
+func TestReadyCheckAllAccept(t *testing.T) {
+	h := NewHarness(t)
+	joinURL := setupReadyCheckQueue(t, h, "rcall", 10)
+
+	var conns []*websocket.Conn
+	for _, name := range []string{"rca1", "rca2"} {
+		token, _ := GuestLogin(t, h.BaseURL(), name)
+		ws := WebsocketConnect(t, joinURL, token)
+		defer ws.Close()
+		readQueueJoined(t, ws)
+		conns = append(conns, ws)
+	}
+	TriggerMatchmaking(t)
+
+	for _, ws := range conns {
+		proposed := awaitStatus(t, ws, "match_proposed")
+		if proposed["proposal_id"] == "" || proposed["expires_at"] == nil {
+			t.Fatalf("expected proposal details, got %+v", proposed)
+		}
+	}
+	for _, ws := range conns {
+		ws.WriteMessage(websocket.TextMessage, []byte("/accept"))
+		awaitStatus(t, ws, "accepted")
+	}
+	for _, ws := range conns {
+		awaitStatus(t, ws, "match_found")
+	}
+}
+
+// TestReadyCheckDecline checks a decliner is dropped while the player who
+// accepted goes back to the queue and is matched with the next arrival.
+// Only the match that started counts in the queue's stats.
+func TestReadyCheckDecline(t *testing.T) {
+	h := NewHarness(t)
+	joinURL := setupReadyCheckQueue(t, h, "rcdecl", 10)
+
+	t1, _ := GuestLogin(t, h.BaseURL(), "rcd1")
+	t2, _ := GuestLogin(t, h.BaseURL(), "rcd2")
+	t3, _ := GuestLogin(t, h.BaseURL(), "rcd3")
+	ws1 := WebsocketConnect(t, joinURL, t1)
+	defer ws1.Close()
+	readQueueJoined(t, ws1)
+	ws2 := WebsocketConnect(t, joinURL, t2)
+	defer ws2.Close()
+	readQueueJoined(t, ws2)
+	TriggerMatchmaking(t)
+	awaitStatus(t, ws1, "match_proposed")
+	awaitStatus(t, ws2, "match_proposed")
+
+	ws3 := WebsocketConnect(t, joinURL, t3)
+	defer ws3.Close()
+	readQueueJoined(t, ws3)
+
+	ws1.WriteMessage(websocket.TextMessage, []byte("/accept"))
+	awaitStatus(t, ws1, "accepted")
+	ws2.WriteMessage(websocket.TextMessage, []byte("/decline"))
+	if msg := awaitError(t, ws2); msg != "match declined" {
+		t.Errorf("expected the decliner to be told, got %q", msg)
+	}
+	if requeued := awaitStatus(t, ws1, "requeued"); requeued["message"] != "another player declined" {
+		t.Errorf("unexpected requeue message: %+v", requeued)
+	}
+
+	// The requeue re-runs pairing, proposing the accepter with player 3.
+	awaitStatus(t, ws1, "match_proposed")
+	awaitStatus(t, ws3, "match_proposed")
+	for _, ws := range []*websocket.Conn{ws1, ws3} {
+		ws.WriteMessage(websocket.TextMessage, []byte("/accept"))
+	}
+	awaitStatus(t, ws1, "match_found")
+	awaitStatus(t, ws3, "match_found")
+
+	u, _ := url.Parse(joinURL)
+	stats, err := matchmaking.GetQueueStats(context.Background(), u.Query().Get("queueID"), 1)
+	if err != nil {
+		t.Fatalf("queue stats: %v", err)
+	}
+	if stats.MatchesFormed != 1 || stats.PlayersMatched != 2 {
+		t.Errorf("expected only the started match counted, got %+v", stats)
+	}
+}
+
+func TestReadyCheckTimeout(t *testing.T) {
+	h := NewHarness(t)
+	joinURL := setupReadyCheckQueue(t, h, "rctime", 1)
+
+	t1, _ := GuestLogin(t, h.BaseURL(), "rct1")
+	t2, _ := GuestLogin(t, h.BaseURL(), "rct2")
+	ws1 := WebsocketConnect(t, joinURL, t1)
+	defer ws1.Close()
+	readQueueJoined(t, ws1)
+	ws2 := WebsocketConnect(t, joinURL, t2)
+	defer ws2.Close()
+	readQueueJoined(t, ws2)
+	TriggerMatchmaking(t)
+	awaitStatus(t, ws1, "match_proposed")
+	awaitStatus(t, ws2, "match_proposed")
+
+	ws1.WriteMessage(websocket.TextMessage, []byte("/accept"))
+	if msg := awaitError(t, ws2); msg != "ready check timed out" {
+		t.Errorf("expected the no-show to time out, got %q", msg)
+	}
+	if requeued := awaitStatus(t, ws1, "requeued"); requeued["message"] != "another player did not accept in time" {
+		t.Errorf("unexpected requeue message: %+v", requeued)
+	}
+}
@@ -0,0 +1,157 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+)
+
+// waitForRecalculation polls a recalculation job until the worker
+// finishes it.
+func waitForRecalculation(t *testing.T, baseURL, token, jobID string) map[string]interface{} {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job := DoReq(t, "GET", fmt.Sprintf("%s/ratings/recalculations/%s", baseURL, jobID), nil, token, http.StatusOK)
+		switch job["status"] {
+		case models.RatingRecalculationStatusCompleted, models.RatingRecalculationStatusFailed:
+			return job
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("recalculation %s did not finish in time", jobID)
+	return nil
+}
+
+// TestRatingRecalculation plays one rated 1v1 at K=32, halves the queue's
+// K-factor, and checks a dry run reports the K=16 diff without touching
+// ratings, while a real run rewrites them with a recalculation history
+// entry.
+func TestRatingRecalculation(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "recalc", models.ELO_STRATEGY_CLASSIC, 2)
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "tsorecalc@example.com", "pass")
+
+	RegisterUser(t, h.BaseURL(), "recalcadmin", "recalcadmin@example.com", "pass")
+	_, adminID := LoginUser(t, h.BaseURL(), "recalcadmin@example.com", "pass")
+	MakeAdmin(t, adminID)
+	adminToken, _ := LoginUser(t, h.BaseURL(), "recalcadmin@example.com", "pass")
+
+	matchID, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"winner_ids": []string{ids[0]},
+		"reason":     "completed",
+	}, "", http.StatusOK)
+
+	result, err := models.GetMatchResult(matchID)
+	if err != nil {
+		t.Fatalf("GetMatchResult: %v", err)
+	}
+	if result.GameQueueID == nil || *result.GameQueueID != queueID || result.Unrated {
+		t.Fatalf("expected a rated result tagged with queue %s, got queue=%v unrated=%v", queueID, result.GameQueueID, result.Unrated)
+	}
+
+	DoReq(t, "PUT", fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, queueID), map[string]interface{}{
+		"k_factor": 16,
+	}, ownerToken, http.StatusOK)
+
+	recalcURL := fmt.Sprintf("%s/game/%s/ratings/recalculate", h.BaseURL(), gameID)
+	DoReq(t, "POST", recalcURL, nil, ownerToken, http.StatusForbidden)
+
+	// Dry run: K=16 would have given 1008/992, but nothing is written.
+	job := DoReq(t, "POST", recalcURL+"?dryRun=true", nil, adminToken, http.StatusAccepted)
+	if job["dry_run"] != true || job["status"] != models.RatingRecalculationStatusPending {
+		t.Fatalf("expected a pending dry-run job, got %+v", job)
+	}
+	job = waitForRecalculation(t, h.BaseURL(), adminToken, job["id"].(string))
+	if job["status"] != models.RatingRecalculationStatusCompleted {
+		t.Fatalf("expected dry run to complete, got %+v", job)
+	}
+	if job["processed"].(float64) != 1 || job["total"].(float64) != 1 {
+		t.Errorf("expected 1/1 results processed, got %v/%v", job["processed"], job["total"])
+	}
+	diff, _ := job["diff"].([]interface{})
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff entries, got %+v", job["diff"])
+	}
+	wantAfter := map[string]float64{ids[0]: 1008, ids[1]: 992}
+	for _, raw := range diff {
+		entry := raw.(map[string]interface{})
+		if entry["after"].(float64) != wantAfter[entry["player_id"].(string)] {
+			t.Errorf("unexpected diff entry %+v", entry)
+		}
+	}
+	resp := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	if resp["rating"].(float64) != 1016 {
+		t.Errorf("expected dry run to leave rating at 1016, got %v", resp["rating"])
+	}
+
+	// Real run rewrites the ratings.
+	job = DoReq(t, "POST", recalcURL, nil, adminToken, http.StatusAccepted)
+	job = waitForRecalculation(t, h.BaseURL(), adminToken, job["id"].(string))
+	if job["status"] != models.RatingRecalculationStatusCompleted {
+		t.Fatalf("expected recalculation to complete, got %+v", job)
+	}
+	for i, want := range []float64{1008, 992} {
+		resp := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[i], http.StatusOK)
+		if resp["rating"].(float64) != want {
+			t.Errorf("player %d: expected rating %v after recalculation, got %v", i, want, resp["rating"])
+		}
+		if resp["games_played"].(float64) != 1 {
+			t.Errorf("player %d: expected games_played 1 after replay, got %v", i, resp["games_played"])
+		}
+	}
+
+	history := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s/history", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	entries, _ := history["history"].([]interface{})
+	if len(entries) != 2 {
+		t.Fatalf("expected match + recalculation history entries, got %+v", history)
+	}
+	latest := entries[0].(map[string]interface{})
+	if latest["reason"] != models.RatingChangeReasonRecalculation || latest["delta"].(float64) != -8 {
+		t.Errorf("expected latest entry to be a -8 recalculation, got %+v", latest)
+	}
+
+	DoReq(t, "GET", h.BaseURL()+"/ratings/recalculations/00000000-0000-0000-0000-000000000000", nil, adminToken, http.StatusNotFound)
+}
+
+// TestRecalculationLease checks only running jobs whose lease has lapsed
+// are failed as interrupted; one a live worker is renewing is left alone.
+func TestRecalculationLease(t *testing.T) {
+	h := NewHarness(t)
+	_, queueID, _, ids := setupRatedGame(t, h, "lease", models.ELO_STRATEGY_CLASSIC, 2)
+
+	live, err := models.CreateRatingRecalculation(queueID, ids[0], true)
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	stale, err := models.CreateRatingRecalculation(queueID, ids[0], true)
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	for _, job := range []*models.RatingRecalculation{live, stale} {
+		if err := server.S.DB.Model(job).Updates(map[string]interface{}{
+			"status": models.RatingRecalculationStatusRunning, "lease_expires_at": time.Now().UTC().Add(-time.Second),
+		}).Error; err != nil {
+			t.Fatalf("mark running: %v", err)
+		}
+	}
+	if err := models.RenewRatingRecalculationLease(live); err != nil {
+		t.Fatalf("renew lease: %v", err)
+	}
+
+	if err := models.FailExpiredRatingRecalculations(); err != nil {
+		t.Fatalf("fail expired: %v", err)
+	}
+	if job, _ := models.GetRatingRecalculation(live.ID); job.Status != models.RatingRecalculationStatusRunning {
+		t.Errorf("expected the leased job left running, got %s", job.Status)
+	}
+	if job, _ := models.GetRatingRecalculation(stale.ID); job.Status != models.RatingRecalculationStatusFailed {
+		t.Errorf("expected the lapsed job failed, got %s", job.Status)
+	}
+}
@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/gorilla/websocket"
+)
+
+func TestMaxPingSettings(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "pingcfg", "pingcfg@example.com", "pass")
+	token, _ := LoginUser(t, h.BaseURL(), "pingcfg@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), token, "PingCfgGame", 2)
+	gameID := game["id"].(string)
+	queueURL := fmt.Sprintf("%s/game/%s/queue", h.BaseURL(), gameID)
+
+	DoReq(t, "POST", queueURL, map[string]interface{}{
+		"name": "too loose", "max_ping_ms": 20000, "matchmaking_machine_ports": []int64{8080},
+	}, token, http.StatusBadRequest)
+	q := CreateGameQueue(t, h.BaseURL(), token, gameID, "pinged", map[string]interface{}{"max_ping_ms": 80})
+	if q["max_ping_ms"].(float64) != 80 {
+		t.Fatalf("expected max_ping_ms 80, got %+v", q)
+	}
+	updateURL := fmt.Sprintf("%s/%s", queueURL, q["id"])
+	DoReq(t, "PUT", updateURL, map[string]interface{}{"max_ping_ms": -1}, token, http.StatusBadRequest)
+	off := DoReq(t, "PUT", updateURL, map[string]interface{}{"max_ping_ms": 0}, token, http.StatusOK)
+	if off["max_ping_ms"].(float64) != 0 {
+		t.Errorf("expected region filtering turned off, got %+v", off)
+	}
+}
+
+// TestRegionMatchmaking queues two players near nbg1 and two near ash on
+// a queue with a 100ms ping limit. Each pair is matched together, on a
+// host provisioned in their region.
+func TestRegionMatchmaking(t *testing.T) {
+	h := NewHarness(t)
+	server.S.Config.HCLOUDLocations = []string{"nbg1", "ash"}
+
+	RegisterUser(t, h.BaseURL(), "region", "region@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "region@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "RegionGame", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "regional", map[string]interface{}{"max_ping_ms": 100})
+	queueID := q["id"].(string)
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s&queueID=%s", h.BaseURL(), gameID, queueID)
+
+	join := func(name, pings string) *websocket.Conn {
+		token, _ := GuestLogin(t, h.BaseURL(), name)
+		ws := WebsocketConnect(t, joinURL+"&pings="+url.QueryEscape(pings), token)
+		readQueueJoined(t, ws)
+		return ws
+	}
+
+	eu1 := join("reu1", "nbg1:30,ash:150,mars:5")
+	defer eu1.Close()
+	us1 := join("rus1", "nbg1:200,ash:40")
+	defer us1.Close()
+
+	// One player per region: nobody to match with yet.
+	TriggerMatchmaking(t)
+	time.Sleep(300 * time.Millisecond)
+	if size := QueueSizeWithQueue(t, h.BaseURL(), ownerToken, gameID, queueID); size != 2 {
+		t.Fatalf("expected players in different regions to stay queued, queue size %v", size)
+	}
+
+	eu2 := join("reu2", "nbg1:60,ash:130")
+	defer eu2.Close()
+	us2 := join("rus2", "nbg1:180,ash:50")
+	defer us2.Close()
+	TriggerMatchmaking(t)
+
+	matchIDs := map[string]string{}
+	for region, conns := range map[string][]*websocket.Conn{"nbg1": {eu1, eu2}, "ash": {us1, us2}} {
+		for _, ws := range conns {
+			found := awaitStatus(t, ws, "match_found")
+			if found["region"] != region {
+				t.Errorf("expected a match in %s, got %+v", region, found)
+			}
+			if prev, ok := matchIDs[region]; ok && prev != found["match_id"] {
+				t.Errorf("expected the %s players in the same match", region)
+			}
+			matchIDs[region] = found["match_id"].(string)
+		}
+	}
+
+	var ashHosts int64
+	server.S.DB.Model(&models.MachineHost{}).Where("region = ?", "ash").Count(&ashHosts)
+	if ashHosts != 1 {
+		t.Errorf("expected one host provisioned in ash, got %d", ashHosts)
+	}
+}
+
+func TestRegionMatchmakingInvalidPings(t *testing.T) {
+	h := NewHarness(t)
+	RegisterUser(t, h.BaseURL(), "badping", "badping@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "badping@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "BadPingGame", 2)
+	token, _ := GuestLogin(t, h.BaseURL(), "badpings")
+
+	ws := WebsocketConnect(t, fmt.Sprintf("%s/match/join?gameID=%s&pings=nbg1", h.BaseURL(), game["id"]), token)
+	defer ws.Close()
+	if msg := awaitError(t, ws); msg != "invalid pings: expected region:milliseconds pairs" {
+		t.Errorf("unexpected error: %q", msg)
+	}
+}
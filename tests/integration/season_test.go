@@ -0,0 +1,225 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/andy98725/elo-service/src/worker/ratings"
+)
+
+// expireSeason backdates a queue's season deadline so the next
+// RolloverSeasons sweep picks it up.
+func expireSeason(t *testing.T, queueID string) {
+	t.Helper()
+	if err := server.S.DB.Model(&models.GameQueue{}).
+		Where("id = ?", queueID).
+		Update("season_ends_at", time.Now().UTC().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire season: %v", err)
+	}
+}
+
+// TestSeasonRolloverArchivesAndSoftResets plays one rated 1v1, ends the
+// season, and checks the standings were archived, both ratings were
+// pulled halfway back to the default with a season_reset history entry,
+// and the next season was scheduled.
+func TestSeasonRolloverArchivesAndSoftResets(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "season", models.ELO_STRATEGY_CLASSIC, 2)
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "tsoseason@example.com", "pass")
+
+	updated := DoReq(t, "PUT", fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, queueID), map[string]interface{}{
+		"season_ends_at":     time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		"season_length_days": 30,
+	}, ownerToken, http.StatusOK)
+	if updated["season_number"].(float64) != 1 {
+		t.Fatalf("expected scheduling an end to open season 1, got %+v", updated)
+	}
+
+	_, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"winner_ids": []string{ids[0]},
+		"reason":     "completed",
+	}, "", http.StatusOK)
+
+	expireSeason(t, queueID)
+	if err := ratings.RolloverSeasons(context.Background()); err != nil {
+		t.Fatalf("RolloverSeasons: %v", err)
+	}
+
+	// Default soft reset is 0.5: 1016 → 1008, 984 → 992.
+	for i, want := range []float64{1008, 992} {
+		resp := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[i], http.StatusOK)
+		if resp["rating"].(float64) != want {
+			t.Errorf("player %d: expected rating %v after reset, got %v", i, want, resp["rating"])
+		}
+	}
+
+	history := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s/history", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	entries, _ := history["history"].([]interface{})
+	if len(entries) != 2 {
+		t.Fatalf("expected match + season_reset history entries, got %+v", history)
+	}
+	latest := entries[0].(map[string]interface{})
+	if latest["reason"] != models.RatingChangeReasonSeasonReset || latest["delta"].(float64) != -8 {
+		t.Errorf("expected latest entry to be a -8 season_reset, got %+v", latest)
+	}
+	if latest["match_result_id"] != nil {
+		t.Errorf("expected no match_result_id on a season reset, got %v", latest["match_result_id"])
+	}
+
+	seasons := DoReq(t, "GET", fmt.Sprintf("%s/game/%s/seasons", h.BaseURL(), gameID), nil, "", http.StatusOK)
+	current := seasons["current"].(map[string]interface{})
+	if current["number"].(float64) != 2 {
+		t.Errorf("expected current season 2, got %+v", current)
+	}
+	endsAt, err := time.Parse(time.RFC3339, current["ends_at"].(string))
+	if err != nil || time.Until(endsAt) < 29*24*time.Hour {
+		t.Errorf("expected next season to end ~30 days out, got %v", current["ends_at"])
+	}
+	archived, _ := seasons["seasons"].([]interface{})
+	if len(archived) != 1 {
+		t.Fatalf("expected 1 archived season, got %+v", seasons)
+	}
+	if s := archived[0].(map[string]interface{}); s["number"].(float64) != 1 || s["player_count"].(float64) != 2 {
+		t.Errorf("unexpected archived season: %+v", s)
+	}
+
+	board := DoReq(t, "GET", fmt.Sprintf("%s/game/%s/seasons/1/leaderboard", h.BaseURL(), gameID), nil, "", http.StatusOK)
+	standings, _ := board["leaderboard"].([]interface{})
+	if len(standings) != 2 {
+		t.Fatalf("expected 2 standings, got %+v", board)
+	}
+	top := standings[0].(map[string]interface{})
+	if top["player_id"] != ids[0] || top["rank"].(float64) != 1 || top["rating"].(float64) != 1016 {
+		t.Errorf("expected winner archived at rank 1 with pre-reset rating 1016, got %+v", top)
+	}
+
+	// A second sweep must not roll the new season over.
+	if err := ratings.RolloverSeasons(context.Background()); err != nil {
+		t.Fatalf("RolloverSeasons: %v", err)
+	}
+	again := DoReq(t, "GET", fmt.Sprintf("%s/game/%s/seasons", h.BaseURL(), gameID), nil, "", http.StatusOK)
+	if n := len(again["seasons"].([]interface{})); n != 1 {
+		t.Errorf("expected still 1 archived season, got %d", n)
+	}
+}
+
+// TestSeasonRolloverWithoutLengthLeavesNextOpen checks a one-off season
+// end (no season_length_days) opens an unscheduled next season, and that
+// a full reset puts everyone back at the default.
+func TestSeasonRolloverWithoutLengthLeavesNextOpen(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "seasonopen", models.ELO_STRATEGY_CLASSIC, 2)
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "tsoseasonopen@example.com", "pass")
+
+	DoReq(t, "PUT", fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, queueID), map[string]interface{}{
+		"season_ends_at":    time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		"season_soft_reset": 1.0,
+	}, ownerToken, http.StatusOK)
+
+	_, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"winner_ids": []string{ids[1]},
+		"reason":     "completed",
+	}, "", http.StatusOK)
+
+	expireSeason(t, queueID)
+	if err := ratings.RolloverSeasons(context.Background()); err != nil {
+		t.Fatalf("RolloverSeasons: %v", err)
+	}
+
+	for i, token := range tokens {
+		resp := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, token, http.StatusOK)
+		if resp["rating"].(float64) != 1000 {
+			t.Errorf("player %d: expected full reset to 1000, got %v", i, resp["rating"])
+		}
+	}
+
+	queue := DoReq(t, "GET", fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, queueID), nil, "", http.StatusOK)
+	if queue["season_number"].(float64) != 2 || queue["season_ends_at"] != nil {
+		t.Errorf("expected open-ended season 2, got number=%v ends_at=%v", queue["season_number"], queue["season_ends_at"])
+	}
+}
+
+// TestSeasonRolloverSkipsProvisional checks players still in their
+// placement matches are soft-reset like everyone else but left out of the
+// archived standings, matching the live leaderboard.
+func TestSeasonRolloverSkipsProvisional(t *testing.T) {
+	h := NewHarness(t)
+	gameID, queueID, tokens, ids := setupRatedGame(t, h, "seasonprov", models.ELO_STRATEGY_CLASSIC, 2)
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "tsoseasonprov@example.com", "pass")
+
+	DoReq(t, "PUT", fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, queueID), map[string]interface{}{
+		"season_ends_at":    time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		"placement_matches": 2,
+	}, ownerToken, http.StatusOK)
+
+	_, authCode := startSyntheticMatch(t, gameID, queueID, ids)
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   authCode,
+		"winner_ids": []string{ids[0]},
+		"reason":     "completed",
+	}, "", http.StatusOK)
+
+	expireSeason(t, queueID)
+	if err := ratings.RolloverSeasons(context.Background()); err != nil {
+		t.Fatalf("RolloverSeasons: %v", err)
+	}
+
+	resp := DoReq(t, "GET", fmt.Sprintf("%s/user/rating/%s", h.BaseURL(), gameID), nil, tokens[0], http.StatusOK)
+	// Placement K doubles the gain to 1032, halved back to 1016.
+	if resp["rating"].(float64) != 1016 {
+		t.Errorf("expected provisional winner soft-reset to 1016, got %v", resp["rating"])
+	}
+
+	seasons := DoReq(t, "GET", fmt.Sprintf("%s/game/%s/seasons", h.BaseURL(), gameID), nil, "", http.StatusOK)
+	archived, _ := seasons["seasons"].([]interface{})
+	if len(archived) != 1 || archived[0].(map[string]interface{})["player_count"].(float64) != 0 {
+		t.Fatalf("expected 1 archived season with no ranked players, got %+v", seasons)
+	}
+	board := DoReq(t, "GET", fmt.Sprintf("%s/game/%s/seasons/1/leaderboard", h.BaseURL(), gameID), nil, "", http.StatusOK)
+	if standings, _ := board["leaderboard"].([]interface{}); len(standings) != 0 {
+		t.Errorf("expected no provisional players in the standings, got %+v", standings)
+	}
+}
+
+// TestSeasonConfigValidation covers the 400s for bad season settings and
+// the 404 for an unarchived season.
+func TestSeasonConfigValidation(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "seasonowner", "seasonowner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "seasonowner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "SeasonValidationGame", 2)
+	gameID := game["id"].(string)
+	queueID := DefaultQueueID(t, game)
+	queueURL := fmt.Sprintf("%s/game/%s/queue/%s", h.BaseURL(), gameID, queueID)
+
+	DoReq(t, "PUT", queueURL, map[string]interface{}{
+		"season_ends_at": time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	}, ownerToken, http.StatusBadRequest)
+	DoReq(t, "PUT", queueURL, map[string]interface{}{
+		"season_soft_reset": 1.5,
+	}, ownerToken, http.StatusBadRequest)
+	DoReq(t, "PUT", queueURL, map[string]interface{}{
+		"season_length_days": -1,
+	}, ownerToken, http.StatusBadRequest)
+
+	created := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "seasonal", map[string]interface{}{
+		"season_ends_at":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		"season_soft_reset": 0.25,
+	})
+	if created["season_number"].(float64) != 1 || created["season_soft_reset"].(float64) != 0.25 {
+		t.Errorf("expected season 1 with soft reset 0.25, got %+v", created)
+	}
+
+	DoReq(t, "GET", fmt.Sprintf("%s/game/%s/seasons/1/leaderboard?queueID=%s", h.BaseURL(), gameID, created["id"]), nil, "", http.StatusNotFound)
+	DoReq(t, "GET", fmt.Sprintf("%s/game/%s/seasons/zero/leaderboard", h.BaseURL(), gameID), nil, "", http.StatusBadRequest)
+}
@@ -57,6 +57,37 @@ func migrateForSQLite(db *gorm.DB) error {
 			default_rating INTEGER DEFAULT 1000,
 			k_factor INTEGER DEFAULT 32,
 			metadata_enabled INTEGER DEFAULT 0,
+			season_number INTEGER NOT NULL DEFAULT 0,
+			season_started_at DATETIME,
+			season_ends_at DATETIME,
+			season_length_days INTEGER NOT NULL DEFAULT 0,
+			season_soft_reset REAL NOT NULL DEFAULT 0.5,
+			decay_grace_days INTEGER NOT NULL DEFAULT 0,
+			decay_per_week INTEGER NOT NULL DEFAULT 0,
+			decay_floor INTEGER NOT NULL DEFAULT 1000,
+			placement_matches INTEGER NOT NULL DEFAULT 0,
+			placement_k_multiplier REAL NOT NULL DEFAULT 2,
+			team_count INTEGER NOT NULL DEFAULT 0,
+			team_size INTEGER NOT NULL DEFAULT 0,
+			ready_check_seconds INTEGER NOT NULL DEFAULT 0,
+			rating_window_steps TEXT,
+			rating_window_cap INTEGER NOT NULL DEFAULT 0,
+			max_ping_ms INTEGER NOT NULL DEFAULT 0,
+			min_players INTEGER NOT NULL DEFAULT 0,
+			fill_timeout_seconds INTEGER NOT NULL DEFAULT 0,
+			penalty_decline_points INTEGER NOT NULL DEFAULT 0,
+			penalty_dodge_points INTEGER NOT NULL DEFAULT 0,
+			penalty_abandon_points INTEGER NOT NULL DEFAULT 0,
+			penalty_decay_per_hour INTEGER NOT NULL DEFAULT 1,
+			penalty_lockout_steps TEXT,
+			rematch_lookback_minutes INTEGER NOT NULL DEFAULT 0,
+			rematch_max_wait_seconds INTEGER NOT NULL DEFAULT 0,
+			heartbeat_grace_seconds INTEGER NOT NULL DEFAULT 0,
+			max_match_duration_minutes INTEGER NOT NULL DEFAULT 360,
+			max_match_extension_minutes INTEGER NOT NULL DEFAULT 0,
+			result_reporting TEXT NOT NULL DEFAULT 'server',
+			result_quorum INTEGER NOT NULL DEFAULT 0,
+			result_report_window_seconds INTEGER NOT NULL DEFAULT 300,
 			UNIQUE (game_id, name),
 			FOREIGN KEY (game_id) REFERENCES games(id) ON DELETE CASCADE
 		)`,
@@ -64,11 +95,17 @@ func migrateForSQLite(db *gorm.DB) error {
 			id TEXT PRIMARY KEY,
 			game_id TEXT NOT NULL,
 			game_queue_id TEXT NOT NULL,
+			queue_key TEXT,
 			server_instance_id TEXT,
 			guest_ids TEXT DEFAULT '{}',
 			auth_code TEXT NOT NULL,
 			status TEXT NOT NULL,
 			spectate_enabled INTEGER DEFAULT 0,
+			teams TEXT,
+			last_heartbeat_at DATETIME,
+			extended_until DATETIME,
+			leaves TEXT,
+			reports TEXT,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (game_id) REFERENCES games(id),
@@ -82,6 +119,7 @@ func migrateForSQLite(db *gorm.DB) error {
 			agent_port INTEGER NOT NULL,
 			agent_token TEXT NOT NULL,
 			status TEXT NOT NULL DEFAULT 'provisioning',
+			region TEXT NOT NULL DEFAULT 'nbg1',
 			max_slots INTEGER NOT NULL,
 			allocated_ports TEXT NOT NULL DEFAULT '{}',
 			public_hostname TEXT,
@@ -115,9 +153,18 @@ func migrateForSQLite(db *gorm.DB) error {
 			result TEXT NOT NULL,
 			logs_key TEXT,
 			artifacts TEXT DEFAULT '{}',
+			teams TEXT,
+			team_placements TEXT DEFAULT '{}',
+			placements TEXT,
+			scores TEXT,
+			abandons TEXT,
+			reports TEXT,
+			game_queue_id TEXT,
+			unrated INTEGER NOT NULL DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (game_id) REFERENCES games(id)
+			FOREIGN KEY (game_id) REFERENCES games(id),
+			FOREIGN KEY (game_queue_id) REFERENCES game_queues(id) ON DELETE SET NULL
 		)`,
 		`CREATE TABLE IF NOT EXISTS match_result_players (
 			match_result_id TEXT,
@@ -128,11 +175,68 @@ func migrateForSQLite(db *gorm.DB) error {
 			player_id TEXT,
 			game_queue_id TEXT,
 			rating INTEGER NOT NULL,
+			deviation REAL NOT NULL DEFAULT 350,
+			volatility REAL NOT NULL DEFAULT 0.06,
+			mu REAL NOT NULL DEFAULT 0,
+			sigma REAL NOT NULL DEFAULT 0,
+			decayed_at DATETIME,
+			games_played INTEGER NOT NULL DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			PRIMARY KEY (player_id, game_queue_id),
 			FOREIGN KEY (game_queue_id) REFERENCES game_queues(id) ON DELETE CASCADE
 		)`,
+		`CREATE TABLE IF NOT EXISTS rating_changes (
+			id TEXT PRIMARY KEY,
+			player_id TEXT NOT NULL,
+			game_queue_id TEXT NOT NULL,
+			match_result_id TEXT,
+			reason TEXT NOT NULL,
+			before INTEGER NOT NULL,
+			after INTEGER NOT NULL,
+			delta INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (player_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (game_queue_id) REFERENCES game_queues(id) ON DELETE CASCADE,
+			FOREIGN KEY (match_result_id) REFERENCES match_results(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS seasons (
+			id TEXT PRIMARY KEY,
+			game_queue_id TEXT NOT NULL,
+			number INTEGER NOT NULL,
+			started_at DATETIME NOT NULL,
+			ended_at DATETIME NOT NULL,
+			soft_reset REAL NOT NULL,
+			player_count INTEGER NOT NULL DEFAULT 0,
+			UNIQUE (game_queue_id, number),
+			FOREIGN KEY (game_queue_id) REFERENCES game_queues(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS season_standings (
+			season_id TEXT,
+			player_id TEXT,
+			rank INTEGER NOT NULL,
+			rating INTEGER NOT NULL,
+			deviation REAL NOT NULL,
+			PRIMARY KEY (season_id, player_id),
+			FOREIGN KEY (season_id) REFERENCES seasons(id) ON DELETE CASCADE,
+			FOREIGN KEY (player_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS rating_recalculations (
+			id TEXT PRIMARY KEY,
+			game_queue_id TEXT NOT NULL,
+			requested_by_id TEXT NOT NULL,
+			dry_run INTEGER NOT NULL DEFAULT 0,
+			status TEXT NOT NULL,
+			total INTEGER NOT NULL DEFAULT 0,
+			processed INTEGER NOT NULL DEFAULT 0,
+			diff TEXT,
+			error TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			started_at DATETIME,
+			finished_at DATETIME,
+			lease_expires_at DATETIME,
+			FOREIGN KEY (game_queue_id) REFERENCES game_queues(id) ON DELETE CASCADE
+		)`,
 		`CREATE TABLE IF NOT EXISTS player_game_entries (
 			game_id TEXT NOT NULL,
 			player_id TEXT NOT NULL,
@@ -145,6 +249,22 @@ func migrateForSQLite(db *gorm.DB) error {
 			FOREIGN KEY (game_id) REFERENCES games(id) ON DELETE CASCADE,
 			FOREIGN KEY (player_id) REFERENCES users(id) ON DELETE CASCADE
 		)`,
+		`CREATE TABLE IF NOT EXISTS player_penalties (
+			player_id TEXT NOT NULL,
+			game_id TEXT NOT NULL,
+			points INTEGER NOT NULL DEFAULT 0,
+			decayed_at DATETIME NOT NULL,
+			locked_until DATETIME,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (player_id, game_id),
+			FOREIGN KEY (game_id) REFERENCES games(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS player_blocks (
+			player_id TEXT NOT NULL,
+			blocked_id TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (player_id, blocked_id)
+		)`,
 	}
 	for _, stmt := range stmts {
 		if err := db.Exec(stmt).Error; err != nil {
@@ -193,9 +313,10 @@ func assertSchemaMatchesModels(db *gorm.DB) error {
 	ns := schema.NamingStrategy{}
 	checks := []interface{}{
 		&models.User{}, &models.Game{}, &models.GameQueue{}, &models.Match{},
-		&models.MatchResult{}, &models.Rating{},
+		&models.MatchResult{}, &models.Rating{}, &models.RatingChange{},
 		&models.MachineHost{}, &models.ServerInstance{},
-		&models.PlayerGameEntry{},
+		&models.PlayerGameEntry{}, &models.Season{}, &models.SeasonStanding{},
+		&models.RatingRecalculation{},
 	}
 	for _, m := range checks {
 		s, err := schema.Parse(m, cache, ns)
@@ -0,0 +1,176 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/andy98725/elo-service/src/models"
+	"github.com/andy98725/elo-service/src/server"
+	"github.com/gorilla/websocket"
+)
+
+func TestQueueTeamSettings(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "teamcfg", "teamcfg@example.com", "pass")
+	token, _ := LoginUser(t, h.BaseURL(), "teamcfg@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), token, "TeamCfgGame", 2)
+	gameID := game["id"].(string)
+
+	q := CreateGameQueue(t, h.BaseURL(), token, gameID, "3v3", map[string]interface{}{
+		"team_count": 2,
+		"team_size":  3,
+	})
+	if q["lobby_size"].(float64) != 6 || q["team_count"].(float64) != 2 || q["team_size"].(float64) != 3 {
+		t.Fatalf("expected a 2x3 queue with lobby_size 6, got %+v", q)
+	}
+	queueURL := fmt.Sprintf("%s/game/%s/queue", h.BaseURL(), gameID)
+	for name, body := range map[string]map[string]interface{}{
+		"mismatch": {"name": "mismatch", "team_count": 2, "team_size": 3, "lobby_size": 4, "matchmaking_machine_ports": []int64{8080}},
+		"one team": {"name": "one", "team_count": 1, "team_size": 3, "matchmaking_machine_ports": []int64{8080}},
+		"no size":  {"name": "nosize", "team_count": 2, "matchmaking_machine_ports": []int64{8080}},
+	} {
+		if resp := DoReq(t, "POST", queueURL, body, token, http.StatusBadRequest); resp == nil {
+			t.Errorf("%s: expected 400", name)
+		}
+	}
+
+	updateURL := fmt.Sprintf("%s/%s", queueURL, q["id"])
+	updated := DoReq(t, "PUT", updateURL, map[string]interface{}{"team_count": 4, "team_size": 1}, token, http.StatusOK)
+	if updated["lobby_size"].(float64) != 4 {
+		t.Errorf("expected lobby_size re-derived as 4, got %+v", updated)
+	}
+	DoReq(t, "PUT", updateURL, map[string]interface{}{"lobby_size": 5}, token, http.StatusBadRequest)
+	off := DoReq(t, "PUT", updateURL, map[string]interface{}{"team_count": 0, "team_size": 0}, token, http.StatusOK)
+	if off["team_count"].(float64) != 0 || off["lobby_size"].(float64) != 4 {
+		t.Errorf("expected teams off with lobby_size kept, got %+v", off)
+	}
+}
+
+// TestTeamsBalancedByRating queues four rated players into a 2x2 queue
+// and checks the split pairs the strongest with the weakest, and that
+// the layout reaches match_found, the Match row, and a result reported
+// without teams.
+func TestTeamsBalancedByRating(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "teamowner", "teamowner@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "teamowner@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "TeamGame", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "2v2", map[string]interface{}{
+		"team_count":   2,
+		"team_size":    2,
+		"elo_strategy": models.ELO_STRATEGY_CLASSIC,
+	})
+	queueID := q["id"].(string)
+
+	ratings := []int{1600, 1500, 1100, 1000}
+	var tokens, ids []string
+	for i, r := range ratings {
+		name := fmt.Sprintf("teamp%d", i)
+		RegisterUser(t, h.BaseURL(), name, name+"@example.com", "pass")
+		token, id := LoginUser(t, h.BaseURL(), name+"@example.com", "pass")
+		tokens, ids = append(tokens, token), append(ids, id)
+		if _, err := models.GetRating(id, queueID); err != nil {
+			t.Fatalf("GetRating: %v", err)
+		}
+		if err := server.S.DB.Model(&models.Rating{}).
+			Where("player_id = ? AND game_queue_id = ?", id, queueID).
+			UpdateColumn("rating", r).Error; err != nil {
+			t.Fatalf("seed rating: %v", err)
+		}
+	}
+
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s&queueID=%s", h.BaseURL(), gameID, queueID)
+	conns := make([]*websocket.Conn, len(tokens))
+	for i, token := range tokens {
+		conns[i] = WebsocketConnect(t, joinURL, token)
+		defer conns[i].Close()
+		readQueueJoined(t, conns[i])
+	}
+	TriggerMatchmaking(t)
+
+	found := make([]map[string]interface{}, len(conns))
+	for i, ws := range conns {
+		found[i] = awaitStatus(t, ws, "match_found")
+	}
+	team := func(i int) float64 { return found[i]["team"].(float64) }
+	if team(0) != team(3) || team(1) != team(2) || team(0) == team(1) {
+		t.Fatalf("expected {1600,1000} vs {1500,1100}, got teams %v %v %v %v", team(0), team(1), team(2), team(3))
+	}
+	if layout, _ := found[0]["teams"].([]interface{}); len(layout) != 2 {
+		t.Errorf("expected the full layout on match_found, got %+v", found[0]["teams"])
+	}
+
+	match, err := models.GetMatch(found[0]["match_id"].(string))
+	if err != nil {
+		t.Fatalf("GetMatch: %v", err)
+	}
+	layout := match.TeamLayout()
+	if len(layout) != 2 || len(layout[0]) != 2 || len(layout[1]) != 2 {
+		t.Fatalf("expected a 2x2 layout on the match row, got %v", layout)
+	}
+
+	DoReq(t, "POST", h.BaseURL()+"/result/report", map[string]interface{}{
+		"token_id":   match.AuthCode,
+		"winner_ids": []string{ids[0], ids[3]},
+		"reason":     "completed",
+	}, "", http.StatusOK)
+	result := DoReq(t, "GET", fmt.Sprintf("%s/results/%s", h.BaseURL(), match.ID), nil, tokens[0], http.StatusOK)
+	if teams, _ := result["teams"].([]interface{}); len(teams) != 2 {
+		t.Errorf("expected the result to inherit the match's teams, got %+v", result)
+	}
+}
+
+// TestTeamsKeepPartiesTogether checks a party of two in a 2x2 queue
+// always shares a team, and a party bigger than a team is refused.
+func TestTeamsKeepPartiesTogether(t *testing.T) {
+	h := NewHarness(t)
+
+	RegisterUser(t, h.BaseURL(), "teamparty", "teamparty@example.com", "pass")
+	ownerToken, _ := LoginUser(t, h.BaseURL(), "teamparty@example.com", "pass")
+	game := CreateGame(t, h.BaseURL(), ownerToken, "TeamPartyGame", 2)
+	gameID := game["id"].(string)
+	q := CreateGameQueue(t, h.BaseURL(), ownerToken, gameID, "2v2", map[string]interface{}{
+		"team_count": 2,
+		"team_size":  2,
+	})
+	joinURL := fmt.Sprintf("%s/match/join?gameID=%s&queueID=%s", h.BaseURL(), gameID, q["id"])
+
+	leaderToken, _ := GuestLogin(t, h.BaseURL(), "tplead")
+	aToken, aID := GuestLogin(t, h.BaseURL(), "tpa")
+	bToken, bID := GuestLogin(t, h.BaseURL(), "tpb")
+	partyID := formParty(t, h.BaseURL(), leaderToken, []string{aToken, bToken}, []string{aID, bID})
+
+	ws := WebsocketConnect(t, joinURL, leaderToken)
+	var rejected map[string]interface{}
+	if err := ws.ReadJSON(&rejected); err != nil || rejected["status"] != "error" {
+		t.Fatalf("expected a party of 3 to be refused by 2-player teams, got %+v (%v)", rejected, err)
+	}
+	ws.Close()
+	DoReq(t, "POST", fmt.Sprintf("%s/party/%s/leave", h.BaseURL(), partyID), nil, bToken, http.StatusOK)
+
+	solo1, _ := GuestLogin(t, h.BaseURL(), "tps1")
+	solo2, _ := GuestLogin(t, h.BaseURL(), "tps2")
+	var conns []*websocket.Conn
+	for _, token := range []string{solo1, leaderToken, aToken, solo2} {
+		c := WebsocketConnect(t, joinURL, token)
+		defer c.Close()
+		awaitStatus(t, c, "queue_joined")
+		conns = append(conns, c)
+	}
+	TriggerMatchmaking(t)
+
+	teams := make([]float64, len(conns))
+	for i, c := range conns {
+		teams[i] = awaitStatus(t, c, "match_found")["team"].(float64)
+	}
+	if teams[1] != teams[2] {
+		t.Errorf("expected the party on one team, got %v", teams)
+	}
+	if teams[0] != teams[3] {
+		t.Errorf("expected the two solos together, got %v", teams)
+	}
+}